@@ -0,0 +1,111 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-05 11:58:10
+ * @LastEditTime: 2025-07-05 12:14:32
+ * @LastEditors: nijineko
+ * @Description: 翻译记忆库管理命令
+ * @FilePath: \AutoTranslation\cache.go
+ */
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/nijinekoyo/AutoTranslation/internal/config"
+	"github.com/nijinekoyo/AutoTranslation/internal/log"
+	"github.com/nijinekoyo/AutoTranslation/pkg/translation/cache"
+)
+
+var (
+	// 翻译记忆库命令用法错误
+	ErrCacheUsage = errors.New("usage: AutoTranslation cache <stats | export <file> | import <file> | purge [service]>")
+)
+
+/**
+ * @description: 执行翻译记忆库管理命令
+ * @param {[]string} Args 命令参数，不包含cache本身
+ * @return {error} 错误信息
+ */
+func runCacheCommand(Args []string) (err error) {
+	if len(Args) == 0 {
+		return ErrCacheUsage
+	}
+
+	CacheStore, err := cache.Open(config.Get().Cache.Path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if CloseErr := CacheStore.Close(); err == nil {
+			err = CloseErr
+		}
+	}()
+
+	switch Args[0] {
+	case "stats":
+		Stats, err := CacheStore.Stats()
+		if err != nil {
+			return err
+		}
+
+		log.Print().Info("Cache", fmt.Sprintf("Entries: %d, total hits: %d, total misses: %d", Stats.Entries, Stats.TotalHits, Stats.TotalMisses))
+		Services := make([]string, 0, len(Stats.ServiceEntries))
+		for Service := range Stats.ServiceEntries {
+			Services = append(Services, Service)
+		}
+		sort.Strings(Services)
+		for _, Service := range Services {
+			log.Print().Info("Cache", fmt.Sprintf("  %s: %d", Service, Stats.ServiceEntries[Service]))
+		}
+	case "export":
+		if len(Args) < 2 {
+			return ErrCacheUsage
+		}
+
+		FileHandle, err := os.Create(Args[1])
+		if err != nil {
+			return err
+		}
+		defer FileHandle.Close()
+
+		Count, err := CacheStore.Export(FileHandle)
+		if err != nil {
+			return err
+		}
+		log.Print().Info("Cache", fmt.Sprintf("Exported %d entries to %s", Count, Args[1]))
+	case "import":
+		if len(Args) < 2 {
+			return ErrCacheUsage
+		}
+
+		FileHandle, err := os.Open(Args[1])
+		if err != nil {
+			return err
+		}
+		defer FileHandle.Close()
+
+		Count, err := CacheStore.Import(FileHandle)
+		if err != nil {
+			return err
+		}
+		log.Print().Info("Cache", fmt.Sprintf("Imported %d entries from %s", Count, Args[1]))
+	case "purge":
+		Service := ""
+		if len(Args) >= 2 {
+			Service = Args[1]
+		}
+
+		Count, err := CacheStore.Purge(Service)
+		if err != nil {
+			return err
+		}
+		log.Print().Info("Cache", fmt.Sprintf("Purged %d entries", Count))
+	default:
+		return ErrCacheUsage
+	}
+
+	return nil
+}
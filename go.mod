@@ -3,16 +3,19 @@ module github.com/nijinekoyo/AutoTranslation
 go 1.24.2
 
 require (
-	github.com/HyacinthusAcademy/yuzuhttp v0.2.1
 	github.com/noa-log/colorize v1.0.1
 	github.com/noa-log/noa v1.0.0
 	github.com/openai/openai-go v1.8.2
 	github.com/pelletier/go-toml v1.9.5
+	github.com/richardlehane/mscfb v1.0.4
 	github.com/xuri/excelize/v2 v2.9.1
+	go.etcd.io/bbolt v1.4.3
+	golang.org/x/text v0.25.0
+	golang.org/x/time v0.9.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
-	github.com/richardlehane/mscfb v1.0.4 // indirect
 	github.com/richardlehane/msoleps v1.0.4 // indirect
 	github.com/tidwall/gjson v1.14.4 // indirect
 	github.com/tidwall/match v1.1.1 // indirect
@@ -23,5 +26,5 @@ require (
 	github.com/xuri/nfp v0.0.1 // indirect
 	golang.org/x/crypto v0.38.0 // indirect
 	golang.org/x/net v0.40.0 // indirect
-	golang.org/x/text v0.25.0 // indirect
+	golang.org/x/sys v0.33.0 // indirect
 )
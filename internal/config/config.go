@@ -8,21 +8,74 @@
  */
 package config
 
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
 // 配置文件结构
 type Config struct {
-	SourceColumn int `toml:"source_column"` // 待翻译列，从1开始计数
-	TargetColumn int `toml:"target_column"` // 翻译目标列，从1开始计数
+	SourceColumn ColumnRef `toml:"source_column"` // 待翻译列，可以是从1开始的列号、表头名称或Excel列字母
+	TargetColumn ColumnRef `toml:"target_column"` // 翻译目标列，表头中不存在该名称时自动创建
+
+	Columns []ColumnMapping `toml:"columns"` // 多组源列与目标列映射，配置后忽略source_column、target_column、provenance_column和context_column
+
+	Sheets struct {
+		All      bool           `toml:"all"`      // 是否翻译全部工作表
+		Names    []string       `toml:"names"`    // 需要翻译的工作表名称
+		Patterns []string       `toml:"patterns"` // 需要翻译的工作表名称正则
+		Mappings []SheetMapping `toml:"mappings"` // 指定工作表使用的列映射，按顺序匹配第一个
+	} `toml:"sheets"` // 工作表选择配置，都未配置时只翻译第一个工作表
 
 	SkipTableHeader bool `toml:"skip_table_header"` // 翻译时是否跳过表头
 	SkipIfNotEmpty  bool `toml:"skip_if_not_empty"` // 如果待翻译单元格不为空，则跳过翻译
 
+	TranslateFormulas bool `toml:"translate_formulas"` // 是否翻译公式单元格的计算结果，默认跳过
+
+	ProvenanceColumn ColumnRef `toml:"provenance_column"` // 记录译文来源翻译服务的列，0或空表示不记录
+	ContextColumn    ColumnRef `toml:"context_column"`    // 说明列，内容作为上下文传给大语言模型，0或空表示不使用，资源文件默认使用其中的注释
+
+	Concurrency int `toml:"concurrency"` // 并发翻译的请求数量，小于等于1表示顺序翻译
+
+	Stream bool `toml:"stream"` // 是否流式逐行读写大文件，支持CSV、TSV和xlsx，xlsx流式写入不保留样式和公式，也不识别公式
+
+	Files struct {
+		Include []string `toml:"include"` // 输入为目录时额外翻译的文件名通配符，JSON和XML文件默认只翻译符合本地化文件结构的文件，TXT文件默认不翻译
+	} `toml:"files"` // 输入文件配置
+
+	Output struct {
+		Directory string `toml:"directory"` // 输出目录，为空时输出到源文件所在目录，输入为目录时保持相对目录结构
+		Filename  string `toml:"filename"`  // 输出文件名模板，支持{name}、{ext}、{source_lang}、{target_lang}，为空时保持原文件名
+	} `toml:"output"` // 输出配置，目录和文件名模板都为空时覆盖源文件
+
+	CSV struct {
+		CSVDialect              // 默认方言
+		Files      []CSVDialect `toml:"files"` // 按文件名匹配的方言，按顺序匹配第一个，匹配后不使用默认方言
+	} `toml:"csv"` // CSV和TSV文件方言配置
+
+	Checkpoint struct {
+		Enable    bool   `toml:"enable"`    // 是否记录翻译进度，中断后重新运行时从上次进度继续
+		Directory string `toml:"directory"` // 翻译进度日志目录
+	} `toml:"checkpoint"` // 翻译进度配置
+
+	Cache struct {
+		Enable bool   `toml:"enable"` // 是否启用翻译记忆库
+		Path   string `toml:"path"`   // 翻译记忆库文件路径
+	} `toml:"cache"` // 翻译记忆库配置
+
 	Translation struct {
-		Service string `toml:"service"` // 需要使用的翻译服务 (google, openai, etc.)
+		Service   ServiceList `toml:"service"`    // 需要使用的翻译服务 (google, openai, etc.)，多个服务时按顺序降级
+		Timeout   int         `toml:"timeout"`    // 单次翻译请求超时时间（秒），0表示不限制
+		BatchSize int         `toml:"batch_size"` // 单次请求批量翻译的行数，小于等于1表示逐行翻译
 
 		// 语言配置
 		SourceLanguage *string `toml:"source_language"` // 源语言，为nil表示自动检测
 		TargetLanguage string  `toml:"target_language"` // 目标语言
 
+		Limits map[string]ServiceLimit `toml:"limits"` // 各翻译服务的限流与重试配置，键为服务名称
+
 		LargeLanguageModel struct {
 			GlossaryPrompt string `toml:"glossary_prompt"` // 术语表提示，提示大语言模型使用术语表进行翻译
 			Glossaries     []struct {
@@ -47,6 +100,143 @@ type Config struct {
 	} `toml:"translation"` // 翻译配置
 }
 
+// 源列与目标列映射
+type ColumnMapping struct {
+	Source           ColumnRef `toml:"source"`            // 待翻译列，可以是从1开始的列号、表头名称或Excel列字母
+	Target           ColumnRef `toml:"target"`            // 翻译目标列，表头中不存在该名称时自动创建
+	ProvenanceColumn ColumnRef `toml:"provenance_column"` // 记录译文来源翻译服务的列，0或空表示不记录
+	ContextColumn    ColumnRef `toml:"context_column"`    // 说明列，内容作为上下文传给大语言模型，0或空表示不使用
+	SourceLanguage   *string   `toml:"source_language"`   // 源语言，为nil时使用translation.source_language
+	TargetLanguage   string    `toml:"target_language"`   // 目标语言，为空时使用translation.target_language
+
+	Service ServiceList `toml:"service"` // 翻译服务，为空时使用translation.service
+	Prompt  string      `toml:"prompt"`  // 附加提示词，追加在大语言模型前置消息之后
+}
+
+// CSV方言
+type CSVDialect struct {
+	Pattern          string `toml:"pattern"`            // 文件名通配符，如"*.tsv"、"strings_*.csv"，只在csv.files中使用
+	Delimiter        string `toml:"delimiter"`          // 分隔符，为空时自动检测，TSV文件默认为制表符
+	Comment          string `toml:"comment"`            // 注释行的起始字符，为空表示不支持注释
+	LazyQuotes       bool   `toml:"lazy_quotes"`        // 是否允许不规范的引号
+	TrimLeadingSpace bool   `toml:"trim_leading_space"` // 是否忽略字段开头的空白
+	Encoding         string `toml:"encoding"`           // 文件编码，如"shift_jis"、"gbk"、"utf-16"，为空时自动检测
+	OutputEncoding   string `toml:"output_encoding"`    // 输出编码，为空时与源文件相同
+	AddBOM           bool   `toml:"add_bom"`            // 输出Unicode编码时是否添加BOM，源文件带有BOM时总是保留
+}
+
+/**
+ * @description: 获取全部列映射，未配置columns时使用source_column和target_column组成单个映射
+ * @return {[]ColumnMapping} 列映射，语言和翻译服务已按全局配置补全
+ */
+func (c Config) ColumnMappings() []ColumnMapping {
+	Mappings := c.Columns
+	if len(Mappings) == 0 {
+		Mappings = []ColumnMapping{{
+			Source:           c.SourceColumn,
+			Target:           c.TargetColumn,
+			ProvenanceColumn: c.ProvenanceColumn,
+			ContextColumn:    c.ContextColumn,
+		}}
+	}
+
+	return c.CompleteMappings(Mappings)
+}
+
+/**
+ * @description: 按全局配置补全列映射中未指定的语言和翻译服务
+ * @param {[]ColumnMapping} Mappings 列映射
+ * @return {[]ColumnMapping} 补全后的列映射
+ */
+func (c Config) CompleteMappings(Mappings []ColumnMapping) []ColumnMapping {
+	Results := make([]ColumnMapping, len(Mappings))
+	for Index, Mapping := range Mappings {
+		if Mapping.SourceLanguage == nil {
+			Mapping.SourceLanguage = c.Translation.SourceLanguage
+		}
+		if Mapping.TargetLanguage == "" {
+			Mapping.TargetLanguage = c.Translation.TargetLanguage
+		}
+		if len(Mapping.Service) == 0 {
+			Mapping.Service = c.Translation.Service
+		}
+		Results[Index] = Mapping
+	}
+	return Results
+}
+
+// 指定工作表的列映射
+type SheetMapping struct {
+	Name    string          `toml:"name"`    // 工作表名称
+	Pattern string          `toml:"pattern"` // 工作表名称正则，与名称任一匹配即可
+	Columns []ColumnMapping `toml:"columns"` // 列映射，语言和翻译服务未指定时使用全局配置
+}
+
+// 列引用，可以是从1开始的列号、表头名称或Excel列字母
+type ColumnRef string
+
+/**
+ * @description: 解析列号或列名称
+ * @param {interface{}} Value TOML值
+ * @return {error} 错误信息
+ */
+func (c *ColumnRef) UnmarshalTOML(Value interface{}) error {
+	switch Data := Value.(type) {
+	case int64:
+		*c = ColumnRef(strconv.FormatInt(Data, 10))
+	case string:
+		*c = ColumnRef(Data)
+	default:
+		return fmt.Errorf("invalid column: %v", Value)
+	}
+	return nil
+}
+
+/**
+ * @description: 是否未指定列
+ * @return {bool} 为空或为0时返回true
+ */
+func (c ColumnRef) IsZero() bool {
+	Reference := strings.TrimSpace(string(c))
+	return Reference == "" || Reference == "0"
+}
+
+// 翻译服务列表，可以是单个服务名称或服务名称数组
+type ServiceList []string
+
+/**
+ * @description: 解析单个服务名称或服务名称数组
+ * @param {interface{}} Value TOML值
+ * @return {error} 错误信息
+ */
+func (s *ServiceList) UnmarshalTOML(Value interface{}) error {
+	switch Data := Value.(type) {
+	case string:
+		*s = ServiceList{Data}
+	case []interface{}:
+		*s = make(ServiceList, 0, len(Data))
+		for _, Item := range Data {
+			Name, ok := Item.(string)
+			if !ok {
+				return fmt.Errorf("invalid translation service: %v", Item)
+			}
+			*s = append(*s, Name)
+		}
+	default:
+		return fmt.Errorf("invalid translation service: %v", Value)
+	}
+	return nil
+}
+
+// 翻译服务限流与重试配置
+type ServiceLimit struct {
+	RequestsPerSecond float64 `toml:"requests_per_second"` // 每秒最多请求次数，0表示不限制
+	TokensPerMinute   int     `toml:"tokens_per_minute"`   // 每分钟最多使用的令牌数量，0表示不限制，适用于大语言模型
+	MaxRetries        int     `toml:"max_retries"`         // 请求失败时的最大重试次数
+	RetryBaseDelay    float64 `toml:"retry_base_delay"`    // 首次重试的基础等待时间（秒），之后按指数增长
+	RetryMaxDelay     float64 `toml:"retry_max_delay"`     // 单次重试等待时间上限（秒），服务端返回Retry-After时以服务端为准
+}
+
 // 全局参数
 var Data Config
 
@@ -62,3 +252,31 @@ type ConfigInstance interface {
 	Create(FilePath string) error        // 创建配置文件
 	Get(FilePath string) (Config, error) // 获取配置
 }
+
+/**
+ * @description: 判断文件是否在files.include中指定
+ * @param {string} FilePath 文件路径
+ * @return {bool} 文件名是否匹配任一通配符
+ */
+func (c Config) IncludeFile(FilePath string) bool {
+	for _, Pattern := range c.Files.Include {
+		if Matched, err := filepath.Match(Pattern, filepath.Base(FilePath)); err == nil && Matched {
+			return true
+		}
+	}
+	return false
+}
+
+/**
+ * @description: 获取文件使用的CSV方言，按顺序匹配csv.files中的文件名通配符，都不匹配时使用默认方言
+ * @param {string} FilePath 文件路径
+ * @return {CSVDialect} CSV方言
+ */
+func (c Config) CSVDialect(FilePath string) CSVDialect {
+	for _, Dialect := range c.CSV.Files {
+		if Matched, err := filepath.Match(Dialect.Pattern, filepath.Base(FilePath)); err == nil && Matched {
+			return Dialect
+		}
+	}
+	return c.CSV.CSVDialect
+}
@@ -1,7 +1,7 @@
 /*
  * @Author: nijineko
  * @Date: 2025-07-03 15:26:27
- * @LastEditTime: 2025-07-03 20:53:02
+ * @LastEditTime: 2025-07-06 15:42:36
  * @LastEditors: nijineko
  * @Description: main package
  * @FilePath: \AutoTranslation\main.go
@@ -9,27 +9,51 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"syscall"
+	"time"
 
 	"github.com/nijinekoyo/AutoTranslation/bootstrap"
 	"github.com/nijinekoyo/AutoTranslation/internal/config"
 	"github.com/nijinekoyo/AutoTranslation/internal/log"
-	"github.com/nijinekoyo/AutoTranslation/pkg/table"
-	"github.com/nijinekoyo/AutoTranslation/pkg/table/csv"
-	"github.com/nijinekoyo/AutoTranslation/pkg/table/excel"
+	"github.com/nijinekoyo/AutoTranslation/pkg/table/resource"
 	"github.com/nijinekoyo/AutoTranslation/pkg/translation"
+	"github.com/nijinekoyo/AutoTranslation/pkg/translation/cache"
 	"github.com/nijinekoyo/AutoTranslation/pkg/translation/google"
 	"github.com/nijinekoyo/AutoTranslation/pkg/translation/openai"
 	"github.com/nijinekoyo/AutoTranslation/tools/file"
-	"github.com/noa-log/colorize"
 )
 
 func main() {
 	// 初始化系统
 	bootstrap.Init()
 
+	if len(os.Args) < 2 {
+		log.Print().Error("System", "Usage: AutoTranslation <file or directory> | AutoTranslation cache <stats|export|import|purge> [args] | AutoTranslation xliff <table file> <output.xlf>")
+		return
+	}
+
+	// 翻译记忆库管理命令
+	if os.Args[1] == "cache" {
+		if err := runCacheCommand(os.Args[2:]); err != nil {
+			log.Print().Error("Cache", err)
+		}
+		return
+	}
+
+	// XLIFF导出命令
+	if os.Args[1] == "xliff" {
+		if err := runXLIFFCommand(os.Args[2:]); err != nil {
+			log.Print().Error("XLIFF", err)
+		}
+		return
+	}
+
 	// 获取第一个命令参数作为待翻译文件路径
 	FilePathData := os.Args[1]
 
@@ -40,12 +64,43 @@ func main() {
 		return
 	}
 
+	// 打开翻译记忆库
+	var CacheStore *cache.Store
+	if config.Get().Cache.Enable {
+		CacheStore, err = cache.Open(config.Get().Cache.Path)
+		if err != nil {
+			log.Print().Error("Cache", err)
+			return
+		}
+		defer func() {
+			if Stats, err := CacheStore.Stats(); err == nil {
+				log.Print().Info("Cache", fmt.Sprintf("Cache hits: %d, misses: %d, entries: %d", Stats.Hits, Stats.Misses, Stats.Entries))
+			}
+			if err := CacheStore.Close(); err != nil {
+				log.Print().Error("Cache", err)
+			}
+		}()
+	}
+
+	// 按照配置文件创建翻译计划，同一服务在各列映射间共享，以便共享限流配额
+	Plan, err := newTranslationPlan(func(Service string) (translation.Translation, error) {
+		return newTranslator(Service, CacheStore)
+	})
+	if err != nil {
+		log.Print().Error("System", err)
+		return
+	}
+
 	// 待翻译文件列表
 	var FilePaths []string
+	// 输入为目录时的根目录，用于在输出目录中保持相对目录结构
+	var InputRoot string
 
 	if FileInfo.IsDir() {
-		// 如果是文件夹，则获取文件夹下所有文件
-		FilePaths, err = file.GetDirectoryFilePaths(FilePathData)
+		InputRoot = FilePathData
+
+		// 如果是文件夹，则获取文件夹下除之前的译文之外的所有文件
+		FilePaths, err = directoryFilePaths(InputRoot, Plan)
 		if err != nil {
 			log.Print().Error("System", err)
 			return
@@ -55,96 +110,137 @@ func main() {
 		FilePaths = append(FilePaths, FilePathData)
 	}
 
-	// 按照配置文件指定翻译器
-	var TranslatorInstance translation.Translation
-	switch config.Get().Translation.Service {
-	case "google":
-		TranslatorInstance = google.New()
-	case "openai":
-		TranslatorInstance = openai.New(config.Get().Translation.OpenAI.APIKey)
-	default:
-		log.Print().Error("System", "Unsupported translation service: "+config.Get().Translation.Service)
-		return
-	}
+	// 收到中断信号时取消进行中的翻译请求
+	Ctx, Stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer Stop()
 
 	// 遍历待翻译文件列表
 	for _, FilePath := range FilePaths {
-		var TableInstance table.Table
-
-		// 通过扩展名需要使用的表格处理器
-		switch filepath.Ext(FilePath) {
-		case ".xlsx", ".xls":
-			TableInstance, err = excel.New(FilePath)
-			if err != nil {
+		if err := translateFile(Ctx, FilePath, InputRoot, Plan); err != nil {
+			if errors.Is(err, ErrSkippedFile) {
+				log.Print().Warning("Translation", err)
+				continue
+			}
+			if errors.Is(err, ErrUnsupportedFormat) || errors.Is(err, ErrOpenTable) || errors.Is(err, ErrResourceMapping) {
 				log.Print().Error("Translation", err)
-				return
+				continue
 			}
-		case ".csv":
-			TableInstance, err = csv.New(FilePath)
-			if err != nil {
+			if Ctx.Err() == nil {
 				log.Print().Error("Translation", err)
-				return
 			}
-		default:
-			log.Print().Error("Translation", "Unsupported table format: "+filepath.Ext(FilePath))
-		}
-		defer TableInstance.Close()
-
-		// 读取表格数据
-		TableDatas, err := TableInstance.Read()
-		if err != nil {
-			log.Print().Error("Translation", "Failed to read table data from "+FilePath, err)
 			return
 		}
+	}
+}
 
-		// 遍历表格数据进行翻译
-		for Index, Row := range TableDatas {
-			if config.Get().SkipTableHeader && Index == 0 {
-				// 如果跳过表头，则继续下一行
-				continue
-			}
+/**
+ * @description: 按服务名称创建翻译器，并按配置添加限流、重试、超时和翻译记忆库
+ * @param {string} Service 翻译服务名称
+ * @param {*cache.Store} CacheStore 翻译记忆库，为nil表示不使用
+ * @return {translation.Translation} 翻译器实例
+ * @return {error} 错误信息
+ */
+func newTranslator(Service string, CacheStore *cache.Store) (translation.Translation, error) {
+	var TranslatorInstance translation.Translation
+	CacheScope := cache.Scope{Service: Service}
+	switch Service {
+	case "google":
+		TranslatorInstance = google.New()
+	case "openai":
+		TranslatorInstance = openai.New(config.Get().Translation.OpenAI.APIKey)
+		CacheScope.Model = config.Get().Translation.OpenAI.Model
+		CacheScope.PromptHash = openai.PromptHash()
+		// 列映射可以追加各自的提示词，条目可以附带说明
+		CacheScope.UsePrompt = true
+	default:
+		return nil, fmt.Errorf("unsupported translation service: %s", Service)
+	}
 
-			// 计算源列和目标列索引
-			SourceColumn := config.Get().SourceColumn - 1 // 转换为0开始计数
-			TargetColumn := config.Get().TargetColumn - 1 // 转换为0开始
-			if len(Row) <= SourceColumn {
-				log.Print().Error("Translation", fmt.Sprintf("Row %d: Source column index %d is out of range", Index+1, SourceColumn+1))
-				continue
-			}
-			if len(TableDatas[Index]) <= TargetColumn {
-				// 如果目标列索引超出范围，则扩展行数据
-				for len(TableDatas[Index]) <= TargetColumn {
-					TableDatas[Index] = append(TableDatas[Index], "")
-				}
-			}
+	// 由内到外依次为：单次请求超时、限流、重试，每次重试都重新等待限流并重新计算超时
+	Limit := config.Get().Translation.Limits[Service]
+	TranslatorInstance = translation.NewTimeoutTranslator(TranslatorInstance, time.Duration(config.Get().Translation.Timeout)*time.Second)
+	TranslatorInstance = translation.NewRateLimitTranslator(TranslatorInstance, Limit.RequestsPerSecond, Limit.TokensPerMinute)
+	TranslatorInstance = translation.NewRetryTranslator(TranslatorInstance, Limit.MaxRetries,
+		time.Duration(Limit.RetryBaseDelay*float64(time.Second)),
+		time.Duration(Limit.RetryMaxDelay*float64(time.Second)),
+	)
+
+	// 翻译记忆库在最外层，命中时不占用限流配额
+	if CacheStore != nil {
+		TranslatorInstance = cache.New(TranslatorInstance, CacheStore, CacheScope)
+	}
 
-			// 获取待翻译文本
-			SourceText := Row[SourceColumn]
+	return TranslatorInstance, nil
+}
 
-			if config.Get().SkipIfNotEmpty && TableDatas[Index][TargetColumn] != "" {
-				// 如果待翻译单元格不为空且配置了跳过，则跳过翻译
-				log.Print().Warning("Translation", fmt.Sprintf("Row %d: cell is not empty, skipping translation", Index+1))
-				continue
-			}
+/**
+ * @description: 按输出配置计算输出文件路径
+ * @param {string} FilePath 输入文件路径
+ * @param {string} InputRoot 输入根目录，输入为文件时为空
+ * @param {string} Language 目标语言，用于文件名模板中的{target_lang}
+ * @return {string} 输出文件路径，与输入相同时覆盖源文件
+ * @return {error} 错误信息
+ */
+func outputPath(FilePath, InputRoot, Language string) (string, error) {
+	Output := config.Get().Output
+	if Output.Directory == "" && Output.Filename == "" {
+		return FilePath, nil
+	}
 
-			// 翻译文本
-			TranslatedText, err := TranslatorInstance.TranslateText(SourceText, config.Get().Translation.SourceLanguage, config.Get().Translation.TargetLanguage)
-			if err != nil {
-				log.Print().Error("Translation", err)
-				continue
-			}
+	SourceLanguage := "auto"
+	if config.Get().Translation.SourceLanguage != nil {
+		SourceLanguage = *config.Get().Translation.SourceLanguage
+	}
+
+	return file.OutputPath(FilePath, InputRoot, Output.Directory, Output.Filename, map[string]string{
+		"source_lang": SourceLanguage,
+		"target_lang": Language,
+	})
+}
 
-			// 更新翻译结果到目标列
-			TableDatas[Index][TargetColumn] = TranslatedText
+/**
+ * @description: 获取输入目录中需要翻译的文件，跳过输出目录和之前运行生成的译文文件，避免再次运行时翻译译文
+ * @param {string} InputRoot 输入目录
+ * @param {*translationPlan} Plan 翻译计划，用于获取文件的目标语言
+ * @return {[]string} 文件路径列表
+ * @return {error} 错误信息
+ */
+func directoryFilePaths(InputRoot string, Plan *translationPlan) ([]string, error) {
+	var SkipDirectories []string
+	if Directory := config.Get().Output.Directory; Directory != "" {
+		SkipDirectories = append(SkipDirectories, Directory)
+	}
+	FilePaths, err := file.GetDirectoryFilePaths(InputRoot, SkipDirectories...)
+	if err != nil {
+		return nil, err
+	}
 
-			log.Print().Info("Translation", fmt.Sprintf("Row %d: %s -> %s", Index+1, colorize.YellowText(SourceText), colorize.GreenText(TranslatedText)))
+	// 按目标语言计算每个文件的输出路径，是其他文件输出路径的文件为之前生成的译文
+	Outputs := map[string]bool{}
+	for _, FilePath := range FilePaths {
+		Language, err := fileLanguage(FilePath, Plan)
+		if err != nil {
+			// 目标语言无法确定的文件在翻译时报告错误
+			continue
+		}
+		OutputPath, err := fileOutputPath(FilePath, InputRoot, Language)
+		if err != nil {
+			return nil, err
+		}
+		if !file.SamePath(FilePath, OutputPath) {
+			Outputs[filepath.Clean(OutputPath)] = true
+			// 资源文件的目标语言文件旁有对应的原文记录
+			Outputs[filepath.Clean(resource.RecordPath(OutputPath))] = true
 		}
+	}
 
-		// 保存翻译后的表格数据
-		if err := TableInstance.Write(TableDatas); err != nil {
-			log.Print().Error("System", err)
-			return
+	var Inputs []string
+	for _, FilePath := range FilePaths {
+		if Outputs[filepath.Clean(FilePath)] {
+			log.Print().Info("Translation", fmt.Sprintf("Skipping %s, it is the output of another file", FilePath))
+			continue
 		}
-		log.Print().Info("Translation", fmt.Sprintf("Translation completed for file: %s", FilePath))
+		Inputs = append(Inputs, FilePath)
 	}
+	return Inputs, nil
 }
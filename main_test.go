@@ -0,0 +1,102 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-21 10:12:40
+ * @LastEditTime: 2025-07-21 10:12:40
+ * @LastEditors: nijineko
+ * @Description: 命令行入口测试
+ * @FilePath: \AutoTranslation\main_test.go
+ */
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/nijinekoyo/AutoTranslation/internal/config"
+	"github.com/nijinekoyo/AutoTranslation/pkg/translation"
+)
+
+func TestDirectoryFilePaths(t *testing.T) {
+	tests := []struct {
+		name      string
+		directory string // 输出目录，相对于输入目录
+		filename  string
+		want      []string // 两次运行后输入目录中的文件，相对于输入目录
+	}{
+		{
+			name:     "filename template next to the source",
+			filename: "{name}.{target_lang}{ext}",
+			want:     []string{".en.zh-CN.json.sources", "en.json", "en.zh-CN.json", "foo.csv", "foo.zh-CN.csv"},
+		},
+		{
+			name:      "output directory inside the input",
+			directory: "out",
+			want:      []string{"en.json", "foo.csv", filepath.Join("out", ".en.json.sources"), filepath.Join("out", "en.json"), filepath.Join("out", "foo.csv")},
+		},
+		{
+			name:      "output directory and filename template",
+			directory: "out",
+			filename:  "{name}.{target_lang}{ext}",
+			want:      []string{"en.json", "foo.csv", filepath.Join("out", ".en.zh-CN.json.sources"), filepath.Join("out", "en.zh-CN.json"), filepath.Join("out", "foo.zh-CN.csv")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			InputRoot := t.TempDir()
+			Config := testConfig(config.ColumnMapping{Source: "1", Target: "2"})
+			Config.Output.Filename = tt.filename
+			if tt.directory != "" {
+				Config.Output.Directory = filepath.Join(InputRoot, tt.directory)
+			}
+			setConfig(t, Config)
+
+			if err := os.WriteFile(filepath.Join(InputRoot, "foo.csv"), []byte("Hello,\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(InputRoot, "en.json"), []byte(`{"title": "Hello"}`), 0644); err != nil {
+				t.Fatal(err)
+			}
+			Plan, err := newTranslationPlan(func(Service string) (translation.Translation, error) {
+				return prefixTranslator{}, nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// 再次运行时不会翻译之前生成的译文
+			for Run := 1; Run <= 2; Run++ {
+				FilePaths, err := directoryFilePaths(InputRoot, Plan)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if want := []string{filepath.Join(InputRoot, "en.json"), filepath.Join(InputRoot, "foo.csv")}; !slices.Equal(FilePaths, want) {
+					t.Fatalf("run %d: directoryFilePaths() = %q, want %q", Run, FilePaths, want)
+				}
+				for _, FilePath := range FilePaths {
+					if err := translateFile(context.Background(), FilePath, InputRoot, Plan); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+
+			var got []string
+			err = filepath.WalkDir(InputRoot, func(Path string, Entry os.DirEntry, err error) error {
+				if err == nil && !Entry.IsDir() {
+					Relative, _ := filepath.Rel(InputRoot, Path)
+					got = append(got, Relative)
+				}
+				return err
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("files after two runs = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
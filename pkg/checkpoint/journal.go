@@ -0,0 +1,215 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-06 14:08:12
+ * @LastEditTime: 2025-07-06 15:20:47
+ * @LastEditors: nijineko
+ * @Description: 翻译进度日志，用于中断后恢复任务
+ * @FilePath: \AutoTranslation\pkg\checkpoint\journal.go
+ */
+package checkpoint
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// 已完成的翻译记录
+type Record struct {
+	Sheet       string `json:"sheet,omitempty"`    // 工作表名称，单工作表格式为空
+	Row         int    `json:"row"`                // 行号，从1开始计数
+	Column      int    `json:"column"`             // 目标列号，从1开始计数
+	Source      string `json:"source"`             // 源文本，恢复时用于校验源文本未被修改
+	Translation string `json:"translation"`        // 翻译结果
+	Provider    string `json:"provider,omitempty"` // 翻译服务名称
+}
+
+// 记录索引
+type recordKey struct {
+	Sheet       string
+	Row, Column int
+}
+
+// 翻译进度日志，每条记录以JSON行的形式追加写入
+type Journal struct {
+	filePath   string
+	fileHandle *os.File
+
+	mutex   sync.Mutex
+	records map[recordKey]Record
+}
+
+/**
+ * @description: 计算任务对应的日志文件路径，相同任务参数得到相同路径
+ * @param {string} Directory 日志目录
+ * @param {string} Name 日志文件名前缀，通常为输入文件名
+ * @param {...string} Parts 区分任务的参数，如输入输出路径和目标语言
+ * @return {string} 日志文件路径
+ */
+func PathFor(Directory, Name string, Parts ...string) string {
+	Hash := sha256.New()
+	for _, Part := range Parts {
+		Hash.Write([]byte(Part))
+		Hash.Write([]byte{0})
+	}
+	return filepath.Join(Directory, Name+"."+hex.EncodeToString(Hash.Sum(nil))[:16]+".jsonl")
+}
+
+/**
+ * @description: 打开翻译进度日志，文件已存在时加载其中的记录
+ * @param {string} FilePath 日志文件路径
+ * @return {*Journal} Journal实例
+ * @return {error} 错误信息
+ */
+func Open(FilePath string) (*Journal, error) {
+	if err := os.MkdirAll(filepath.Dir(FilePath), 0755); err != nil {
+		return nil, err
+	}
+
+	JournalInstance := &Journal{
+		filePath: FilePath,
+		records:  map[recordKey]Record{},
+	}
+
+	// 加载已有记录
+	Data, err := os.ReadFile(FilePath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	Valid := 0 // 有效记录的字节长度
+	for len(Data[Valid:]) > 0 {
+		End := bytes.IndexByte(Data[Valid:], '\n')
+		if End < 0 {
+			// 进程被终止时最后一行可能没有写完整
+			break
+		}
+
+		var RecordData Record
+		if err := json.Unmarshal(Data[Valid:Valid+End], &RecordData); err != nil {
+			break
+		}
+		JournalInstance.records[recordKey{RecordData.Sheet, RecordData.Row, RecordData.Column}] = RecordData
+		Valid += End + 1
+	}
+
+	FileHandle, err := os.OpenFile(FilePath, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	// 丢弃不完整的记录，之后从有效记录末尾追加
+	if err := FileHandle.Truncate(int64(Valid)); err != nil {
+		FileHandle.Close()
+		return nil, err
+	}
+	if _, err := FileHandle.Seek(int64(Valid), 0); err != nil {
+		FileHandle.Close()
+		return nil, err
+	}
+	JournalInstance.fileHandle = FileHandle
+
+	return JournalInstance, nil
+}
+
+/**
+ * @description: 已加载的记录数量
+ * @return {int} 记录数量
+ */
+func (j *Journal) Len() int {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	return len(j.records)
+}
+
+/**
+ * @description: 查找已完成的翻译，源文本与记录不一致时视为未完成
+ * @param {string} Sheet 工作表名称，单工作表格式为空
+ * @param {int} Row 行号，从1开始计数
+ * @param {int} Column 目标列号，从1开始计数
+ * @param {string} Source 当前的源文本
+ * @return {Record} 翻译记录
+ * @return {bool} 是否找到
+ */
+func (j *Journal) Lookup(Sheet string, Row, Column int, Source string) (Record, bool) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	RecordData, ok := j.records[recordKey{Sheet, Row, Column}]
+	if !ok || RecordData.Source != Source {
+		return Record{}, false
+	}
+	return RecordData, true
+}
+
+/**
+ * @description: 追加翻译记录并同步到磁盘
+ * @param {...Record} Records 翻译记录
+ * @return {error} 错误信息
+ */
+func (j *Journal) Append(Records ...Record) error {
+	if len(Records) == 0 {
+		return nil
+	}
+
+	var Buffer bytes.Buffer
+	Encoder := json.NewEncoder(&Buffer)
+	Encoder.SetEscapeHTML(false)
+	for _, RecordData := range Records {
+		if err := Encoder.Encode(RecordData); err != nil {
+			return err
+		}
+	}
+
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	if j.fileHandle == nil {
+		return os.ErrClosed
+	}
+	if _, err := j.fileHandle.Write(Buffer.Bytes()); err != nil {
+		return err
+	}
+	if err := j.fileHandle.Sync(); err != nil {
+		return err
+	}
+	for _, RecordData := range Records {
+		j.records[recordKey{RecordData.Sheet, RecordData.Row, RecordData.Column}] = RecordData
+	}
+
+	return nil
+}
+
+/**
+ * @description: 关闭翻译进度日志
+ * @return {error} 错误信息
+ */
+func (j *Journal) Close() error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	if j.fileHandle == nil {
+		return nil
+	}
+	err := j.fileHandle.Close()
+	j.fileHandle = nil
+	return err
+}
+
+/**
+ * @description: 关闭并删除翻译进度日志，任务完成后调用
+ * @return {error} 错误信息
+ */
+func (j *Journal) Remove() error {
+	if err := j.Close(); err != nil {
+		return err
+	}
+	if err := os.Remove(j.filePath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
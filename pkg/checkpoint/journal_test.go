@@ -0,0 +1,78 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-06 15:22:09
+ * @LastEditTime: 2025-07-06 15:31:44
+ * @LastEditors: nijineko
+ * @Description: 翻译进度日志测试
+ * @FilePath: \AutoTranslation\pkg\checkpoint\journal_test.go
+ */
+package checkpoint
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestJournal(t *testing.T) {
+	JournalPath := PathFor(t.TempDir(), "script.xlsx", "input", "output", "zh-CN")
+
+	JournalInstance, err := Open(JournalPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := JournalInstance.Append(
+		Record{Row: 2, Column: 2, Source: "はい", Translation: "是", Provider: "google"},
+		Record{Row: 3, Column: 2, Source: "いいえ", Translation: "不", Provider: "google"},
+	); err != nil {
+		t.Fatal(err)
+	}
+	if err := JournalInstance.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 模拟进程在写入过程中被终止
+	FileHandle, err := os.OpenFile(JournalPath, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	FileHandle.WriteString(`{"row":4,"column":2,"sour`)
+	FileHandle.Close()
+
+	JournalInstance, err = Open(JournalPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := JournalInstance.Len(); got != 2 {
+		t.Errorf("Journal.Len() = %v, want 2", got)
+	}
+	if got, ok := JournalInstance.Lookup("", 2, 2, "はい"); !ok || got.Translation != "是" {
+		t.Errorf("Journal.Lookup() = %+v, %v", got, ok)
+	}
+	if _, ok := JournalInstance.Lookup("", 3, 2, "いいえ、違います"); ok {
+		t.Errorf("Journal.Lookup() found a record whose source text changed")
+	}
+	if _, ok := JournalInstance.Lookup("Sheet2", 2, 2, "はい"); ok {
+		t.Errorf("Journal.Lookup() found a record from another sheet")
+	}
+
+	// 截断的记录被丢弃后可以继续追加
+	if err := JournalInstance.Append(Record{Row: 4, Column: 2, Source: "東京", Translation: "东京"}); err != nil {
+		t.Fatal(err)
+	}
+	JournalInstance.Close()
+	JournalInstance, err = Open(JournalPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := JournalInstance.Len(); got != 3 {
+		t.Errorf("Journal.Len() after append = %v, want 3", got)
+	}
+
+	if err := JournalInstance.Remove(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(JournalPath); !os.IsNotExist(err) {
+		t.Errorf("Journal.Remove() left %s", filepath.Base(JournalPath))
+	}
+}
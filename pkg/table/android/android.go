@@ -0,0 +1,606 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-18 11:32:14
+ * @LastEditTime: 2025-07-18 17:41:03
+ * @LastEditors: nijineko
+ * @Description: Android strings.xml字符串资源处理
+ * @FilePath: \AutoTranslation\pkg\table\android\android.go
+ */
+package android
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/nijinekoyo/AutoTranslation/pkg/table/resource"
+)
+
+// 资源文件格式
+var Format = resource.Format{Parse: Parse, Protect: resource.SpecifierPattern}
+
+// 默认资源目录和带语言限定符的资源目录，如"values"、"values-ja"、"values-zh-rCN"、"values-b+zh+Hans"
+var valuesDirectoryPattern = regexp.MustCompile(`^values(-[a-z]{2,3}(-r[A-Z]{2})?|-b\+[A-Za-z0-9+]+)?$`)
+
+// 元素内容的写法
+type contentKind int
+
+const (
+	contentText   contentKind = iota // 纯文本，读取时去除Android转义
+	contentMarkup                    // 包含<b>等HTML标记，原样读取内部XML
+	contentCDATA                     // 整个内容为CDATA
+)
+
+// 文件中的一段原始内容
+type span struct {
+	start, end int
+}
+
+// 可翻译的字符串，string元素或string-array、plurals中的item元素
+type value struct {
+	content span // 元素内容在文件中的位置
+	kind    contentKind
+}
+
+// 复数资源
+type plurals struct {
+	items      map[string]int  // 复数类别对应的条目索引
+	categories []string        // 源文件中的复数类别，按出现顺序排列
+	starts     map[string]span // 各item元素的开始位置到结束标记之后的位置
+	indent     string          // item元素的缩进
+}
+
+// 解析后的strings.xml文件
+type document struct {
+	data    []byte
+	entries []resource.Entry
+	values  []value // 与条目一一对应
+	plurals []*plurals
+	removed []span // 不可翻译的资源，不写入目标语言文件
+}
+
+/**
+ * @description: 创建一个新的Android字符串资源处理实例
+ * @param {string} FilePath strings.xml文件路径
+ * @param {string} OutputPath 输出文件路径，为空时覆盖源文件
+ * @param {string} Language 目标语言，用于调整复数资源的类别
+ * @return {*resource.ResourceTable} 返回一个新的资源文件表格实例
+ * @return {error} 错误信息
+ */
+func New(FilePath, OutputPath, Language string) (*resource.ResourceTable, error) {
+	return resource.New(FilePath, OutputPath, Language, Format)
+}
+
+/**
+ * @description: 获取目标语言的资源目录限定符
+ * @param {string} Language 语言代码，如"ja"、"zh-CN"、"zh-Hans"
+ * @return {string} 限定符，如"ja"、"zh-rCN"、"b+zh+Hans"
+ */
+func Qualifier(Language string) string {
+	Parts := strings.FieldsFunc(Language, func(r rune) bool { return r == '-' || r == '_' })
+	if len(Parts) == 0 {
+		return ""
+	}
+	Parts[0] = strings.ToLower(Parts[0])
+	if len(Parts) == 1 {
+		return Parts[0]
+	}
+	if len(Parts) == 2 && len(Parts[1]) == 2 {
+		return Parts[0] + "-r" + strings.ToUpper(Parts[1])
+	}
+	// 包含文字代码等子标签时使用BCP 47格式
+	return "b+" + strings.Join(Parts, "+")
+}
+
+/**
+ * @description: 将values目录中的资源文件路径替换为目标语言的目录，如"res/values/strings.xml"替换为"res/values-zh-rCN/strings.xml"
+ * @param {string} Path 资源文件路径
+ * @param {string} Language 目标语言
+ * @return {string} 目标语言的资源文件路径，不在values目录中时保持不变
+ */
+func LocalePath(Path, Language string) string {
+	if Language == "" || !IsResourcePath(Path) {
+		return Path
+	}
+	return filepath.Join(filepath.Dir(filepath.Dir(Path)), "values-"+Qualifier(Language), filepath.Base(Path))
+}
+
+/**
+ * @description: 判断文件是否位于values资源目录中，如"res/values/strings.xml"、"res/values-ja/strings.xml"
+ * @param {string} Path 文件路径
+ * @return {bool} 是否为Android字符串资源的路径
+ */
+func IsResourcePath(Path string) bool {
+	return valuesDirectoryPattern.MatchString(filepath.Base(filepath.Dir(Path)))
+}
+
+/**
+ * @description: 解析strings.xml文件，string、string-array和plurals中的文本可翻译，translatable="false"的资源跳过
+ * @param {[]byte} Data 文件内容
+ * @return {resource.Document} 解析后的文件
+ * @return {error} 错误信息
+ */
+func Parse(Data []byte) (resource.Document, error) {
+	Document := &document{data: Data}
+	Decoder := xml.NewDecoder(bytes.NewReader(Data))
+	Offset := func() int { return int(Decoder.InputOffset()) }
+
+	FoundRoot := false
+	Comment := "" // 紧邻资源元素之前的注释
+	for {
+		Start := Offset()
+		Token, err := Decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		switch Element := Token.(type) {
+		case xml.Comment:
+			Comment = strings.TrimSpace(string(Element))
+		case xml.CharData:
+			if len(bytes.TrimSpace(Element)) > 0 {
+				Comment = ""
+			}
+		case xml.StartElement:
+			if !FoundRoot {
+				if Element.Name.Local != "resources" {
+					return nil, fmt.Errorf("unexpected root element %s", Element.Name.Local)
+				}
+				FoundRoot = true
+				continue
+			}
+
+			Name := attribute(Element, "name")
+			if attribute(Element, "translatable") == "false" {
+				if err := Decoder.Skip(); err != nil {
+					return nil, err
+				}
+				Document.removed = append(Document.removed, lineSpan(Data, Start, Offset()))
+				Comment = ""
+				continue
+			}
+
+			switch Element.Name.Local {
+			case "string":
+				if err := Document.readValue(Decoder, Name, Comment); err != nil {
+					return nil, err
+				}
+			case "string-array":
+				if err := Document.readItems(Decoder, Name, Comment, nil); err != nil {
+					return nil, err
+				}
+			case "plurals":
+				Plurals := &plurals{items: map[string]int{}, starts: map[string]span{}}
+				if err := Document.readItems(Decoder, Name, Comment, Plurals); err != nil {
+					return nil, err
+				}
+				if _, ok := Plurals.items["other"]; ok {
+					Document.plurals = append(Document.plurals, Plurals)
+				}
+			default:
+				if err := Decoder.Skip(); err != nil {
+					return nil, err
+				}
+			}
+			Comment = ""
+		}
+	}
+	if !FoundRoot {
+		return nil, errors.New("missing resources element")
+	}
+
+	return Document, nil
+}
+
+/**
+ * @description: 读取当前元素的内容作为一个条目
+ * @param {*xml.Decoder} Decoder 解码器，位于元素的开始标记之后
+ * @param {string} Key 条目的键
+ * @param {string} Comment 条目的说明
+ * @return {error} 错误信息
+ */
+func (d *document) readValue(Decoder *xml.Decoder, Key, Comment string) error {
+	Start := int(Decoder.InputOffset())
+	HasChild := false
+	End := Start
+	for Depth := 0; ; {
+		Before := int(Decoder.InputOffset())
+		Token, err := Decoder.Token()
+		if err != nil {
+			return err
+		}
+		switch Token.(type) {
+		case xml.StartElement:
+			HasChild = true
+			Depth++
+		case xml.EndElement:
+			Depth--
+		}
+		if Depth < 0 {
+			End = Before
+			break
+		}
+	}
+
+	Raw := string(d.data[Start:End])
+	Value := value{content: span{Start, End}}
+	Source := ""
+	switch {
+	case HasChild:
+		Value.kind = contentMarkup
+		Source = strings.TrimSpace(Raw)
+	case strings.HasPrefix(strings.TrimSpace(Raw), "<![CDATA[") && strings.HasSuffix(strings.TrimSpace(Raw), "]]>") && strings.Count(Raw, "<![CDATA[") == 1:
+		Value.kind = contentCDATA
+		Source = strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(Raw), "<![CDATA["), "]]>")
+	default:
+		var Text strings.Builder
+		Inner := xml.NewDecoder(strings.NewReader("<v>" + Raw + "</v>"))
+		for {
+			Token, err := Inner.Token()
+			if err != nil {
+				break
+			}
+			if Data, ok := Token.(xml.CharData); ok {
+				Text.Write(Data)
+			}
+		}
+		// 首尾的空白不属于文本，转义的换行等保留
+		Source = unescape(strings.TrimSpace(Text.String()))
+	}
+	if Source == "" {
+		return nil
+	}
+
+	d.values = append(d.values, Value)
+	d.entries = append(d.entries, resource.Entry{Key: Key, Source: Source, Context: Comment})
+	return nil
+}
+
+/**
+ * @description: 读取string-array或plurals中的item元素
+ * @param {*xml.Decoder} Decoder 解码器，位于元素的开始标记之后
+ * @param {string} Name 资源名称
+ * @param {string} Comment 资源的说明
+ * @param {*plurals} Plurals 复数资源，读取string-array时为nil
+ * @return {error} 错误信息
+ */
+func (d *document) readItems(Decoder *xml.Decoder, Name, Comment string, Plurals *plurals) error {
+	Index := 0
+	for {
+		Start := int(Decoder.InputOffset())
+		Token, err := Decoder.Token()
+		if err != nil {
+			return err
+		}
+		switch Element := Token.(type) {
+		case xml.EndElement:
+			return nil
+		case xml.StartElement:
+			if Element.Name.Local != "item" {
+				if err := Decoder.Skip(); err != nil {
+					return err
+				}
+				continue
+			}
+
+			Key := Name + "." + strconv.Itoa(Index)
+			Quantity := attribute(Element, "quantity")
+			if Plurals != nil {
+				Key = Name + "." + Quantity
+			}
+			Count := len(d.entries)
+			if err := d.readValue(Decoder, Key, Comment); err != nil {
+				return err
+			}
+			Index++
+			if Plurals != nil && len(d.entries) > Count && slices.Contains(resource.PluralCategories, Quantity) {
+				Plurals.items[Quantity] = Count
+				Plurals.categories = append(Plurals.categories, Quantity)
+				Plurals.starts[Quantity] = span{Start, int(Decoder.InputOffset())}
+				Plurals.indent = lineIndent(d.data, Start)
+			}
+		}
+	}
+}
+
+/**
+ * @description: 获取可翻译条目
+ * @return {[]resource.Entry} 可翻译条目
+ */
+func (d *document) Entries() []resource.Entry {
+	return d.entries
+}
+
+/**
+ * @description: 生成目标语言文件，保持注释和格式，去除不可翻译的资源，复数资源按目标语言调整类别，未翻译的条目保留原文
+ * @param {[]string} Translations 各条目的译文
+ * @param {string} Language 目标语言
+ * @return {[]byte} 文件内容
+ * @return {error} 错误信息
+ */
+func (d *document) Marshal(Translations []string, Language string) ([]byte, error) {
+	type edit struct {
+		span
+		text string
+	}
+	var Edits []edit
+	for _, Removed := range d.removed {
+		Edits = append(Edits, edit{Removed, ""})
+	}
+
+	// 写入条目的内容
+	Content := func(Index int) string {
+		Value := d.values[Index]
+		if Index >= len(Translations) || Translations[Index] == "" {
+			return string(d.data[Value.content.start:Value.content.end])
+		}
+		Translation := Translations[Index]
+		switch Value.kind {
+		case contentMarkup:
+			if !wellFormed(Translation) {
+				return escape(Translation)
+			}
+			return escapeMarkup(Translation)
+		case contentCDATA:
+			return "<![CDATA[" + strings.ReplaceAll(escapeMarkup(Translation), "]]>", "]]]]><![CDATA[>") + "]]>"
+		default:
+			return escape(Translation)
+		}
+	}
+
+	InPlurals := map[int]bool{}
+	for _, Plurals := range d.plurals {
+		for _, Index := range Plurals.items {
+			InPlurals[Index] = true
+		}
+		Keep, Missing := resource.AdjustPlurals(Plurals.categories, Language)
+		for _, Category := range Plurals.categories {
+			Item := Plurals.starts[Category]
+			if !slices.Contains(Keep, Category) {
+				Edits = append(Edits, edit{lineSpan(d.data, Item.start, Item.end), ""})
+				continue
+			}
+			Value := d.values[Plurals.items[Category]]
+			Edits = append(Edits, edit{Value.content, Content(Plurals.items[Category])})
+		}
+
+		// 缺少的类别复制other的item元素，插入在保留的最后一个item之后
+		if len(Missing) > 0 {
+			Other := Plurals.starts["other"]
+			OtherValue := d.values[Plurals.items["other"]].content
+			Last := Other
+			for _, Category := range Keep {
+				if Plurals.starts[Category].end > Last.end {
+					Last = Plurals.starts[Category]
+				}
+			}
+			var Added strings.Builder
+			for _, Category := range Missing {
+				StartTag := regexp.MustCompile(`quantity\s*=\s*("[^"]*"|'[^']*')`).ReplaceAllString(string(d.data[Other.start:OtherValue.start]), `quantity="`+Category+`"`)
+				Added.WriteString(lineBreak(d.data) + Plurals.indent + StartTag + Content(Plurals.items["other"]) + string(d.data[OtherValue.end:Other.end]))
+			}
+			Edits = append(Edits, edit{span{Last.end, Last.end}, Added.String()})
+		}
+	}
+
+	for Index, Value := range d.values {
+		if !InPlurals[Index] {
+			Edits = append(Edits, edit{Value.content, Content(Index)})
+		}
+	}
+
+	slices.SortFunc(Edits, func(A, B edit) int { return A.start - B.start })
+	var Buffer bytes.Buffer
+	Last := 0
+	for _, Edit := range Edits {
+		if Edit.start < Last {
+			// 被删除的资源中的条目
+			continue
+		}
+		Buffer.Write(d.data[Last:Edit.start])
+		Buffer.WriteString(Edit.text)
+		Last = Edit.end
+	}
+	Buffer.Write(d.data[Last:])
+	return Buffer.Bytes(), nil
+}
+
+/**
+ * @description: 去除Android字符串资源的转义和包围文本的双引号
+ * @param {string} Text 元素中的文本
+ * @return {string} 原文
+ */
+func unescape(Text string) string {
+	var Builder strings.Builder
+	for Index := 0; Index < len(Text); Index++ {
+		Char := Text[Index]
+		switch {
+		case Char == '"':
+			// 未转义的双引号用于保留空白，不属于文本
+			continue
+		case Char != '\\' || Index+1 >= len(Text):
+			Builder.WriteByte(Char)
+			continue
+		}
+
+		Index++
+		switch Text[Index] {
+		case 'n':
+			Builder.WriteByte('\n')
+		case 't':
+			Builder.WriteByte('\t')
+		case 'u':
+			if Index+4 < len(Text) {
+				if Code, err := strconv.ParseUint(Text[Index+1:Index+5], 16, 32); err == nil {
+					Builder.WriteRune(rune(Code))
+					Index += 4
+					continue
+				}
+			}
+			Builder.WriteString(`\u`)
+		default:
+			Builder.WriteByte(Text[Index])
+		}
+	}
+	return Builder.String()
+}
+
+/**
+ * @description: 按Android字符串资源的规则转义纯文本译文
+ * @param {string} Text 译文
+ * @return {string} 元素内容
+ */
+func escape(Text string) string {
+	var Builder strings.Builder
+	for Index, Char := range Text {
+		switch Char {
+		case '\\':
+			Builder.WriteString(`\\`)
+		case '\'', '"':
+			Builder.WriteByte('\\')
+			Builder.WriteRune(Char)
+		case '\n':
+			Builder.WriteString(`\n`)
+		case '\t':
+			Builder.WriteString(`\t`)
+		case '&':
+			Builder.WriteString("&amp;")
+		case '<':
+			Builder.WriteString("&lt;")
+		case '>':
+			Builder.WriteString("&gt;")
+		case '@', '?':
+			// 开头的@和?表示资源引用
+			if Index == 0 {
+				Builder.WriteByte('\\')
+			}
+			Builder.WriteRune(Char)
+		default:
+			Builder.WriteRune(Char)
+		}
+	}
+	return Builder.String()
+}
+
+/**
+ * @description: 转义包含HTML标记的译文，标记和已有的转义保持不变，只转义标记外未转义的引号和换行
+ * @param {string} Text 译文
+ * @return {string} 元素内容
+ */
+func escapeMarkup(Text string) string {
+	var Builder strings.Builder
+	InTag := false
+	for Index := 0; Index < len(Text); Index++ {
+		Char := Text[Index]
+		switch {
+		case InTag:
+			InTag = Char != '>'
+		case Char == '<':
+			InTag = true
+		case Char == '\\' && Index+1 < len(Text):
+			Builder.WriteByte(Char)
+			Index++
+			Char = Text[Index]
+		case Char == '\'' || Char == '"':
+			Builder.WriteByte('\\')
+		case Char == '\n':
+			Builder.WriteString(`\n`)
+			continue
+		}
+		Builder.WriteByte(Char)
+	}
+	return Builder.String()
+}
+
+/**
+ * @description: 判断包含HTML标记的译文是否为格式正确的XML片段
+ * @param {string} Text 译文
+ * @return {bool} 是否格式正确
+ */
+func wellFormed(Text string) bool {
+	if !utf8.ValidString(Text) {
+		return false
+	}
+	Decoder := xml.NewDecoder(strings.NewReader("<v>" + Text + "</v>"))
+	for {
+		if _, err := Decoder.Token(); errors.Is(err, io.EOF) {
+			return true
+		} else if err != nil {
+			return false
+		}
+	}
+}
+
+/**
+ * @description: 获取元素的属性
+ * @param {xml.StartElement} Element 开始标记
+ * @param {string} Name 属性名称
+ * @return {string} 属性值，不存在时为空
+ */
+func attribute(Element xml.StartElement, Name string) string {
+	for _, Attr := range Element.Attr {
+		if Attr.Name.Local == Name && Attr.Name.Space == "" {
+			return Attr.Value
+		}
+	}
+	return ""
+}
+
+/**
+ * @description: 将元素的位置扩展为所在的整行，元素前后没有其他内容时包括缩进和换行符
+ * @param {[]byte} Data 文件内容
+ * @param {int} Start 元素开始位置
+ * @param {int} End 元素结束位置
+ * @return {span} 扩展后的位置
+ */
+func lineSpan(Data []byte, Start, End int) span {
+	LineStart := bytes.LastIndexByte(Data[:Start], '\n') + 1
+	LineEnd := bytes.IndexByte(Data[End:], '\n')
+	if LineEnd < 0 {
+		LineEnd = len(Data) - End
+	}
+	if len(bytes.TrimSpace(Data[LineStart:Start])) > 0 || len(bytes.TrimSpace(Data[End:End+LineEnd])) > 0 {
+		return span{Start, End}
+	}
+	if End+LineEnd < len(Data) {
+		LineEnd++
+	}
+	return span{LineStart, End + LineEnd}
+}
+
+/**
+ * @description: 获取指定位置所在行的缩进
+ * @param {[]byte} Data 文件内容
+ * @param {int} Offset 位置
+ * @return {string} 缩进，该位置之前有其他内容时为空
+ */
+func lineIndent(Data []byte, Offset int) string {
+	LineStart := bytes.LastIndexByte(Data[:Offset], '\n') + 1
+	Indent := Data[LineStart:Offset]
+	if len(bytes.TrimLeft(Indent, " \t")) > 0 {
+		return ""
+	}
+	return string(Indent)
+}
+
+/**
+ * @description: 获取文件使用的换行符
+ * @param {[]byte} Data 文件内容
+ * @return {string} 换行符
+ */
+func lineBreak(Data []byte) string {
+	if bytes.Contains(Data, []byte("\r\n")) {
+		return "\r\n"
+	}
+	return "\n"
+}
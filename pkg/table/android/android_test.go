@@ -0,0 +1,147 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-18 17:45:30
+ * @LastEditTime: 2025-07-18 18:37:12
+ * @LastEditors: nijineko
+ * @Description: Android strings.xml字符串资源处理测试
+ * @FilePath: \AutoTranslation\pkg\table\android\android_test.go
+ */
+package android
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/nijinekoyo/AutoTranslation/pkg/table/resource"
+)
+
+const testStrings = `<?xml version="1.0" encoding="utf-8"?>
+<resources>
+    <!-- Launcher label -->
+    <string name="app_name">My App</string>
+    <string name="api_key" translatable="false">abc123</string>
+    <string name="welcome">Don\'t panic, %1$s!</string>
+    <string name="html"><![CDATA[<b>Bold</b> text]]></string>
+    <string name="styled">Tap <b>here</b></string>
+    <string-array name="planets">
+        <item>Mercury</item>
+        <item>Venus</item>
+    </string-array>
+    <plurals name="songs">
+        <item quantity="one">%d song</item>
+        <item quantity="other">%d songs</item>
+    </plurals>
+</resources>
+`
+
+func TestAndroid_RoundTrip(t *testing.T) {
+	Directory := t.TempDir()
+	FilePath := filepath.Join(Directory, "values", "strings.xml")
+	OutputPath := LocalePath(FilePath, "ru")
+	if err := os.MkdirAll(filepath.Dir(FilePath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(FilePath, []byte(testStrings), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	TableInstance, err := New(FilePath, OutputPath, "ru")
+	if err != nil {
+		t.Fatal(err)
+	}
+	Datas, err := TableInstance.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"source", "target", "key", "context"},
+		{"My App", "", "app_name", "Launcher label"},
+		{"Don't panic, ⟦1⟧!", "", "welcome", resource.PlaceholderNote},
+		{"<b>Bold</b> text", "", "html", ""},
+		{"Tap <b>here</b>", "", "styled", ""},
+		{"Mercury", "", "planets.0", ""},
+		{"Venus", "", "planets.1", ""},
+		{"⟦1⟧ song", "", "songs.one", resource.PlaceholderNote},
+		{"⟦1⟧ songs", "", "songs.other", resource.PlaceholderNote},
+	}
+	if !reflect.DeepEqual(Datas, want) {
+		t.Fatalf("Read() = %q, want %q", Datas, want)
+	}
+
+	Datas[1][1] = "Моё приложение"
+	Datas[2][1] = "Не паникуй, ⟦1⟧!"
+	Datas[3][1] = "<b>Жирный</b> текст"
+	Datas[4][1] = "Нажмите <b>здесь</b>"
+	Datas[5][1] = "Меркурий"
+	Datas[7][1] = "⟦1⟧ песня"
+	Datas[8][1] = "⟦1⟧ песен"
+	if err := TableInstance.Write(Datas); err != nil {
+		t.Fatal(err)
+	}
+
+	// 不可翻译的资源被去除，占位符还原为格式说明符，俄语补充few和many
+	Output := `<?xml version="1.0" encoding="utf-8"?>
+<resources>
+    <!-- Launcher label -->
+    <string name="app_name">Моё приложение</string>
+    <string name="welcome">Не паникуй, %1$s!</string>
+    <string name="html"><![CDATA[<b>Жирный</b> текст]]></string>
+    <string name="styled">Нажмите <b>здесь</b></string>
+    <string-array name="planets">
+        <item>Меркурий</item>
+        <item>Venus</item>
+    </string-array>
+    <plurals name="songs">
+        <item quantity="one">%d песня</item>
+        <item quantity="other">%d песен</item>
+        <item quantity="few">%d песен</item>
+        <item quantity="many">%d песен</item>
+    </plurals>
+</resources>
+`
+	if got, _ := os.ReadFile(filepath.Join(Directory, "values-ru", "strings.xml")); string(got) != Output {
+		t.Errorf("output = %s, want %s", got, Output)
+	}
+
+	// 再次打开时已有译文中的格式说明符按原文编号替换为占位符
+	TableInstance, err = New(FilePath, OutputPath, "ru")
+	if err != nil {
+		t.Fatal(err)
+	}
+	Datas, _ = TableInstance.Read()
+	if got := Datas[2][1]; got != "Не паникуй, ⟦1⟧!" {
+		t.Errorf("Read() existing translation = %q", got)
+	}
+}
+
+func TestEscape(t *testing.T) {
+	for Text, want := range map[string]string{
+		"It's \"ok\"\n": `It\'s \"ok\"\n`,
+		"@string/x":     `\@string/x`,
+		"a & <b>":       `a &amp; &lt;b&gt;`,
+	} {
+		if got := escape(Text); got != want {
+			t.Errorf("escape(%q) = %q, want %q", Text, got, want)
+		}
+		if Text != "a & <b>" && unescape(escape(Text)) != Text {
+			t.Errorf("unescape(escape(%q)) = %q", Text, unescape(escape(Text)))
+		}
+	}
+}
+
+func TestLocalePath(t *testing.T) {
+	for Language, want := range map[string]string{
+		"ja":      filepath.Join("res", "values-ja", "strings.xml"),
+		"zh-CN":   filepath.Join("res", "values-zh-rCN", "strings.xml"),
+		"zh-Hans": filepath.Join("res", "values-b+zh+Hans", "strings.xml"),
+	} {
+		if got := LocalePath(filepath.Join("res", "values", "strings.xml"), Language); got != want {
+			t.Errorf("LocalePath(%q) = %q, want %q", Language, got, want)
+		}
+	}
+	if got := LocalePath(filepath.Join("res", "layout", "main.xml"), "ja"); got != filepath.Join("res", "layout", "main.xml") {
+		t.Errorf("LocalePath() outside values = %q", got)
+	}
+}
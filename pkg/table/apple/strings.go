@@ -0,0 +1,311 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-19 09:40:22
+ * @LastEditTime: 2025-07-19 14:18:37
+ * @LastEditors: nijineko
+ * @Description: Apple .strings本地化字符串文件处理
+ * @FilePath: \AutoTranslation\pkg\table\apple\strings.go
+ */
+package apple
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/nijinekoyo/AutoTranslation/pkg/table/resource"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// .strings文件格式
+var StringsFormat = resource.Format{Parse: ParseStrings, Protect: resource.SpecifierPattern}
+
+// 文件中的一段原始内容
+type span struct {
+	start, end int
+}
+
+// 解析后的.strings文件
+type stringsDocument struct {
+	text     string            // 解码后的文件内容
+	encoding encoding.Encoding // UTF-16文件的编码，UTF-8时为nil
+	values   []span            // 各条目的值，包括引号
+	entries  []resource.Entry
+}
+
+/**
+ * @description: 创建一个新的.strings文件处理实例
+ * @param {string} FilePath .strings文件路径
+ * @param {string} OutputPath 输出文件路径，为空时覆盖源文件
+ * @param {string} Language 目标语言
+ * @return {*resource.ResourceTable} 返回一个新的资源文件表格实例
+ * @return {error} 错误信息
+ */
+func NewStrings(FilePath, OutputPath, Language string) (*resource.ResourceTable, error) {
+	return resource.New(FilePath, OutputPath, Language, StringsFormat)
+}
+
+/**
+ * @description: 获取目标语言的.lproj目录名称，简体和繁体中文使用文字代码
+ * @param {string} Language 语言代码，如"ja"、"zh-CN"、"pt_BR"
+ * @return {string} 目录名称，如"ja"、"zh-Hans"、"pt-BR"
+ */
+func LocaleName(Language string) string {
+	Parts := strings.FieldsFunc(Language, func(r rune) bool { return r == '-' || r == '_' })
+	if len(Parts) == 0 {
+		return ""
+	}
+	Parts[0] = strings.ToLower(Parts[0])
+	if Parts[0] == "zh" && len(Parts) > 1 {
+		switch strings.ToUpper(Parts[1]) {
+		case "CN", "SG", "HANS":
+			return "zh-Hans"
+		case "TW", "HK", "MO", "HANT":
+			return "zh-Hant"
+		}
+	}
+	for Index := 1; Index < len(Parts); Index++ {
+		if len(Parts[Index]) == 2 {
+			Parts[Index] = strings.ToUpper(Parts[Index])
+		}
+	}
+	return strings.Join(Parts, "-")
+}
+
+/**
+ * @description: 将.lproj目录中的资源文件路径替换为目标语言的目录，如"en.lproj/Localizable.strings"替换为"zh-Hans.lproj/Localizable.strings"
+ * @param {string} Path 资源文件路径
+ * @param {string} Language 目标语言
+ * @return {string} 目标语言的资源文件路径，不在.lproj目录中时保持不变
+ */
+func LocalePath(Path, Language string) string {
+	Directory := filepath.Dir(Path)
+	if Language == "" || filepath.Ext(Directory) != ".lproj" {
+		return Path
+	}
+	return filepath.Join(filepath.Dir(Directory), LocaleName(Language)+".lproj", filepath.Base(Path))
+}
+
+/**
+ * @description: 解析.strings文件，支持UTF-8和带BOM的UTF-16编码，条目之前的注释作为说明
+ * @param {[]byte} Data 文件内容
+ * @return {resource.Document} 解析后的文件
+ * @return {error} 错误信息
+ */
+func ParseStrings(Data []byte) (resource.Document, error) {
+	Document := &stringsDocument{}
+	switch {
+	case bytes.HasPrefix(Data, []byte{0xFF, 0xFE}):
+		Document.encoding = unicode.UTF16(unicode.LittleEndian, unicode.UseBOM)
+	case bytes.HasPrefix(Data, []byte{0xFE, 0xFF}):
+		Document.encoding = unicode.UTF16(unicode.BigEndian, unicode.UseBOM)
+	}
+	if Document.encoding != nil {
+		Decoded, err := Document.encoding.NewDecoder().Bytes(Data)
+		if err != nil {
+			return nil, err
+		}
+		Data = Decoded
+	}
+	Document.text = string(Data)
+
+	// UTF-8 BOM保留在输出中
+	Scanner := &stringsScanner{text: Document.text}
+	if strings.HasPrefix(Document.text, "\uFEFF") {
+		Scanner.offset = len("\uFEFF")
+	}
+	for {
+		Comment := Scanner.skip()
+		if Scanner.offset >= len(Scanner.text) {
+			break
+		}
+
+		Key, _, err := Scanner.token()
+		if err != nil {
+			return nil, err
+		}
+		Scanner.skip()
+		if !Scanner.consume('=') {
+			return nil, Scanner.errorf("expected '=' after %q", Key)
+		}
+		Scanner.skip()
+		Value, Span, err := Scanner.token()
+		if err != nil {
+			return nil, err
+		}
+		Scanner.skip()
+		if !Scanner.consume(';') {
+			return nil, Scanner.errorf("expected ';' after %q", Key)
+		}
+
+		if Value == "" {
+			continue
+		}
+		Document.values = append(Document.values, Span)
+		Document.entries = append(Document.entries, resource.Entry{Key: Key, Source: Value, Context: Comment})
+	}
+
+	return Document, nil
+}
+
+/**
+ * @description: 获取可翻译条目
+ * @return {[]resource.Entry} 可翻译条目
+ */
+func (d *stringsDocument) Entries() []resource.Entry {
+	return d.entries
+}
+
+/**
+ * @description: 生成目标语言文件，保持注释、格式和编码，未翻译的条目保留原文
+ * @param {[]string} Translations 各条目的译文
+ * @param {string} Language 目标语言
+ * @return {[]byte} 文件内容
+ * @return {error} 错误信息
+ */
+func (d *stringsDocument) Marshal(Translations []string, Language string) ([]byte, error) {
+	var Builder strings.Builder
+	Last := 0
+	for Index, Span := range d.values {
+		if Index >= len(Translations) || Translations[Index] == "" {
+			continue
+		}
+		Builder.WriteString(d.text[Last:Span.start])
+		Builder.WriteString(quoteString(Translations[Index]))
+		Last = Span.end
+	}
+	Builder.WriteString(d.text[Last:])
+
+	if d.encoding != nil {
+		return d.encoding.NewEncoder().Bytes([]byte(Builder.String()))
+	}
+	return []byte(Builder.String()), nil
+}
+
+// .strings文件的词法分析器
+type stringsScanner struct {
+	text   string
+	offset int
+}
+
+/**
+ * @description: 跳过空白和注释
+ * @return {string} 跳过的最后一段注释
+ */
+func (s *stringsScanner) skip() string {
+	Comment := ""
+	for s.offset < len(s.text) {
+		Rest := s.text[s.offset:]
+		switch {
+		case strings.HasPrefix(Rest, "/*"):
+			Body, _, Found := strings.Cut(Rest[2:], "*/")
+			Comment = strings.TrimSpace(Body)
+			s.offset += 2 + len(Body)
+			if Found {
+				s.offset += 2
+			}
+		case strings.HasPrefix(Rest, "//"):
+			Body, _, _ := strings.Cut(Rest[2:], "\n")
+			Comment = strings.TrimSpace(Body)
+			s.offset += 2 + len(Body)
+		case strings.ContainsRune(" \t\r\n", rune(Rest[0])):
+			s.offset++
+		default:
+			return Comment
+		}
+	}
+	return Comment
+}
+
+/**
+ * @description: 读取下一个字符
+ * @param {byte} Char 期望的字符
+ * @return {bool} 下一个字符是否为期望的字符
+ */
+func (s *stringsScanner) consume(Char byte) bool {
+	if s.offset < len(s.text) && s.text[s.offset] == Char {
+		s.offset++
+		return true
+	}
+	return false
+}
+
+/**
+ * @description: 读取带引号的字符串或不带引号的标识符
+ * @return {string} 值
+ * @return {span} 在文件中的位置
+ * @return {error} 错误信息
+ */
+func (s *stringsScanner) token() (string, span, error) {
+	Start := s.offset
+	if s.offset >= len(s.text) {
+		return "", span{}, s.errorf("unexpected end of file")
+	}
+	if s.text[s.offset] != '"' {
+		for s.offset < len(s.text) && !strings.ContainsRune(" \t\r\n=;\"", rune(s.text[s.offset])) {
+			s.offset++
+		}
+		if s.offset == Start {
+			return "", span{}, s.errorf("unexpected character %q", s.text[s.offset])
+		}
+		return s.text[Start:s.offset], span{Start, s.offset}, nil
+	}
+
+	var Builder strings.Builder
+	for s.offset++; s.offset < len(s.text); s.offset++ {
+		Char := s.text[s.offset]
+		switch {
+		case Char == '"':
+			s.offset++
+			return Builder.String(), span{Start, s.offset}, nil
+		case Char != '\\' || s.offset+1 >= len(s.text):
+			Builder.WriteByte(Char)
+			continue
+		}
+
+		s.offset++
+		switch s.text[s.offset] {
+		case 'n':
+			Builder.WriteByte('\n')
+		case 't':
+			Builder.WriteByte('\t')
+		case 'r':
+			Builder.WriteByte('\r')
+		case 'U', 'u':
+			if s.offset+4 < len(s.text) {
+				if Code, err := strconv.ParseUint(s.text[s.offset+1:s.offset+5], 16, 32); err == nil {
+					Builder.WriteRune(rune(Code))
+					s.offset += 4
+					continue
+				}
+			}
+			Builder.WriteByte(s.text[s.offset])
+		default:
+			Builder.WriteByte(s.text[s.offset])
+		}
+	}
+	return "", span{}, s.errorf("unterminated string")
+}
+
+/**
+ * @description: 生成带有位置的错误信息
+ * @param {string} Format 格式
+ * @param {...any} Args 参数
+ * @return {error} 错误信息
+ */
+func (s *stringsScanner) errorf(Format string, Args ...any) error {
+	Line := strings.Count(s.text[:min(s.offset, len(s.text))], "\n") + 1
+	return fmt.Errorf("line %d: %s", Line, fmt.Sprintf(Format, Args...))
+}
+
+/**
+ * @description: 按.strings的转义规则编码字符串
+ * @param {string} Text 字符串
+ * @return {string} 带引号的字符串
+ */
+func quoteString(Text string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`, "\r", `\r`).Replace(Text) + `"`
+}
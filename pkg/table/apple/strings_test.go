@@ -0,0 +1,96 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-19 18:10:44
+ * @LastEditTime: 2025-07-19 18:52:06
+ * @LastEditors: nijineko
+ * @Description: Apple .strings本地化字符串文件处理测试
+ * @FilePath: \AutoTranslation\pkg\table\apple\strings_test.go
+ */
+package apple
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/nijinekoyo/AutoTranslation/pkg/table/resource"
+	"golang.org/x/text/encoding/unicode"
+)
+
+const testStrings = `/* Title of the main window */
+"title" = "Hello \"World\"";
+
+// Greeting
+greeting = "Hi, %@!";
+"empty" = "";
+`
+
+func TestStrings_RoundTrip(t *testing.T) {
+	Directory := t.TempDir()
+	FilePath := filepath.Join(Directory, "en.lproj", "Localizable.strings")
+	OutputPath := LocalePath(FilePath, "zh-CN")
+	if err := os.MkdirAll(filepath.Dir(FilePath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	// Xcode生成的.strings文件通常为UTF-16
+	Data, _ := unicode.UTF16(unicode.LittleEndian, unicode.UseBOM).NewEncoder().Bytes([]byte(testStrings))
+	if err := os.WriteFile(FilePath, Data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	TableInstance, err := NewStrings(FilePath, OutputPath, "zh-CN")
+	if err != nil {
+		t.Fatal(err)
+	}
+	Datas, err := TableInstance.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"source", "target", "key", "context"},
+		{`Hello "World"`, "", "title", "Title of the main window"},
+		{"Hi, ⟦1⟧!", "", "greeting", "Greeting\n" + resource.PlaceholderNote},
+	}
+	if !reflect.DeepEqual(Datas, want) {
+		t.Fatalf("Read() = %q, want %q", Datas, want)
+	}
+
+	Datas[1][1] = `你好"世界"`
+	Datas[2][1] = "嗨！"
+	if err := TableInstance.Write(Datas); err != nil {
+		t.Fatal(err)
+	}
+
+	// 遗漏占位符的译文不写入，保留原文
+	Output := `/* Title of the main window */
+"title" = "你好\"世界\"";
+
+// Greeting
+greeting = "Hi, %@!";
+"empty" = "";
+`
+	Written, err := os.ReadFile(filepath.Join(Directory, "zh-Hans.lproj", "Localizable.strings"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := unicode.UTF16(unicode.LittleEndian, unicode.ExpectBOM).NewDecoder().Bytes(Written); string(got) != Output {
+		t.Errorf("output = %s, want %s", got, Output)
+	}
+}
+
+func TestParseStrings_Invalid(t *testing.T) {
+	for _, Content := range []string{`"a" "b";`, `"a" = "b"`, `"a" = "b`} {
+		if _, err := ParseStrings([]byte(Content)); err == nil {
+			t.Errorf("ParseStrings(%q) should fail", Content)
+		}
+	}
+}
+
+func TestLocaleName(t *testing.T) {
+	for Language, want := range map[string]string{"ja": "ja", "zh-CN": "zh-Hans", "zh_TW": "zh-Hant", "pt-br": "pt-BR"} {
+		if got := LocaleName(Language); got != want {
+			t.Errorf("LocaleName(%q) = %q, want %q", Language, got, want)
+		}
+	}
+}
@@ -0,0 +1,406 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-19 14:25:09
+ * @LastEditTime: 2025-07-19 18:03:51
+ * @LastEditors: nijineko
+ * @Description: Apple .xcstrings字符串目录处理
+ * @FilePath: \AutoTranslation\pkg\table\apple\xcstrings.go
+ */
+package apple
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/nijinekoyo/AutoTranslation/pkg/table/resource"
+)
+
+// .xcstrings文件格式，字符串目录同时包含各语言的译文
+var CatalogFormat = resource.Format{Parse: ParseCatalog, Bilingual: true, Protect: resource.SpecifierPattern}
+
+// 写入译文后的状态
+const CatalogState = "needs_review"
+
+// 字符串目录中的可翻译文本
+type catalogValue struct {
+	key  string // 字符串的键
+	path string // 在本地化中的路径，如""、"plural.one"、"substitutions.count.plural.other"
+}
+
+// 解析后的.xcstrings文件
+type catalog struct {
+	root           map[string]any
+	sourceLanguage string
+	values         []catalogValue
+	entries        []resource.Entry
+	lineEnding     string
+}
+
+/**
+ * @description: 创建一个新的.xcstrings文件处理实例，译文写入目标语言的本地化
+ * @param {string} FilePath .xcstrings文件路径
+ * @param {string} OutputPath 输出文件路径，为空时覆盖源文件
+ * @param {string} Language 目标语言
+ * @return {*resource.ResourceTable} 返回一个新的资源文件表格实例
+ * @return {error} 错误信息
+ */
+func NewCatalog(FilePath, OutputPath, Language string) (*resource.ResourceTable, error) {
+	return resource.New(FilePath, OutputPath, LocaleName(Language), CatalogFormat)
+}
+
+/**
+ * @description: 解析.xcstrings文件，原文取源语言的本地化，没有时使用键，复数等变体的每个类别为一个条目
+ * @param {[]byte} Data 文件内容
+ * @return {resource.Document} 解析后的文件
+ * @return {error} 错误信息
+ */
+func ParseCatalog(Data []byte) (resource.Document, error) {
+	Catalog := &catalog{lineEnding: "\n"}
+	if bytes.Contains(Data, []byte("\r\n")) {
+		Catalog.lineEnding = "\r\n"
+	}
+	Decoder := json.NewDecoder(bytes.NewReader(Data))
+	Decoder.UseNumber()
+	if err := Decoder.Decode(&Catalog.root); err != nil {
+		return nil, err
+	}
+	Catalog.sourceLanguage, _ = Catalog.root["sourceLanguage"].(string)
+	Strings, ok := Catalog.root["strings"].(map[string]any)
+	if Catalog.sourceLanguage == "" || !ok {
+		return nil, errors.New("missing sourceLanguage or strings")
+	}
+
+	for _, Key := range sortedKeys(Strings) {
+		String, _ := Strings[Key].(map[string]any)
+		if String == nil || String["shouldTranslate"] == false {
+			continue
+		}
+		Comment, _ := String["comment"].(string)
+		Localizations, _ := String["localizations"].(map[string]any)
+		Source, _ := Localizations[Catalog.sourceLanguage].(map[string]any)
+		if Source == nil {
+			// 没有源语言本地化时键即为原文
+			Source = map[string]any{"stringUnit": map[string]any{"value": Key}}
+		}
+
+		for _, Unit := range walkLocalization(Source, "") {
+			if Unit.value == "" {
+				continue
+			}
+			EntryKey := Key
+			if Unit.path != "" {
+				EntryKey += "|" + Unit.path
+			}
+			Catalog.values = append(Catalog.values, catalogValue{key: Key, path: Unit.path})
+			Catalog.entries = append(Catalog.entries, resource.Entry{Key: EntryKey, Source: Unit.value, Context: Comment})
+		}
+	}
+
+	return Catalog, nil
+}
+
+// 本地化中的文本单元
+type stringUnit struct {
+	path  string
+	value string
+	state string
+}
+
+/**
+ * @description: 按路径顺序收集本地化中的文本单元
+ * @param {map[string]any} Localization 本地化或变体
+ * @param {string} Path 路径
+ * @return {[]stringUnit} 文本单元
+ */
+func walkLocalization(Localization map[string]any, Path string) []stringUnit {
+	Join := func(Parts ...string) string {
+		if Path != "" {
+			Parts = append([]string{Path}, Parts...)
+		}
+		return strings.Join(Parts, ".")
+	}
+
+	var Units []stringUnit
+	if Unit, ok := Localization["stringUnit"].(map[string]any); ok {
+		Value, _ := Unit["value"].(string)
+		State, _ := Unit["state"].(string)
+		Units = append(Units, stringUnit{path: Path, value: Value, state: State})
+	}
+	if Substitutions, ok := Localization["substitutions"].(map[string]any); ok {
+		for _, Name := range sortedKeys(Substitutions) {
+			if Substitution, ok := Substitutions[Name].(map[string]any); ok {
+				Units = append(Units, walkLocalization(Substitution, Join("substitutions", Name))...)
+			}
+		}
+	}
+	if Variations, ok := Localization["variations"].(map[string]any); ok {
+		for _, Kind := range sortedKeys(Variations) {
+			Cases, _ := Variations[Kind].(map[string]any)
+			for _, Case := range sortedKeys(Cases) {
+				if Variation, ok := Cases[Case].(map[string]any); ok {
+					Units = append(Units, walkLocalization(Variation, Join(Kind, Case))...)
+				}
+			}
+		}
+	}
+	return Units
+}
+
+/**
+ * @description: 判断本地化是否已全部翻译
+ * @param {map[string]any} Localization 本地化
+ * @return {bool} 是否已翻译
+ */
+func isTranslated(Localization map[string]any) bool {
+	Units := walkLocalization(Localization, "")
+	for _, Unit := range Units {
+		if Unit.value == "" || Unit.state == "new" {
+			return false
+		}
+	}
+	return len(Units) > 0
+}
+
+/**
+ * @description: 获取可翻译条目
+ * @return {[]resource.Entry} 可翻译条目
+ */
+func (c *catalog) Entries() []resource.Entry {
+	return c.entries
+}
+
+/**
+ * @description: 获取目标语言已有的译文，只有已全部翻译的本地化才视为已翻译
+ * @param {string} Language 目标语言
+ * @return {[]string} 译文，与条目顺序一致，未翻译时为空
+ */
+func (c *catalog) Targets(Language string) []string {
+	Strings := c.root["strings"].(map[string]any)
+	Targets := make([]string, len(c.values))
+	for Index, Value := range c.values {
+		Localizations, _ := Strings[Value.key].(map[string]any)["localizations"].(map[string]any)
+		Localization, _ := Localizations[Language].(map[string]any)
+		if Localization == nil || !isTranslated(Localization) {
+			continue
+		}
+		for _, Unit := range walkLocalization(Localization, "") {
+			if Unit.path == Value.path {
+				Targets[Index] = Unit.value
+			}
+		}
+	}
+	return Targets
+}
+
+/**
+ * @description: 将译文写入目标语言的本地化，结构与源语言相同，复数变体按目标语言调整类别，已翻译的本地化保持不变
+ * @param {[]string} Translations 各条目的译文
+ * @param {string} Language 目标语言
+ * @return {[]byte} 文件内容
+ * @return {error} 错误信息
+ */
+func (c *catalog) Marshal(Translations []string, Language string) ([]byte, error) {
+	if Language == "" {
+		return nil, errors.New("target language is required for string catalogs")
+	}
+
+	// 按字符串的键分组译文
+	Groups := map[string]map[string]string{}
+	for Index, Value := range c.values {
+		if Index >= len(Translations) || Translations[Index] == "" {
+			continue
+		}
+		if Groups[Value.key] == nil {
+			Groups[Value.key] = map[string]string{}
+		}
+		Groups[Value.key][Value.path] = Translations[Index]
+	}
+
+	Strings := c.root["strings"].(map[string]any)
+	for Key, Group := range Groups {
+		String := Strings[Key].(map[string]any)
+		Localizations, _ := String["localizations"].(map[string]any)
+		if Localizations == nil {
+			Localizations = map[string]any{}
+			String["localizations"] = Localizations
+		}
+		if Existing, ok := Localizations[Language].(map[string]any); ok && isTranslated(Existing) {
+			continue
+		}
+		Source, _ := Localizations[c.sourceLanguage].(map[string]any)
+		if Source == nil {
+			Source = map[string]any{"stringUnit": map[string]any{"value": Key}}
+		}
+		Localizations[Language] = mirrorLocalization(Source, "", Group, Language)
+	}
+
+	var Buffer bytes.Buffer
+	writeJSON(&Buffer, c.root, 0, c.lineEnding)
+	Buffer.WriteString(c.lineEnding)
+	return Buffer.Bytes(), nil
+}
+
+/**
+ * @description: 按源语言本地化的结构生成目标语言的本地化，缺少的复数类别使用other的译文
+ * @param {map[string]any} Source 源语言本地化或变体
+ * @param {string} Path 路径
+ * @param {map[string]string} Translations 译文，键为路径，缺少时使用原文
+ * @param {string} Language 目标语言
+ * @return {map[string]any} 目标语言的本地化
+ */
+func mirrorLocalization(Source map[string]any, Path string, Translations map[string]string, Language string) map[string]any {
+	Join := func(Parts ...string) string {
+		if Path != "" {
+			Parts = append([]string{Path}, Parts...)
+		}
+		return strings.Join(Parts, ".")
+	}
+
+	Target := map[string]any{}
+	for Name, Value := range Source {
+		switch Name {
+		case "stringUnit":
+			Unit, _ := Value.(map[string]any)
+			Text, ok := Translations[Path]
+			if !ok {
+				Text, _ = Unit["value"].(string)
+			}
+			Target[Name] = map[string]any{"state": CatalogState, "value": Text}
+		case "substitutions":
+			Substitutions, _ := Value.(map[string]any)
+			Result := map[string]any{}
+			for Key, Substitution := range Substitutions {
+				if Child, ok := Substitution.(map[string]any); ok {
+					Result[Key] = mirrorLocalization(Child, Join("substitutions", Key), Translations, Language)
+				}
+			}
+			Target[Name] = Result
+		case "variations":
+			Variations, _ := Value.(map[string]any)
+			Result := map[string]any{}
+			for Kind, Cases := range Variations {
+				Cases, _ := Cases.(map[string]any)
+				Mapping := map[string]string{} // 目标语言的类别对应的源语言类别
+				if Kind == "plural" {
+					Keep, Missing := resource.AdjustPlurals(sortedKeys(Cases), Language)
+					for _, Case := range Keep {
+						Mapping[Case] = Case
+					}
+					if _, ok := Cases["other"]; ok {
+						for _, Case := range Missing {
+							Mapping[Case] = "other"
+						}
+					}
+				} else {
+					for Case := range Cases {
+						Mapping[Case] = Case
+					}
+				}
+
+				Mirrored := map[string]any{}
+				for Case, From := range Mapping {
+					if Child, ok := Cases[From].(map[string]any); ok {
+						Mirrored[Case] = mirrorLocalization(Child, Join(Kind, From), Translations, Language)
+					}
+				}
+				Result[Kind] = Mirrored
+			}
+			Target[Name] = Result
+		default:
+			// argNum、formatSpecifier等属性保持不变
+			Target[Name] = Value
+		}
+	}
+	return Target
+}
+
+/**
+ * @description: 按Xcode的格式写入JSON，键按字母顺序排列，缩进为两个空格，键和值之间为" : "
+ * @param {*bytes.Buffer} Buffer 输出
+ * @param {any} Value 值
+ * @param {int} Depth 缩进层级
+ * @param {string} LineEnding 换行符
+ */
+func writeJSON(Buffer *bytes.Buffer, Value any, Depth int, LineEnding string) {
+	Newline := func(Depth int) {
+		Buffer.WriteString(LineEnding)
+		Buffer.WriteString(strings.Repeat("  ", Depth))
+	}
+
+	switch Data := Value.(type) {
+	case map[string]any:
+		if len(Data) == 0 {
+			// Xcode在空对象中保留一个空行
+			Buffer.WriteString("{" + LineEnding)
+			Newline(Depth)
+			Buffer.WriteString("}")
+			return
+		}
+		Buffer.WriteString("{")
+		for Index, Key := range sortedKeys(Data) {
+			if Index > 0 {
+				Buffer.WriteString(",")
+			}
+			Newline(Depth + 1)
+			writeString(Buffer, Key)
+			Buffer.WriteString(" : ")
+			writeJSON(Buffer, Data[Key], Depth+1, LineEnding)
+		}
+		Newline(Depth)
+		Buffer.WriteString("}")
+	case []any:
+		Buffer.WriteString("[")
+		for Index, Item := range Data {
+			if Index > 0 {
+				Buffer.WriteString(",")
+			}
+			Newline(Depth + 1)
+			writeJSON(Buffer, Item, Depth+1, LineEnding)
+		}
+		if len(Data) > 0 {
+			Newline(Depth)
+		}
+		Buffer.WriteString("]")
+	case string:
+		writeString(Buffer, Data)
+	case json.Number:
+		Buffer.WriteString(Data.String())
+	case bool:
+		if Data {
+			Buffer.WriteString("true")
+		} else {
+			Buffer.WriteString("false")
+		}
+	default:
+		Buffer.WriteString("null")
+	}
+}
+
+/**
+ * @description: 写入JSON字符串，不转义HTML字符
+ * @param {*bytes.Buffer} Buffer 输出
+ * @param {string} Text 字符串
+ */
+func writeString(Buffer *bytes.Buffer, Text string) {
+	Encoder := json.NewEncoder(Buffer)
+	Encoder.SetEscapeHTML(false)
+	Encoder.Encode(Text)
+	Buffer.Truncate(Buffer.Len() - 1)
+}
+
+/**
+ * @description: 获取按字母顺序排列的键
+ * @param {map[string]any} Data 对象
+ * @return {[]string} 键
+ */
+func sortedKeys(Data map[string]any) []string {
+	Keys := make([]string, 0, len(Data))
+	for Key := range Data {
+		Keys = append(Keys, Key)
+	}
+	sort.Strings(Keys)
+	return Keys
+}
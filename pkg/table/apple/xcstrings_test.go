@@ -0,0 +1,169 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-19 18:55:17
+ * @LastEditTime: 2025-07-19 19:40:28
+ * @LastEditors: nijineko
+ * @Description: Apple .xcstrings字符串目录处理测试
+ * @FilePath: \AutoTranslation\pkg\table\apple\xcstrings_test.go
+ */
+package apple
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/nijinekoyo/AutoTranslation/pkg/table/resource"
+)
+
+const testCatalog = `{
+  "sourceLanguage" : "en",
+  "strings" : {
+    "%lld items" : {
+      "localizations" : {
+        "en" : {
+          "variations" : {
+            "plural" : {
+              "one" : {
+                "stringUnit" : {
+                  "state" : "translated",
+                  "value" : "%lld item"
+                }
+              },
+              "other" : {
+                "stringUnit" : {
+                  "state" : "translated",
+                  "value" : "%lld items"
+                }
+              }
+            }
+          }
+        }
+      }
+    },
+    "Done" : {
+      "comment" : "Button title",
+      "localizations" : {
+        "zh-Hans" : {
+          "stringUnit" : {
+            "state" : "translated",
+            "value" : "完成"
+          }
+        }
+      }
+    },
+    "Hello" : {
+
+    },
+    "v1.0" : {
+      "shouldTranslate" : false
+    }
+  },
+  "version" : "1.0"
+}
+`
+
+func TestCatalog_RoundTrip(t *testing.T) {
+	FilePath := filepath.Join(t.TempDir(), "Localizable.xcstrings")
+	if err := os.WriteFile(FilePath, []byte(testCatalog), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	TableInstance, err := NewCatalog(FilePath, "", "zh-CN")
+	if err != nil {
+		t.Fatal(err)
+	}
+	Datas, err := TableInstance.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 已翻译的本地化带有译文
+	want := [][]string{
+		{"source", "target", "key", "context"},
+		{"⟦1⟧ item", "", "%lld items|plural.one", resource.PlaceholderNote},
+		{"⟦1⟧ items", "", "%lld items|plural.other", resource.PlaceholderNote},
+		{"Done", "完成", "Done", "Button title"},
+		{"Hello", "", "Hello", ""},
+	}
+	if !reflect.DeepEqual(Datas, want) {
+		t.Fatalf("Read() = %q, want %q", Datas, want)
+	}
+
+	Datas[1][1] = "⟦1⟧ 个项目"
+	Datas[2][1] = "⟦1⟧ 个项目"
+	Datas[4][1] = "你好"
+	if err := TableInstance.Write(Datas); err != nil {
+		t.Fatal(err)
+	}
+
+	// 中文只有other，键按字母顺序排列
+	Output := `{
+  "sourceLanguage" : "en",
+  "strings" : {
+    "%lld items" : {
+      "localizations" : {
+        "en" : {
+          "variations" : {
+            "plural" : {
+              "one" : {
+                "stringUnit" : {
+                  "state" : "translated",
+                  "value" : "%lld item"
+                }
+              },
+              "other" : {
+                "stringUnit" : {
+                  "state" : "translated",
+                  "value" : "%lld items"
+                }
+              }
+            }
+          }
+        },
+        "zh-Hans" : {
+          "variations" : {
+            "plural" : {
+              "other" : {
+                "stringUnit" : {
+                  "state" : "needs_review",
+                  "value" : "%lld 个项目"
+                }
+              }
+            }
+          }
+        }
+      }
+    },
+    "Done" : {
+      "comment" : "Button title",
+      "localizations" : {
+        "zh-Hans" : {
+          "stringUnit" : {
+            "state" : "translated",
+            "value" : "完成"
+          }
+        }
+      }
+    },
+    "Hello" : {
+      "localizations" : {
+        "zh-Hans" : {
+          "stringUnit" : {
+            "state" : "needs_review",
+            "value" : "你好"
+          }
+        }
+      }
+    },
+    "v1.0" : {
+      "shouldTranslate" : false
+    }
+  },
+  "version" : "1.0"
+}
+`
+	if got, _ := os.ReadFile(FilePath); string(got) != Output {
+		t.Errorf("output = %s, want %s", got, Output)
+	}
+}
@@ -0,0 +1,141 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-07 16:10:32
+ * @LastEditTime: 2025-07-07 17:02:45
+ * @LastEditors: nijineko
+ * @Description: 列引用解析
+ * @FilePath: \AutoTranslation\pkg\table\column.go
+ */
+package table
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	// 表头中找不到指定的列
+	ErrColumnNotFound = errors.New("column not found")
+	// 表头中有多个同名的列
+	ErrAmbiguousColumn = errors.New("ambiguous column name")
+)
+
+// Excel最大列数
+const maxColumnCount = 16384
+
+/**
+ * @description: 解析列引用，依次尝试从1开始的列号、表头名称和Excel列字母，用方括号包围时只按表头名称查找
+ * @param {[]string} Header 表头，即表格的第一行
+ * @param {string} Reference 列引用，如"3"、"Japanese"、"C"、"[EN]"
+ * @return {int} 列索引，从0开始计数
+ * @return {error} 错误信息，找不到时返回ErrColumnNotFound，表头重名时返回ErrAmbiguousColumn
+ */
+func ResolveColumn(Header []string, Reference string) (int, error) {
+	Reference = strings.TrimSpace(Reference)
+	if Reference == "" {
+		return 0, fmt.Errorf("%w: empty column reference", ErrColumnNotFound)
+	}
+
+	// 方括号包围的表头名称，用于与列字母相同的表头，如"[EN]"
+	if Name, ok := HeaderName(Reference); ok {
+		return FindHeader(Header, Name)
+	}
+
+	// 列号
+	if Number, err := strconv.Atoi(Reference); err == nil {
+		if Number < 1 {
+			return 0, fmt.Errorf("invalid column number: %d", Number)
+		}
+		return Number - 1, nil
+	}
+
+	// 表头名称优先于列字母，避免"ID"之类的表头被当作列字母
+	Index, err := FindHeader(Header, Reference)
+	if err == nil || errors.Is(err, ErrAmbiguousColumn) {
+		return Index, err
+	}
+
+	if Index, ok := ColumnLetterIndex(Reference); ok {
+		return Index, nil
+	}
+	return 0, err
+}
+
+/**
+ * @description: 获取方括号包围的表头名称
+ * @param {string} Reference 列引用
+ * @return {string} 表头名称
+ * @return {bool} 是否为方括号包围的表头名称
+ */
+func HeaderName(Reference string) (string, bool) {
+	Reference = strings.TrimSpace(Reference)
+	if len(Reference) < 2 || !strings.HasPrefix(Reference, "[") || !strings.HasSuffix(Reference, "]") {
+		return "", false
+	}
+	return strings.TrimSpace(Reference[1 : len(Reference)-1]), true
+}
+
+/**
+ * @description: 按名称查找表头，忽略首尾空白
+ * @param {[]string} Header 表头
+ * @param {string} Name 表头名称
+ * @return {int} 列索引，从0开始计数
+ * @return {error} 错误信息
+ */
+func FindHeader(Header []string, Name string) (int, error) {
+	Name = strings.TrimSpace(Name)
+
+	Found := -1
+	for Index, Cell := range Header {
+		if strings.TrimSpace(Cell) != Name {
+			continue
+		}
+		if Found >= 0 {
+			return 0, fmt.Errorf("%w: %q appears in columns %s and %s", ErrAmbiguousColumn, Name, ColumnLetters(Found), ColumnLetters(Index))
+		}
+		Found = Index
+	}
+	if Found < 0 {
+		return 0, fmt.Errorf("%w: %q", ErrColumnNotFound, Name)
+	}
+	return Found, nil
+}
+
+/**
+ * @description: 将Excel列字母转换为列索引
+ * @param {string} Letters 大写列字母，如"A"、"AB"
+ * @return {int} 列索引，从0开始计数
+ * @return {bool} 是否为有效的列字母
+ */
+func ColumnLetterIndex(Letters string) (int, bool) {
+	if Letters == "" || len(Letters) > 3 {
+		return 0, false
+	}
+
+	Number := 0
+	for _, Letter := range Letters {
+		if Letter < 'A' || Letter > 'Z' {
+			return 0, false
+		}
+		Number = Number*26 + int(Letter-'A') + 1
+	}
+	if Number > maxColumnCount {
+		return 0, false
+	}
+	return Number - 1, true
+}
+
+/**
+ * @description: 将列索引转换为Excel列字母
+ * @param {int} Index 列索引，从0开始计数
+ * @return {string} 列字母
+ */
+func ColumnLetters(Index int) string {
+	var Letters []byte
+	for Number := Index + 1; Number > 0; Number = (Number - 1) / 26 {
+		Letters = append([]byte{byte('A' + (Number-1)%26)}, Letters...)
+	}
+	return string(Letters)
+}
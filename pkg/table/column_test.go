@@ -0,0 +1,61 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-07 16:48:03
+ * @LastEditTime: 2025-07-07 17:00:19
+ * @LastEditors: nijineko
+ * @Description: 列引用解析测试
+ * @FilePath: \AutoTranslation\pkg\table\column_test.go
+ */
+package table
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestResolveColumn(t *testing.T) {
+	Header := []string{"ID", "Japanese", " English ", "Note", "Note"}
+
+	tests := []struct {
+		name      string
+		reference string
+		want      int
+		wantErr   error
+	}{
+		{name: "Column number", reference: "3", want: 2},
+		{name: "Header name", reference: "Japanese", want: 1},
+		{name: "Header name with spaces", reference: "English", want: 2},
+		{name: "Header name before letters", reference: "ID", want: 0},
+		{name: "Column letters", reference: "C", want: 2},
+		{name: "Wide column letters", reference: "AB", want: 27},
+		{name: "Bracketed header", reference: "[ID]", want: 0},
+		{name: "Bracketed missing header", reference: "[C]", wantErr: ErrColumnNotFound},
+		{name: "Ambiguous header", reference: "Note", wantErr: ErrAmbiguousColumn},
+		{name: "Missing header", reference: "Korean", wantErr: ErrColumnNotFound},
+		{name: "Empty reference", reference: "", wantErr: ErrColumnNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveColumn(Header, tt.reference)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ResolveColumn() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr == nil && got != tt.want {
+				t.Errorf("ResolveColumn() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestColumnLetters(t *testing.T) {
+	for _, Index := range []int{0, 25, 26, 701, 702, 16383} {
+		Letters := ColumnLetters(Index)
+		if got, ok := ColumnLetterIndex(Letters); !ok || got != Index {
+			t.Errorf("ColumnLetterIndex(%q) = %v, %v, want %v", Letters, got, ok, Index)
+		}
+	}
+	if _, ok := ColumnLetterIndex("XFE"); ok {
+		t.Errorf("ColumnLetterIndex(%q) should be out of range", "XFE")
+	}
+}
@@ -1,7 +1,7 @@
 /*
  * @Author: nijineko
  * @Date: 2025-07-03 15:33:36
- * @LastEditTime: 2025-07-03 20:45:44
+ * @LastEditTime: 2025-07-06 11:05:37
  * @LastEditors: nijineko
  * @Description: CSV表格数据处理实现
  * @FilePath: \AutoTranslation\pkg\table\csv\csv.go
@@ -9,53 +9,127 @@
 package csv
 
 import (
-	"encoding/csv"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
 	"os"
+
+	"github.com/nijinekoyo/AutoTranslation/tools/file"
 )
 
 // CSV表格数据处理结构体
 type CSVTable struct {
-	fileHandle *os.File
-	reader     *csv.Reader
-	writer     *csv.Writer
+	filePath   string // 源文件路径
+	outputPath string // 输出文件路径
+
+	dialect        Dialect      // 方言，分隔符已确定
+	encoding       fileEncoding // 源文件编码
+	outputEncoding fileEncoding // 输出文件编码
+	lineEnding     string       // 换行符，与源文件保持一致
+	document       *document    // 最近一次读取的文件，写入时保留其中的注释行和空行
+	stream         *streamState // 最近一次流式读取时记录的注释行和空行
+
+	isWritten bool // 是否已写入过输出文件
+	isClosed  bool // 是否已关闭
+}
 
-	isClosed bool // 是否已关闭
+/**
+ * @description: 创建一个新的CSV表格处理实例，自动检测分隔符
+ * @param {string} FilePath CSV文件路径
+ * @param {string} OutputPath 输出文件路径，为空时覆盖源文件
+ * @return {*CSVTable} 返回一个新的CSVTable实例
+ * @return {error} 错误信息
+ */
+func New(FilePath string, OutputPath string) (*CSVTable, error) {
+	return NewWithDialect(FilePath, OutputPath, Dialect{})
 }
 
 /**
- * @description: 创建一个新的CSV表格处理实例
+ * @description: 按指定方言创建一个新的CSV表格处理实例，未指定分隔符时自动检测，换行风格与源文件保持一致
  * @param {string} FilePath CSV文件路径
+ * @param {string} OutputPath 输出文件路径，为空时覆盖源文件
+ * @param {Dialect} Dialect 方言
  * @return {*CSVTable} 返回一个新的CSVTable实例
  * @return {error} 错误信息
  */
-func New(FilePath string) (*CSVTable, error) {
-	// 打开CSV文件，如果不存在则创建
-	FileHandle, err := os.OpenFile(FilePath, os.O_RDWR|os.O_CREATE, 0644)
+func NewWithDialect(FilePath string, OutputPath string, Dialect Dialect) (*CSVTable, error) {
+	// 检查CSV文件，如果不存在则创建
+	FileHandle, err := os.OpenFile(FilePath, os.O_RDONLY|os.O_CREATE, 0644)
 	if err != nil {
 		return nil, err
 	}
+	// 只读取文件开头用于检测，流式读写大文件时不必整个读入内存
+	Data := make([]byte, detectSize)
+	Size, err := io.ReadFull(FileHandle, Data)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		FileHandle.Close()
+		return nil, err
+	}
+	if err := FileHandle.Close(); err != nil {
+		return nil, err
+	}
+	Data = Data[:Size]
+	if Size == detectSize {
+		// 截断处可能是不完整的字符
+		Data = trimPartialRune(Data)
+	}
+
+	// 检测或按配置获取编码，之后的检测都基于解码后的文本
+	Encoding := detectEncoding(Data)
+	if Dialect.Encoding != "" {
+		if Encoding, err = lookupEncoding(Dialect.Encoding); err != nil {
+			return nil, err
+		}
+		Encoding.bom = bytes.HasPrefix(Data, byteOrderMarks[Encoding.name])
+	}
+	OutputEncoding := Encoding
+	if Dialect.OutputEncoding != "" {
+		if OutputEncoding, err = lookupEncoding(Dialect.OutputEncoding); err != nil {
+			return nil, err
+		}
+	}
+	OutputEncoding.bom = Encoding.bom || Dialect.AddBOM
+
+	if Data, err = Encoding.decode(Data); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", FilePath, err)
+	}
+
+	if Dialect.Delimiter == 0 {
+		Dialect.Delimiter = detectDelimiter(Data, Dialect.Comment)
+	}
+	if Dialect.Delimiter == Dialect.Comment || Dialect.Delimiter == '"' || Dialect.Delimiter == '\r' || Dialect.Delimiter == '\n' {
+		return nil, fmt.Errorf("invalid csv delimiter %q", Dialect.Delimiter)
+	}
+
+	if OutputPath == "" {
+		OutputPath = FilePath
+	}
 
 	return &CSVTable{
-		fileHandle: FileHandle,
-		reader:     csv.NewReader(FileHandle),
-		writer:     csv.NewWriter(FileHandle),
-		isClosed:   false,
+		filePath:       FilePath,
+		outputPath:     OutputPath,
+		dialect:        Dialect,
+		encoding:       Encoding,
+		outputEncoding: OutputEncoding,
+		lineEnding:     detectLineEnding(Data, Dialect),
+		isClosed:       false,
 	}, nil
 }
 
+/**
+ * @description: 获取检测后的方言
+ * @return {Dialect} 方言
+ */
+func (c *CSVTable) Dialect() Dialect {
+	return c.dialect
+}
+
 /**
  * @description: 关闭表格实例
  * @return {*}
  */
 func (c *CSVTable) Close() error {
-	// 刷新CSV写入器
-	c.writer.Flush()
-
-	// 关闭文件句柄
-	if err := c.fileHandle.Close(); err != nil {
-		return err
-	}
-
 	// 标记为已关闭
 	c.isClosed = true
 
@@ -63,7 +137,7 @@ func (c *CSVTable) Close() error {
 }
 
 /**
- * @description: 读取CSV表格数据
+ * @description: 读取CSV表格数据，写入过输出文件后读取输出文件
  * @return {[][]string} 表格数据
  * @return {error} 错误信息
  */
@@ -72,11 +146,34 @@ func (c *CSVTable) Read() ([][]string, error) {
 		return nil, os.ErrClosed
 	}
 
-	return c.reader.ReadAll()
+	FilePath, Encoding := c.filePath, c.encoding
+	if c.isWritten {
+		FilePath, Encoding = c.outputPath, c.outputEncoding
+	}
+
+	Data, err := os.ReadFile(FilePath)
+	if err != nil {
+		return nil, err
+	}
+	if Data, err = Encoding.decode(Data); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", FilePath, err)
+	}
+
+	Document, err := parse(Data, c.dialect)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", FilePath, err)
+	}
+	c.document = Document
+
+	Datas := make([][]string, len(Document.records))
+	for Index, Record := range Document.records {
+		Datas[Index] = append([]string{}, Record...)
+	}
+	return Datas, nil
 }
 
 /**
- * @description: 写入CSV表格数据
+ * @description: 写入CSV表格数据到输出文件，先写入临时文件再替换，失败时不影响原有文件
  * @param {[][]string} Datas 要写入的数据
  * @return {error} 错误信息
  */
@@ -85,27 +182,29 @@ func (c *CSVTable) Write(Datas [][]string) error {
 		return os.ErrClosed
 	}
 
-	// 关闭旧的文件句柄
-	if err := c.fileHandle.Close(); err != nil {
-		return err
+	// 保留最近一次读取时的注释行、空行和结尾换行，新文件以换行结尾
+	Document := &document{extras: map[int][]string{}, finalNewline: true}
+	if c.document != nil {
+		Document.extras, Document.finalNewline = c.document.extras, c.document.finalNewline || len(c.document.records) == 0
 	}
+	Document.records = Datas
 
-	// 创建一个新的文件句柄以覆盖数据
-	NewFileHandle, err := os.Create(c.fileHandle.Name())
+	// 按输出编码转换，存在无法表示的字符时不写入文件
+	Data, err := c.outputEncoding.encode(Document.format(c.dialect, c.lineEnding))
 	if err != nil {
 		return err
 	}
 
-	c.fileHandle = NewFileHandle
-	c.writer = csv.NewWriter(NewFileHandle)
-
-	for _, Data := range Datas {
-		if err := c.writer.Write(Data); err != nil {
-			return err
-		}
+	err = file.WriteAtomic(c.outputPath, func(Writer io.Writer) error {
+		_, err := Writer.Write(Data)
+		return err
+	})
+	if err != nil {
+		return err
 	}
+	c.isWritten = true
 
-	return c.writer.Error()
+	return nil
 }
 
 /**
@@ -221,6 +320,7 @@ func (c *CSVTable) Insert(Row int, Data []string) error {
 
 	// 插入新行数据
 	TableDatas = append(TableDatas[:Row], append([][]string{Data}, TableDatas[Row:]...)...)
+	c.document.shift(Row, 1)
 
 	// 写回更新后的数据
 	return c.Write(TableDatas)
@@ -252,6 +352,7 @@ func (c *CSVTable) Delete(Row int) error {
 
 	// 删除指定行数据
 	TableDatas = append(TableDatas[:Row], TableDatas[Row+1:]...)
+	c.document.shift(Row, -1)
 
 	// 写回更新后的数据
 	return c.Write(TableDatas)
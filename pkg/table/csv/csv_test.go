@@ -0,0 +1,331 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-11 15:40:08
+ * @LastEditTime: 2025-07-11 16:12:33
+ * @LastEditors: nijineko
+ * @Description: CSV表格数据处理测试
+ * @FilePath: \AutoTranslation\pkg\table\csv\csv_test.go
+ */
+package csv
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/unicode"
+)
+
+func TestCSVTable_Dialect(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialect Dialect
+		content string
+		want    [][]string
+		update  string // 更新第一行第二列后的文件内容
+	}{
+		{
+			name:    "Detect semicolon and keep CRLF",
+			content: "id;text\r\n1;\"a;b\"\r\n",
+			want:    [][]string{{"id", "text"}, {"1", "a;b"}},
+			update:  "id;翻译\r\n1;\"a;b\"\r\n",
+		},
+		{
+			name:    "Tab delimiter without final newline",
+			dialect: Dialect{Delimiter: '\t'},
+			content: "key\tvalue\nhello\t\"multi\nline\"",
+			want:    [][]string{{"key", "value"}, {"hello", "multi\nline"}},
+			update:  "key\t翻译\nhello\t\"multi\nline\"",
+		},
+		{
+			name:    "Keep comments and blank lines",
+			dialect: Dialect{Comment: '#'},
+			content: "# header comment\nkey,value\n\n# section\nhello,world\n",
+			want:    [][]string{{"key", "value"}, {"hello", "world"}},
+			update:  "# header comment\nkey,翻译\n\n# section\nhello,world\n",
+		},
+		{
+			name:    "Lazy quotes and leading space",
+			dialect: Dialect{LazyQuotes: true, TrimLeadingSpace: true},
+			content: "a, 5\" screen\nb, c\n",
+			want:    [][]string{{"a", "5\" screen"}, {"b", "c"}},
+			update:  "a,翻译\nb,c\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			FilePath := filepath.Join(t.TempDir(), "strings.csv")
+			if err := os.WriteFile(FilePath, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			TableInstance, err := NewWithDialect(FilePath, "", tt.dialect)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := TableInstance.Read()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CSVTable.Read() = %q, want %q", got, tt.want)
+			}
+
+			if err := TableInstance.UpdateCell(1, 2, "翻译"); err != nil {
+				t.Fatal(err)
+			}
+			if Content, _ := os.ReadFile(FilePath); string(Content) != tt.update {
+				t.Errorf("file content = %q, want %q", Content, tt.update)
+			}
+		})
+	}
+}
+
+func TestCSVTable_InsertKeepsComments(t *testing.T) {
+	FilePath := filepath.Join(t.TempDir(), "strings.csv")
+	if err := os.WriteFile(FilePath, []byte("# first\na\n# second\nb\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	TableInstance, err := NewWithDialect(FilePath, "", Dialect{Comment: '#'})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := TableInstance.Insert(2, []string{"#new"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := TableInstance.Delete(1); err != nil {
+		t.Fatal(err)
+	}
+
+	// 以注释字符开头的字段需要引号，删除行后其前的注释保留
+	want := "# first\n\"#new\"\n# second\nb\n"
+	if Content, _ := os.ReadFile(FilePath); string(Content) != want {
+		t.Errorf("file content = %q, want %q", Content, want)
+	}
+}
+
+func TestCSVTable_Encoding(t *testing.T) {
+	ShiftJIS, _ := japanese.ShiftJIS.NewEncoder().String("名前,説明\r\nはるか,こんにちは\r\n")
+	GBK, _ := simplifiedchinese.GBK.NewEncoder().String("名称,说明\n你好,世界\n")
+	UTF16, _ := unicode.UTF16(unicode.LittleEndian, unicode.UseBOM).NewEncoder().String("key,value\nhello,世界\n")
+
+	tests := []struct {
+		name    string
+		dialect Dialect
+		content string
+		want    string // 第一行第一列
+		value   string // 写入第二行第二列的内容
+		output  string // 输出文件内容
+	}{
+		{
+			name:    "UTF-8 with BOM",
+			content: "\xEF\xBB\xBFkey,value\nhello,world\n",
+			want:    "key",
+			value:   "你好",
+			output:  "\xEF\xBB\xBFkey,value\nhello,你好\n",
+		},
+		{
+			name:    "Shift_JIS",
+			content: ShiftJIS,
+			want:    "名前",
+			value:   "やあ",
+			output:  mustEncode(t, japanese.ShiftJIS, "名前,説明\r\nはるか,やあ\r\n"),
+		},
+		{
+			name:    "GBK",
+			content: GBK,
+			want:    "名称",
+			value:   "再见",
+			output:  mustEncode(t, simplifiedchinese.GBK, "名称,说明\n你好,再见\n"),
+		},
+		{
+			name:    "UTF-16 with BOM",
+			content: UTF16,
+			want:    "key",
+			value:   "你好",
+			output:  mustEncode(t, unicode.UTF16(unicode.LittleEndian, unicode.UseBOM), "key,value\nhello,你好\n"),
+		},
+		{
+			name:    "Shift_JIS to UTF-8 with BOM",
+			dialect: Dialect{Encoding: "shift_jis", OutputEncoding: "utf-8", AddBOM: true},
+			content: ShiftJIS,
+			want:    "名前",
+			value:   "你好",
+			output:  "\xEF\xBB\xBF名前,説明\r\nはるか,你好\r\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			Directory := t.TempDir()
+			FilePath := filepath.Join(Directory, "strings.csv")
+			OutputPath := filepath.Join(Directory, "output.csv")
+			if err := os.WriteFile(FilePath, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			TableInstance, err := NewWithDialect(FilePath, OutputPath, tt.dialect)
+			if err != nil {
+				t.Fatal(err)
+			}
+			Datas, err := TableInstance.Read()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if Datas[0][0] != tt.want {
+				t.Errorf("CSVTable.Read()[0][0] = %q, want %q", Datas[0][0], tt.want)
+			}
+
+			Datas[1][1] = tt.value
+			if err := TableInstance.Write(Datas); err != nil {
+				t.Fatal(err)
+			}
+			if Content, _ := os.ReadFile(OutputPath); string(Content) != tt.output {
+				t.Errorf("output content = %q, want %q", Content, tt.output)
+			}
+		})
+	}
+}
+
+func TestCSVTable_UnsupportedCharacter(t *testing.T) {
+	FilePath := filepath.Join(t.TempDir(), "strings.csv")
+	Content := mustEncode(t, japanese.ShiftJIS, "名前\nはるか\n")
+	if err := os.WriteFile(FilePath, []byte(Content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	TableInstance, err := NewWithDialect(FilePath, "", Dialect{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 简体中文的"这"无法使用Shift_JIS表示，源文件保持不变
+	if err := TableInstance.UpdateCell(2, 1, "这"); !errors.Is(err, ErrUnsupportedCharacter) {
+		t.Errorf("CSVTable.UpdateCell() error = %v, want %v", err, ErrUnsupportedCharacter)
+	}
+	if got, _ := os.ReadFile(FilePath); string(got) != Content {
+		t.Errorf("source file was changed")
+	}
+}
+
+func TestCSVTable_Stream(t *testing.T) {
+	Directory := t.TempDir()
+	FilePath := filepath.Join(Directory, "strings.csv")
+	OutputPath := filepath.Join(Directory, "output.csv")
+	Content := mustEncode(t, japanese.ShiftJIS, "# 台詞\r\n名前,説明\r\n\r\nはるか,\"複数\r\n行\"\r\n# 終わり\r\n")
+	if err := os.WriteFile(FilePath, []byte(Content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	TableInstance, err := NewWithDialect(FilePath, OutputPath, Dialect{Comment: '#'})
+	if err != nil {
+		t.Fatal(err)
+	}
+	Reader, err := TableInstance.ReadRows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	Writer, err := TableInstance.WriteRows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got [][]string
+	for Reader.Next() {
+		got = append(got, Reader.Row())
+		if err := Writer.WriteRow(append(Reader.Row(), "やあ")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := Reader.Err(); err != nil {
+		t.Fatal(err)
+	}
+	Reader.Close()
+	if err := Writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := [][]string{{"名前", "説明"}, {"はるか", "複数\n行"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("rows = %q, want %q", got, want)
+	}
+	// 注释行、空行、换行风格和编码保持不变
+	Output := mustEncode(t, japanese.ShiftJIS, "# 台詞\r\n名前,説明,やあ\r\n\r\nはるか,\"複数\r\n行\",やあ\r\n# 終わり\r\n")
+	if Data, _ := os.ReadFile(OutputPath); string(Data) != Output {
+		t.Errorf("output content = %q, want %q", Data, Output)
+	}
+	if Datas, err := TableInstance.Read(); err != nil || len(Datas) != 2 || len(Datas[1]) != 3 {
+		t.Errorf("CSVTable.Read() after stream = %q, %v", Datas, err)
+	}
+}
+
+func TestCSVTable_DetectFromPrefix(t *testing.T) {
+	// 多字节字符跨越检测范围的边界
+	var Builder strings.Builder
+	Builder.WriteString("id;text\r\n")
+	for Builder.Len() < detectSize-64 {
+		Builder.WriteString("1;テキスト\r\n")
+	}
+	Builder.WriteString("2;" + strings.Repeat("a", detectSize-Builder.Len()-3) + "あい\r\n3;最後\r\n")
+	Text := Builder.String()
+
+	tests := []struct {
+		name     string
+		encoding encoding.Encoding
+	}{
+		{name: "UTF-8", encoding: nil},
+		{name: "Shift_JIS", encoding: japanese.ShiftJIS},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			Content := Text
+			if tt.encoding != nil {
+				Content = mustEncode(t, tt.encoding, Text)
+			}
+			FilePath := filepath.Join(t.TempDir(), "strings.csv")
+			if err := os.WriteFile(FilePath, []byte(Content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			TableInstance, err := NewWithDialect(FilePath, "", Dialect{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := TableInstance.Dialect().Delimiter; got != ';' {
+				t.Errorf("CSVTable.Dialect().Delimiter = %q, want ';'", got)
+			}
+			if TableInstance.lineEnding != "\r\n" {
+				t.Errorf("CSVTable line ending = %q, want CRLF", TableInstance.lineEnding)
+			}
+
+			Reader, err := TableInstance.ReadRows()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer Reader.Close()
+			var Last []string
+			for Reader.Next() {
+				Last = Reader.Row()
+			}
+			if err := Reader.Err(); err != nil {
+				t.Fatal(err)
+			}
+			if want := []string{"3", "最後"}; !reflect.DeepEqual(Last, want) {
+				t.Errorf("last row = %q, want %q", Last, want)
+			}
+		})
+	}
+}
+
+func mustEncode(t *testing.T, Encoding encoding.Encoding, Text string) string {
+	t.Helper()
+	Data, err := Encoding.NewEncoder().String(Text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Data
+}
@@ -0,0 +1,362 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-11 10:14:52
+ * @LastEditTime: 2025-07-11 15:37:20
+ * @LastEditors: nijineko
+ * @Description: CSV方言解析与写入
+ * @FilePath: \AutoTranslation\pkg\table\csv\dialect.go
+ */
+package csv
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// CSV方言
+type Dialect struct {
+	Delimiter        rune // 分隔符，为0时自动检测
+	Comment          rune // 注释行的起始字符，为0表示不支持注释
+	LazyQuotes       bool // 是否允许不规范的引号
+	TrimLeadingSpace bool // 是否忽略字段开头的空白
+
+	Encoding       string // 文件编码，如"shift_jis"、"gbk"、"utf-16"，为空时自动检测
+	OutputEncoding string // 输出编码，为空时与源文件相同
+	AddBOM         bool   // 输出Unicode编码时是否添加BOM，源文件带有BOM时总是保留
+}
+
+// 自动检测时的候选分隔符，按优先级排列
+var delimiterCandidates = []rune{',', '\t', ';', '|'}
+
+// 自动检测分隔符时检查的行数和字节数
+const (
+	detectLineCount = 20
+	detectSize      = 64 * 1024
+)
+
+// 解析后的CSV文件
+type document struct {
+	records      [][]string       // 数据行
+	extras       map[int][]string // 原样保留的注释行和空行，键为其后数据行的索引
+	finalNewline bool             // 文件是否以换行结尾
+}
+
+// 逐个读取逻辑行，引号内的换行属于字段内容
+type lineScanner struct {
+	reader       *bufio.Reader
+	dialect      Dialect
+	finalNewline bool  // 最后读取的行是否以换行结尾
+	err          error // 读取错误
+}
+
+/**
+ * @description: 创建逻辑行读取器
+ * @param {io.Reader} Reader UTF-8文本
+ * @param {Dialect} Dialect 方言，只有字段开头的引号才会开始引用
+ * @return {*lineScanner} 逻辑行读取器
+ */
+func newLineScanner(Reader io.Reader, Dialect Dialect) *lineScanner {
+	return &lineScanner{reader: bufio.NewReader(Reader), dialect: Dialect}
+}
+
+/**
+ * @description: 读取下一个逻辑行
+ * @return {string} 逻辑行，不包含换行符
+ * @return {bool} 是否读取到行，文件结束或出错时返回false
+ */
+func (s *lineScanner) scan() (string, bool) {
+	var Line []byte
+	InQuotes, FieldStart := false, true
+	for {
+		Char, err := s.reader.ReadByte()
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				s.err = err
+				return "", false
+			}
+			if len(Line) == 0 {
+				return "", false
+			}
+			s.finalNewline = false
+			return string(Line), true
+		}
+
+		if InQuotes {
+			Line = append(Line, Char)
+			if Char == '"' {
+				if Next, err := s.reader.Peek(1); err == nil && Next[0] == '"' {
+					s.reader.ReadByte()
+					Line = append(Line, '"')
+				} else {
+					InQuotes = false
+				}
+			}
+			continue
+		}
+
+		switch Char {
+		case '"':
+			InQuotes = FieldStart
+		case '\n':
+			s.finalNewline = true
+			return string(bytes.TrimSuffix(Line, []byte{'\r'})), true
+		}
+		Line = append(Line, Char)
+		FieldStart = rune(Char) == s.dialect.Delimiter || (FieldStart && s.dialect.TrimLeadingSpace && (Char == ' ' || Char == '\t'))
+	}
+}
+
+/**
+ * @description: 按引号外的换行拆分为逻辑行
+ * @param {[]byte} Data 文件内容
+ * @param {Dialect} Dialect 方言
+ * @return {[]string} 逻辑行，不包含换行符
+ * @return {bool} 文件是否以换行结尾
+ */
+func splitLines(Data []byte, Dialect Dialect) ([]string, bool) {
+	Scanner := newLineScanner(bytes.NewReader(Data), Dialect)
+	var Lines []string
+	for {
+		Line, ok := Scanner.scan()
+		if !ok {
+			break
+		}
+		Lines = append(Lines, Line)
+	}
+	return Lines, Scanner.finalNewline
+}
+
+/**
+ * @description: 检测换行风格，以第一个引号外的换行为准
+ * @param {[]byte} Data 文件内容
+ * @param {Dialect} Dialect 方言
+ * @return {string} 换行符，没有换行时返回"\n"
+ */
+func detectLineEnding(Data []byte, Dialect Dialect) string {
+	Lines, _ := splitLines(Data, Dialect)
+	if len(Lines) > 0 && len(Lines[0]) < len(Data) && Data[len(Lines[0])] == '\r' {
+		return "\r\n"
+	}
+	return "\n"
+}
+
+/**
+ * @description: 检测分隔符，优先选择在前几行中数量一致的候选分隔符
+ * @param {[]byte} Data 文件内容
+ * @param {rune} Comment 注释行的起始字符
+ * @return {rune} 分隔符，无法判断时返回逗号
+ */
+func detectDelimiter(Data []byte, Comment rune) rune {
+	// 只检查文件开头的部分
+	if len(Data) > detectSize {
+		Data = Data[:detectSize]
+	}
+	Lines, _ := splitLines(Data, Dialect{Delimiter: ','})
+
+	var Samples []string
+	for _, Line := range Lines {
+		if isExtraLine(Line, Dialect{Comment: Comment}) {
+			continue
+		}
+		Samples = append(Samples, Line)
+		if len(Samples) >= detectLineCount {
+			break
+		}
+	}
+	if len(Samples) == 0 {
+		return ','
+	}
+
+	Best, BestCount, BestConsistent := ',', 0, false
+	for _, Candidate := range delimiterCandidates {
+		First := countOutsideQuotes(Samples[0], Candidate)
+		if First == 0 {
+			continue
+		}
+		Consistent := true
+		for _, Line := range Samples[1:] {
+			if countOutsideQuotes(Line, Candidate) != First {
+				Consistent = false
+				break
+			}
+		}
+
+		// 各行数量一致的分隔符优先，其次选择数量更多的分隔符
+		if (Consistent && !BestConsistent) || (Consistent == BestConsistent && First > BestCount) {
+			Best, BestCount, BestConsistent = Candidate, First, Consistent
+		}
+	}
+	return Best
+}
+
+/**
+ * @description: 统计引号外的字符数量
+ * @param {string} Line 逻辑行
+ * @param {rune} Char 字符
+ * @return {int} 数量
+ */
+func countOutsideQuotes(Line string, Char rune) int {
+	Count := 0
+	InQuotes := false
+	for _, Current := range Line {
+		switch {
+		case Current == '"':
+			InQuotes = !InQuotes
+		case Current == Char && !InQuotes:
+			Count++
+		}
+	}
+	return Count
+}
+
+/**
+ * @description: 按方言解析CSV文件，注释行和空行单独保留
+ * @param {[]byte} Data 文件内容
+ * @param {Dialect} Dialect 方言，分隔符必须已确定
+ * @return {*document} 解析结果
+ * @return {error} 错误信息
+ */
+func parse(Data []byte, Dialect Dialect) (*document, error) {
+	Lines, FinalNewline := splitLines(Data, Dialect)
+	Document := &document{records: [][]string{}, extras: map[int][]string{}, finalNewline: FinalNewline}
+
+	LineNumber := 1
+	for _, Line := range Lines {
+		if isExtraLine(Line, Dialect) {
+			Document.extras[len(Document.records)] = append(Document.extras[len(Document.records)], Line)
+		} else {
+			Record, err := parseRecord(Line, Dialect)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", LineNumber, err)
+			}
+			Document.records = append(Document.records, Record)
+		}
+		LineNumber += strings.Count(Line, "\n") + 1
+	}
+
+	return Document, nil
+}
+
+/**
+ * @description: 判断是否为原样保留的注释行或空行
+ * @param {string} Line 逻辑行
+ * @param {Dialect} Dialect 方言
+ * @return {bool} 是否为注释行或空行
+ */
+func isExtraLine(Line string, Dialect Dialect) bool {
+	return Line == "" || (Dialect.Comment != 0 && strings.HasPrefix(Line, string(Dialect.Comment)))
+}
+
+/**
+ * @description: 解析单个逻辑行
+ * @param {string} Line 逻辑行
+ * @param {Dialect} Dialect 方言
+ * @return {[]string} 数据行
+ * @return {error} 错误信息
+ */
+func parseRecord(Line string, Dialect Dialect) ([]string, error) {
+	Reader := csv.NewReader(strings.NewReader(Line))
+	Reader.Comma = Dialect.Delimiter
+	Reader.LazyQuotes = Dialect.LazyQuotes
+	Reader.TrimLeadingSpace = Dialect.TrimLeadingSpace
+	Reader.FieldsPerRecord = -1
+	return Reader.Read()
+}
+
+/**
+ * @description: 按方言写入CSV文件，注释行和空行写回原来的位置
+ * @param {*document} Document CSV文件
+ * @param {Dialect} Dialect 方言
+ * @param {string} LineEnding 换行符
+ * @return {[]byte} 文件内容
+ */
+func (d *document) format(Dialect Dialect, LineEnding string) []byte {
+	var Lines []string
+	for Index, Record := range d.records {
+		Lines = append(Lines, d.extras[Index]...)
+		Lines = append(Lines, formatRecord(Record, Dialect, LineEnding))
+	}
+	// 数据行之后的注释行和空行
+	for Index := len(d.records); Index <= maxKey(d.extras); Index++ {
+		Lines = append(Lines, d.extras[Index]...)
+	}
+
+	var Buffer bytes.Buffer
+	Buffer.WriteString(strings.Join(Lines, LineEnding))
+	if d.finalNewline && len(Lines) > 0 {
+		Buffer.WriteString(LineEnding)
+	}
+	return Buffer.Bytes()
+}
+
+/**
+ * @description: 插入或删除数据行后移动注释行和空行的位置
+ * @param {int} From 起始数据行索引，之后的行都会移动
+ * @param {int} Delta 移动的行数
+ */
+func (d *document) shift(From, Delta int) {
+	// 按顺序移动，删除行时合并的注释保持原有顺序
+	Keys := make([]int, 0, len(d.extras))
+	for Index := range d.extras {
+		Keys = append(Keys, Index)
+	}
+	sort.Ints(Keys)
+
+	Extras := map[int][]string{}
+	for _, Index := range Keys {
+		Lines := d.extras[Index]
+		if Index > From || (Index == From && Delta > 0) {
+			Index = max(Index+Delta, From)
+		}
+		Extras[Index] = append(Extras[Index], Lines...)
+	}
+	d.extras = Extras
+}
+
+/**
+ * @description: 格式化单个数据行，只在需要时为字段添加引号
+ * @param {[]string} Record 数据行
+ * @param {Dialect} Dialect 方言
+ * @param {string} LineEnding 换行符，字段内的换行使用相同的风格
+ * @return {string} 格式化后的行
+ */
+func formatRecord(Record []string, Dialect Dialect, LineEnding string) string {
+	Fields := make([]string, len(Record))
+	for Index, Field := range Record {
+		NeedsQuotes := strings.ContainsAny(Field, string(Dialect.Delimiter)+"\"\r\n") ||
+			strings.HasPrefix(Field, " ") || strings.HasPrefix(Field, "\t") ||
+			// 以注释字符开头的第一个字段和只有一个空字段的行需要引号，否则读取时会被忽略
+			(Index == 0 && Dialect.Comment != 0 && strings.HasPrefix(Field, string(Dialect.Comment))) ||
+			(len(Record) == 1 && Field == "")
+		if !NeedsQuotes {
+			Fields[Index] = Field
+			continue
+		}
+
+		Field = strings.ReplaceAll(Field, `"`, `""`)
+		if LineEnding == "\r\n" {
+			Field = strings.ReplaceAll(strings.ReplaceAll(Field, "\r\n", "\n"), "\n", "\r\n")
+		}
+		Fields[Index] = `"` + Field + `"`
+	}
+	return strings.Join(Fields, string(Dialect.Delimiter))
+}
+
+/**
+ * @description: 获取最大的键
+ * @param {map[int][]string} Extras 注释行和空行
+ * @return {int} 最大的键，为空时返回-1
+ */
+func maxKey(Extras map[int][]string) int {
+	Max := -1
+	for Index := range Extras {
+		Max = max(Max, Index)
+	}
+	return Max
+}
@@ -0,0 +1,224 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-12 10:21:45
+ * @LastEditTime: 2025-07-12 14:58:03
+ * @LastEditors: nijineko
+ * @Description: CSV文件编码检测与转换
+ * @FilePath: \AutoTranslation\pkg\table\csv\encoding.go
+ */
+package csv
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// 无法使用指定编码表示的字符
+var ErrUnsupportedCharacter = errors.New("character cannot be encoded")
+
+// 编码名称
+const (
+	encodingUTF8    = "utf-8"
+	encodingUTF16LE = "utf-16le"
+	encodingUTF16BE = "utf-16be"
+	encodingSJIS    = "shift_jis"
+	encodingGBK     = "gbk"
+)
+
+// 各Unicode编码的BOM
+var byteOrderMarks = map[string][]byte{
+	encodingUTF8:    {0xEF, 0xBB, 0xBF},
+	encodingUTF16LE: {0xFF, 0xFE},
+	encodingUTF16BE: {0xFE, 0xFF},
+}
+
+// 文件编码
+type fileEncoding struct {
+	name     string            // 编码名称
+	encoding encoding.Encoding // 编码，UTF-8时为nil
+	bom      bool              // 是否带有BOM
+}
+
+/**
+ * @description: 按名称获取编码
+ * @param {string} Name 编码名称，如"utf-8"、"shift_jis"、"gbk"、"utf-16"
+ * @return {fileEncoding} 编码，不包含BOM
+ * @return {error} 错误信息
+ */
+func lookupEncoding(Name string) (fileEncoding, error) {
+	Name = strings.ToLower(strings.TrimSpace(Name))
+	switch Name {
+	case "utf-8", "utf8":
+		return fileEncoding{name: encodingUTF8}, nil
+	case "utf-16", "utf16", "utf-16le":
+		// BOM由调用方处理
+		return fileEncoding{name: encodingUTF16LE, encoding: unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM)}, nil
+	case "utf-16be":
+		return fileEncoding{name: encodingUTF16BE, encoding: unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM)}, nil
+	}
+
+	Encoding, err := htmlindex.Get(Name)
+	if err != nil {
+		return fileEncoding{}, fmt.Errorf("unsupported encoding %q", Name)
+	}
+	if Canonical, err := htmlindex.Name(Encoding); err == nil {
+		Name = Canonical
+	}
+	return fileEncoding{name: Name, encoding: Encoding}, nil
+}
+
+/**
+ * @description: 检测文件编码，依次检查BOM、UTF-8、无BOM的UTF-16，最后在Shift_JIS和GBK中选择
+ * @param {[]byte} Data 文件开头的内容，可能被截断
+ * @return {fileEncoding} 编码
+ */
+func detectEncoding(Data []byte) fileEncoding {
+	for _, Name := range []string{encodingUTF8, encodingUTF16LE, encodingUTF16BE} {
+		if bytes.HasPrefix(Data, byteOrderMarks[Name]) {
+			Encoding, _ := lookupEncoding(Name)
+			Encoding.bom = true
+			return Encoding
+		}
+	}
+
+	if utf8.Valid(Data) {
+		return fileEncoding{name: encodingUTF8}
+	}
+
+	// 没有BOM的UTF-16，ASCII字符的高位字节为0
+	Sample := Data[:min(len(Data), detectSize)&^1]
+	var EvenZeros, OddZeros int
+	for Index := 0; Index < len(Sample); Index += 2 {
+		if Sample[Index] == 0 {
+			EvenZeros++
+		}
+		if Sample[Index+1] == 0 {
+			OddZeros++
+		}
+	}
+	if Pairs := len(Sample) / 2; Pairs > 0 {
+		if OddZeros*3 > Pairs && EvenZeros*10 < Pairs {
+			Encoding, _ := lookupEncoding(encodingUTF16LE)
+			return Encoding
+		}
+		if EvenZeros*3 > Pairs && OddZeros*10 < Pairs {
+			Encoding, _ := lookupEncoding(encodingUTF16BE)
+			return Encoding
+		}
+	}
+
+	// 解码错误更少的编码优先，相同时日文假名多于半角片假名则为Shift_JIS
+	SJIS := decodeScore(japanese.ShiftJIS, Sample)
+	GBK := decodeScore(simplifiedchinese.GBK, Sample)
+	if SJIS.errors < GBK.errors || (SJIS.errors == GBK.errors && SJIS.kana > SJIS.halfwidthKana) {
+		Encoding, _ := lookupEncoding(encodingSJIS)
+		return Encoding
+	}
+	Encoding, _ := lookupEncoding(encodingGBK)
+	return Encoding
+}
+
+/**
+ * @description: 去除末尾不完整的UTF-8字符，用于检测截取的文件开头
+ * @param {[]byte} Data 文件开头
+ * @return {[]byte} 去除后的内容
+ */
+func trimPartialRune(Data []byte) []byte {
+	for Index := len(Data) - 1; Index >= 0 && Index > len(Data)-utf8.UTFMax; Index-- {
+		if utf8.RuneStart(Data[Index]) {
+			if !utf8.FullRune(Data[Index:]) {
+				return Data[:Index]
+			}
+			break
+		}
+	}
+	return Data
+}
+
+// 解码结果统计
+type score struct {
+	errors        int // 无法解码的字符数量
+	kana          int // 平假名和片假名数量
+	halfwidthKana int // 半角片假名数量
+}
+
+/**
+ * @description: 统计按指定编码解码的结果
+ * @param {encoding.Encoding} Encoding 编码
+ * @param {[]byte} Data 数据
+ * @return {score} 统计结果
+ */
+func decodeScore(Encoding encoding.Encoding, Data []byte) score {
+	Text, err := Encoding.NewDecoder().Bytes(Data)
+	if err != nil {
+		return score{errors: len(Data)}
+	}
+
+	var Result score
+	for _, Char := range string(Text) {
+		switch {
+		case Char == utf8.RuneError:
+			Result.errors++
+		case Char >= 0x3041 && Char <= 0x30FF:
+			Result.kana++
+		case Char >= 0xFF61 && Char <= 0xFF9F:
+			Result.halfwidthKana++
+		}
+	}
+	return Result
+}
+
+/**
+ * @description: 将文件内容解码为UTF-8文本，去除BOM
+ * @param {[]byte} Data 文件内容
+ * @param {fileEncoding} Encoding 编码
+ * @return {[]byte} UTF-8文本
+ * @return {error} 错误信息
+ */
+func (e fileEncoding) decode(Data []byte) ([]byte, error) {
+	Data = bytes.TrimPrefix(Data, byteOrderMarks[e.name])
+	if e.encoding == nil {
+		return Data, nil
+	}
+	Text, err := e.encoding.NewDecoder().Bytes(Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", e.name, err)
+	}
+	return Text, nil
+}
+
+/**
+ * @description: 将UTF-8文本编码为文件内容，需要时添加BOM
+ * @param {[]byte} Text UTF-8文本
+ * @return {[]byte} 文件内容
+ * @return {error} 错误信息，存在无法表示的字符时返回ErrUnsupportedCharacter
+ */
+func (e fileEncoding) encode(Text []byte) ([]byte, error) {
+	Data := Text
+	if e.encoding != nil {
+		var err error
+		if Data, err = e.encoding.NewEncoder().Bytes(Text); err != nil {
+			// 找出第一个无法表示的字符，便于用户调整输出编码
+			for _, Char := range string(Text) {
+				if _, err := e.encoding.NewEncoder().String(string(Char)); err != nil {
+					return nil, fmt.Errorf("%w: %q in %s, set csv.output_encoding to utf-8 to keep it", ErrUnsupportedCharacter, Char, e.name)
+				}
+			}
+			return nil, err
+		}
+	}
+
+	if BOM, ok := byteOrderMarks[e.name]; ok && e.bom {
+		Data = append(append([]byte{}, BOM...), Data...)
+	}
+	return Data, nil
+}
@@ -0,0 +1,284 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-13 09:42:18
+ * @LastEditTime: 2025-07-13 11:26:40
+ * @LastEditors: nijineko
+ * @Description: CSV表格流式读写
+ * @FilePath: \AutoTranslation\pkg\table\csv\stream.go
+ */
+package csv
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/nijinekoyo/AutoTranslation/pkg/table"
+	"github.com/nijinekoyo/AutoTranslation/tools/file"
+	"golang.org/x/text/transform"
+)
+
+// 流式读取时记录的注释行和空行，写入时写回原来的位置
+type streamState struct {
+	extras       map[int][]string // 注释行和空行，键为其后数据行的索引
+	finalNewline bool             // 文件是否以换行结尾
+}
+
+// CSV流式读取器
+type rowReader struct {
+	file    *os.File
+	scanner *lineScanner
+	dialect Dialect
+	state   *streamState
+	path    string
+
+	row        []string // 当前行数据
+	count      int      // 已读取的数据行数
+	lineNumber int      // 下一个逻辑行的行号
+	err        error
+}
+
+/**
+ * @description: 从第一行开始流式读取CSV表格，写入过输出文件后读取输出文件
+ * @return {table.RowReader} 行迭代器
+ * @return {error} 错误信息
+ */
+func (c *CSVTable) ReadRows() (table.RowReader, error) {
+	if c.isClosed {
+		return nil, os.ErrClosed
+	}
+
+	FilePath, Encoding := c.filePath, c.encoding
+	if c.isWritten {
+		FilePath, Encoding = c.outputPath, c.outputEncoding
+	}
+
+	File, err := os.Open(FilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	// 去除BOM后再解码
+	Reader := bufio.NewReader(File)
+	if BOM, ok := byteOrderMarks[Encoding.name]; ok {
+		if Prefix, err := Reader.Peek(len(BOM)); err == nil && bytes.Equal(Prefix, BOM) {
+			Reader.Discard(len(BOM))
+		}
+	}
+	var Text = Reader
+	if Encoding.encoding != nil {
+		Text = bufio.NewReader(transform.NewReader(Reader, Encoding.encoding.NewDecoder()))
+	}
+
+	c.stream = &streamState{extras: map[int][]string{}}
+	return &rowReader{
+		file:       File,
+		scanner:    newLineScanner(Text, c.dialect),
+		dialect:    c.dialect,
+		state:      c.stream,
+		path:       FilePath,
+		lineNumber: 1,
+	}, nil
+}
+
+/**
+ * @description: 读取下一个数据行，注释行和空行记录后跳过
+ * @return {bool} 是否读取到数据行
+ */
+func (r *rowReader) Next() bool {
+	if r.err != nil {
+		return false
+	}
+	for {
+		Line, ok := r.scanner.scan()
+		if !ok {
+			if r.scanner.err != nil {
+				r.err = fmt.Errorf("failed to read %s: %w", r.path, r.scanner.err)
+			}
+			r.state.finalNewline = r.scanner.finalNewline
+			r.row = nil
+			return false
+		}
+
+		LineNumber := r.lineNumber
+		r.lineNumber += bytes.Count([]byte(Line), []byte{'\n'}) + 1
+		if isExtraLine(Line, r.dialect) {
+			r.state.extras[r.count] = append(r.state.extras[r.count], Line)
+			continue
+		}
+
+		Record, err := parseRecord(Line, r.dialect)
+		if err != nil {
+			r.err = fmt.Errorf("failed to parse %s: line %d: %w", r.path, LineNumber, err)
+			return false
+		}
+		r.row = Record
+		r.count++
+		return true
+	}
+}
+
+/**
+ * @description: 获取当前行数据
+ * @return {[]string} 当前行数据
+ */
+func (r *rowReader) Row() []string {
+	return r.row
+}
+
+/**
+ * @description: 获取读取过程中的错误
+ * @return {error} 错误信息
+ */
+func (r *rowReader) Err() error {
+	return r.err
+}
+
+/**
+ * @description: 关闭读取器
+ * @return {error} 错误信息
+ */
+func (r *rowReader) Close() error {
+	return r.file.Close()
+}
+
+// CSV逐行写入器
+type rowWriter struct {
+	table    *CSVTable
+	file     *file.AtomicFile
+	encoding fileEncoding // 输出编码，BOM已在开头写入
+	state    *streamState
+	count    int // 已写入的数据行数
+	lines    int // 已写入的逻辑行数
+}
+
+/**
+ * @description: 创建逐行写入器，写入同目录下的临时文件，关闭时替换输出文件
+ * @return {table.RowWriter} 逐行写入器
+ * @return {error} 错误信息
+ */
+func (c *CSVTable) WriteRows() (table.RowWriter, error) {
+	if c.isClosed {
+		return nil, os.ErrClosed
+	}
+
+	// 保留流式读取时的注释行、空行和结尾换行，新文件以换行结尾
+	State := c.stream
+	if State == nil {
+		State = &streamState{extras: map[int][]string{}, finalNewline: true}
+	}
+
+	File, err := file.CreateAtomic(c.outputPath)
+	if err != nil {
+		return nil, err
+	}
+	Encoding := c.outputEncoding
+	if BOM, ok := byteOrderMarks[Encoding.name]; ok && Encoding.bom {
+		if _, err := File.Write(BOM); err != nil {
+			File.Abort()
+			return nil, err
+		}
+	}
+	Encoding.bom = false
+
+	return &rowWriter{table: c, file: File, encoding: Encoding, state: State}, nil
+}
+
+/**
+ * @description: 写入下一个数据行，其前的注释行和空行一起写入
+ * @param {[]string} Row 数据行
+ * @return {error} 错误信息，存在无法表示的字符时返回ErrUnsupportedCharacter
+ */
+func (w *rowWriter) WriteRow(Row []string) error {
+	if err := w.writeExtras(w.count); err != nil {
+		return err
+	}
+	if err := w.writeLine(formatRecord(Row, w.table.dialect, w.table.lineEnding)); err != nil {
+		return err
+	}
+	w.count++
+	return nil
+}
+
+/**
+ * @description: 写入指定数据行之前的注释行和空行，写入后不再保留
+ * @param {int} Index 数据行索引
+ * @return {error} 错误信息
+ */
+func (w *rowWriter) writeExtras(Index int) error {
+	for _, Line := range w.state.extras[Index] {
+		if err := w.writeLine(Line); err != nil {
+			return err
+		}
+	}
+	delete(w.state.extras, Index)
+	return nil
+}
+
+/**
+ * @description: 按输出编码写入一个逻辑行，行之间使用源文件的换行符
+ * @param {string} Line 逻辑行
+ * @return {error} 错误信息
+ */
+func (w *rowWriter) writeLine(Line string) error {
+	if w.lines > 0 {
+		Line = w.table.lineEnding + Line
+	}
+	Data, err := w.encoding.encode([]byte(Line))
+	if err != nil {
+		return err
+	}
+	if _, err := w.file.Write(Data); err != nil {
+		return err
+	}
+	w.lines++
+	return nil
+}
+
+/**
+ * @description: 将已写入的行写入临时文件
+ * @return {error} 错误信息
+ */
+func (w *rowWriter) Flush() error {
+	return w.file.Flush()
+}
+
+/**
+ * @description: 写入剩余的注释行和空行后替换输出文件
+ * @return {error} 错误信息
+ */
+func (w *rowWriter) Close() error {
+	for Index := w.count; Index <= maxKey(w.state.extras); Index++ {
+		if err := w.writeExtras(Index); err != nil {
+			w.file.Abort()
+			return err
+		}
+	}
+	if w.state.finalNewline && w.lines > 0 {
+		Data, err := w.encoding.encode([]byte(w.table.lineEnding))
+		if err != nil {
+			w.file.Abort()
+			return err
+		}
+		if _, err := w.file.Write(Data); err != nil {
+			w.file.Abort()
+			return err
+		}
+	}
+
+	if err := w.file.Commit(); err != nil {
+		return err
+	}
+	w.table.isWritten = true
+	w.table.document = nil
+	return nil
+}
+
+/**
+ * @description: 放弃写入，输出文件保持不变
+ * @return {error} 错误信息
+ */
+func (w *rowWriter) Abort() error {
+	return w.file.Abort()
+}
@@ -1,7 +1,7 @@
 /*
  * @Author: nijineko
  * @Date: 2025-07-03 15:54:30
- * @LastEditTime: 2025-07-03 18:26:31
+ * @LastEditTime: 2025-07-06 11:12:50
  * @LastEditors: nijineko
  * @Description: Excel表格数据处理实现
  * @FilePath: \AutoTranslation\pkg\table\excel\excel.go
@@ -9,16 +9,25 @@
 package excel
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"strings"
 
+	"github.com/nijinekoyo/AutoTranslation/pkg/table"
+	"github.com/nijinekoyo/AutoTranslation/tools/file"
 	"github.com/xuri/excelize/v2"
 )
 
 type ExcelTable struct {
 	filePath       string
+	outputPath     string // 输出文件路径
 	excelizeHandle *excelize.File
+	xlsFormulas    map[string]bool // 从xls转换的公式单元格，键为"工作表!单元格"
 
-	defaultSheetName string // 默认工作表名称
+	sheetName string // 当前工作表名称，默认为第一个工作表
 
 	isClosed bool // 是否已关闭
 }
@@ -26,28 +35,50 @@ type ExcelTable struct {
 /**
  * @description: 创建一个新的Excel表格处理实例
  * @param {string} FilePath Excel文件路径
+ * @param {string} OutputPath 输出文件路径，为空时覆盖源文件，xls文件输出为同名的xlsx文件
  * @return {*ExcelTable} 返回一个新的ExcelTable实例
  * @return {error} 错误信息
  */
-func New(FilePath string) (*ExcelTable, error) {
-	// 创建一个新的Excel文件处理实例
-	ExcelizeHandle, err := excelize.OpenFile(FilePath)
-	if err != nil {
-		// 如果文件不存在，则创建一个新的Excel文件
-		ExcelizeHandle = excelize.NewFile()
-		if err := ExcelizeHandle.SaveAs(FilePath); err != nil {
-			return nil, err
+func New(FilePath string, OutputPath string) (*ExcelTable, error) {
+	if OutputPath == "" {
+		OutputPath = FilePath
+	}
+
+	var ExcelizeHandle *excelize.File
+	var XLSFormulas map[string]bool
+	var err error
+	if strings.EqualFold(filepath.Ext(FilePath), ".xls") {
+		// xls只能读取，转换后输出为xlsx，不会覆盖源文件
+		ExcelizeHandle, XLSFormulas, err = openXLS(FilePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", FilePath, err)
+		}
+		OutputPath = XLSXPath(OutputPath)
+	} else {
+		// 创建一个新的Excel文件处理实例
+		ExcelizeHandle, err = excelize.OpenFile(FilePath)
+		if errors.Is(err, os.ErrNotExist) {
+			// 如果文件不存在，则创建一个新的Excel文件
+			ExcelizeHandle = excelize.NewFile()
+			if err := ExcelizeHandle.SaveAs(FilePath); err != nil {
+				return nil, err
+			}
+		} else if err != nil {
+			// 文件存在但无法解析时直接返回错误，避免覆盖用户的文件
+			return nil, fmt.Errorf("failed to read %s: %w", FilePath, err)
 		}
 	}
 
 	// 获取默认工作表名称
-	DefaultSheetName := ExcelizeHandle.GetSheetName(0)
+	SheetName := ExcelizeHandle.GetSheetName(0)
 
 	return &ExcelTable{
-		filePath:         FilePath,
-		excelizeHandle:   ExcelizeHandle,
-		defaultSheetName: DefaultSheetName,
-		isClosed:         false,
+		filePath:       FilePath,
+		outputPath:     OutputPath,
+		excelizeHandle: ExcelizeHandle,
+		xlsFormulas:    XLSFormulas,
+		sheetName:      SheetName,
+		isClosed:       false,
 	}, nil
 }
 
@@ -56,8 +87,12 @@ func New(FilePath string) (*ExcelTable, error) {
  * @return {error} 错误信息
  */
 func (e *ExcelTable) Close() error {
-	// 保存Excel文件
-	if err := e.excelizeHandle.SaveAs(e.filePath); err != nil {
+	// 保存Excel文件到输出路径，先写入临时文件再替换
+	err := file.WriteAtomic(e.outputPath, func(Writer io.Writer) error {
+		_, err := e.excelizeHandle.WriteTo(Writer)
+		return err
+	})
+	if err != nil {
 		return err
 	}
 	// 关闭Excelize文件句柄
@@ -71,6 +106,51 @@ func (e *ExcelTable) Close() error {
 	return nil
 }
 
+/**
+ * @description: 放弃修改并关闭Excel表格处理实例，不写入输出文件
+ * @return {error} 错误信息
+ */
+func (e *ExcelTable) Discard() error {
+	if err := e.excelizeHandle.Close(); err != nil {
+		return err
+	}
+
+	// 标记为已关闭
+	e.isClosed = true
+
+	return nil
+}
+
+/**
+ * @description: 获取全部工作表名称
+ * @return {[]string} 工作表名称，按工作簿中的顺序排列
+ */
+func (e *ExcelTable) Sheets() []string {
+	return e.excelizeHandle.GetSheetList()
+}
+
+/**
+ * @description: 选中工作表，之后的读写操作作用于该工作表
+ * @param {string} Name 工作表名称
+ * @return {error} 错误信息
+ */
+func (e *ExcelTable) SelectSheet(Name string) error {
+	if e.isClosed {
+		return os.ErrClosed
+	}
+
+	Index, err := e.excelizeHandle.GetSheetIndex(Name)
+	if err != nil {
+		return err
+	}
+	if Index < 0 {
+		return fmt.Errorf("sheet %q does not exist", Name)
+	}
+	e.sheetName = Name
+
+	return nil
+}
+
 /**
  * @description: 读取Excel表格数据
  * @return {[][]string} 表格数据
@@ -78,7 +158,7 @@ func (e *ExcelTable) Close() error {
  */
 func (e *ExcelTable) Read() ([][]string, error) {
 	// 读取Excel文件中的数据
-	rows, err := e.excelizeHandle.GetRows(e.defaultSheetName)
+	rows, err := e.excelizeHandle.GetRows(e.sheetName)
 	if err != nil {
 		return nil, err
 	}
@@ -96,16 +176,97 @@ func (e *ExcelTable) Write(Datas [][]string) error {
 		return os.ErrClosed
 	}
 
-	// 写入新数据到工作表
+	// 读取现有数据，只写入内容变化的单元格，避免改变其他单元格的类型、公式和富文本
+	Rows, err := e.excelizeHandle.GetRows(e.sheetName)
+	if err != nil {
+		return err
+	}
+
+	var Updates []table.CellUpdate
 	for RowIndex, RowData := range Datas {
 		for ColIndex, CellData := range RowData {
-			Cell, err := excelize.CoordinatesToCellName(ColIndex+1, RowIndex+1)
-			if err != nil {
-				return err
+			if RowIndex < len(Rows) && ColIndex < len(Rows[RowIndex]) && Rows[RowIndex][ColIndex] == CellData {
+				continue
 			}
-			if err := e.excelizeHandle.SetCellValue(e.defaultSheetName, Cell, CellData); err != nil {
-				return err
+			if CellData == "" && (RowIndex >= len(Rows) || ColIndex >= len(Rows[RowIndex])) {
+				continue
 			}
+			Updates = append(Updates, table.CellUpdate{Row: RowIndex + 1, Column: ColIndex + 1, Value: CellData})
+		}
+	}
+
+	return e.WriteCells(Updates)
+}
+
+/**
+ * @description: 判断单元格是否为公式
+ * @param {int} Row 行号，从1开始计数
+ * @param {int} Col 列号，从1开始计数
+ * @return {bool} 是否为公式
+ * @return {error} 错误信息
+ */
+func (e *ExcelTable) IsFormula(Row, Col int) (bool, error) {
+	if e.isClosed {
+		return false, os.ErrClosed
+	}
+
+	Cell, err := excelize.CoordinatesToCellName(Col, Row)
+	if err != nil {
+		return false, err
+	}
+	if e.xlsFormulas[e.sheetName+"!"+Cell] {
+		return true, nil
+	}
+	Formula, err := e.excelizeHandle.GetCellFormula(e.sheetName, Cell)
+	if err != nil {
+		return false, err
+	}
+
+	return Formula != "", nil
+}
+
+/**
+ * @description: 只写入指定的单元格，其他单元格保持不变，没有样式的单元格继承指定源单元格的样式
+ * @param {[]table.CellUpdate} Updates 单元格更新
+ * @return {error} 错误信息
+ */
+func (e *ExcelTable) WriteCells(Updates []table.CellUpdate) error {
+	if e.isClosed {
+		return os.ErrClosed
+	}
+
+	for _, Update := range Updates {
+		Cell, err := excelize.CoordinatesToCellName(Update.Column, Update.Row)
+		if err != nil {
+			return err
+		}
+		if err := e.excelizeHandle.SetCellStr(e.sheetName, Cell, Update.Value); err != nil {
+			return err
+		}
+
+		if Update.StyleColumn < 1 {
+			continue
+		}
+		// 目标单元格已有样式时保留用户设置的样式
+		Style, err := e.excelizeHandle.GetCellStyle(e.sheetName, Cell)
+		if err != nil {
+			return err
+		}
+		if Style != 0 {
+			continue
+		}
+		StyleCell, err := excelize.CoordinatesToCellName(Update.StyleColumn, Update.Row)
+		if err != nil {
+			return err
+		}
+		if Style, err = e.excelizeHandle.GetCellStyle(e.sheetName, StyleCell); err != nil {
+			return err
+		}
+		if Style == 0 {
+			continue
+		}
+		if err := e.excelizeHandle.SetCellStyle(e.sheetName, Cell, Cell, Style); err != nil {
+			return err
 		}
 	}
 
@@ -129,7 +290,7 @@ func (e *ExcelTable) UpdateLine(Row int, Data []string) error {
 		if err != nil {
 			return err
 		}
-		if err := e.excelizeHandle.SetCellValue(e.defaultSheetName, Cell, CellData); err != nil {
+		if err := e.excelizeHandle.SetCellValue(e.sheetName, Cell, CellData); err != nil {
 			return err
 		}
 	}
@@ -154,7 +315,7 @@ func (e *ExcelTable) UpdateCell(Row, Col int, Data string) error {
 	if err != nil {
 		return err
 	}
-	if err := e.excelizeHandle.SetCellValue(e.defaultSheetName, Cell, Data); err != nil {
+	if err := e.excelizeHandle.SetCellValue(e.sheetName, Cell, Data); err != nil {
 		return err
 	}
 
@@ -172,7 +333,7 @@ func (e *ExcelTable) Append(Data []string) error {
 	}
 
 	// 获取当前行数
-	Rows, err := e.excelizeHandle.GetRows(e.defaultSheetName)
+	Rows, err := e.excelizeHandle.GetRows(e.sheetName)
 	if err != nil {
 		return err
 	}
@@ -184,7 +345,7 @@ func (e *ExcelTable) Append(Data []string) error {
 		if err != nil {
 			return err
 		}
-		if err := e.excelizeHandle.SetCellValue(e.defaultSheetName, Cell, CellData); err != nil {
+		if err := e.excelizeHandle.SetCellValue(e.sheetName, Cell, CellData); err != nil {
 			return err
 		}
 	}
@@ -204,7 +365,7 @@ func (e *ExcelTable) Insert(Row int, Data []string) error {
 	}
 
 	// 获取当前行数
-	Rows, err := e.excelizeHandle.GetRows(e.defaultSheetName)
+	Rows, err := e.excelizeHandle.GetRows(e.sheetName)
 	if err != nil {
 		return err
 	}
@@ -215,7 +376,7 @@ func (e *ExcelTable) Insert(Row int, Data []string) error {
 	}
 
 	// 插入空白行
-	if err := e.excelizeHandle.InsertRows(e.defaultSheetName, Row, 1); err != nil {
+	if err := e.excelizeHandle.InsertRows(e.sheetName, Row, 1); err != nil {
 		return err
 	}
 
@@ -225,7 +386,7 @@ func (e *ExcelTable) Insert(Row int, Data []string) error {
 		if err != nil {
 			return err
 		}
-		if err := e.excelizeHandle.SetCellValue(e.defaultSheetName, Cell, CellData); err != nil {
+		if err := e.excelizeHandle.SetCellValue(e.sheetName, Cell, CellData); err != nil {
 			return err
 		}
 	}
@@ -244,5 +405,5 @@ func (e *ExcelTable) Delete(Row int) error {
 	}
 
 	// 删除指定行
-	return e.excelizeHandle.RemoveRow(e.defaultSheetName, Row)
+	return e.excelizeHandle.RemoveRow(e.sheetName, Row)
 }
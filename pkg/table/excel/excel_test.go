@@ -0,0 +1,203 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-08 15:20:17
+ * @LastEditTime: 2025-07-08 15:46:52
+ * @LastEditors: nijineko
+ * @Description: Excel表格数据处理测试
+ * @FilePath: \AutoTranslation\pkg\table\excel\excel_test.go
+ */
+package excel
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/nijinekoyo/AutoTranslation/pkg/table"
+	"github.com/xuri/excelize/v2"
+)
+
+func TestExcelTable_WriteCells(t *testing.T) {
+	FilePath := filepath.Join(t.TempDir(), "script.xlsx")
+
+	// 准备包含数字、公式、富文本和样式的工作簿
+	File := excelize.NewFile()
+	Style, err := File.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	File.SetCellValue("Sheet1", "A1", "こんにちは")
+	File.SetCellStyle("Sheet1", "A1", "A1", Style)
+	File.SetCellValue("Sheet1", "C1", 42)
+	File.SetCellFormula("Sheet1", "A2", `"猫"&"犬"`)
+	File.SetCellRichText("Sheet1", "D1", []excelize.RichTextRun{{Text: "太字", Font: &excelize.Font{Bold: true}}, {Text: "普通"}})
+	if err := File.SaveAs(FilePath); err != nil {
+		t.Fatal(err)
+	}
+
+	TableInstance, err := New(FilePath, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if IsFormula, err := TableInstance.IsFormula(2, 1); err != nil || !IsFormula {
+		t.Errorf("ExcelTable.IsFormula(A2) = %v, %v, want true", IsFormula, err)
+	}
+	if IsFormula, err := TableInstance.IsFormula(1, 1); err != nil || IsFormula {
+		t.Errorf("ExcelTable.IsFormula(A1) = %v, %v, want false", IsFormula, err)
+	}
+	if err := TableInstance.WriteCells([]table.CellUpdate{{Row: 1, Column: 2, Value: "你好", StyleColumn: 1}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := TableInstance.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	Result, err := excelize.OpenFile(FilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer Result.Close()
+
+	if Value, _ := Result.GetCellValue("Sheet1", "B1"); Value != "你好" {
+		t.Errorf("B1 = %q, want %q", Value, "你好")
+	}
+	if got, _ := Result.GetCellStyle("Sheet1", "B1"); got != Style {
+		t.Errorf("B1 style = %v, want inherited style %v", got, Style)
+	}
+	if CellType, _ := Result.GetCellType("Sheet1", "C1"); CellType == excelize.CellTypeSharedString || CellType == excelize.CellTypeInlineString {
+		t.Errorf("C1 type = %v, number was rewritten as text", CellType)
+	}
+	if Formula, _ := Result.GetCellFormula("Sheet1", "A2"); Formula == "" {
+		t.Errorf("A2 formula was removed")
+	}
+	if Runs, _ := Result.GetCellRichText("Sheet1", "D1"); len(Runs) != 2 {
+		t.Errorf("D1 rich text runs = %d, want 2", len(Runs))
+	}
+}
+
+func TestNew_InvalidFile(t *testing.T) {
+	for _, Name := range []string{"broken.xlsx", "broken.xls"} {
+		FilePath := filepath.Join(t.TempDir(), Name)
+		Content := []byte("not a workbook")
+		if err := os.WriteFile(FilePath, Content, 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := New(FilePath, ""); err == nil {
+			t.Errorf("New(%s) should fail", Name)
+		}
+		// 无法解析的文件不能被覆盖
+		if got, _ := os.ReadFile(FilePath); !bytes.Equal(got, Content) {
+			t.Errorf("%s was overwritten", Name)
+		}
+	}
+}
+
+func TestNew_XLS(t *testing.T) {
+	// LibreOffice Calc保存的多工作表工作簿，写入同名的xlsx文件
+	OutputPath := filepath.Join(t.TempDir(), "calc.xlsx")
+	TableInstance, err := New(filepath.Join("..", "xls", "testdata", "calc.xls"), OutputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := TableInstance.Sheets(), []string{"Test sheet 1", "Test sheet 2", "Sheet3"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Sheets() = %q, want %q", got, want)
+	}
+	if err := TableInstance.SelectSheet("Test sheet 2"); err != nil {
+		t.Fatal(err)
+	}
+	Datas, err := TableInstance.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	Datas[0] = append(Datas[0], "Prueba2")
+	if err := TableInstance.Write(Datas); err != nil {
+		t.Fatal(err)
+	}
+	if err := TableInstance.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	Result, err := excelize.OpenFile(OutputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer Result.Close()
+	if got := Result.GetSheetList(); len(got) != 3 {
+		t.Errorf("output sheets = %q, want all three sheets", got)
+	}
+	if got, _ := Result.GetCellValue("Test sheet 2", "B1"); got != "Prueba2" {
+		t.Errorf("Test sheet 2!B1 = %q, want %q", got, "Prueba2")
+	}
+	if got, _ := Result.GetCellValue("Test sheet 1", "A2"); got != "Avocado" {
+		t.Errorf("Test sheet 1!A2 = %q, want %q", got, "Avocado")
+	}
+}
+
+func TestExcelTable_Stream(t *testing.T) {
+	FilePath := filepath.Join(t.TempDir(), "script.xlsx")
+
+	File := excelize.NewFile()
+	File.NewSheet("Script")
+	File.NewSheet("Notes")
+	File.SetActiveSheet(1)
+	File.SetSheetRow("Script", "A1", &[]interface{}{"key", "text"})
+	File.SetSheetRow("Script", "A2", &[]interface{}{"hello", "こんにちは"})
+	File.SetSheetRow("Script", "A4", &[]interface{}{"cat", "猫"})
+	if err := File.SaveAs(FilePath); err != nil {
+		t.Fatal(err)
+	}
+
+	TableInstance, err := New(FilePath, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := TableInstance.SelectSheet("Script"); err != nil {
+		t.Fatal(err)
+	}
+	Reader, err := TableInstance.ReadRows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	Writer, err := TableInstance.WriteRows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for Reader.Next() {
+		Row := append(Reader.Row(), "译文")
+		if err := Writer.WriteRow(Row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := Reader.Err(); err != nil {
+		t.Fatal(err)
+	}
+	Reader.Close()
+	if err := Writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := TableInstance.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	Result, err := excelize.OpenFile(FilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer Result.Close()
+
+	// 工作表的顺序、名称和活动工作表保持不变，空行原样保留
+	if got := Result.GetSheetList(); !reflect.DeepEqual(got, []string{"Sheet1", "Script", "Notes"}) {
+		t.Errorf("sheets = %v", got)
+	}
+	if got := Result.GetActiveSheetIndex(); got != 1 {
+		t.Errorf("active sheet = %d, want 1", got)
+	}
+	Rows, _ := Result.GetRows("Script")
+	want := [][]string{{"key", "text", "译文"}, {"hello", "こんにちは", "译文"}, {"译文"}, {"cat", "猫", "译文"}}
+	if !reflect.DeepEqual(Rows, want) {
+		t.Errorf("rows = %q, want %q", Rows, want)
+	}
+}
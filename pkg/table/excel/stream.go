@@ -0,0 +1,209 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-13 13:05:27
+ * @LastEditTime: 2025-07-13 15:18:09
+ * @LastEditors: nijineko
+ * @Description: Excel表格流式读写
+ * @FilePath: \AutoTranslation\pkg\table\excel\stream.go
+ */
+package excel
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/nijinekoyo/AutoTranslation/pkg/table"
+	"github.com/xuri/excelize/v2"
+)
+
+// 工作表名称的最大长度
+const maxSheetNameLength = 31
+
+// Excel流式读取器
+type rowReader struct {
+	rows *excelize.Rows
+	row  []string
+	err  error
+}
+
+/**
+ * @description: 从第一行开始流式读取当前工作表，不会一次读入全部单元格
+ * @return {table.RowReader} 行迭代器
+ * @return {error} 错误信息
+ */
+func (e *ExcelTable) ReadRows() (table.RowReader, error) {
+	if e.isClosed {
+		return nil, os.ErrClosed
+	}
+
+	Rows, err := e.excelizeHandle.Rows(e.sheetName)
+	if err != nil {
+		return nil, err
+	}
+	return &rowReader{rows: Rows}, nil
+}
+
+/**
+ * @description: 读取下一行
+ * @return {bool} 是否读取到行
+ */
+func (r *rowReader) Next() bool {
+	if r.err != nil || !r.rows.Next() {
+		r.row = nil
+		return false
+	}
+	if r.row, r.err = r.rows.Columns(); r.err != nil {
+		return false
+	}
+	return true
+}
+
+/**
+ * @description: 获取当前行数据
+ * @return {[]string} 当前行数据
+ */
+func (r *rowReader) Row() []string {
+	return r.row
+}
+
+/**
+ * @description: 获取读取过程中的错误
+ * @return {error} 错误信息
+ */
+func (r *rowReader) Err() error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.rows.Error()
+}
+
+/**
+ * @description: 关闭读取器
+ * @return {error} 错误信息
+ */
+func (r *rowReader) Close() error {
+	return r.rows.Close()
+}
+
+// Excel逐行写入器，写入临时工作表，完成后替换当前工作表
+type rowWriter struct {
+	table     *ExcelTable
+	sheetName string // 被替换的工作表名称
+	tempSheet string // 临时工作表名称
+	writer    *excelize.StreamWriter
+	count     int  // 已写入的行数
+	isDone    bool // 是否已完成或放弃
+}
+
+/**
+ * @description: 创建当前工作表的逐行写入器，单元格都写入为文本，不保留原有的样式和公式
+ * @return {table.RowWriter} 逐行写入器
+ * @return {error} 错误信息
+ */
+func (e *ExcelTable) WriteRows() (table.RowWriter, error) {
+	if e.isClosed {
+		return nil, os.ErrClosed
+	}
+
+	// 在已有的工作表上流式写入会读入整个工作表，因此写入新的临时工作表
+	TempSheet := ""
+	for Index := 1; TempSheet == ""; Index++ {
+		Suffix := "~" + strconv.Itoa(Index)
+		Name := e.sheetName
+		if Runes := []rune(Name); len(Runes)+len(Suffix) > maxSheetNameLength {
+			Name = string(Runes[:maxSheetNameLength-len(Suffix)])
+		}
+		if SheetIndex, _ := e.excelizeHandle.GetSheetIndex(Name + Suffix); SheetIndex < 0 {
+			TempSheet = Name + Suffix
+		}
+	}
+	if _, err := e.excelizeHandle.NewSheet(TempSheet); err != nil {
+		return nil, err
+	}
+
+	Writer, err := e.excelizeHandle.NewStreamWriter(TempSheet)
+	if err != nil {
+		e.excelizeHandle.DeleteSheet(TempSheet)
+		return nil, err
+	}
+
+	return &rowWriter{table: e, sheetName: e.sheetName, tempSheet: TempSheet, writer: Writer}, nil
+}
+
+/**
+ * @description: 写入下一行
+ * @param {[]string} Row 行数据
+ * @return {error} 错误信息
+ */
+func (w *rowWriter) WriteRow(Row []string) error {
+	if w.isDone {
+		return os.ErrClosed
+	}
+
+	w.count++
+	Values := make([]interface{}, len(Row))
+	for Index, Value := range Row {
+		// 空字符串不写入单元格
+		if Value != "" {
+			Values[Index] = Value
+		}
+	}
+	return w.writer.SetRow("A"+strconv.Itoa(w.count), Values)
+}
+
+/**
+ * @description: 流式写入器超过内存阈值时自动写入临时文件，无需手动刷新
+ * @return {error} 错误信息
+ */
+func (w *rowWriter) Flush() error {
+	if w.isDone {
+		return os.ErrClosed
+	}
+	return nil
+}
+
+/**
+ * @description: 完成写入，临时工作表替换原工作表并保持其位置和名称
+ * @return {error} 错误信息
+ */
+func (w *rowWriter) Close() error {
+	if w.isDone {
+		return os.ErrClosed
+	}
+	w.isDone = true
+
+	Handle := w.table.excelizeHandle
+	if err := w.writer.Flush(); err != nil {
+		Handle.DeleteSheet(w.tempSheet)
+		return err
+	}
+
+	Active := Handle.GetSheetName(Handle.GetActiveSheetIndex())
+	if err := Handle.MoveSheet(w.tempSheet, w.sheetName); err != nil {
+		Handle.DeleteSheet(w.tempSheet)
+		return err
+	}
+	if err := Handle.DeleteSheet(w.sheetName); err != nil {
+		return err
+	}
+	if err := Handle.SetSheetName(w.tempSheet, w.sheetName); err != nil {
+		return fmt.Errorf("failed to rename sheet %q: %w", w.tempSheet, err)
+	}
+	if Index, _ := Handle.GetSheetIndex(Active); Index >= 0 {
+		Handle.SetActiveSheet(Index)
+	}
+	return nil
+}
+
+/**
+ * @description: 放弃写入，删除临时工作表
+ * @return {error} 错误信息
+ */
+func (w *rowWriter) Abort() error {
+	if w.isDone {
+		return nil
+	}
+	w.isDone = true
+	return w.table.excelizeHandle.DeleteSheet(w.tempSheet)
+}
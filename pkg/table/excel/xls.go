@@ -0,0 +1,152 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-09 15:02:11
+ * @LastEditTime: 2025-07-09 16:18:40
+ * @LastEditors: nijineko
+ * @Description: 旧版xls工作簿转换
+ * @FilePath: \AutoTranslation\pkg\table\excel\xls.go
+ */
+package excel
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/nijinekoyo/AutoTranslation/pkg/table/xls"
+	"github.com/xuri/excelize/v2"
+)
+
+/**
+ * @description: 获取输出文件路径，xls只能读取，输出时改为xlsx
+ * @param {string} FilePath 文件路径
+ * @return {string} 输出文件路径
+ */
+func XLSXPath(FilePath string) string {
+	Ext := filepath.Ext(FilePath)
+	if !strings.EqualFold(Ext, ".xls") {
+		return FilePath
+	}
+	return strings.TrimSuffix(FilePath, Ext) + ".xlsx"
+}
+
+/**
+ * @description: 读取xls工作簿并转换为内存中的xlsx工作簿，保留数字格式、合并单元格和隐藏状态
+ * @param {string} FilePath xls文件路径
+ * @return {*excelize.File} 转换后的工作簿
+ * @return {map[string]bool} 公式单元格，键为"工作表!单元格"，转换后只保留公式的计算结果
+ * @return {error} 错误信息
+ */
+func openXLS(FilePath string) (*excelize.File, map[string]bool, error) {
+	Workbook, err := xls.Open(FilePath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ExcelizeHandle := excelize.NewFile()
+	Formulas := map[string]bool{}
+	Styles := map[string]int{} // 数字格式对应的样式
+
+	for Index, Sheet := range Workbook.Sheets {
+		if Index == 0 {
+			if err := ExcelizeHandle.SetSheetName(ExcelizeHandle.GetSheetName(0), Sheet.Name); err != nil {
+				return nil, nil, err
+			}
+		} else if _, err := ExcelizeHandle.NewSheet(Sheet.Name); err != nil {
+			return nil, nil, err
+		}
+
+		for RowIndex, Row := range Sheet.Rows {
+			for ColIndex, CellData := range Row {
+				if CellData.Value == nil {
+					continue
+				}
+				Cell, err := excelize.CoordinatesToCellName(ColIndex+1, RowIndex+1)
+				if err != nil {
+					return nil, nil, err
+				}
+				if Text, ok := CellData.Value.(string); ok {
+					err = ExcelizeHandle.SetCellStr(Sheet.Name, Cell, Text)
+				} else {
+					err = ExcelizeHandle.SetCellValue(Sheet.Name, Cell, CellData.Value)
+				}
+				if err != nil {
+					return nil, nil, err
+				}
+				if CellData.IsFormula {
+					Formulas[Sheet.Name+"!"+Cell] = true
+				}
+
+				// 保留数字格式，日期等数字才能正确显示
+				Style, err := numberFormatStyle(ExcelizeHandle, Styles, CellData)
+				if err != nil {
+					return nil, nil, err
+				}
+				if Style != 0 {
+					if err := ExcelizeHandle.SetCellStyle(Sheet.Name, Cell, Cell, Style); err != nil {
+						return nil, nil, err
+					}
+				}
+			}
+		}
+
+		for _, Merge := range Sheet.Merges {
+			TopLeft, err := excelize.CoordinatesToCellName(Merge.FirstCol+1, Merge.FirstRow+1)
+			if err != nil {
+				return nil, nil, err
+			}
+			BottomRight, err := excelize.CoordinatesToCellName(Merge.LastCol+1, Merge.LastRow+1)
+			if err != nil {
+				return nil, nil, err
+			}
+			if err := ExcelizeHandle.MergeCell(Sheet.Name, TopLeft, BottomRight); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	// 隐藏工作表需要在全部工作表创建后设置，工作簿至少保留一个可见工作表
+	for _, Sheet := range Workbook.Sheets {
+		if Sheet.Hidden {
+			if err := ExcelizeHandle.SetSheetVisible(Sheet.Name, false); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	return ExcelizeHandle, Formulas, nil
+}
+
+/**
+ * @description: 获取数字格式对应的样式，相同格式复用同一个样式
+ * @param {*excelize.File} ExcelizeHandle 工作簿
+ * @param {map[string]int} Styles 已创建的样式
+ * @param {xls.Cell} CellData 单元格
+ * @return {int} 样式编号，常规格式为0
+ * @return {error} 错误信息
+ */
+func numberFormatStyle(ExcelizeHandle *excelize.File, Styles map[string]int, CellData xls.Cell) (int, error) {
+	if CellData.NumFmt == 0 || strings.EqualFold(CellData.NumFmtCode, "General") {
+		return 0, nil
+	}
+
+	Key := CellData.NumFmtCode
+	StyleData := &excelize.Style{}
+	if CellData.NumFmtCode != "" {
+		StyleData.CustomNumFmt = &CellData.NumFmtCode
+	} else {
+		// 内置格式编号与xlsx相同
+		StyleData.NumFmt = CellData.NumFmt
+		Key = "#" + strconv.Itoa(CellData.NumFmt)
+	}
+
+	if Style, ok := Styles[Key]; ok {
+		return Style, nil
+	}
+	Style, err := ExcelizeHandle.NewStyle(StyleData)
+	if err != nil {
+		return 0, err
+	}
+	Styles[Key] = Style
+	return Style, nil
+}
@@ -0,0 +1,148 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-22 11:48:05
+ * @LastEditTime: 2025-07-22 16:03:51
+ * @LastEditors: nijineko
+ * @Description: INI键值文件处理
+ * @FilePath: \AutoTranslation\pkg\table\ini\ini.go
+ */
+package ini
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/nijinekoyo/AutoTranslation/pkg/table/resource"
+)
+
+// INI资源格式，译文写入另一个文件
+var Format = resource.Format{Parse: Parse, Protect: resource.FormatPattern}
+
+// 一个值在文件中的位置
+type property struct {
+	start, end int
+}
+
+// 解析后的INI文件
+type document struct {
+	text       string
+	properties []property
+	entries    []resource.Entry
+}
+
+/**
+ * @description: 创建一个新的INI文件处理实例
+ * @param {string} FilePath INI文件路径
+ * @param {string} OutputPath 输出文件路径
+ * @param {string} Language 目标语言
+ * @return {*resource.ResourceTable} 返回一个新的资源文件表格实例
+ * @return {error} 错误信息
+ */
+func New(FilePath, OutputPath, Language string) (*resource.ResourceTable, error) {
+	return resource.New(FilePath, OutputPath, Language, Format)
+}
+
+/**
+ * @description: 获取与原文件放在一起的目标语言文件路径，如"strings.ini"替换为"strings.zh-CN.ini"
+ * @param {string} Path INI文件路径
+ * @param {string} Language 目标语言
+ * @return {string} 目标语言的文件路径
+ */
+func LocalePath(Path, Language string) string {
+	return resource.SuffixPath(Path, Language)
+}
+
+/**
+ * @description: 解析INI文件，键为"节.键"，;和#开头的注释作为说明，值两端的引号保留，值中的\n作为换行传给翻译，没有文字的值跳过
+ * @param {[]byte} Data 文件内容
+ * @return {resource.Document} 解析后的文件
+ * @return {error} 错误信息
+ */
+func Parse(Data []byte) (resource.Document, error) {
+	Document := &document{text: string(Data)}
+	Text := strings.TrimPrefix(Document.text, "\uFEFF")
+
+	Section := ""
+	var Comments []string
+	Offset := len(Data) - len(Text)
+	for _, Line := range strings.SplitAfter(Text, "\n") {
+		LineStart := Offset
+		Offset += len(Line)
+		Line = strings.TrimRight(Line, "\r\n")
+		Trimmed := strings.TrimSpace(Line)
+		switch {
+		case Trimmed == "":
+			Comments = nil
+			continue
+		case Trimmed[0] == ';' || Trimmed[0] == '#':
+			Comments = append(Comments, strings.TrimSpace(Trimmed[1:]))
+			continue
+		case Trimmed[0] == '[' && strings.HasSuffix(Trimmed, "]"):
+			Section = strings.TrimSpace(Trimmed[1 : len(Trimmed)-1])
+			Comments = nil
+			continue
+		}
+
+		Separator := strings.IndexByte(Line, '=')
+		if Separator < 0 {
+			Separator = strings.IndexByte(Line, ':')
+		}
+		if Separator < 0 {
+			Comments = nil
+			continue
+		}
+		Key := strings.TrimSpace(Line[:Separator])
+		if Section != "" {
+			Key = Section + "." + Key
+		}
+
+		// 去除值两端的空白和引号，写回时只替换引号中的内容
+		Start := Separator + 1 + len(Line[Separator+1:]) - len(strings.TrimLeft(Line[Separator+1:], " \t"))
+		End := len(strings.TrimRight(Line, " \t"))
+		if End-Start >= 2 && (Line[Start] == '"' || Line[Start] == '\'') && Line[End-1] == Line[Start] {
+			Start, End = Start+1, End-1
+		}
+		Value := strings.ReplaceAll(Line[Start:max(Start, End)], `\n`, "\n")
+		if !strings.ContainsFunc(Value, unicode.IsLetter) {
+			Comments = nil
+			continue
+		}
+
+		Context := strings.Join(Comments, "\n")
+		Document.properties = append(Document.properties, property{LineStart + Start, LineStart + End})
+		Document.entries = append(Document.entries, resource.Entry{Key: Key, Source: Value, Context: Context})
+		Comments = nil
+	}
+	return Document, nil
+}
+
+/**
+ * @description: 获取可翻译条目
+ * @return {[]resource.Entry} 可翻译条目
+ */
+func (d *document) Entries() []resource.Entry {
+	return d.entries
+}
+
+/**
+ * @description: 生成目标语言文件，只替换值，节、键、注释和格式保持不变，未翻译的条目保留原文
+ * @param {[]string} Translations 各条目的译文
+ * @param {string} Language 目标语言
+ * @return {[]byte} 文件内容
+ * @return {error} 错误信息
+ */
+func (d *document) Marshal(Translations []string, Language string) ([]byte, error) {
+	var Builder strings.Builder
+	Last := 0
+	for Index, Property := range d.properties {
+		if Index >= len(Translations) || Translations[Index] == "" {
+			continue
+		}
+		Translation := Translations[Index]
+		Builder.WriteString(d.text[Last:Property.start])
+		Builder.WriteString(strings.ReplaceAll(strings.ReplaceAll(Translation, "\r\n", "\n"), "\n", `\n`))
+		Last = Property.end
+	}
+	Builder.WriteString(d.text[Last:])
+	return []byte(Builder.String()), nil
+}
@@ -0,0 +1,57 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-22 15:50:14
+ * @LastEditTime: 2025-07-22 16:08:25
+ * @LastEditors: nijineko
+ * @Description: INI键值文件处理测试
+ * @FilePath: \AutoTranslation\pkg\table\ini\ini_test.go
+ */
+package ini
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/nijinekoyo/AutoTranslation/pkg/table/resource"
+)
+
+func TestINI_RoundTrip(t *testing.T) {
+	FilePath := filepath.Join(t.TempDir(), "strings.ini")
+	Content := "; Main menu\r\n[menu]\r\nstart = Start game\r\nquit=\"Quit, %s?\"\r\nversion = 1.0\r\n\r\n[dialog]\r\n# Two lines\r\nintro : Hello {0}!\\nWelcome.\r\n"
+	if err := os.WriteFile(FilePath, []byte(Content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	TableInstance, err := New(FilePath, LocalePath(FilePath, "ja"), "ja")
+	if err != nil {
+		t.Fatal(err)
+	}
+	Datas, err := TableInstance.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 没有文字的值跳过，节之前的注释不属于条目
+	want := [][]string{
+		{"source", "target", "key", "context"},
+		{"Start game", "", "menu.start", ""},
+		{"Quit, ⟦1⟧?", "", "menu.quit", resource.PlaceholderNote},
+		{"Hello ⟦1⟧!\nWelcome.", "", "dialog.intro", "Two lines\n" + resource.PlaceholderNote},
+	}
+	if !reflect.DeepEqual(Datas, want) {
+		t.Fatalf("Read() = %q, want %q", Datas, want)
+	}
+
+	Datas[1][1] = "ゲーム開始"
+	Datas[2][1] = "⟦1⟧ を終了しますか？"
+	Datas[3][1] = "こんにちは、⟦1⟧！\nようこそ。"
+	if err := TableInstance.Write(Datas); err != nil {
+		t.Fatal(err)
+	}
+
+	Output := "; Main menu\r\n[menu]\r\nstart = ゲーム開始\r\nquit=\"%s を終了しますか？\"\r\nversion = 1.0\r\n\r\n[dialog]\r\n# Two lines\r\nintro : こんにちは、{0}！\\nようこそ。\r\n"
+	if got, _ := os.ReadFile(filepath.Join(filepath.Dir(FilePath), "strings.ja.ini")); string(got) != Output {
+		t.Errorf("output = %q, want %q", got, Output)
+	}
+}
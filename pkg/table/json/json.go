@@ -0,0 +1,430 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-14 14:10:36
+ * @LastEditTime: 2025-07-14 17:22:08
+ * @LastEditors: nijineko
+ * @Description: JSON本地化资源文件处理，支持i18next和vue-i18n风格的嵌套键
+ * @FilePath: \AutoTranslation\pkg\table\json\json.go
+ */
+package json
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/nijinekoyo/AutoTranslation/pkg/table/resource"
+	"golang.org/x/text/language"
+)
+
+// UTF-8 BOM
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// 资源文件格式
+var Format = resource.Format{Parse: Parse}
+
+// 文件名或目录名中的语言代码，如"en"、"zh-CN"、"pt_BR"
+var localePattern = regexp.MustCompile(`^[a-z]{2}([-_][A-Za-z0-9]{2,8})*$`)
+
+// 常用于存放本地化文件的目录名称
+var localeDirectories = []string{"locales", "locale", "i18n", "lang", "langs", "languages", "translations"}
+
+// 节点类型
+type nodeKind int
+
+const (
+	kindObject  nodeKind = iota // 对象
+	kindArray                   // 数组
+	kindString                  // 字符串
+	kindLiteral                 // 数字、布尔值和null，原样保留
+)
+
+// JSON节点，对象保持键的顺序
+type node struct {
+	kind  nodeKind
+	keys  []string // 对象的键
+	items []*node  // 对象的值或数组的元素
+	text  string   // 字符串的值或字面量的原文
+
+	inline bool // 对象或数组在源文件中是否写在一行内
+}
+
+// 解析后的JSON文件
+type document struct {
+	root         *node
+	indent       string // 缩进，为空表示紧凑格式
+	lineEnding   string // 换行符
+	finalNewline bool   // 文件是否以换行结尾
+	bom          bool   // 文件是否带有UTF-8 BOM
+	entries      []resource.Entry
+	nodes        map[*node]int // 可翻译的字符串节点，值为条目索引
+}
+
+/**
+ * @description: 创建一个新的JSON资源文件处理实例
+ * @param {string} FilePath JSON文件路径
+ * @param {string} OutputPath 输出文件路径，为空时覆盖源文件
+ * @param {string} Language 目标语言，用于调整复数键
+ * @return {*resource.ResourceTable} 返回一个新的资源文件表格实例
+ * @return {error} 错误信息
+ */
+func New(FilePath, OutputPath, Language string) (*resource.ResourceTable, error) {
+	return resource.New(FilePath, OutputPath, Language, Format)
+}
+
+/**
+ * @description: 判断文件是否符合本地化文件的结构，文件名或所在目录名为语言代码，如"en.json"、"locales/zh-CN/common.json"，或者位于locales、i18n等目录中
+ * @param {string} Path 文件路径
+ * @return {bool} 是否为本地化文件
+ */
+func IsLocalePath(Path string) bool {
+	Name := strings.TrimSuffix(filepath.Base(Path), filepath.Ext(Path))
+	Directory := filepath.Base(filepath.Dir(Path))
+	if slices.Contains(localeDirectories, strings.ToLower(Directory)) {
+		return true
+	}
+	// 文件名可能带有语言后缀，如"messages.en.json"
+	for _, Candidate := range []string{Name, strings.TrimPrefix(filepath.Ext(Name), "."), Directory} {
+		if localePattern.MatchString(Candidate) {
+			if _, err := language.Parse(strings.ReplaceAll(Candidate, "_", "-")); err == nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+/**
+ * @description: 解析JSON文件，嵌套对象和数组展开为以点连接的键路径，只有非空字符串可翻译
+ * @param {[]byte} Data 文件内容
+ * @return {resource.Document} 解析后的文件
+ * @return {error} 错误信息
+ */
+func Parse(Data []byte) (resource.Document, error) {
+	Content, HasBOM := bytes.CutPrefix(Data, utf8BOM)
+	Decoder := json.NewDecoder(bytes.NewReader(Content))
+	Decoder.UseNumber()
+
+	Root, err := parseNode(Decoder, Content)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := Decoder.Token(); !errors.Is(err, io.EOF) {
+		return nil, errors.New("unexpected data after top-level value")
+	}
+
+	Document := &document{
+		root:         Root,
+		indent:       detectIndent(Data),
+		lineEnding:   "\n",
+		finalNewline: bytes.HasSuffix(bytes.TrimRight(Data, " \t"), []byte("\n")),
+		bom:          HasBOM,
+		nodes:        map[*node]int{},
+	}
+	if bytes.Contains(Data, []byte("\r\n")) {
+		Document.lineEnding = "\r\n"
+	}
+	Document.collect(Root, "")
+
+	return Document, nil
+}
+
+/**
+ * @description: 读取一个JSON值
+ * @param {*json.Decoder} Decoder 解码器
+ * @param {[]byte} Data 解码器读取的内容，用于判断对象或数组是否写在一行内
+ * @return {*node} 节点
+ * @return {error} 错误信息
+ */
+func parseNode(Decoder *json.Decoder, Data []byte) (*node, error) {
+	Token, err := Decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch Value := Token.(type) {
+	case json.Delim:
+		Node := &node{kind: kindArray}
+		Start := Decoder.InputOffset()
+		if Value == '{' {
+			Node.kind = kindObject
+		}
+		for Decoder.More() {
+			if Node.kind == kindObject {
+				Key, err := Decoder.Token()
+				if err != nil {
+					return nil, err
+				}
+				Node.keys = append(Node.keys, Key.(string))
+			}
+			Item, err := parseNode(Decoder, Data)
+			if err != nil {
+				return nil, err
+			}
+			Node.items = append(Node.items, Item)
+		}
+		// 读取结束符
+		if _, err := Decoder.Token(); err != nil {
+			return nil, err
+		}
+		Node.inline = !bytes.Contains(Data[Start:Decoder.InputOffset()], []byte("\n"))
+		return Node, nil
+	case string:
+		return &node{kind: kindString, text: Value}, nil
+	case json.Number:
+		return &node{kind: kindLiteral, text: Value.String()}, nil
+	case bool:
+		return &node{kind: kindLiteral, text: strconv.FormatBool(Value)}, nil
+	case nil:
+		return &node{kind: kindLiteral, text: "null"}, nil
+	default:
+		return nil, fmt.Errorf("unexpected token %v", Token)
+	}
+}
+
+/**
+ * @description: 检测缩进，以第一个缩进的行为准
+ * @param {[]byte} Data 文件内容
+ * @return {string} 缩进，没有换行时为空
+ */
+func detectIndent(Data []byte) string {
+	for _, Line := range bytes.Split(Data, []byte("\n"))[1:] {
+		Line = bytes.TrimRight(Line, "\r")
+		Content := bytes.TrimLeft(Line, " \t")
+		if len(Content) > 0 && len(Content) < len(Line) {
+			return string(Line[:len(Line)-len(Content)])
+		}
+	}
+	if bytes.Contains(Data, []byte("\n")) {
+		return "  "
+	}
+	return ""
+}
+
+/**
+ * @description: 按文件顺序收集可翻译的字符串
+ * @param {*node} Node 节点
+ * @param {string} Path 节点的键路径
+ */
+func (d *document) collect(Node *node, Path string) {
+	switch Node.kind {
+	case kindObject, kindArray:
+		for Index, Item := range Node.items {
+			Key := strconv.Itoa(Index)
+			if Node.kind == kindObject {
+				Key = Node.keys[Index]
+			}
+			if Path != "" {
+				Key = Path + "." + Key
+			}
+			d.collect(Item, Key)
+		}
+	case kindString:
+		if Node.text == "" {
+			return
+		}
+		d.nodes[Node] = len(d.entries)
+		d.entries = append(d.entries, resource.Entry{Key: Path, Source: Node.text})
+	}
+}
+
+/**
+ * @description: 获取可翻译条目
+ * @return {[]resource.Entry} 可翻译条目
+ */
+func (d *document) Entries() []resource.Entry {
+	return d.entries
+}
+
+/**
+ * @description: 生成目标语言文件，保持键的顺序和缩进，复数键按目标语言调整
+ * @param {[]string} Translations 各条目的译文
+ * @param {string} Language 目标语言
+ * @return {[]byte} 文件内容
+ * @return {error} 错误信息
+ */
+func (d *document) Marshal(Translations []string, Language string) ([]byte, error) {
+	Writer := &writer{document: d, translations: Translations, language: Language}
+	if d.bom {
+		Writer.buffer.Write(utf8BOM)
+	}
+	Writer.writeNode(d.root, 0)
+	if d.finalNewline {
+		Writer.buffer.WriteString(d.lineEnding)
+	}
+	return Writer.buffer.Bytes(), nil
+}
+
+// JSON写入器
+type writer struct {
+	document     *document
+	translations []string
+	language     string
+	buffer       bytes.Buffer
+}
+
+/**
+ * @description: 获取字符串节点写入的值，未翻译时使用原文
+ * @param {*node} Node 字符串节点
+ * @return {string} 写入的值
+ */
+func (w *writer) value(Node *node) string {
+	if Index, ok := w.document.nodes[Node]; ok && Index < len(w.translations) && w.translations[Index] != "" {
+		return w.translations[Index]
+	}
+	return Node.text
+}
+
+/**
+ * @description: 写入节点
+ * @param {*node} Node 节点
+ * @param {int} Depth 缩进层级
+ */
+func (w *writer) writeNode(Node *node, Depth int) {
+	switch Node.kind {
+	case kindString:
+		w.buffer.WriteString(quote(w.value(Node)))
+	case kindLiteral:
+		w.buffer.WriteString(Node.text)
+	case kindArray:
+		w.writeContainer('[', ']', len(Node.items), Depth, Node.inline, func(Index int) {
+			w.writeNode(Node.items[Index], Depth+1)
+		})
+	case kindObject:
+		Keys, Items := w.pluralize(Node)
+		w.writeContainer('{', '}', len(Items), Depth, Node.inline, func(Index int) {
+			w.buffer.WriteString(quote(Keys[Index]))
+			w.buffer.WriteByte(':')
+			if w.document.indent != "" {
+				w.buffer.WriteByte(' ')
+			}
+			w.writeNode(Items[Index], Depth+1)
+		})
+	}
+}
+
+/**
+ * @description: 写入对象或数组
+ * @param {byte} Open 开始符
+ * @param {byte} Close 结束符
+ * @param {int} Count 成员数量
+ * @param {int} Depth 缩进层级
+ * @param {bool} Inline 是否写在一行内
+ * @param {func(int)} WriteItem 写入指定成员
+ */
+func (w *writer) writeContainer(Open, Close byte, Count, Depth int, Inline bool, WriteItem func(Index int)) {
+	w.buffer.WriteByte(Open)
+	if Count == 0 {
+		w.buffer.WriteByte(Close)
+		return
+	}
+	for Index := 0; Index < Count; Index++ {
+		if Index > 0 {
+			w.buffer.WriteByte(',')
+			if Inline && w.document.indent != "" {
+				w.buffer.WriteByte(' ')
+			}
+		}
+		if !Inline {
+			w.newline(Depth + 1)
+		}
+		WriteItem(Index)
+	}
+	if !Inline {
+		w.newline(Depth)
+	}
+	w.buffer.WriteByte(Close)
+}
+
+/**
+ * @description: 换行并缩进，紧凑格式时不写入
+ * @param {int} Depth 缩进层级
+ */
+func (w *writer) newline(Depth int) {
+	if w.document.indent == "" {
+		return
+	}
+	w.buffer.WriteString(w.document.lineEnding)
+	w.buffer.WriteString(strings.Repeat(w.document.indent, Depth))
+}
+
+/**
+ * @description: 按目标语言调整对象中的复数键，如"item_one"和"item_other"，去除目标语言不使用的类别，缺少的类别使用other的译文
+ * @param {*node} Node 对象节点
+ * @return {[]string} 调整后的键
+ * @return {[]*node} 调整后的值
+ */
+func (w *writer) pluralize(Node *node) ([]string, []*node) {
+	// 按去除后缀的键分组，只有存在other字符串时才视为复数
+	Groups := map[string]map[string]int{}
+	for Index, Key := range Node.keys {
+		Base, Category := resource.SplitPlural(Key, "_")
+		if Category == "" || Node.items[Index].kind != kindString {
+			continue
+		}
+		if Groups[Base] == nil {
+			Groups[Base] = map[string]int{}
+		}
+		Groups[Base][Category] = Index
+	}
+
+	Keep := map[int]bool{}
+	After := map[int][]string{} // 在指定成员之后补充的类别
+	for Base, Members := range Groups {
+		if _, ok := Members["other"]; !ok {
+			delete(Groups, Base)
+			continue
+		}
+
+		var Categories []string
+		Last := 0
+		for _, Category := range resource.PluralCategories {
+			if Index, ok := Members[Category]; ok {
+				Categories = append(Categories, Category)
+				Last = max(Last, Index)
+			}
+		}
+		Kept, Missing := resource.AdjustPlurals(Categories, w.language)
+		for _, Category := range Kept {
+			Keep[Members[Category]] = true
+		}
+		if len(Missing) > 0 {
+			After[Last] = Missing
+		}
+	}
+
+	var Keys []string
+	var Items []*node
+	for Index, Key := range Node.keys {
+		Base, Category := resource.SplitPlural(Key, "_")
+		if _, IsPlural := Groups[Base]; !IsPlural || Category == "" || Node.items[Index].kind != kindString || Keep[Index] {
+			Keys = append(Keys, Key)
+			Items = append(Items, Node.items[Index])
+		}
+		for _, Missing := range After[Index] {
+			Keys = append(Keys, Base+"_"+Missing)
+			Items = append(Items, &node{kind: kindString, text: w.value(Node.items[Groups[Base]["other"]])})
+		}
+	}
+	return Keys, Items
+}
+
+/**
+ * @description: 编码JSON字符串，不转义HTML字符
+ * @param {string} Text 字符串
+ * @return {string} JSON字符串
+ */
+func quote(Text string) string {
+	var Buffer bytes.Buffer
+	Encoder := json.NewEncoder(&Buffer)
+	Encoder.SetEscapeHTML(false)
+	Encoder.Encode(Text)
+	return strings.TrimSuffix(Buffer.String(), "\n")
+}
@@ -0,0 +1,156 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-14 17:25:40
+ * @LastEditTime: 2025-07-14 18:03:12
+ * @LastEditors: nijineko
+ * @Description: JSON本地化资源文件处理测试
+ * @FilePath: \AutoTranslation\pkg\table\json\json_test.go
+ */
+package json
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testLocale = `{
+    "app": {
+        "title": "Hello",
+        "count": 3,
+        "enabled": true,
+        "items": ["First", "", null]
+    },
+    "apple_one": "{{count}} apple",
+    "apple_other": "{{count}} apples",
+    "empty": {}
+}
+`
+
+func TestJSON_RoundTrip(t *testing.T) {
+	Directory := t.TempDir()
+	FilePath := filepath.Join(Directory, "en.json")
+	OutputPath := filepath.Join(Directory, "ru.json")
+	if err := os.WriteFile(FilePath, []byte(testLocale), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	TableInstance, err := New(FilePath, OutputPath, "ru")
+	if err != nil {
+		t.Fatal(err)
+	}
+	Datas, err := TableInstance.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"source", "target", "key", "context"},
+		{"Hello", "", "app.title", ""},
+		{"First", "", "app.items.0", ""},
+		{"{{count}} apple", "", "apple_one", ""},
+		{"{{count}} apples", "", "apple_other", ""},
+	}
+	if !reflect.DeepEqual(Datas, want) {
+		t.Fatalf("Read() = %q, want %q", Datas, want)
+	}
+
+	Datas[1][1] = "Привет"
+	Datas[2][1] = "Первый"
+	Datas[3][1] = "{{count}} яблоко"
+	Datas[4][1] = "{{count}} яблок"
+	if err := TableInstance.Write(Datas); err != nil {
+		t.Fatal(err)
+	}
+
+	// 保持键的顺序、缩进和非字符串值，俄语补充few和many
+	Output := `{
+    "app": {
+        "title": "Привет",
+        "count": 3,
+        "enabled": true,
+        "items": ["Первый", "", null]
+    },
+    "apple_one": "{{count}} яблоко",
+    "apple_other": "{{count}} яблок",
+    "apple_few": "{{count}} яблок",
+    "apple_many": "{{count}} яблок",
+    "empty": {}
+}
+`
+	if got, _ := os.ReadFile(OutputPath); string(got) != Output {
+		t.Errorf("output = %s, want %s", got, Output)
+	}
+
+	// 再次打开时读取已有的译文
+	Reopened, err := New(FilePath, OutputPath, "ru")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if Datas, _ := Reopened.Read(); Datas[1][1] != "Привет" {
+		t.Errorf("existing translation = %q, want %q", Datas[1][1], "Привет")
+	}
+
+	// 原文修改后的条目重新翻译，其他条目的译文保留
+	Changed := strings.Replace(testLocale, `"title": "Hello"`, `"title": "Hello again"`, 1)
+	if err := os.WriteFile(FilePath, []byte(Changed), 0644); err != nil {
+		t.Fatal(err)
+	}
+	Reopened, err = New(FilePath, OutputPath, "ru")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if Datas, _ := Reopened.Read(); Datas[1][1] != "" || Datas[2][1] != "Первый" {
+		t.Errorf("translations after source change = %q, %q, want changed entry untranslated", Datas[1][1], Datas[2][1])
+	}
+}
+
+func TestJSON_PluralsAndCompact(t *testing.T) {
+	FilePath := filepath.Join(t.TempDir(), "en.json")
+	if err := os.WriteFile(FilePath, []byte(`{"item_zero":"none","item_one":"one item","item_other":"items","done_one":"ok"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	TableInstance, err := New(FilePath, "", "ja")
+	if err != nil {
+		t.Fatal(err)
+	}
+	Datas, _ := TableInstance.Read()
+	if err := TableInstance.Write(Datas); err != nil {
+		t.Fatal(err)
+	}
+
+	// 日语只有other，zero和没有other的键保留
+	want := `{"item_zero":"none","item_other":"items","done_one":"ok"}`
+	if got, _ := os.ReadFile(FilePath); string(got) != want {
+		t.Errorf("output = %s, want %s", got, want)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, Content := range []string{`{"a": }`, `{"a": "b"} {}`, ``} {
+		if _, err := Parse([]byte(Content)); err == nil {
+			t.Errorf("Parse(%q) should fail", Content)
+		}
+	}
+}
+
+func TestIsLocalePath(t *testing.T) {
+	for Path, want := range map[string]bool{
+		"locales/en.json":                  true,
+		"src/i18n/messages.json":           true,
+		"public/locales/zh-CN/common.json": true,
+		"lang/pt_BR.json":                  true,
+		"strings/messages.en.json":         true,
+		"package.json":                     false,
+		"tsconfig.json":                    false,
+		"src/config/app.json":              false,
+		"node_modules/left-pad/index.json": false,
+		"data/fixtures.json":               false,
+	} {
+		if got := IsLocalePath(filepath.FromSlash(Path)); got != want {
+			t.Errorf("IsLocalePath(%q) = %v, want %v", Path, got, want)
+		}
+	}
+}
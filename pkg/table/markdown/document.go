@@ -0,0 +1,209 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-21 10:05:12
+ * @LastEditTime: 2025-07-21 17:10:44
+ * @LastEditors: nijineko
+ * @Description: 按文本块翻译的文档，译文按原文的位置写回
+ * @FilePath: \AutoTranslation\pkg\table\markdown\document.go
+ */
+package markdown
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/nijinekoyo/AutoTranslation/internal/log"
+	"github.com/nijinekoyo/AutoTranslation/pkg/table/resource"
+)
+
+// 传给大语言模型的占位符说明
+const placeholderNote = "⟦1⟧等为代码、链接目标等不可翻译内容的占位符，须原样保留在译文中的对应位置"
+
+// 占位符，用于判断文本块中是否有需要翻译的文字
+var placeholderPattern = regexp.MustCompile(`⟦\d+⟧`)
+
+// 一个文本块在文件中的位置和写回方式
+type segment struct {
+	start, end   int    // 被译文替换的范围
+	inline       bool   // 标题、表格单元格等只能为一行
+	frontMatter  bool   // front matter字段的值，按原引号写回
+	quote        byte   // front matter值的引号，没有引号时为0
+	continuation string // 译文换行后每行的前缀，如引用的"> "、列表项的缩进
+	hardBreak    string // 换行前的硬换行标记
+	values       []string
+}
+
+// 解析后的文档
+type document struct {
+	text       string // 换行统一为\n的文件内容
+	lineEnding string // 换行符
+	bom        bool   // 文件是否带有UTF-8 BOM
+	segments   []segment
+	entries    []resource.Entry
+	keys       resource.TextKeys // 按原文生成的条目键
+}
+
+/**
+ * @description: 创建文档，记录换行符和BOM
+ * @param {[]byte} Data 文件内容
+ * @return {*document} 未包含文本块的文档
+ */
+func newDocument(Data []byte) *document {
+	Text, HasBOM := strings.CutPrefix(string(Data), "\uFEFF")
+	Document := &document{text: Text, lineEnding: "\n", bom: HasBOM, keys: resource.TextKeys{}}
+	if strings.Contains(Text, "\r\n") {
+		Document.lineEnding = "\r\n"
+		Document.text = strings.ReplaceAll(Text, "\r\n", "\n")
+	}
+	return Document
+}
+
+/**
+ * @description: 添加一个文本块，不可翻译的内容替换为占位符，没有文字的文本块跳过
+ * @param {segment} Segment 文本块的位置
+ * @param {string} Text 原文
+ * @param {*regexp.Regexp} Pattern 不可翻译内容的格式，有子匹配时只替换子匹配的部分
+ * @param {string} Context 说明
+ */
+func (d *document) add(Segment segment, Text string, Pattern *regexp.Regexp, Context string) {
+	var Builder strings.Builder
+	Offset := 0
+	for _, Match := range Pattern.FindAllStringSubmatchIndex(Text, -1) {
+		Start, End := Match[0], Match[1]
+		for Group := 2; Group < len(Match); Group += 2 {
+			if Match[Group] >= 0 {
+				Start, End = Match[Group], Match[Group+1]
+				break
+			}
+		}
+		Builder.WriteString(Text[Offset:Start])
+		Segment.values = append(Segment.values, Text[Start:End])
+		Builder.WriteString(resource.Placeholder(len(Segment.values)))
+		Offset = End
+	}
+	Builder.WriteString(Text[Offset:])
+	Source := Builder.String()
+
+	if !strings.ContainsFunc(placeholderPattern.ReplaceAllString(Source, ""), unicode.IsLetter) {
+		return
+	}
+	if len(Segment.values) > 0 {
+		if Context != "" {
+			Context += "\n"
+		}
+		Context += placeholderNote
+	}
+	d.segments = append(d.segments, Segment)
+	d.entries = append(d.entries, resource.Entry{Key: d.keys.Next(Source), Source: Source, Context: Context})
+}
+
+/**
+ * @description: 获取可翻译条目
+ * @return {[]resource.Entry} 可翻译条目
+ */
+func (d *document) Entries() []resource.Entry {
+	return d.entries
+}
+
+/**
+ * @description: 生成目标语言文档，文本块之外的内容保持不变，未翻译或译文遗漏占位符的文本块保留原文
+ * @param {[]string} Translations 各条目的译文
+ * @param {string} Language 目标语言
+ * @return {[]byte} 文件内容
+ * @return {error} 错误信息
+ */
+func (d *document) Marshal(Translations []string, Language string) ([]byte, error) {
+	var Builder strings.Builder
+	if d.bom {
+		Builder.WriteString("\uFEFF")
+	}
+	Last := 0
+	for Index, Segment := range d.segments {
+		if Index >= len(Translations) || Translations[Index] == "" {
+			continue
+		}
+		// 译文遗漏了占位符时保留原文，避免代码、链接目标等内容丢失
+		Text, ok := Segment.render(Translations[Index])
+		if !ok {
+			log.Print().Warning("Translation", fmt.Sprintf("Translation of %q is missing placeholders, keeping the source", d.entries[Index].Source))
+			continue
+		}
+		Builder.WriteString(d.text[Last:Segment.start])
+		Builder.WriteString(Text)
+		Last = Segment.end
+	}
+	Builder.WriteString(d.text[Last:])
+	return []byte(strings.ReplaceAll(Builder.String(), "\n", d.lineEnding)), nil
+}
+
+/**
+ * @description: 还原译文中的占位符并按文本块的类型排版
+ * @param {string} Translation 译文
+ * @return {string} 写回文件的内容
+ * @return {bool} 译文中的占位符是否完整
+ */
+func (s segment) render(Translation string) (string, bool) {
+	Text, ok := resource.RestorePlaceholders(Translation, s.values)
+	if !ok {
+		return "", false
+	}
+	if s.inline || s.frontMatter {
+		Text = strings.Join(strings.Fields(strings.ReplaceAll(Text, "\n", " ")), " ")
+		if s.frontMatter {
+			return quote(Text, s.quote), true
+		}
+		return Text, true
+	}
+
+	// 空行会结束段落，译文中的空行去除
+	var Lines []string
+	for _, Line := range strings.Split(Text, "\n") {
+		if strings.TrimSpace(Line) != "" {
+			Lines = append(Lines, strings.TrimSpace(Line))
+		}
+	}
+	return strings.Join(Lines, s.hardBreak+"\n"+s.continuation), true
+}
+
+/**
+ * @description: 去除front matter值的引号
+ * @param {string} Value 值
+ * @param {bool} TOML 是否为TOML，TOML的字符串必须加引号
+ * @return {string} 去除引号后的值
+ * @return {byte} 引号，没有引号时为0
+ * @return {bool} 是否为可翻译的字符串
+ */
+func unquote(Value string, TOML bool) (string, byte, bool) {
+	if len(Value) >= 2 && Value[0] == '"' && Value[len(Value)-1] == '"' {
+		Text, err := strconv.Unquote(Value)
+		return Text, '"', err == nil
+	}
+	if len(Value) >= 2 && Value[0] == '\'' && Value[len(Value)-1] == '\'' {
+		return strings.ReplaceAll(Value[1:len(Value)-1], "''", "'"), '\'', true
+	}
+	// YAML的数组、对象、块标量、锚点和标签不是普通字符串，引号不完整的值跳过
+	if TOML || Value == "" || strings.ContainsRune("[{|>&*!%@`\"'", rune(Value[0])) {
+		return "", 0, false
+	}
+	return Value, 0, true
+}
+
+/**
+ * @description: 按原引号写回front matter的值，单引号中不能包含单引号、普通值中不能包含": "等时改用双引号
+ * @param {string} Text 值
+ * @param {byte} Quote 原引号
+ * @return {string} 写回文件的值
+ */
+func quote(Text string, Quote byte) string {
+	switch {
+	case Text == "":
+	case Quote == '\'' && !strings.Contains(Text, "'"):
+		return "'" + Text + "'"
+	case Quote == 0 && !strings.Contains(Text, ": ") && !strings.Contains(Text, " #") && !strings.ContainsAny(Text[:1], "[{|>&*!%@`'\"#-?:,"):
+		return Text
+	}
+	return strconv.Quote(Text)
+}
@@ -0,0 +1,413 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-21 10:12:40
+ * @LastEditTime: 2025-07-21 17:26:03
+ * @LastEditors: nijineko
+ * @Description: Markdown文档处理，段落、标题、列表项和表格单元格为一行
+ * @FilePath: \AutoTranslation\pkg\table\markdown\markdown.go
+ */
+package markdown
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/nijinekoyo/AutoTranslation/pkg/table/resource"
+)
+
+// Markdown资源格式，每个文本块为一行，译文写入另一个文件
+var Format = resource.Format{Parse: Parse, Document: true}
+
+var (
+	// 围栏代码块，如"```go"、"~~~"
+	fencePattern = regexp.MustCompile("^[ \t]*(```+|~~~+)")
+	// ATX标题，如"## 安装"，结尾的#不属于标题文本
+	headingPattern = regexp.MustCompile(`^ {0,3}#{1,6}(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	// Setext标题的下划线
+	setextPattern = regexp.MustCompile(`^ {0,3}(=+|-+)[ \t]*$`)
+	// 分隔线
+	thematicPattern = regexp.MustCompile(`^ {0,3}((\*[ \t]*){3,}|(-[ \t]*){3,}|(_[ \t]*){3,})$`)
+	// 引用的前缀
+	quotePattern = regexp.MustCompile(`^ {0,3}>[ \t]?`)
+	// 列表项、任务列表项和脚注定义的标记
+	listPattern = regexp.MustCompile(`^([ \t]*)([-*+]|\d{1,9}[.)]|\[\^[^\]]+\]:)([ \t]+|$)(\[[ xX]\][ \t]+)?`)
+	// 链接引用定义，如"[id]: https://example.com"
+	referencePattern = regexp.MustCompile(`^ {0,3}\[[^\]^][^\]]*\]:`)
+	// 表格的分隔行，如"|---|:---:|"
+	delimiterPattern = regexp.MustCompile(`^ {0,3}\|?[ \t]*:?-+:?[ \t]*(\|[ \t]*:?-+:?[ \t]*)*\|?[ \t]*$`)
+	// HTML块，保持不变直到空行
+	htmlBlockPattern = regexp.MustCompile(`(?i)^ {0,3}</?(address|article|aside|blockquote|center|details|dialog|div|dl|figure|footer|form|h[1-6]|header|hr|img|nav|ol|p|picture|pre|section|summary|table|ul|video)\b`)
+)
+
+// 不传给翻译的行内内容：链接目标、引用链接的标识、行内代码、脚注引用、自动链接、HTML标签和注释、URL
+// 链接只替换括号中的目标，链接文本仍然翻译
+var inlinePattern = regexp.MustCompile(`\]\(([^()\s]*(?:\([^()\s]*\)[^()\s]*)*(?:\s+"[^"]*")?)\)` +
+	`|\]\[([^\]]+)\]` +
+	"|``.+?``|`[^`]+`" +
+	`|\[\^[^\]]+\]` +
+	`|<(?:https?|mailto|ftp):[^>\s]+>` +
+	`|<!--.*?-->` +
+	`|</?[A-Za-z][A-Za-z0-9-]*(?:\s[^<>]*)?/?>` +
+	`|https?://[^\s<>()\[\]]*[^\s<>()\[\].,;:!?'"]`)
+
+// front matter中翻译的字段，其余字段保持不变
+var frontMatterPattern = regexp.MustCompile(`^(title|description|summary|subtitle|excerpt|linkTitle|sidebar_label)[ \t]*([:=])[ \t]*(.*?)[ \t]*$`)
+
+// 段落中的一行
+type paragraphLine struct {
+	start, end int    // 行内容在文件中的范围，不包括行首缩进和行尾空白
+	text       string // 行内容
+	hardBreak  string // 行尾的硬换行标记，"\"或两个以上空格
+}
+
+// 正在解析的段落或列表项
+type paragraph struct {
+	lines        []paragraphLine
+	continuation string // 续行的前缀
+}
+
+// Markdown解析器
+type parser struct {
+	document  *document
+	lines     []string
+	offsets   []int // 各行在文件中的起始位置
+	paragraph *paragraph
+	heading   string // 当前所在的章节标题
+}
+
+/**
+ * @description: 创建一个新的Markdown文档处理实例
+ * @param {string} FilePath 文档路径
+ * @param {string} OutputPath 输出文件路径
+ * @param {string} Language 目标语言
+ * @return {*resource.ResourceTable} 返回一个新的资源文件表格实例
+ * @return {error} 错误信息
+ */
+func New(FilePath, OutputPath, Language string) (*resource.ResourceTable, error) {
+	return resource.New(FilePath, OutputPath, Language, Format)
+}
+
+/**
+ * @description: 获取与原文档放在一起的目标语言文档路径，如"README.md"替换为"README.zh-CN.md"
+ * @param {string} Path 文档路径
+ * @param {string} Language 目标语言
+ * @return {string} 目标语言的文档路径
+ */
+func LocalePath(Path, Language string) string {
+	return resource.SuffixPath(Path, Language)
+}
+
+/**
+ * @description: 解析Markdown文档，代码块、HTML块、链接引用定义和front matter的字段名保持不变
+ * @param {[]byte} Data 文件内容
+ * @return {resource.Document} 解析后的文件
+ * @return {error} 错误信息
+ */
+func Parse(Data []byte) (resource.Document, error) {
+	Parser := &parser{document: newDocument(Data)}
+	Parser.lines = strings.Split(Parser.document.text, "\n")
+	Offset := 0
+	for _, Line := range Parser.lines {
+		Parser.offsets = append(Parser.offsets, Offset)
+		Offset += len(Line) + 1
+	}
+
+	Index := Parser.parseFrontMatter()
+	InList := false // 缩进的内容属于列表项而不是代码块
+	Blank := false
+	for Index < len(Parser.lines) {
+		Prefix, Content := splitQuote(Parser.lines[Index])
+		ContentStart := Parser.offsets[Index] + len(Prefix)
+		Trimmed := strings.TrimSpace(Content)
+		if Trimmed == "" {
+			Parser.flush()
+			Blank = true
+			Index++
+			continue
+		}
+
+		switch {
+		case fencePattern.MatchString(Content):
+			Parser.flush()
+			Index = Parser.skipFence(Index, fencePattern.FindStringSubmatch(Content)[1])
+		case strings.HasPrefix(Trimmed, "<!--"):
+			Parser.flush()
+			for Index < len(Parser.lines) && !strings.Contains(Parser.lines[Index], "-->") {
+				Index++
+			}
+			Index++
+		case htmlBlockPattern.MatchString(Content):
+			Parser.flush()
+			for Index < len(Parser.lines) && strings.TrimSpace(Parser.lines[Index]) != "" {
+				Index++
+			}
+		case Parser.paragraph != nil && setextPattern.MatchString(Content):
+			// 段落下方的=或-使段落成为标题
+			Heading := Parser.paragraph.source()
+			Parser.flush()
+			Parser.heading = Heading
+			Index++
+		case thematicPattern.MatchString(Content), referencePattern.MatchString(Content):
+			Parser.flush()
+			Index++
+		case headingPattern.MatchString(Content):
+			Parser.flush()
+			Match := headingPattern.FindStringSubmatchIndex(Content)
+			if Match[2] >= 0 {
+				Text := Content[Match[2]:Match[3]]
+				Parser.document.add(segment{start: ContentStart + Match[2], end: ContentStart + Match[3], inline: true}, Text, inlinePattern, Parser.context(""))
+				Parser.heading = Text
+			}
+			Index++
+		case Parser.paragraph == nil && strings.Contains(Content, "|") && Index+1 < len(Parser.lines) && isDelimiter(Parser.lines[Index+1]):
+			Index = Parser.parseTable(Index)
+		case listPattern.MatchString(Content):
+			Parser.flush()
+			InList = true
+			Marker := listPattern.FindString(Content)
+			Parser.paragraph = &paragraph{continuation: Prefix + strings.Repeat(" ", len(Marker))}
+			if Rest := strings.TrimSpace(Content[len(Marker):]); Rest != "" {
+				Parser.paragraph.add(ContentStart+len(Marker), Content[len(Marker):])
+			}
+			Index++
+		default:
+			Indent := len(Content) - len(strings.TrimLeft(Content, " \t"))
+			if Parser.paragraph == nil {
+				if Blank && Indent == 0 {
+					InList = false
+				}
+				if strings.HasPrefix(Content, "    ") || strings.HasPrefix(Content, "\t") {
+					if !InList {
+						// 缩进代码块
+						Index++
+						Blank = false
+						continue
+					}
+				}
+				Parser.paragraph = &paragraph{continuation: Prefix + Content[:Indent]}
+			}
+			Parser.paragraph.add(ContentStart+Indent, Content[Indent:])
+			Index++
+		}
+		Blank = false
+	}
+	Parser.flush()
+	return Parser.document, nil
+}
+
+/**
+ * @description: 拆分行首的引用前缀
+ * @param {string} Line 行
+ * @return {string} 引用前缀，如"> "、"> > "
+ * @return {string} 行内容
+ */
+func splitQuote(Line string) (string, string) {
+	Prefix := ""
+	for {
+		Match := quotePattern.FindString(Line[len(Prefix):])
+		if Match == "" {
+			return Prefix, Line[len(Prefix):]
+		}
+		Prefix += Match
+	}
+}
+
+/**
+ * @description: 判断行是否为表格的分隔行
+ * @param {string} Line 行
+ * @return {bool} 是否为分隔行
+ */
+func isDelimiter(Line string) bool {
+	_, Content := splitQuote(Line)
+	return strings.Contains(Content, "|") && delimiterPattern.MatchString(Content)
+}
+
+/**
+ * @description: 解析文档开头的YAML (---)或TOML (+++) front matter，只翻译标题、描述等字段的值
+ * @return {int} front matter之后的行
+ */
+func (p *parser) parseFrontMatter() int {
+	Delimiter := strings.TrimSpace(p.lines[0])
+	if Delimiter != "---" && Delimiter != "+++" {
+		return 0
+	}
+	for Index := 1; Index < len(p.lines); Index++ {
+		Line := p.lines[Index]
+		if Trimmed := strings.TrimSpace(Line); Trimmed == Delimiter || (Delimiter == "---" && Trimmed == "...") {
+			return Index + 1
+		}
+		Match := frontMatterPattern.FindStringSubmatchIndex(Line)
+		if Match == nil || (Line[Match[4]:Match[5]] == "=") != (Delimiter == "+++") {
+			continue
+		}
+		Key, Start, End := Line[Match[2]:Match[3]], Match[6], Match[7]
+		// 值之后可以有注释
+		if Quote := Line[Start]; Quote == '"' || Quote == '\'' {
+			if Close := strings.LastIndexByte(Line[Start:End], Quote); Close > 0 && strings.HasPrefix(strings.TrimSpace(Line[Start+Close+1:End])+"#", "#") {
+				End = Start + Close + 1
+			}
+		} else if Comment := strings.Index(Line[Start:End], " #"); Comment >= 0 {
+			End = Start + len(strings.TrimRight(Line[Start:Start+Comment], " \t"))
+		}
+		Value, Quote, OK := unquote(Line[Start:End], Delimiter == "+++")
+		if !OK {
+			continue
+		}
+		p.document.add(segment{start: p.offsets[Index] + Start, end: p.offsets[Index] + End, frontMatter: true, quote: Quote}, Value, inlinePattern, "文档元数据："+Key)
+	}
+	// 没有结束标记时不是front matter
+	p.document.segments, p.document.entries = nil, nil
+	return 0
+}
+
+/**
+ * @description: 跳过围栏代码块
+ * @param {int} Index 代码块开始的行
+ * @param {string} Fence 开始的围栏，如"```"
+ * @return {int} 代码块之后的行
+ */
+func (p *parser) skipFence(Index int, Fence string) int {
+	for Index++; Index < len(p.lines); Index++ {
+		_, Content := splitQuote(p.lines[Index])
+		if Match := fencePattern.FindStringSubmatch(Content); Match != nil && Match[1][0] == Fence[0] && len(Match[1]) >= len(Fence) && strings.TrimSpace(Content[len(Match[0]):]) == "" {
+			return Index + 1
+		}
+	}
+	return Index
+}
+
+/**
+ * @description: 解析表格，每个非空单元格为一行，分隔行保持不变
+ * @param {int} Index 表头所在的行
+ * @return {int} 表格之后的行
+ */
+func (p *parser) parseTable(Index int) int {
+	for Row := Index; Row < len(p.lines); Row++ {
+		Prefix, Content := splitQuote(p.lines[Row])
+		if strings.TrimSpace(Content) == "" || !strings.Contains(Content, "|") {
+			return Row
+		}
+		if Row == Index+1 {
+			continue
+		}
+		for _, Cell := range tableCells(Content) {
+			Start := p.offsets[Row] + len(Prefix) + Cell[0]
+			p.document.add(segment{start: Start, end: Start + Cell[1] - Cell[0], inline: true}, Content[Cell[0]:Cell[1]], inlinePattern, p.context("表格单元格"))
+		}
+	}
+	return len(p.lines)
+}
+
+/**
+ * @description: 拆分表格行为单元格，行内代码和转义的|不作为分隔
+ * @param {string} Row 表格行
+ * @return {[][2]int} 各非空单元格去除首尾空白后的范围
+ */
+func tableCells(Row string) [][2]int {
+	Bounds := []int{-1}
+	InCode := false
+	for Index := 0; Index < len(Row); Index++ {
+		switch Row[Index] {
+		case '\\':
+			Index++
+		case '`':
+			InCode = !InCode
+		case '|':
+			if !InCode {
+				Bounds = append(Bounds, Index)
+			}
+		}
+	}
+	Bounds = append(Bounds, len(Row))
+
+	var Cells [][2]int
+	for Index := 0; Index+1 < len(Bounds); Index++ {
+		Start, End := Bounds[Index]+1, Bounds[Index+1]
+		for Start < End && (Row[Start] == ' ' || Row[Start] == '\t') {
+			Start++
+		}
+		for End > Start && (Row[End-1] == ' ' || Row[End-1] == '\t') {
+			End--
+		}
+		if Start < End {
+			Cells = append(Cells, [2]int{Start, End})
+		}
+	}
+	return Cells
+}
+
+/**
+ * @description: 获取文本块的说明，包括所在的章节
+ * @param {string} Kind 文本块的类型，如"表格单元格"，为空时不添加
+ * @return {string} 说明
+ */
+func (p *parser) context(Kind string) string {
+	var Context []string
+	if Kind != "" {
+		Context = append(Context, Kind)
+	}
+	if p.heading != "" {
+		Context = append(Context, "所在章节："+p.heading)
+	}
+	return strings.Join(Context, "\n")
+}
+
+/**
+ * @description: 结束当前段落或列表项，添加为一个文本块
+ */
+func (p *parser) flush() {
+	Paragraph := p.paragraph
+	p.paragraph = nil
+	if Paragraph == nil || len(Paragraph.lines) == 0 {
+		return
+	}
+	Segment := segment{
+		start:        Paragraph.lines[0].start,
+		end:          Paragraph.lines[len(Paragraph.lines)-1].end,
+		continuation: Paragraph.continuation,
+	}
+	for _, Line := range Paragraph.lines[:len(Paragraph.lines)-1] {
+		if Line.hardBreak != "" {
+			Segment.hardBreak = Line.hardBreak
+			break
+		}
+	}
+	p.document.add(Segment, Paragraph.source(), inlinePattern, p.context(""))
+}
+
+/**
+ * @description: 添加段落的一行，记录行尾的硬换行
+ * @param {int} Start 行内容在文件中的起始位置
+ * @param {string} Text 行内容，不包括行首缩进
+ */
+func (p *paragraph) add(Start int, Text string) {
+	Line := paragraphLine{start: Start, text: strings.TrimRight(Text, " \t")}
+	Line.end = Start + len(Line.text)
+	switch {
+	case strings.HasSuffix(Line.text, "\\"):
+		Line.hardBreak = "\\"
+	case strings.HasSuffix(Text, "  "):
+		Line.hardBreak = "  "
+	}
+	p.lines = append(p.lines, Line)
+}
+
+/**
+ * @description: 获取段落的原文，软换行替换为空格，硬换行保留为换行
+ * @return {string} 原文
+ */
+func (p *paragraph) source() string {
+	var Builder strings.Builder
+	for Index, Line := range p.lines {
+		if Index == len(p.lines)-1 {
+			Builder.WriteString(Line.text)
+		} else if Line.hardBreak != "" {
+			Builder.WriteString(strings.TrimSuffix(Line.text, "\\"))
+			Builder.WriteString("\n")
+		} else {
+			Builder.WriteString(Line.text)
+			Builder.WriteString(" ")
+		}
+	}
+	return Builder.String()
+}
@@ -0,0 +1,194 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-21 16:05:33
+ * @LastEditTime: 2025-07-21 17:30:12
+ * @LastEditors: nijineko
+ * @Description: Markdown和纯文本文档处理测试
+ * @FilePath: \AutoTranslation\pkg\table\markdown\markdown_test.go
+ */
+package markdown
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const testMarkdown = "---\n" +
+	"title: Getting Started\n" +
+	"layout: post\n" +
+	"description: 'A short guide' # SEO\n" +
+	"---\n" +
+	"\n" +
+	"# Getting Started\n" +
+	"\n" +
+	"Read the [guide](docs/guide.md \"Guide\") and run\n" +
+	"`make build` first.  \n" +
+	"Then visit https://example.com.\n" +
+	"\n" +
+	"- [ ] Install Go\n" +
+	"  - Nested item\n" +
+	"\n" +
+	"> Quoted text\n" +
+	"\n" +
+	"```sh\n" +
+	"echo hello\n" +
+	"```\n" +
+	"\n" +
+	"| Name | Description |\n" +
+	"|------|-------------|\n" +
+	"| `id` | The user id |\n" +
+	"\n" +
+	"<div align=\"center\">\n" +
+	"  <img src=\"logo.png\">\n" +
+	"</div>\n" +
+	"\n" +
+	"[guide]: https://example.com/guide\n"
+
+func TestMarkdown_RoundTrip(t *testing.T) {
+	Directory := t.TempDir()
+	FilePath := filepath.Join(Directory, "README.md")
+	if err := os.WriteFile(FilePath, []byte(testMarkdown), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	TableInstance, err := New(FilePath, LocalePath(FilePath, "zh-CN"), "zh-CN")
+	if err != nil {
+		t.Fatal(err)
+	}
+	Datas, err := TableInstance.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 代码、链接目标和URL替换为占位符，代码块、HTML块和front matter的其他字段跳过
+	Section := "所在章节：Getting Started"
+	want := [][]string{
+		{"source", "target", "key", "context"},
+		{"Getting Started", "", "d00eca1bae674280", "文档元数据：title"},
+		{"A short guide", "", "1e03a3a07994a9d5", "文档元数据：description"},
+		{"Getting Started", "", "d00eca1bae674280#2", ""},
+		{"Read the [guide](⟦1⟧) and run ⟦2⟧ first.\nThen visit ⟦3⟧.", "", "efee6bcc805d764b", Section + "\n" + placeholderNote},
+		{"Install Go", "", "e065ef4ef4c87371", Section},
+		{"Nested item", "", "68b02764a30398a5", Section},
+		{"Quoted text", "", "0a4c81a06e76a6fe", Section},
+		{"Name", "", "dcd1d5223f73b3a9", "表格单元格\n" + Section},
+		{"Description", "", "526e0087cc3f254d", "表格单元格\n" + Section},
+		{"The user id", "", "0f63e0bb6437c940", "表格单元格\n" + Section},
+	}
+	if !reflect.DeepEqual(Datas, want) {
+		t.Fatalf("Read() = %q, want %q", Datas, want)
+	}
+
+	for Index, Translation := range []string{"入门", "简短的指南", "入门", "先阅读[指南](⟦1⟧)并运行⟦2⟧。\n然后访问⟦3⟧。", "安装 Go", "嵌套项", "引用的文本", "名称", "说明", "用户 ID"} {
+		Datas[Index+1][1] = Translation
+	}
+	if err := TableInstance.Write(Datas); err != nil {
+		t.Fatal(err)
+	}
+
+	Output := "---\n" +
+		"title: 入门\n" +
+		"layout: post\n" +
+		"description: '简短的指南' # SEO\n" +
+		"---\n" +
+		"\n" +
+		"# 入门\n" +
+		"\n" +
+		"先阅读[指南](docs/guide.md \"Guide\")并运行`make build`。  \n" +
+		"然后访问https://example.com。\n" +
+		"\n" +
+		"- [ ] 安装 Go\n" +
+		"  - 嵌套项\n" +
+		"\n" +
+		"> 引用的文本\n" +
+		"\n" +
+		"```sh\n" +
+		"echo hello\n" +
+		"```\n" +
+		"\n" +
+		"| 名称 | 说明 |\n" +
+		"|------|-------------|\n" +
+		"| `id` | 用户 ID |\n" +
+		"\n" +
+		"<div align=\"center\">\n" +
+		"  <img src=\"logo.png\">\n" +
+		"</div>\n" +
+		"\n" +
+		"[guide]: https://example.com/guide\n"
+	if got, _ := os.ReadFile(filepath.Join(Directory, "README.zh-CN.md")); string(got) != Output {
+		t.Errorf("output = %s, want %s", got, Output)
+	}
+}
+
+func TestMarkdown_Blocks(t *testing.T) {
+	Content := "Title\n=====\n\n    indented code\n\n1. Step one\n   continues\n\n   Second paragraph\n\n> > Nested quote\n> > wraps\n\n~~~\n```\nstill code\n~~~\n\nSee [docs][ref] and <https://a.b>.[^1]\n\n[^1]: A footnote.\n"
+	Document, err := Parse([]byte(Content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var Sources []string
+	for _, Entry := range Document.Entries() {
+		Sources = append(Sources, Entry.Source)
+	}
+	want := []string{"Title", "Step one continues", "Second paragraph", "Nested quote wraps", "See [docs][⟦1⟧] and ⟦2⟧.⟦3⟧", "A footnote."}
+	if !reflect.DeepEqual(Sources, want) {
+		t.Fatalf("Entries() = %q, want %q", Sources, want)
+	}
+
+	// 译文中的换行使用原段落的前缀，遗漏占位符的译文不写入，保留原文
+	Data, err := Document.Marshal([]string{"", "第一步\n继续", "", "嵌套引用\n换行", "参见[文档][⟦1⟧]。⟦3⟧"}, "zh-CN")
+	if err != nil {
+		t.Fatal(err)
+	}
+	Output := "Title\n=====\n\n    indented code\n\n1. 第一步\n   继续\n\n   Second paragraph\n\n> > 嵌套引用\n> > 换行\n\n~~~\n```\nstill code\n~~~\n\nSee [docs][ref] and <https://a.b>.[^1]\n\n[^1]: A footnote.\n"
+	if string(Data) != Output {
+		t.Errorf("Marshal() = %s, want %s", Data, Output)
+	}
+}
+
+func TestText_RoundTrip(t *testing.T) {
+	FilePath := filepath.Join(t.TempDir(), "notes.txt")
+	if err := os.WriteFile(FilePath, []byte("Hello,\r\nworld!\r\n\r\n  Contact admin@example.com\r\n\r\nhttps://example.com\r\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	TableInstance, err := NewText(FilePath, LocalePath(FilePath, "ja"), "ja")
+	if err != nil {
+		t.Fatal(err)
+	}
+	Datas, err := TableInstance.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 只有URL的段落跳过，段落中的换行保留
+	want := [][]string{
+		{"source", "target", "key", "context"},
+		{"Hello,\nworld!", "", "2028b682b557bbda", ""},
+		{"Contact ⟦1⟧", "", "8ea32d1ea3b8bae0", placeholderNote},
+	}
+	if !reflect.DeepEqual(Datas, want) {
+		t.Fatalf("Read() = %q, want %q", Datas, want)
+	}
+
+	Datas[1][1] = "こんにちは、\n世界！"
+	Datas[2][1] = "連絡先：⟦1⟧"
+	if err := TableInstance.Write(Datas); err != nil {
+		t.Fatal(err)
+	}
+	Output := "こんにちは、\r\n世界！\r\n\r\n  連絡先：admin@example.com\r\n\r\nhttps://example.com\r\n"
+	if got, _ := os.ReadFile(filepath.Join(filepath.Dir(FilePath), "notes.ja.txt")); string(got) != Output {
+		t.Errorf("output = %q, want %q", got, Output)
+	}
+	// 原文插入段落后，其他段落的键不变，也不会读取输出文件中按位置错开的译文
+	if err := os.WriteFile(FilePath, []byte("New intro.\r\n\r\nHello,\r\nworld!\r\n\r\n  Contact admin@example.com\r\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	TableInstance, err = NewText(FilePath, LocalePath(FilePath, "ja"), "ja")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if Datas, _ = TableInstance.Read(); len(Datas) != 4 || Datas[2][2] != "2028b682b557bbda" || Datas[1][1] != "" || Datas[2][1] != "" || Datas[3][1] != "" {
+		t.Errorf("Read() after inserting a paragraph = %q", Datas)
+	}
+}
@@ -0,0 +1,64 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-21 15:02:19
+ * @LastEditTime: 2025-07-21 15:48:37
+ * @LastEditors: nijineko
+ * @Description: 纯文本文档处理，以空行分隔的段落为一行
+ * @FilePath: \AutoTranslation\pkg\table\markdown\text.go
+ */
+package markdown
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/nijinekoyo/AutoTranslation/pkg/table/resource"
+)
+
+// 纯文本资源格式，每个段落为一行，译文写入另一个文件
+var TextFormat = resource.Format{Parse: ParseText, Document: true}
+
+// 纯文本中不传给翻译的URL和邮箱地址
+var urlPattern = regexp.MustCompile(`https?://[^\s<>()\[\]]*[^\s<>()\[\].,;:!?'"]|[\w.+-]+@[\w-]+(?:\.[\w-]+)+`)
+
+/**
+ * @description: 创建一个新的纯文本文档处理实例
+ * @param {string} FilePath 文档路径
+ * @param {string} OutputPath 输出文件路径
+ * @param {string} Language 目标语言
+ * @return {*resource.ResourceTable} 返回一个新的资源文件表格实例
+ * @return {error} 错误信息
+ */
+func NewText(FilePath, OutputPath, Language string) (*resource.ResourceTable, error) {
+	return resource.New(FilePath, OutputPath, Language, TextFormat)
+}
+
+/**
+ * @description: 解析纯文本文档，段落中的换行和缩进原样传给翻译
+ * @param {[]byte} Data 文件内容
+ * @return {resource.Document} 解析后的文件
+ * @return {error} 错误信息
+ */
+func ParseText(Data []byte) (resource.Document, error) {
+	Document := newDocument(Data)
+	Start, End := -1, 0
+	Offset := 0
+	for _, Line := range strings.Split(Document.text, "\n") {
+		if Trimmed := strings.TrimSpace(Line); Trimmed == "" {
+			if Start >= 0 {
+				Document.add(segment{start: Start, end: End}, Document.text[Start:End], urlPattern, "")
+				Start = -1
+			}
+		} else {
+			if Start < 0 {
+				Start = Offset + strings.Index(Line, Trimmed)
+			}
+			End = Offset + len(strings.TrimRight(Line, " \t"))
+		}
+		Offset += len(Line) + 1
+	}
+	if Start >= 0 {
+		Document.add(segment{start: Start, end: End}, Document.text[Start:End], urlPattern, "")
+	}
+	return Document, nil
+}
@@ -0,0 +1,981 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-10 11:52:40
+ * @LastEditTime: 2025-07-10 16:27:09
+ * @LastEditors: nijineko
+ * @Description: OpenDocument电子表格数据处理实现
+ * @FilePath: \AutoTranslation\pkg\table\ods\ods.go
+ */
+package ods
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/nijinekoyo/AutoTranslation/pkg/table"
+	"github.com/nijinekoyo/AutoTranslation/tools/file"
+)
+
+// 工作表内容所在的文件
+const contentFile = "content.xml"
+
+// 命名空间
+const (
+	namespaceOffice = "urn:oasis:names:tc:opendocument:xmlns:office:1.0"
+	namespaceTable  = "urn:oasis:names:tc:opendocument:xmlns:table:1.0"
+	namespaceText   = "urn:oasis:names:tc:opendocument:xmlns:text:1.0"
+)
+
+// ODS表格数据处理结构体
+type ODSTable struct {
+	filePath   string      // 源文件路径
+	outputPath string      // 输出文件路径
+	archive    *zip.Reader // 源文件压缩包，关闭时原样复制除content.xml之外的文件
+	content    *node       // content.xml节点树
+
+	office, table, text string // 文档中使用的命名空间前缀
+
+	sheetName string // 当前工作表名称，默认为第一个工作表
+
+	isClosed bool // 是否已关闭
+}
+
+/**
+ * @description: 创建一个新的ODS表格处理实例
+ * @param {string} FilePath ODS文件路径
+ * @param {string} OutputPath 输出文件路径，为空时覆盖源文件
+ * @return {*ODSTable} 返回一个新的ODSTable实例
+ * @return {error} 错误信息
+ */
+func New(FilePath string, OutputPath string) (*ODSTable, error) {
+	Data, err := os.ReadFile(FilePath)
+	if errors.Is(err, os.ErrNotExist) {
+		// 如果文件不存在，则创建一个新的ODS文件
+		if Data, err = emptyDocument(); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(FilePath, Data, 0644); err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	}
+
+	Archive, err := zip.NewReader(bytes.NewReader(Data), int64(len(Data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", FilePath, err)
+	}
+	Content, err := readContent(Archive)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", FilePath, err)
+	}
+
+	if OutputPath == "" {
+		OutputPath = FilePath
+	}
+
+	ODSTableInstance := &ODSTable{
+		filePath:   FilePath,
+		outputPath: OutputPath,
+		archive:    Archive,
+		content:    Content,
+	}
+	ODSTableInstance.resolveNamespaces()
+
+	// 默认选中第一个工作表
+	if Sheets := ODSTableInstance.Sheets(); len(Sheets) > 0 {
+		ODSTableInstance.sheetName = Sheets[0]
+	}
+
+	return ODSTableInstance, nil
+}
+
+/**
+ * @description: 关闭ODS表格处理实例，将修改后的content.xml和其他原样复制的文件写入输出文件
+ * @return {error} 错误信息
+ */
+func (o *ODSTable) Close() error {
+	if o.isClosed {
+		return nil
+	}
+
+	err := file.WriteAtomic(o.outputPath, func(Writer io.Writer) error {
+		ZipWriter := zip.NewWriter(Writer)
+		for _, File := range o.archive.File {
+			if File.Name != contentFile {
+				// mimetype等文件保持原有的压缩方式和顺序
+				if err := ZipWriter.Copy(File); err != nil {
+					return err
+				}
+				continue
+			}
+
+			ContentWriter, err := ZipWriter.CreateHeader(&zip.FileHeader{Name: contentFile, Method: zip.Deflate, Modified: File.Modified})
+			if err != nil {
+				return err
+			}
+			if _, err := ContentWriter.Write(o.content.marshal()); err != nil {
+				return err
+			}
+		}
+		return ZipWriter.Close()
+	})
+	if err != nil {
+		return err
+	}
+
+	// 标记为已关闭
+	o.isClosed = true
+
+	return nil
+}
+
+/**
+ * @description: 放弃修改并关闭ODS表格处理实例，不写入输出文件
+ * @return {error} 错误信息
+ */
+func (o *ODSTable) Discard() error {
+	// 标记为已关闭
+	o.isClosed = true
+
+	return nil
+}
+
+/**
+ * @description: 获取全部工作表名称
+ * @return {[]string} 工作表名称，按文档中的顺序排列
+ */
+func (o *ODSTable) Sheets() []string {
+	var Names []string
+	for _, Table := range o.tables() {
+		Name, _ := Table.attr(o.name(o.table, "name"))
+		Names = append(Names, Name)
+	}
+	return Names
+}
+
+/**
+ * @description: 选中工作表，之后的读写操作作用于该工作表
+ * @param {string} Name 工作表名称
+ * @return {error} 错误信息
+ */
+func (o *ODSTable) SelectSheet(Name string) error {
+	if o.isClosed {
+		return os.ErrClosed
+	}
+
+	for _, SheetName := range o.Sheets() {
+		if SheetName == Name {
+			o.sheetName = Name
+			return nil
+		}
+	}
+	return fmt.Errorf("sheet %q does not exist", Name)
+}
+
+/**
+ * @description: 读取ODS表格数据，省略末尾的空行和空单元格
+ * @return {[][]string} 表格数据
+ * @return {error} 错误信息
+ */
+func (o *ODSTable) Read() ([][]string, error) {
+	if o.isClosed {
+		return nil, os.ErrClosed
+	}
+
+	Table, err := o.currentTable()
+	if err != nil {
+		return nil, err
+	}
+
+	Datas := [][]string{}
+	EmptyRows := 0 // 尚未确定是否在末尾的空行
+	for _, Row := range o.rows(Table) {
+		var RowData []string
+		EmptyCells := 0
+		for _, Cell := range o.cells(Row) {
+			Text := o.cellText(Cell)
+			Count := o.repeat(Cell, "number-columns-repeated")
+			if Text == "" {
+				EmptyCells += Count
+				continue
+			}
+			for ; EmptyCells > 0; EmptyCells-- {
+				RowData = append(RowData, "")
+			}
+			for range Count {
+				RowData = append(RowData, Text)
+			}
+		}
+
+		Count := o.repeat(Row, "number-rows-repeated")
+		if len(RowData) == 0 {
+			EmptyRows += Count
+			continue
+		}
+		for ; EmptyRows > 0; EmptyRows-- {
+			Datas = append(Datas, []string{})
+		}
+		for range Count {
+			Datas = append(Datas, append([]string{}, RowData...))
+		}
+	}
+
+	return Datas, nil
+}
+
+/**
+ * @description: 写入ODS表格数据，只写入内容变化的单元格
+ * @param {[][]string} Datas 要写入的数据
+ * @return {error} 错误信息
+ */
+func (o *ODSTable) Write(Datas [][]string) error {
+	if o.isClosed {
+		return os.ErrClosed
+	}
+
+	// 读取现有数据，只写入内容变化的单元格，避免改变其他单元格的类型和公式
+	Rows, err := o.Read()
+	if err != nil {
+		return err
+	}
+
+	var Updates []table.CellUpdate
+	for RowIndex, RowData := range Datas {
+		for ColIndex, CellData := range RowData {
+			if RowIndex < len(Rows) && ColIndex < len(Rows[RowIndex]) && Rows[RowIndex][ColIndex] == CellData {
+				continue
+			}
+			if CellData == "" && (RowIndex >= len(Rows) || ColIndex >= len(Rows[RowIndex])) {
+				continue
+			}
+			Updates = append(Updates, table.CellUpdate{Row: RowIndex + 1, Column: ColIndex + 1, Value: CellData})
+		}
+	}
+
+	return o.WriteCells(Updates)
+}
+
+/**
+ * @description: 判断单元格是否为公式
+ * @param {int} Row 行号，从1开始计数
+ * @param {int} Col 列号，从1开始计数
+ * @return {bool} 是否为公式
+ * @return {error} 错误信息
+ */
+func (o *ODSTable) IsFormula(Row, Col int) (bool, error) {
+	if o.isClosed {
+		return false, os.ErrClosed
+	}
+
+	Table, err := o.currentTable()
+	if err != nil {
+		return false, err
+	}
+
+	RowNode, _, _ := o.locate(o.rows(Table), Row-1, "number-rows-repeated")
+	if RowNode == nil {
+		return false, nil
+	}
+	Cell, _, _ := o.locate(o.cells(RowNode), Col-1, "number-columns-repeated")
+	if Cell == nil {
+		return false, nil
+	}
+	_, IsFormula := Cell.attr(o.name(o.table, "formula"))
+
+	return IsFormula, nil
+}
+
+/**
+ * @description: 只写入指定的单元格，其他单元格保持不变，没有样式的单元格继承指定源单元格的样式
+ * @param {[]table.CellUpdate} Updates 单元格更新
+ * @return {error} 错误信息
+ */
+func (o *ODSTable) WriteCells(Updates []table.CellUpdate) error {
+	if o.isClosed {
+		return os.ErrClosed
+	}
+
+	Table, err := o.currentTable()
+	if err != nil {
+		return err
+	}
+
+	StyleName := o.name(o.table, "style-name")
+	for _, Update := range Updates {
+		if Update.Row < 1 || Update.Column < 1 {
+			return os.ErrInvalid
+		}
+
+		RowNode := o.row(Table, Update.Row-1, true)
+		Cell := o.cell(RowNode, Update.Column-1, true)
+		o.setCellText(Cell, Update.Value)
+
+		if Update.StyleColumn < 1 {
+			continue
+		}
+		// 目标单元格已有样式时保留用户设置的样式
+		if _, ok := Cell.attr(StyleName); ok {
+			continue
+		}
+		StyleCell, _, _ := o.locate(o.cells(RowNode), Update.StyleColumn-1, "number-columns-repeated")
+		if StyleCell == nil {
+			continue
+		}
+		if Style, ok := StyleCell.attr(StyleName); ok {
+			Cell.setAttr(StyleName, Style)
+		}
+	}
+
+	return nil
+}
+
+/**
+ * @description: 更新指定行的数据
+ * @param {int} Row 行号，从1开始计数
+ * @param {[]string} Data 要更新的数据
+ * @return {error} 错误信息
+ */
+func (o *ODSTable) UpdateLine(Row int, Data []string) error {
+	if o.isClosed {
+		return os.ErrClosed
+	}
+
+	// 更新指定行的数据
+	var Updates []table.CellUpdate
+	for ColIndex, CellData := range Data {
+		Updates = append(Updates, table.CellUpdate{Row: Row, Column: ColIndex + 1, Value: CellData})
+	}
+
+	return o.WriteCells(Updates)
+}
+
+/**
+ * @description: 更新指定单元格的数据
+ * @param {int} Row 行号，从1开始计数
+ * @param {int} Col 列号，从1开始计数
+ * @param {string} Data 数据内容
+ * @return {error} 错误信息
+ */
+func (o *ODSTable) UpdateCell(Row, Col int, Data string) error {
+	if o.isClosed {
+		return os.ErrClosed
+	}
+
+	return o.WriteCells([]table.CellUpdate{{Row: Row, Column: Col, Value: Data}})
+}
+
+/**
+ * @description: 在ODS表格中追加一行数据
+ * @param {[]string} Data 要追加的数据
+ * @return {error} 错误信息
+ */
+func (o *ODSTable) Append(Data []string) error {
+	if o.isClosed {
+		return os.ErrClosed
+	}
+
+	// 获取当前行数
+	Rows, err := o.Read()
+	if err != nil {
+		return err
+	}
+
+	// 新数据将添加到最后一行
+	return o.UpdateLine(len(Rows)+1, Data)
+}
+
+/**
+ * @description: 在ODS表格中插入一行数据
+ * @param {int} Row 行号，从1开始计数
+ * @param {[]string} Data 要插入的数据
+ * @return {error} 错误信息
+ */
+func (o *ODSTable) Insert(Row int, Data []string) error {
+	if o.isClosed {
+		return os.ErrClosed
+	}
+
+	// 获取当前行数
+	Rows, err := o.Read()
+	if err != nil {
+		return err
+	}
+
+	// 检查插入行号是否有效
+	if Row < 1 || Row > len(Rows)+1 {
+		return os.ErrInvalid
+	}
+
+	// 在原有行之前插入空白行，末尾追加时直接使用空行
+	if Row <= len(Rows) {
+		Table, err := o.currentTable()
+		if err != nil {
+			return err
+		}
+		Target := o.row(Table, Row-1, false)
+		Target.parent.insert(Target.parent.indexOf(Target), o.newRow(1))
+		o.trimTrailingRows(Table)
+	}
+
+	// 写入新数据到指定行
+	return o.UpdateLine(Row, Data)
+}
+
+/**
+ * @description: 删除指定行的数据
+ * @param {int} Row 行号，从1开始计数
+ * @return {error} 错误信息
+ */
+func (o *ODSTable) Delete(Row int) error {
+	if o.isClosed {
+		return os.ErrClosed
+	}
+
+	Table, err := o.currentTable()
+	if err != nil {
+		return err
+	}
+	if Row < 1 {
+		return os.ErrInvalid
+	}
+
+	// 删除指定行
+	Target := o.row(Table, Row-1, false)
+	if Target == nil {
+		return os.ErrInvalid
+	}
+	Target.remove()
+
+	return nil
+}
+
+/**
+ * @description: 读取压缩包中的content.xml
+ * @param {*zip.Reader} Archive 压缩包
+ * @return {*node} 节点树
+ * @return {error} 错误信息
+ */
+func readContent(Archive *zip.Reader) (*node, error) {
+	Reader, err := Archive.Open(contentFile)
+	if err != nil {
+		return nil, err
+	}
+	defer Reader.Close()
+
+	Data, err := io.ReadAll(Reader)
+	if err != nil {
+		return nil, err
+	}
+	return parseXML(Data)
+}
+
+/**
+ * @description: 从根元素的命名空间声明中获取前缀，未声明时使用标准前缀
+ */
+func (o *ODSTable) resolveNamespaces() {
+	o.office, o.table, o.text = "office", "table", "text"
+
+	Root := o.root()
+	if Root == nil {
+		return
+	}
+	for _, Attr := range Root.attrs {
+		if Attr.Name.Space != "xmlns" {
+			continue
+		}
+		switch Attr.Value {
+		case namespaceOffice:
+			o.office = Attr.Name.Local
+		case namespaceTable:
+			o.table = Attr.Name.Local
+		case namespaceText:
+			o.text = Attr.Name.Local
+		}
+	}
+}
+
+/**
+ * @description: 获取带命名空间前缀的名称
+ * @param {string} Prefix 命名空间前缀
+ * @param {string} Local 名称
+ * @return {xml.Name} 名称
+ */
+func (o *ODSTable) name(Prefix, Local string) xml.Name {
+	return xml.Name{Space: Prefix, Local: Local}
+}
+
+/**
+ * @description: 获取根元素
+ * @return {*node} 根元素，不存在时返回nil
+ */
+func (o *ODSTable) root() *node {
+	for _, Child := range o.content.children {
+		if Child.kind == nodeElement {
+			return Child
+		}
+	}
+	return nil
+}
+
+/**
+ * @description: 获取全部工作表元素
+ * @return {[]*node} 工作表元素
+ */
+func (o *ODSTable) tables() []*node {
+	Root := o.root()
+	if Root == nil {
+		return nil
+	}
+
+	var Tables []*node
+	for _, Body := range Root.children {
+		if Body.name != o.name(o.office, "body") {
+			continue
+		}
+		for _, Spreadsheet := range Body.children {
+			if Spreadsheet.name != o.name(o.office, "spreadsheet") {
+				continue
+			}
+			for _, Table := range Spreadsheet.children {
+				if Table.name == o.name(o.table, "table") {
+					Tables = append(Tables, Table)
+				}
+			}
+		}
+	}
+	return Tables
+}
+
+/**
+ * @description: 获取当前工作表元素
+ * @return {*node} 工作表元素
+ * @return {error} 错误信息
+ */
+func (o *ODSTable) currentTable() (*node, error) {
+	for _, Table := range o.tables() {
+		if Name, _ := Table.attr(o.name(o.table, "name")); Name == o.sheetName {
+			return Table, nil
+		}
+	}
+	return nil, fmt.Errorf("sheet %q does not exist", o.sheetName)
+}
+
+/**
+ * @description: 按顺序获取工作表中的行元素，包括表头行和行分组中的行
+ * @param {*node} Parent 工作表或行分组元素
+ * @return {[]*node} 行元素
+ */
+func (o *ODSTable) rows(Parent *node) []*node {
+	var Rows []*node
+	for _, Child := range Parent.children {
+		switch Child.name {
+		case o.name(o.table, "table-row"):
+			Rows = append(Rows, Child)
+		case o.name(o.table, "table-header-rows"), o.name(o.table, "table-rows"), o.name(o.table, "table-row-group"):
+			Rows = append(Rows, o.rows(Child)...)
+		}
+	}
+	return Rows
+}
+
+/**
+ * @description: 按顺序获取行中的单元格元素，包括被合并的单元格
+ * @param {*node} Row 行元素
+ * @return {[]*node} 单元格元素
+ */
+func (o *ODSTable) cells(Row *node) []*node {
+	var Cells []*node
+	for _, Child := range Row.children {
+		if Child.name == o.name(o.table, "table-cell") || Child.name == o.name(o.table, "covered-table-cell") {
+			Cells = append(Cells, Child)
+		}
+	}
+	return Cells
+}
+
+/**
+ * @description: 获取元素的重复次数
+ * @param {*node} Node 行或单元格元素
+ * @param {string} Attr 重复次数属性名称
+ * @return {int} 重复次数，至少为1
+ */
+func (o *ODSTable) repeat(Node *node, Attr string) int {
+	Value, ok := Node.attr(o.name(o.table, Attr))
+	if !ok {
+		return 1
+	}
+	Count, err := strconv.Atoi(Value)
+	if err != nil || Count < 1 {
+		return 1
+	}
+	return Count
+}
+
+/**
+ * @description: 设置元素的重复次数
+ * @param {*node} Node 行或单元格元素
+ * @param {string} Attr 重复次数属性名称
+ * @param {int} Count 重复次数
+ */
+func (o *ODSTable) setRepeat(Node *node, Attr string, Count int) {
+	Name := o.name(o.table, Attr)
+	if Count <= 1 {
+		Node.removeAttrs(func(AttrName xml.Name) bool { return AttrName == Name })
+		return
+	}
+	Node.setAttr(Name, strconv.Itoa(Count))
+}
+
+/**
+ * @description: 查找包含指定位置的元素
+ * @param {[]*node} Nodes 按顺序排列的行或单元格元素
+ * @param {int} Index 位置，从0开始计数
+ * @param {string} Attr 重复次数属性名称
+ * @return {*node} 包含该位置的元素，不存在时返回nil
+ * @return {int} 位置在元素重复范围中的偏移
+ * @return {int} 全部元素覆盖的数量
+ */
+func (o *ODSTable) locate(Nodes []*node, Index int, Attr string) (*node, int, int) {
+	Total := 0
+	for _, Node := range Nodes {
+		Count := o.repeat(Node, Attr)
+		if Index < Total+Count {
+			return Node, Index - Total, Total
+		}
+		Total += Count
+	}
+	return nil, 0, Total
+}
+
+/**
+ * @description: 拆分重复的元素，使指定偏移处成为单独的元素
+ * @param {*node} Node 重复的元素
+ * @param {string} Attr 重复次数属性名称
+ * @param {int} Offset 偏移
+ * @return {*node} 偏移处的元素
+ */
+func (o *ODSTable) split(Node *node, Attr string, Offset int) *node {
+	Count := o.repeat(Node, Attr)
+	if Count <= 1 {
+		return Node
+	}
+
+	Parent := Node.parent
+	if After := Count - Offset - 1; After > 0 {
+		AfterNode := Node.clone()
+		o.setRepeat(AfterNode, Attr, After)
+		Parent.insert(Parent.indexOf(Node)+1, AfterNode)
+	}
+	if Offset > 0 {
+		BeforeNode := Node.clone()
+		o.setRepeat(BeforeNode, Attr, Offset)
+		Parent.insert(Parent.indexOf(Node), BeforeNode)
+	}
+	o.setRepeat(Node, Attr, 1)
+
+	return Node
+}
+
+/**
+ * @description: 获取单独的行元素
+ * @param {*node} Table 工作表元素
+ * @param {int} Row 行号，从0开始计数
+ * @param {bool} Create 行不存在时是否创建
+ * @return {*node} 行元素，不存在且不创建时返回nil
+ */
+func (o *ODSTable) row(Table *node, Row int, Create bool) *node {
+	Rows := o.rows(Table)
+	Node, Offset, Total := o.locate(Rows, Row, "number-rows-repeated")
+	if Node != nil {
+		return o.split(Node, "number-rows-repeated", Offset)
+	}
+	if !Create {
+		return nil
+	}
+
+	// 在最后一行之后追加，中间缺少的行使用重复的空行填充
+	Parent, Index := Table, o.rowInsertIndex(Table)
+	if len(Rows) > 0 {
+		Last := Rows[len(Rows)-1]
+		Parent, Index = Last.parent, Last.parent.indexOf(Last)+1
+	}
+	NewRow := o.newRow(1)
+	if Gap := Row - Total; Gap > 0 {
+		Parent.insert(Index, o.newRow(Gap), NewRow)
+	} else {
+		Parent.insert(Index, NewRow)
+	}
+	return NewRow
+}
+
+/**
+ * @description: 获取没有行的工作表中插入行的位置，行位于列定义之后
+ * @param {*node} Table 工作表元素
+ * @return {int} 插入位置
+ */
+func (o *ODSTable) rowInsertIndex(Table *node) int {
+	Index := len(Table.children)
+	for ChildIndex, Child := range Table.children {
+		if Child.name == o.name(o.table, "named-expressions") {
+			return ChildIndex
+		}
+	}
+	return Index
+}
+
+/**
+ * @description: 获取单独的单元格元素
+ * @param {*node} Row 行元素
+ * @param {int} Col 列号，从0开始计数
+ * @param {bool} Create 单元格不存在时是否创建
+ * @return {*node} 单元格元素，不存在且不创建时返回nil
+ */
+func (o *ODSTable) cell(Row *node, Col int, Create bool) *node {
+	Node, Offset, Total := o.locate(o.cells(Row), Col, "number-columns-repeated")
+	if Node != nil {
+		return o.split(Node, "number-columns-repeated", Offset)
+	}
+	if !Create {
+		return nil
+	}
+
+	// 中间缺少的单元格使用重复的空单元格填充
+	if Gap := Col - Total; Gap > 0 {
+		GapCell := &node{kind: nodeElement, name: o.name(o.table, "table-cell")}
+		o.setRepeat(GapCell, "number-columns-repeated", Gap)
+		Row.append(GapCell)
+	}
+	NewCell := &node{kind: nodeElement, name: o.name(o.table, "table-cell")}
+	Row.append(NewCell)
+	return NewCell
+}
+
+/**
+ * @description: 创建空行元素，行中至少需要一个单元格
+ * @param {int} Count 重复次数
+ * @return {*node} 行元素
+ */
+func (o *ODSTable) newRow(Count int) *node {
+	Row := &node{kind: nodeElement, name: o.name(o.table, "table-row")}
+	o.setRepeat(Row, "number-rows-repeated", Count)
+	Row.append(&node{kind: nodeElement, name: o.name(o.table, "table-cell")})
+	return Row
+}
+
+/**
+ * @description: 插入行后减少末尾重复空行的数量，保持工作表的总行数不变
+ * @param {*node} Table 工作表元素
+ */
+func (o *ODSTable) trimTrailingRows(Table *node) {
+	Rows := o.rows(Table)
+	if len(Rows) == 0 {
+		return
+	}
+	Last := Rows[len(Rows)-1]
+	Count := o.repeat(Last, "number-rows-repeated")
+	if Count <= 1 {
+		return
+	}
+	for _, Cell := range o.cells(Last) {
+		if o.cellText(Cell) != "" {
+			return
+		}
+	}
+	o.setRepeat(Last, "number-rows-repeated", Count-1)
+}
+
+/**
+ * @description: 获取单元格显示的文本，多个段落以换行连接
+ * @param {*node} Cell 单元格元素
+ * @return {string} 文本
+ */
+func (o *ODSTable) cellText(Cell *node) string {
+	var Paragraphs []string
+	for _, Child := range Cell.children {
+		if Child.name == o.name(o.text, "p") || Child.name == o.name(o.text, "h") {
+			Paragraphs = append(Paragraphs, o.paragraphText(Child))
+		}
+	}
+	if len(Paragraphs) > 0 {
+		return strings.Join(Paragraphs, "\n")
+	}
+
+	// 没有段落时使用单元格的值
+	for _, Attr := range []string{"string-value", "value", "date-value", "time-value", "boolean-value"} {
+		if Value, ok := Cell.attr(o.name(o.office, Attr)); ok {
+			return Value
+		}
+	}
+	return ""
+}
+
+/**
+ * @description: 获取段落中的文本，展开空格、制表符和换行元素
+ * @param {*node} Paragraph 段落元素
+ * @return {string} 文本
+ */
+func (o *ODSTable) paragraphText(Paragraph *node) string {
+	var Builder strings.Builder
+	for _, Child := range Paragraph.children {
+		switch {
+		case Child.kind == nodeText:
+			Builder.WriteString(Child.text)
+		case Child.kind != nodeElement:
+		case Child.name == o.name(o.text, "s"):
+			Count := 1
+			if Value, ok := Child.attr(o.name(o.text, "c")); ok {
+				if Number, err := strconv.Atoi(Value); err == nil && Number > 0 {
+					Count = Number
+				}
+			}
+			Builder.WriteString(strings.Repeat(" ", Count))
+		case Child.name == o.name(o.text, "tab"):
+			Builder.WriteString("\t")
+		case Child.name == o.name(o.text, "line-break"):
+			Builder.WriteString("\n")
+		case Child.name == o.name(o.text, "note"), Child.name == o.name(o.office, "annotation"):
+			// 批注不属于单元格内容
+		default:
+			Builder.WriteString(o.paragraphText(Child))
+		}
+	}
+	return Builder.String()
+}
+
+/**
+ * @description: 将单元格设置为文本，保留样式和批注，删除原有的值和公式
+ * @param {*node} Cell 单元格元素
+ * @param {string} Value 文本
+ */
+func (o *ODSTable) setCellText(Cell *node, Value string) {
+	Cell.removeAttrs(func(Name xml.Name) bool {
+		if Name.Local == "value-type" || Name == o.name(o.table, "formula") {
+			return true
+		}
+		if Name.Space != o.office {
+			return false
+		}
+		switch Name.Local {
+		case "value", "date-value", "time-value", "boolean-value", "string-value", "currency":
+			return true
+		}
+		return false
+	})
+
+	Children := Cell.children[:0]
+	for _, Child := range Cell.children {
+		if Child.name != o.name(o.text, "p") && Child.name != o.name(o.text, "h") {
+			Children = append(Children, Child)
+		}
+	}
+	Cell.children = Children
+
+	if Value == "" {
+		return
+	}
+	Cell.setAttr(o.name(o.office, "value-type"), "string")
+	for _, Line := range strings.Split(Value, "\n") {
+		Cell.append(o.newParagraph(Line))
+	}
+}
+
+/**
+ * @description: 创建段落元素，连续空格和制表符使用对应的元素表示，避免被折叠
+ * @param {string} Line 单行文本
+ * @return {*node} 段落元素
+ */
+func (o *ODSTable) newParagraph(Line string) *node {
+	Paragraph := &node{kind: nodeElement, name: o.name(o.text, "p")}
+
+	var Builder strings.Builder
+	Flush := func() {
+		if Builder.Len() > 0 {
+			Paragraph.append(&node{kind: nodeText, text: Builder.String()})
+			Builder.Reset()
+		}
+	}
+
+	Runes := []rune(Line)
+	for Index := 0; Index < len(Runes); Index++ {
+		switch Runes[Index] {
+		case '\t':
+			Flush()
+			Paragraph.append(&node{kind: nodeElement, name: o.name(o.text, "tab")})
+		case ' ':
+			// 行首的空格和连续空格中第一个之后的空格需要使用text:s
+			End := Index
+			for End < len(Runes) && Runes[End] == ' ' {
+				End++
+			}
+			Count := End - Index
+			if Index > 0 {
+				Builder.WriteRune(' ')
+				Count--
+			}
+			if Count > 0 {
+				Flush()
+				Space := &node{kind: nodeElement, name: o.name(o.text, "s")}
+				if Count > 1 {
+					Space.setAttr(o.name(o.text, "c"), strconv.Itoa(Count))
+				}
+				Paragraph.append(Space)
+			}
+			Index = End - 1
+		default:
+			Builder.WriteRune(Runes[Index])
+		}
+	}
+	Flush()
+
+	return Paragraph
+}
+
+/**
+ * @description: 创建只包含一个空工作表的ODS文件
+ * @return {[]byte} 文件数据
+ * @return {error} 错误信息
+ */
+func emptyDocument() ([]byte, error) {
+	var Buffer bytes.Buffer
+	ZipWriter := zip.NewWriter(&Buffer)
+
+	// mimetype必须是第一个文件且不压缩
+	Files := []struct {
+		name    string
+		method  uint16
+		content string
+	}{
+		{"mimetype", zip.Store, "application/vnd.oasis.opendocument.spreadsheet"},
+		{contentFile, zip.Deflate, `<?xml version="1.0" encoding="UTF-8"?>` +
+			`<office:document-content xmlns:office="` + namespaceOffice + `" xmlns:table="` + namespaceTable + `" xmlns:text="` + namespaceText + `" office:version="1.3">` +
+			`<office:body><office:spreadsheet><table:table table:name="Sheet1"><table:table-column/><table:table-row><table:table-cell/></table:table-row></table:table></office:spreadsheet></office:body>` +
+			`</office:document-content>`},
+		{"META-INF/manifest.xml", zip.Deflate, `<?xml version="1.0" encoding="UTF-8"?>` +
+			`<manifest:manifest xmlns:manifest="urn:oasis:names:tc:opendocument:xmlns:manifest:1.0" manifest:version="1.3">` +
+			`<manifest:file-entry manifest:full-path="/" manifest:media-type="application/vnd.oasis.opendocument.spreadsheet"/>` +
+			`<manifest:file-entry manifest:full-path="content.xml" manifest:media-type="text/xml"/>` +
+			`</manifest:manifest>`},
+	}
+	for _, File := range Files {
+		Writer, err := ZipWriter.CreateHeader(&zip.FileHeader{Name: File.name, Method: File.method})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := Writer.Write([]byte(File.content)); err != nil {
+			return nil, err
+		}
+	}
+	if err := ZipWriter.Close(); err != nil {
+		return nil, err
+	}
+
+	return Buffer.Bytes(), nil
+}
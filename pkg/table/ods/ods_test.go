@@ -0,0 +1,204 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-10 16:30:12
+ * @LastEditTime: 2025-07-10 17:04:45
+ * @LastEditors: nijineko
+ * @Description: ODS表格数据处理测试
+ * @FilePath: \AutoTranslation\pkg\table\ods\ods_test.go
+ */
+package ods
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/nijinekoyo/AutoTranslation/pkg/table"
+)
+
+const testStyles = `<?xml version="1.0" encoding="UTF-8"?><office:document-styles xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" xmlns:style="urn:oasis:names:tc:opendocument:xmlns:style:1.0"><office:styles/></office:document-styles>`
+
+const testContent = `<?xml version="1.0" encoding="UTF-8"?>
+<office:document-content xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" xmlns:style="urn:oasis:names:tc:opendocument:xmlns:style:1.0" xmlns:fo="urn:oasis:names:tc:opendocument:xmlns:xsl-fo-compatible:1.0" xmlns:table="urn:oasis:names:tc:opendocument:xmlns:table:1.0" xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0" xmlns:of="urn:oasis:names:tc:opendocument:xmlns:of:1.2" office:version="1.3">
+<office:automatic-styles><style:style style:name="ce1" style:family="table-cell"><style:text-properties fo:font-weight="bold"/></style:style></office:automatic-styles>
+<office:body><office:spreadsheet>
+<table:table table:name="Script"><table:table-column table:number-columns-repeated="3"/>
+<table:table-row><table:table-cell table:style-name="ce1" office:value-type="string"><text:p>こんにちは</text:p></table:table-cell><table:table-cell/><table:table-cell office:value-type="float" office:value="42"><text:p>42</text:p></table:table-cell></table:table-row>
+<table:table-row><table:table-cell table:formula="of:=&quot;猫&quot;&amp;&quot;犬&quot;" office:value-type="string"><text:p>猫犬</text:p></table:table-cell><table:table-cell table:number-columns-repeated="2"/></table:table-row>
+<table:table-row table:number-rows-repeated="2"><table:table-cell table:number-columns-repeated="3"/></table:table-row>
+<table:table-row><table:table-cell office:value-type="string"><text:p>二<text:s text:c="2"/>行</text:p><text:p>目</text:p></table:table-cell></table:table-row>
+<table:table-row table:number-rows-repeated="1048571"><table:table-cell table:number-columns-repeated="1024"/></table:table-row>
+</table:table>
+<table:table table:name="Notes"><table:table-row><table:table-cell office:value-type="string"><text:p>memo</text:p></table:table-cell></table:table-row></table:table>
+</office:spreadsheet></office:body></office:document-content>`
+
+// 创建测试用的ODS文件
+func writeTestDocument(t *testing.T, FilePath string) {
+	t.Helper()
+
+	var Buffer bytes.Buffer
+	Writer := zip.NewWriter(&Buffer)
+	for _, File := range []struct {
+		name, content string
+		method        uint16
+	}{
+		{"mimetype", "application/vnd.oasis.opendocument.spreadsheet", zip.Store},
+		{"content.xml", testContent, zip.Deflate},
+		{"styles.xml", testStyles, zip.Deflate},
+	} {
+		FileWriter, err := Writer.CreateHeader(&zip.FileHeader{Name: File.name, Method: File.method})
+		if err != nil {
+			t.Fatal(err)
+		}
+		FileWriter.Write([]byte(File.content))
+	}
+	if err := Writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(FilePath, Buffer.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestODSTable_Read(t *testing.T) {
+	FilePath := filepath.Join(t.TempDir(), "script.ods")
+	writeTestDocument(t, FilePath)
+
+	TableInstance, err := New(FilePath, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := TableInstance.Sheets(); !reflect.DeepEqual(got, []string{"Script", "Notes"}) {
+		t.Errorf("ODSTable.Sheets() = %v", got)
+	}
+
+	got, err := TableInstance.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{{"こんにちは", "", "42"}, {"猫犬"}, {}, {}, {"二  行\n目"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ODSTable.Read() = %q, want %q", got, want)
+	}
+	if IsFormula, err := TableInstance.IsFormula(2, 1); err != nil || !IsFormula {
+		t.Errorf("ODSTable.IsFormula(A2) = %v, %v, want true", IsFormula, err)
+	}
+
+	if err := TableInstance.SelectSheet("Notes"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := TableInstance.Read(); !reflect.DeepEqual(got, [][]string{{"memo"}}) {
+		t.Errorf("ODSTable.Read(Notes) = %q", got)
+	}
+}
+
+func TestODSTable_RoundTrip(t *testing.T) {
+	Directory := t.TempDir()
+	FilePath := filepath.Join(Directory, "script.ods")
+	OutputPath := filepath.Join(Directory, "output.ods")
+	writeTestDocument(t, FilePath)
+
+	TableInstance, err := New(FilePath, OutputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := TableInstance.WriteCells([]table.CellUpdate{{Row: 1, Column: 2, Value: "你好", StyleColumn: 1}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := TableInstance.UpdateCell(4, 2, "  前后 空格\t"); err != nil {
+		t.Fatal(err)
+	}
+	if err := TableInstance.Insert(2, []string{"插入"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := TableInstance.Delete(4); err != nil {
+		t.Fatal(err)
+	}
+	if err := TableInstance.Append([]string{"末尾"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := TableInstance.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	Result, err := New(OutputPath, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Result.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{{"こんにちは", "你好", "42"}, {"插入"}, {"猫犬"}, {"", "  前后 空格\t"}, {"二  行\n目"}, {"末尾"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ODSTable.Read() after round trip = %q, want %q", got, want)
+	}
+	if IsFormula, _ := Result.IsFormula(3, 1); !IsFormula {
+		t.Errorf("formula in A3 was removed")
+	}
+
+	Content := string(Result.content.marshal())
+	for _, Keep := range []string{
+		`<table:table-cell office:value-type="string" table:style-name="ce1"><text:p>你好</text:p></table:table-cell>`,
+		`office:value-type="float" office:value="42"`,
+		`<style:text-properties fo:font-weight="bold"/>`,
+	} {
+		if !strings.Contains(Content, Keep) {
+			t.Errorf("content.xml does not contain %s", Keep)
+		}
+	}
+	// 插入行不会使工作表超出最大行数，删除行减少一行
+	if Count := totalRows(Result); Count != 1048575 {
+		t.Errorf("total rows = %d, want 1048575", Count)
+	}
+
+	// 其他文件原样保留，mimetype仍是第一个不压缩的文件
+	Archive, err := zip.OpenReader(OutputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer Archive.Close()
+	if First := Archive.File[0]; First.Name != "mimetype" || First.Method != zip.Store {
+		t.Errorf("first file = %s (method %d), want stored mimetype", First.Name, First.Method)
+	}
+	for _, File := range Archive.File {
+		if File.Name != "styles.xml" {
+			continue
+		}
+		Reader, _ := File.Open()
+		Data, _ := io.ReadAll(Reader)
+		Reader.Close()
+		if string(Data) != testStyles {
+			t.Errorf("styles.xml was changed")
+		}
+	}
+}
+
+func TestNew_InvalidFile(t *testing.T) {
+	FilePath := filepath.Join(t.TempDir(), "broken.ods")
+	Content := []byte("not a spreadsheet")
+	if err := os.WriteFile(FilePath, Content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := New(FilePath, ""); err == nil {
+		t.Errorf("New() should fail")
+	}
+	if got, _ := os.ReadFile(FilePath); !bytes.Equal(got, Content) {
+		t.Errorf("broken.ods was overwritten")
+	}
+}
+
+// 统计工作表的总行数，包括重复的行
+func totalRows(TableInstance *ODSTable) int {
+	Table, _ := TableInstance.currentTable()
+	Total := 0
+	for _, Row := range TableInstance.rows(Table) {
+		Total += TableInstance.repeat(Row, "number-rows-repeated")
+	}
+	return Total
+}
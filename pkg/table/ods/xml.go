@@ -0,0 +1,269 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-10 10:05:22
+ * @LastEditTime: 2025-07-10 11:48:16
+ * @LastEditors: nijineko
+ * @Description: 保留原始结构的XML节点树
+ * @FilePath: \AutoTranslation\pkg\table\ods\xml.go
+ */
+package ods
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+)
+
+// 节点类型
+const (
+	nodeElement = iota // 元素
+	nodeText           // 文本
+	nodeRaw            // 处理指令、注释等原样输出的内容
+)
+
+// XML节点，名称保留原始的命名空间前缀，序列化时不改变前缀和属性顺序
+type node struct {
+	kind     int
+	name     xml.Name // 元素名称，Space为命名空间前缀
+	attrs    []xml.Attr
+	children []*node
+	parent   *node
+	text     string // 文本内容或原始标记
+}
+
+/**
+ * @description: 解析XML文档，返回包含全部顶层节点的文档节点
+ * @param {[]byte} Data XML数据
+ * @return {*node} 文档节点
+ * @return {error} 错误信息
+ */
+func parseXML(Data []byte) (*node, error) {
+	Decoder := xml.NewDecoder(bytes.NewReader(Data))
+	Document := &node{kind: nodeElement}
+	Current := Document
+
+	for {
+		// RawToken不解析命名空间，保留原始前缀
+		Token, err := Decoder.RawToken()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch Token := Token.(type) {
+		case xml.StartElement:
+			Element := &node{kind: nodeElement, name: Token.Name, attrs: append([]xml.Attr{}, Token.Attr...)}
+			Current.append(Element)
+			Current = Element
+		case xml.EndElement:
+			if Current == Document || Current.name != Token.Name {
+				return nil, errors.New("mismatched end element </" + qualifiedName(Token.Name) + ">")
+			}
+			Current = Current.parent
+		case xml.CharData:
+			Current.append(&node{kind: nodeText, text: string(Token)})
+		case xml.Comment:
+			Current.append(&node{kind: nodeRaw, text: "<!--" + string(Token) + "-->"})
+		case xml.ProcInst:
+			Current.append(&node{kind: nodeRaw, text: "<?" + Token.Target + " " + string(Token.Inst) + "?>"})
+		case xml.Directive:
+			Current.append(&node{kind: nodeRaw, text: "<!" + string(Token) + ">"})
+		}
+	}
+	if Current != Document {
+		return nil, errors.New("unexpected end of XML document")
+	}
+
+	return Document, nil
+}
+
+/**
+ * @description: 序列化节点树
+ * @param {*node} Document 文档节点
+ * @return {[]byte} XML数据
+ */
+func (n *node) marshal() []byte {
+	var Buffer bytes.Buffer
+	for _, Child := range n.children {
+		Child.write(&Buffer)
+	}
+	return Buffer.Bytes()
+}
+
+/**
+ * @description: 写入节点
+ * @param {*bytes.Buffer} Buffer 输出缓冲区
+ */
+func (n *node) write(Buffer *bytes.Buffer) {
+	switch n.kind {
+	case nodeText:
+		Buffer.WriteString(escapeText(n.text, false))
+	case nodeRaw:
+		Buffer.WriteString(n.text)
+	case nodeElement:
+		Buffer.WriteString("<" + qualifiedName(n.name))
+		for _, Attr := range n.attrs {
+			Buffer.WriteString(" " + qualifiedName(Attr.Name) + `="` + escapeText(Attr.Value, true) + `"`)
+		}
+		if len(n.children) == 0 {
+			Buffer.WriteString("/>")
+			return
+		}
+		Buffer.WriteString(">")
+		for _, Child := range n.children {
+			Child.write(Buffer)
+		}
+		Buffer.WriteString("</" + qualifiedName(n.name) + ">")
+	}
+}
+
+/**
+ * @description: 深拷贝节点
+ * @return {*node} 新节点，没有父节点
+ */
+func (n *node) clone() *node {
+	Clone := &node{kind: n.kind, name: n.name, attrs: append([]xml.Attr{}, n.attrs...), text: n.text}
+	for _, Child := range n.children {
+		Clone.append(Child.clone())
+	}
+	return Clone
+}
+
+/**
+ * @description: 追加子节点
+ * @param {*node} Child 子节点
+ */
+func (n *node) append(Child *node) {
+	Child.parent = n
+	n.children = append(n.children, Child)
+}
+
+/**
+ * @description: 在子节点中的指定位置插入节点
+ * @param {int} Index 插入位置
+ * @param {...*node} Children 子节点
+ */
+func (n *node) insert(Index int, Children ...*node) {
+	for _, Child := range Children {
+		Child.parent = n
+	}
+	n.children = append(n.children[:Index], append(Children, n.children[Index:]...)...)
+}
+
+/**
+ * @description: 从父节点中移除
+ */
+func (n *node) remove() {
+	if n.parent == nil {
+		return
+	}
+	if Index := n.parent.indexOf(n); Index >= 0 {
+		n.parent.children = append(n.parent.children[:Index], n.parent.children[Index+1:]...)
+	}
+	n.parent = nil
+}
+
+/**
+ * @description: 获取子节点的位置
+ * @param {*node} Child 子节点
+ * @return {int} 位置，不存在时返回-1
+ */
+func (n *node) indexOf(Child *node) int {
+	for Index, Node := range n.children {
+		if Node == Child {
+			return Index
+		}
+	}
+	return -1
+}
+
+/**
+ * @description: 获取属性值
+ * @param {xml.Name} Name 属性名称，Space为命名空间前缀
+ * @return {string} 属性值
+ * @return {bool} 属性是否存在
+ */
+func (n *node) attr(Name xml.Name) (string, bool) {
+	for _, Attr := range n.attrs {
+		if Attr.Name == Name {
+			return Attr.Value, true
+		}
+	}
+	return "", false
+}
+
+/**
+ * @description: 设置属性值，已存在时保持原有位置
+ * @param {xml.Name} Name 属性名称
+ * @param {string} Value 属性值
+ */
+func (n *node) setAttr(Name xml.Name, Value string) {
+	for Index, Attr := range n.attrs {
+		if Attr.Name == Name {
+			n.attrs[Index].Value = Value
+			return
+		}
+	}
+	n.attrs = append(n.attrs, xml.Attr{Name: Name, Value: Value})
+}
+
+/**
+ * @description: 删除属性
+ * @param {func(xml.Name) bool} Match 判断属性是否需要删除
+ */
+func (n *node) removeAttrs(Match func(xml.Name) bool) {
+	Attrs := n.attrs[:0]
+	for _, Attr := range n.attrs {
+		if !Match(Attr.Name) {
+			Attrs = append(Attrs, Attr)
+		}
+	}
+	n.attrs = Attrs
+}
+
+/**
+ * @description: 获取带前缀的名称
+ * @param {xml.Name} Name 名称
+ * @return {string} 如"table:table-cell"
+ */
+func qualifiedName(Name xml.Name) string {
+	if Name.Space == "" {
+		return Name.Local
+	}
+	return Name.Space + ":" + Name.Local
+}
+
+/**
+ * @description: 转义XML文本，属性值中的空白字符也需要转义，避免读取时被规范化
+ * @param {string} Text 文本
+ * @param {bool} IsAttr 是否为属性值
+ * @return {string} 转义后的文本
+ */
+func escapeText(Text string, IsAttr bool) string {
+	var Builder strings.Builder
+	for _, Char := range Text {
+		switch {
+		case Char == '&':
+			Builder.WriteString("&amp;")
+		case Char == '<':
+			Builder.WriteString("&lt;")
+		case Char == '>':
+			Builder.WriteString("&gt;")
+		case Char == '\r':
+			Builder.WriteString("&#xD;")
+		case IsAttr && Char == '"':
+			Builder.WriteString("&quot;")
+		case IsAttr && Char == '\n':
+			Builder.WriteString("&#xA;")
+		case IsAttr && Char == '\t':
+			Builder.WriteString("&#x9;")
+		default:
+			Builder.WriteRune(Char)
+		}
+	}
+	return Builder.String()
+}
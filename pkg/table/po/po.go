@@ -0,0 +1,485 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-16 09:48:05
+ * @LastEditTime: 2025-07-16 16:21:37
+ * @LastEditors: nijineko
+ * @Description: gettext PO/POT翻译目录处理
+ * @FilePath: \AutoTranslation\pkg\table\po\po.go
+ */
+package po
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/nijinekoyo/AutoTranslation/pkg/table/resource"
+)
+
+// 资源文件格式，PO文件同时包含原文和译文
+var Format = resource.Format{Parse: Parse, Bilingual: true}
+
+// 复数形式条目中复数原文的键后缀
+const pluralSuffix = "[plural]"
+
+// 各语言的Plural-Forms，键为语言代码
+var pluralForms = map[string]string{}
+
+func init() {
+	for Forms, Languages := range map[string][]string{
+		"nplurals=1; plural=0;":        {"ja", "zh", "ko", "vi", "th", "id", "ms", "lo", "my", "km"},
+		"nplurals=2; plural=(n != 1);": {"en", "de", "nl", "sv", "da", "no", "nb", "nn", "fi", "et", "el", "hu", "tr", "bg", "hi", "bn", "fa", "ur", "af", "sq", "az", "eu", "gl", "ka", "kk", "ky", "mn", "sw", "ta", "te", "uz", "es", "it", "ca", "he", "iw"},
+		"nplurals=2; plural=(n > 1);":  {"fr", "pt"},
+		"nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);": {"ru", "uk", "be", "hr", "sr", "bs"},
+		"nplurals=3; plural=(n==1 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);":                 {"pl"},
+		"nplurals=3; plural=(n==1 ? 0 : (n>=2 && n<=4) ? 1 : 2);":                                                {"cs", "sk"},
+		"nplurals=6; plural=(n==0 ? 0 : n==1 ? 1 : n==2 ? 2 : n%100>=3 && n%100<=10 ? 3 : n%100>=11 ? 4 : 5);":   {"ar"},
+	} {
+		for _, Language := range Languages {
+			pluralForms[Language] = Forms
+		}
+	}
+}
+
+// Plural-Forms中nplurals的格式
+var npluralsPattern = regexp.MustCompile(`nplurals\s*=\s*(\d+)`)
+
+// msgstr[n]的格式
+var msgstrIndexPattern = regexp.MustCompile(`^msgstr\[\d+\]$`)
+
+// 条目
+type message struct {
+	lines []string // 原始行，不包含换行符
+	blank bool     // 是否为条目之间的空行
+
+	isHeader   bool // 是否为文件头，即msgid为空的条目
+	isObsolete bool // 是否为以#~开头的废弃条目
+
+	comments    []string // 译者注释和提取的注释
+	flags       []string // #,中的标记，如fuzzy、c-format
+	flagLine    int      // #,所在的行，为-1时没有
+	insertAt    int      // 添加#,时插入的行，位于#|、msgctxt和msgid之前
+	msgctxt     *string
+	msgid       string
+	msgidPlural *string
+	msgstr      []string // 译文，非复数条目只有一个
+	msgstrStart int      // msgstr所在的起始行，为-1时没有
+	msgstrEnd   int      // msgstr之后的行
+
+	entry int // 第一个可翻译条目的索引，为-1时不可翻译
+}
+
+// 解析后的PO文件
+type document struct {
+	messages     []*message // 条目和条目之间的空行
+	lineEnding   string     // 换行符
+	finalNewline bool       // 文件是否以换行结尾
+	bom          bool       // 文件是否带有UTF-8 BOM
+	entries      []resource.Entry
+}
+
+/**
+ * @description: 创建一个新的PO文件处理实例
+ * @param {string} FilePath PO或POT文件路径
+ * @param {string} OutputPath 输出文件路径，为空时覆盖源文件
+ * @param {string} Language 目标语言，用于补全文件头的Language和Plural-Forms
+ * @return {*resource.ResourceTable} 返回一个新的资源文件表格实例
+ * @return {error} 错误信息
+ */
+func New(FilePath, OutputPath, Language string) (*resource.ResourceTable, error) {
+	return resource.New(FilePath, OutputPath, Language, Format)
+}
+
+/**
+ * @description: 解析PO文件，已翻译且没有fuzzy标记的条目带有译文，复数条目的单数和复数原文各为一行
+ * @param {[]byte} Data 文件内容
+ * @return {resource.Document} 解析后的文件
+ * @return {error} 错误信息
+ */
+func Parse(Data []byte) (resource.Document, error) {
+	Text, HasBOM := strings.CutPrefix(string(Data), "\uFEFF")
+	Document := &document{lineEnding: "\n", finalNewline: strings.HasSuffix(Text, "\n"), bom: HasBOM}
+	if strings.Contains(Text, "\r\n") {
+		Document.lineEnding = "\r\n"
+		Text = strings.ReplaceAll(Text, "\r\n", "\n")
+	}
+	Text = strings.TrimSuffix(Text, "\n")
+
+	// 按空行拆分条目
+	var Current *message
+	for _, Line := range strings.Split(Text, "\n") {
+		IsBlank := strings.TrimSpace(Line) == ""
+		if Current == nil || IsBlank != Current.blank {
+			Current = &message{blank: IsBlank, entry: -1}
+			Document.messages = append(Document.messages, Current)
+		}
+		Current.lines = append(Current.lines, Line)
+	}
+
+	LineNumber := 1
+	for _, Message := range Document.messages {
+		if !Message.blank {
+			if err := Message.parse(); err != nil {
+				return nil, fmt.Errorf("entry at line %d: %w", LineNumber, err)
+			}
+			Document.collect(Message)
+		}
+		LineNumber += len(Message.lines)
+	}
+	return Document, nil
+}
+
+/**
+ * @description: 解析条目的各个字段
+ * @return {error} 错误信息
+ */
+func (m *message) parse() error {
+	m.flagLine, m.insertAt, m.msgstrStart, m.msgstrEnd = -1, -1, -1, -1
+
+	var Append func(Value string) // 续行追加到当前字段
+	for Index, Line := range m.lines {
+		Trimmed := strings.TrimSpace(Line)
+		switch {
+		case strings.HasPrefix(Trimmed, "#~"):
+			m.isObsolete = true
+			continue
+		case strings.HasPrefix(Trimmed, "#,"):
+			m.flagLine = Index
+			for _, Flag := range strings.Split(Trimmed[2:], ",") {
+				if Flag = strings.TrimSpace(Flag); Flag != "" {
+					m.flags = append(m.flags, Flag)
+				}
+			}
+			continue
+		case strings.HasPrefix(Trimmed, "#."):
+			if Comment := strings.TrimSpace(Trimmed[2:]); Comment != "" {
+				m.comments = append(m.comments, Comment)
+			}
+			continue
+		case strings.HasPrefix(Trimmed, "#|"):
+			if m.insertAt < 0 {
+				m.insertAt = Index
+			}
+			continue
+		case Trimmed == "#" || strings.HasPrefix(Trimmed, "# "):
+			if Comment := strings.TrimSpace(Trimmed[1:]); Comment != "" {
+				m.comments = append(m.comments, Comment)
+			}
+			continue
+		case strings.HasPrefix(Trimmed, "#"):
+			// 引用位置等其他注释
+			continue
+		}
+
+		Keyword, Quoted := "", Trimmed
+		if !strings.HasPrefix(Trimmed, `"`) {
+			Keyword, Quoted, _ = strings.Cut(Trimmed, " ")
+		}
+		Value, err := strconv.Unquote(strings.TrimSpace(Quoted))
+		if err != nil {
+			return fmt.Errorf("invalid string in %s: %w", Trimmed, err)
+		}
+
+		if Keyword == "" {
+			// 续行
+			if Append == nil {
+				return fmt.Errorf("unexpected string %s", Trimmed)
+			}
+			Append(Value)
+			if m.msgstrStart >= 0 {
+				m.msgstrEnd = Index + 1
+			}
+			continue
+		}
+
+		if m.insertAt < 0 {
+			m.insertAt = Index
+		}
+		switch {
+		case Keyword == "msgctxt":
+			m.msgctxt = &Value
+			Append = func(Value string) { *m.msgctxt += Value }
+		case Keyword == "msgid":
+			m.msgid = Value
+			Append = func(Value string) { m.msgid += Value }
+		case Keyword == "msgid_plural":
+			m.msgidPlural = &Value
+			Append = func(Value string) { *m.msgidPlural += Value }
+		case Keyword == "msgstr" || msgstrIndexPattern.MatchString(Keyword):
+			if m.msgstrStart < 0 {
+				m.msgstrStart = Index
+			}
+			m.msgstrEnd = Index + 1
+			Form := len(m.msgstr)
+			m.msgstr = append(m.msgstr, Value)
+			Append = func(Value string) { m.msgstr[Form] += Value }
+		default:
+			return fmt.Errorf("unknown keyword %s", Keyword)
+		}
+	}
+
+	if m.msgstrStart < 0 && !m.isObsolete && m.insertAt >= 0 {
+		return fmt.Errorf("missing msgstr for %q", m.msgid)
+	}
+	m.isHeader = !m.isObsolete && m.msgctxt == nil && m.msgid == "" && m.msgstrStart >= 0
+	return nil
+}
+
+/**
+ * @description: 收集条目中可翻译的原文
+ * @param {*message} Message 条目
+ */
+func (d *document) collect(Message *message) {
+	if Message.msgstrStart < 0 || Message.isHeader || Message.isObsolete || Message.msgid == "" {
+		return
+	}
+
+	Key := Message.msgid
+	Context := slices.Clone(Message.comments)
+	if Message.msgctxt != nil {
+		Key = *Message.msgctxt + "|" + Key
+		Context = append(Context, "msgctxt: "+*Message.msgctxt)
+	}
+
+	// 已翻译且没有fuzzy标记的条目不再翻译
+	Translated := !slices.Contains(Message.flags, "fuzzy") && len(Message.msgstr) > 0
+	for _, Value := range Message.msgstr {
+		Translated = Translated && Value != ""
+	}
+	Target := func(Form int) string {
+		if !Translated || Form >= len(Message.msgstr) {
+			return ""
+		}
+		return Message.msgstr[Form]
+	}
+
+	Message.entry = len(d.entries)
+	d.entries = append(d.entries, resource.Entry{Key: Key, Source: Message.msgid, Target: Target(0), Context: strings.Join(Context, "\n")})
+	if Message.msgidPlural != nil {
+		d.entries = append(d.entries, resource.Entry{Key: Key + pluralSuffix, Source: *Message.msgidPlural, Target: Target(1), Context: strings.Join(Context, "\n")})
+	}
+}
+
+/**
+ * @description: 获取可翻译条目
+ * @return {[]resource.Entry} 可翻译条目
+ */
+func (d *document) Entries() []resource.Entry {
+	return d.entries
+}
+
+/**
+ * @description: 写入译文，新写入的条目添加fuzzy标记，已翻译的条目保持不变，文件头缺少Language和Plural-Forms时按目标语言补全
+ * @param {[]string} Translations 各条目的译文
+ * @param {string} Language 目标语言
+ * @return {[]byte} 文件内容
+ * @return {error} 错误信息
+ */
+func (d *document) Marshal(Translations []string, Language string) ([]byte, error) {
+	Translation := func(Index int) string {
+		if Index < len(Translations) {
+			return Translations[Index]
+		}
+		return ""
+	}
+
+	// 复数形式的数量以补全后的文件头为准
+	Header := ""
+	HasHeader := false
+	for _, Message := range d.messages {
+		if Message.isHeader {
+			Header, HasHeader = completeHeader(Message.msgstr[0], Language), true
+			break
+		}
+	}
+	if !HasHeader {
+		Header = "Plural-Forms: " + pluralForms[baseLanguage(Language)] + "\n"
+	}
+	PluralCount := pluralCount(Header)
+
+	var Lines []string
+	for _, Message := range d.messages {
+		switch {
+		case Message.isHeader && Header != Message.msgstr[0]:
+			Lines = append(Lines, Message.replaceMsgstr([]string{Header}, false)...)
+			continue
+		case Message.isHeader, Message.entry < 0 || d.entries[Message.entry].Target != "":
+			// 不可翻译和已翻译的条目保持不变
+			Lines = append(Lines, Message.lines...)
+			continue
+		}
+
+		Singular := Translation(Message.entry)
+		if Message.msgidPlural == nil {
+			if Singular == "" {
+				Lines = append(Lines, Message.lines...)
+			} else {
+				Lines = append(Lines, Message.replaceMsgstr([]string{Singular}, true)...)
+			}
+			continue
+		}
+
+		// 第一个复数形式使用单数译文，其余使用复数译文，只有一个复数形式时使用复数译文
+		Plural := Translation(Message.entry + 1)
+		if Singular == "" && Plural == "" {
+			Lines = append(Lines, Message.lines...)
+			continue
+		}
+		if Singular == "" {
+			Singular = Plural
+		} else if Plural == "" {
+			Plural = Singular
+		}
+		Forms := []string{Plural}
+		if PluralCount > 1 {
+			Forms[0] = Singular
+		}
+		for len(Forms) < PluralCount {
+			Forms = append(Forms, Plural)
+		}
+		Lines = append(Lines, Message.replaceMsgstr(Forms, true)...)
+	}
+
+	Text := strings.Join(Lines, d.lineEnding)
+	if d.finalNewline {
+		Text += d.lineEnding
+	}
+	if d.bom {
+		Text = "\uFEFF" + Text
+	}
+	return []byte(Text), nil
+}
+
+/**
+ * @description: 替换条目的译文
+ * @param {[]string} Forms 译文，复数条目按复数形式的顺序排列
+ * @param {bool} Fuzzy 是否添加fuzzy标记
+ * @return {[]string} 替换后的行
+ */
+func (m *message) replaceMsgstr(Forms []string, Fuzzy bool) []string {
+	var Msgstr []string
+	for Form, Value := range Forms {
+		Keyword := "msgstr"
+		if m.msgidPlural != nil {
+			Keyword = fmt.Sprintf("msgstr[%d]", Form)
+		}
+		Msgstr = append(Msgstr, formatString(Keyword, Value)...)
+	}
+	HasFuzzy := slices.Contains(m.flags, "fuzzy")
+
+	var Lines []string
+	for Index := 0; Index < len(m.lines); Index++ {
+		switch {
+		case Index == m.msgstrStart:
+			Lines = append(Lines, Msgstr...)
+			Index = m.msgstrEnd - 1
+			continue
+		case Fuzzy && !HasFuzzy && Index == m.flagLine:
+			Lines = append(Lines, "#, "+strings.Join(append([]string{"fuzzy"}, m.flags...), ", "))
+			continue
+		case Fuzzy && m.flagLine < 0 && Index == m.insertAt:
+			Lines = append(Lines, "#, fuzzy")
+		}
+		Lines = append(Lines, m.lines[Index])
+	}
+	return Lines
+}
+
+/**
+ * @description: 格式化字段，包含换行的文本按行拆分为多个字符串
+ * @param {string} Keyword 关键字，如"msgstr"、"msgstr[1]"
+ * @param {string} Value 值
+ * @return {[]string} 格式化后的行
+ */
+func formatString(Keyword, Value string) []string {
+	Parts := strings.SplitAfter(Value, "\n")
+	if Parts[len(Parts)-1] == "" {
+		Parts = Parts[:len(Parts)-1]
+	}
+	if len(Parts) <= 1 {
+		return []string{Keyword + " " + quote(Value)}
+	}
+
+	Lines := []string{Keyword + ` ""`}
+	for _, Part := range Parts {
+		Lines = append(Lines, quote(Part))
+	}
+	return Lines
+}
+
+/**
+ * @description: 按gettext的转义规则编码字符串
+ * @param {string} Text 字符串
+ * @return {string} 带引号的字符串
+ */
+func quote(Text string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`, "\r", `\r`).Replace(Text) + `"`
+}
+
+/**
+ * @description: 获取语言代码的基本语言
+ * @param {string} Language 语言代码，如"zh-CN"、"pt_BR"
+ * @return {string} 基本语言，如"zh"、"pt"
+ */
+func baseLanguage(Language string) string {
+	Base, _, _ := strings.Cut(strings.ToLower(strings.ReplaceAll(Language, "_", "-")), "-")
+	return Base
+}
+
+/**
+ * @description: 按目标语言补全文件头中为空的Language和POT模板中的Plural-Forms
+ * @param {string} Header 文件头
+ * @param {string} Language 目标语言
+ * @return {string} 补全后的文件头
+ */
+func completeHeader(Header, Language string) string {
+	if Language == "" {
+		return Header
+	}
+	Fields := map[string]string{
+		"Language":     Language,
+		"Plural-Forms": pluralForms[baseLanguage(Language)],
+	}
+
+	Lines := strings.SplitAfter(Header, "\n")
+	for Index, Line := range Lines {
+		Name, Value, ok := strings.Cut(Line, ":")
+		Replacement, Known := Fields[Name]
+		if !ok || !Known {
+			continue
+		}
+		delete(Fields, Name)
+		Value = strings.TrimSpace(Value)
+		if Replacement != "" && (Value == "" || strings.Contains(Value, "INTEGER")) {
+			Lines[Index] = Name + ": " + Replacement + "\n"
+		}
+	}
+	Header = strings.Join(Lines, "")
+
+	// 缺少的字段追加到末尾
+	for _, Name := range []string{"Language", "Plural-Forms"} {
+		if Replacement := Fields[Name]; Replacement != "" {
+			if Header != "" && !strings.HasSuffix(Header, "\n") {
+				Header += "\n"
+			}
+			Header += Name + ": " + Replacement + "\n"
+		}
+	}
+	return Header
+}
+
+/**
+ * @description: 获取文件头中的复数形式数量
+ * @param {string} Header 文件头
+ * @return {int} 复数形式数量，没有Plural-Forms时为2
+ */
+func pluralCount(Header string) int {
+	if Match := npluralsPattern.FindStringSubmatch(Header); Match != nil {
+		if Count, err := strconv.Atoi(Match[1]); err == nil && Count > 0 {
+			return Count
+		}
+	}
+	return 2
+}
@@ -0,0 +1,159 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-16 16:25:10
+ * @LastEditTime: 2025-07-16 17:02:44
+ * @LastEditors: nijineko
+ * @Description: gettext PO/POT翻译目录处理测试
+ * @FilePath: \AutoTranslation\pkg\table\po\po_test.go
+ */
+package po
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const testCatalog = `# Translation template.
+msgid ""
+msgstr ""
+"Project-Id-Version: demo\n"
+"Language: \n"
+"Plural-Forms: nplurals=INTEGER; plural=EXPRESSION;\n"
+
+# Shown on the start page
+#. TRANSLATORS: keep it short
+#: main.c:10
+msgid "Hello"
+msgstr ""
+
+#: main.c:12
+#, c-format
+msgid "%d file"
+msgid_plural "%d files"
+msgstr[0] ""
+msgstr[1] ""
+
+msgctxt "menu"
+msgid "Open"
+msgstr "Открыть"
+
+#, fuzzy
+msgid ""
+"Line one\n"
+"Line two"
+msgstr "Старое"
+
+#~ msgid "Removed"
+#~ msgstr "Удалено"
+`
+
+func TestPO_RoundTrip(t *testing.T) {
+	FilePath := filepath.Join(t.TempDir(), "ru.po")
+	if err := os.WriteFile(FilePath, []byte(testCatalog), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	TableInstance, err := New(FilePath, "", "ru")
+	if err != nil {
+		t.Fatal(err)
+	}
+	Datas, err := TableInstance.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 已翻译的条目带有译文，fuzzy条目需要重新翻译
+	want := [][]string{
+		{"source", "target", "key", "context"},
+		{"Hello", "", "Hello", "Shown on the start page\nTRANSLATORS: keep it short"},
+		{"%d file", "", "%d file", ""},
+		{"%d files", "", "%d file[plural]", ""},
+		{"Open", "Открыть", "menu|Open", "msgctxt: menu"},
+		{"Line one\nLine two", "", "Line one\nLine two", ""},
+	}
+	if !reflect.DeepEqual(Datas, want) {
+		t.Fatalf("Read() = %q, want %q", Datas, want)
+	}
+
+	Datas[1][1] = "Привет"
+	Datas[2][1] = "%d файл"
+	Datas[3][1] = "%d файлов"
+	Datas[4][1] = "Открыть файл"
+	Datas[5][1] = "Первая строка\nВторая строка"
+	if err := TableInstance.Write(Datas); err != nil {
+		t.Fatal(err)
+	}
+
+	Output := `# Translation template.
+msgid ""
+msgstr ""
+"Project-Id-Version: demo\n"
+"Language: ru\n"
+"Plural-Forms: nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);\n"
+
+# Shown on the start page
+#. TRANSLATORS: keep it short
+#: main.c:10
+#, fuzzy
+msgid "Hello"
+msgstr "Привет"
+
+#: main.c:12
+#, fuzzy, c-format
+msgid "%d file"
+msgid_plural "%d files"
+msgstr[0] "%d файл"
+msgstr[1] "%d файлов"
+msgstr[2] "%d файлов"
+
+msgctxt "menu"
+msgid "Open"
+msgstr "Открыть"
+
+#, fuzzy
+msgid ""
+"Line one\n"
+"Line two"
+msgstr ""
+"Первая строка\n"
+"Вторая строка"
+
+#~ msgid "Removed"
+#~ msgstr "Удалено"
+`
+	if got, _ := os.ReadFile(FilePath); string(got) != Output {
+		t.Errorf("output = %s, want %s", got, Output)
+	}
+}
+
+func TestPO_SingleFormAndCRLF(t *testing.T) {
+	FilePath := filepath.Join(t.TempDir(), "messages.pot")
+	if err := os.WriteFile(FilePath, []byte("msgid \"apple\"\r\nmsgid_plural \"apples\"\r\nmsgstr[0] \"\"\r\nmsgstr[1] \"\"\r\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	TableInstance, err := New(FilePath, "", "ja")
+	if err != nil {
+		t.Fatal(err)
+	}
+	Datas, _ := TableInstance.Read()
+	Datas[2][1] = "りんご"
+	if err := TableInstance.Write(Datas); err != nil {
+		t.Fatal(err)
+	}
+
+	// 没有文件头时按目标语言决定复数形式数量，日语只有一个
+	want := "#, fuzzy\r\nmsgid \"apple\"\r\nmsgid_plural \"apples\"\r\nmsgstr[0] \"りんご\"\r\n"
+	if got, _ := os.ReadFile(FilePath); string(got) != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, Content := range []string{"msgid \"a\"\n\"b", "msgid \"a\"\nmsgstr \"b\"\nfoo \"c\"", "\"orphan\"", "msgid \"a\""} {
+		if _, err := Parse([]byte(Content)); err == nil {
+			t.Errorf("Parse(%q) should fail", Content)
+		}
+	}
+}
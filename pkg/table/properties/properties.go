@@ -0,0 +1,349 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-22 09:40:11
+ * @LastEditTime: 2025-07-22 15:18:46
+ * @LastEditors: nijineko
+ * @Description: Java .properties资源包处理
+ * @FilePath: \AutoTranslation\pkg\table\properties\properties.go
+ */
+package properties
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/nijinekoyo/AutoTranslation/pkg/table/resource"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/language"
+)
+
+// .properties资源格式，译文写入目标语言的资源包
+var Format = resource.Format{Parse: Parse, Protect: resource.ArgumentPattern}
+
+// 文件名中的语言后缀，如"messages_en_US"中的"_en_US"
+var localeSuffixPattern = regexp.MustCompile(`_([a-z]{2,3})(_[A-Z][a-z]{3})?(_[A-Z]{2}|_\d{3})?$`)
+
+// 一个属性的值在文件中的位置
+type property struct {
+	start, end    int  // 值在文件中的范围，包括续行
+	messageFormat bool // 值是否为MessageFormat模式，单引号需要写为两个
+}
+
+// 解析后的.properties文件
+type document struct {
+	text       string // 文件内容，ISO-8859-1编码的文件转换为UTF-8
+	latin1     bool   // 文件是否为ISO-8859-1编码
+	ascii      bool   // 文件是否只包含ASCII字符，非ASCII字符写为\uXXXX
+	properties []property
+	entries    []resource.Entry
+}
+
+/**
+ * @description: 创建一个新的.properties资源包处理实例
+ * @param {string} FilePath 资源包路径
+ * @param {string} OutputPath 输出文件路径
+ * @param {string} Language 目标语言
+ * @return {*resource.ResourceTable} 返回一个新的资源文件表格实例
+ * @return {error} 错误信息
+ */
+func New(FilePath, OutputPath, Language string) (*resource.ResourceTable, error) {
+	return resource.New(FilePath, OutputPath, Language, Format)
+}
+
+/**
+ * @description: 获取目标语言的资源包后缀
+ * @param {string} Language 语言代码，如"zh-CN"、"zh-Hant-TW"
+ * @return {string} 后缀，如"zh_CN"、"zh_Hant_TW"
+ */
+func LocaleSuffix(Language string) string {
+	Tag, err := language.Parse(strings.ReplaceAll(Language, "_", "-"))
+	if err != nil {
+		return strings.ReplaceAll(Language, "-", "_")
+	}
+	Base, _ := Tag.Base()
+	Suffix := Base.String()
+	if Script, Confidence := Tag.Script(); Confidence == language.Exact {
+		Suffix += "_" + Script.String()
+	}
+	if Region, Confidence := Tag.Region(); Confidence == language.Exact {
+		Suffix += "_" + Region.String()
+	}
+	return Suffix
+}
+
+/**
+ * @description: 获取目标语言的资源包路径，如"messages.properties"替换为"messages_zh_CN.properties"，同目录下存在基础资源包时替换已有的语言后缀
+ * @param {string} Path 源资源包路径
+ * @param {string} Language 目标语言
+ * @return {string} 目标语言的资源包路径
+ */
+func LocalePath(Path, Language string) string {
+	if Language == "" {
+		return Path
+	}
+	Extension := filepath.Ext(Path)
+	Base := strings.TrimSuffix(Path, Extension)
+	// "user_id"之类的名称末尾也像语言后缀，只有基础资源包存在时才确定是语言后缀
+	if Match := localeSuffixPattern.FindStringSubmatchIndex(filepath.Base(Base)); Match != nil {
+		if _, err := language.ParseBase(filepath.Base(Base)[Match[2]:Match[3]]); err == nil {
+			BundleBase := Base[:len(Base)-(Match[1]-Match[0])]
+			if _, err := os.Stat(BundleBase + Extension); err == nil {
+				Base = BundleBase
+			}
+		}
+	}
+	return Base + "_" + LocaleSuffix(Language) + Extension
+}
+
+/**
+ * @description: 解析.properties文件，支持续行和\uXXXX转义，注释作为说明；不是UTF-8的文件按ISO-8859-1读取
+ * @param {[]byte} Data 文件内容
+ * @return {resource.Document} 解析后的文件
+ * @return {error} 错误信息
+ */
+func Parse(Data []byte) (resource.Document, error) {
+	Document := &document{text: string(Data), ascii: true}
+	if !utf8.Valid(Data) {
+		Text, err := charmap.ISO8859_1.NewDecoder().Bytes(Data)
+		if err != nil {
+			return nil, err
+		}
+		Document.text, Document.latin1 = string(Text), true
+	}
+	// ISO-8859-1编码的文件中译文总是转义
+	for _, Char := range Data {
+		if Char >= utf8.RuneSelf && !Document.latin1 {
+			Document.ascii = false
+			break
+		}
+	}
+
+	Lines := strings.Split(Document.text, "\n")
+	Offsets := make([]int, len(Lines))
+	for Index, Offset := 1, 0; Index < len(Lines); Index++ {
+		Offset += len(Lines[Index-1]) + 1
+		Offsets[Index] = Offset
+	}
+	for Index := range Lines {
+		Lines[Index] = strings.TrimSuffix(Lines[Index], "\r")
+	}
+
+	var Comments []string
+	for Index := 0; Index < len(Lines); Index++ {
+		Line := strings.TrimLeft(Lines[Index], " \t\f")
+		if Line == "" {
+			Comments = nil
+			continue
+		}
+		if Line[0] == '#' || Line[0] == '!' {
+			Comments = append(Comments, strings.TrimSpace(Line[1:]))
+			continue
+		}
+
+		// 以奇数个反斜杠结尾的行与下一行连接，下一行的前导空白去除
+		Start := Offsets[Index] + len(Lines[Index]) - len(Line)
+		First := len(Line)
+		Logical := Line
+		for continues(Logical) && Index+1 < len(Lines) {
+			Index++
+			Logical = Logical[:len(Logical)-1] + strings.TrimLeft(Lines[Index], " \t\f")
+			if First == len(Line) {
+				First--
+			}
+		}
+		End := Offsets[Index] + len(Lines[Index])
+
+		Key, ValueIndex := splitKey(Logical)
+		Value, err := unescape(Logical[ValueIndex:])
+		if err == nil {
+			Key, err = unescape(Key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", Index+1, err)
+		}
+		// 值从续行开始时无法写回
+		if Value == "" || ValueIndex > First {
+			Comments = nil
+			continue
+		}
+
+		Property := property{start: Start + ValueIndex, end: End}
+		Context := strings.Join(Comments, "\n")
+		if len(resource.Arguments(Value)) > 0 {
+			// MessageFormat中的单引号写为两个
+			Property.messageFormat = true
+			Value = strings.ReplaceAll(Value, "''", "'")
+		}
+		Document.properties = append(Document.properties, Property)
+		Document.entries = append(Document.entries, resource.Entry{Key: Key, Source: Value, Context: Context})
+		Comments = nil
+	}
+	return Document, nil
+}
+
+/**
+ * @description: 判断逻辑行是否以转义换行结尾
+ * @param {string} Line 逻辑行
+ * @return {bool} 是否有续行
+ */
+func continues(Line string) bool {
+	Count := len(Line) - len(strings.TrimRight(Line, "\\"))
+	return Count%2 == 1
+}
+
+/**
+ * @description: 拆分逻辑行的键和值，键以未转义的=、:或空白结束
+ * @param {string} Line 去除前导空白的逻辑行
+ * @return {string} 未转义的键
+ * @return {int} 值在逻辑行中的起始位置
+ */
+func splitKey(Line string) (string, int) {
+	Index := 0
+	for Index < len(Line) && !strings.ContainsRune("=: \t\f", rune(Line[Index])) {
+		if Line[Index] == '\\' {
+			Index++
+		}
+		Index++
+	}
+	Key := Line[:min(Index, len(Line))]
+	for Index < len(Line) && strings.ContainsRune(" \t\f", rune(Line[Index])) {
+		Index++
+	}
+	if Index < len(Line) && (Line[Index] == '=' || Line[Index] == ':') {
+		Index++
+	}
+	for Index < len(Line) && strings.ContainsRune(" \t\f", rune(Line[Index])) {
+		Index++
+	}
+	return Key, min(Index, len(Line))
+}
+
+/**
+ * @description: 去除.properties的转义，包括\uXXXX和UTF-16代理对
+ * @param {string} Text 转义的文本
+ * @return {string} 原文
+ * @return {error} 错误信息
+ */
+func unescape(Text string) (string, error) {
+	if !strings.Contains(Text, "\\") {
+		return Text, nil
+	}
+	var Units []uint16
+	var Builder strings.Builder
+	Flush := func() {
+		Builder.WriteString(string(utf16.Decode(Units)))
+		Units = nil
+	}
+	for Index := 0; Index < len(Text); Index++ {
+		if Text[Index] != '\\' || Index+1 >= len(Text) {
+			Flush()
+			Builder.WriteByte(Text[Index])
+			continue
+		}
+		Index++
+		if Text[Index] == 'u' {
+			if Index+5 > len(Text) {
+				return "", fmt.Errorf("malformed \\uxxxx encoding")
+			}
+			Unit, err := strconv.ParseUint(Text[Index+1:Index+5], 16, 16)
+			if err != nil {
+				return "", fmt.Errorf("malformed \\uxxxx encoding: %s", Text[Index-1:Index+5])
+			}
+			Units = append(Units, uint16(Unit))
+			Index += 4
+			continue
+		}
+		Flush()
+		switch Text[Index] {
+		case 't':
+			Builder.WriteByte('\t')
+		case 'n':
+			Builder.WriteByte('\n')
+		case 'r':
+			Builder.WriteByte('\r')
+		case 'f':
+			Builder.WriteByte('\f')
+		default:
+			Builder.WriteByte(Text[Index])
+		}
+	}
+	Flush()
+	return Builder.String(), nil
+}
+
+/**
+ * @description: 转义.properties的值，行首空格写为"\ "
+ * @param {string} Text 原文
+ * @param {bool} ASCII 是否将非ASCII字符写为\uXXXX
+ * @return {string} 转义的文本
+ */
+func escape(Text string, ASCII bool) string {
+	var Builder strings.Builder
+	for Index, Char := range Text {
+		switch {
+		case Char == '\\':
+			Builder.WriteString(`\\`)
+		case Char == '\n':
+			Builder.WriteString(`\n`)
+		case Char == '\r':
+			Builder.WriteString(`\r`)
+		case Char == '\t':
+			Builder.WriteString(`\t`)
+		case Char == '\f':
+			Builder.WriteString(`\f`)
+		case Char == ' ' && Index == 0:
+			Builder.WriteString(`\ `)
+		case ASCII && (Char < 0x20 || Char > 0x7E):
+			for _, Unit := range utf16.Encode([]rune{Char}) {
+				fmt.Fprintf(&Builder, `\u%04X`, Unit)
+			}
+		default:
+			Builder.WriteRune(Char)
+		}
+	}
+	return Builder.String()
+}
+
+/**
+ * @description: 获取可翻译条目
+ * @return {[]resource.Entry} 可翻译条目
+ */
+func (d *document) Entries() []resource.Entry {
+	return d.entries
+}
+
+/**
+ * @description: 生成目标语言资源包，保持注释、键和格式，续行的值写为一行，未翻译的条目保留原文
+ * @param {[]string} Translations 各条目的译文
+ * @param {string} Language 目标语言
+ * @return {[]byte} 文件内容
+ * @return {error} 错误信息
+ */
+func (d *document) Marshal(Translations []string, Language string) ([]byte, error) {
+	var Builder strings.Builder
+	Last := 0
+	for Index, Property := range d.properties {
+		if Index >= len(Translations) || Translations[Index] == "" {
+			continue
+		}
+		Translation := Translations[Index]
+		if Property.messageFormat {
+			Translation = strings.ReplaceAll(Translation, "'", "''")
+		}
+		Builder.WriteString(d.text[Last:Property.start])
+		Builder.WriteString(escape(Translation, d.ascii))
+		Last = Property.end
+	}
+	Builder.WriteString(d.text[Last:])
+
+	if d.latin1 {
+		return charmap.ISO8859_1.NewEncoder().Bytes([]byte(Builder.String()))
+	}
+	return []byte(Builder.String()), nil
+}
@@ -0,0 +1,131 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-22 14:02:37
+ * @LastEditTime: 2025-07-22 15:20:09
+ * @LastEditors: nijineko
+ * @Description: Java .properties资源包处理测试
+ * @FilePath: \AutoTranslation\pkg\table\properties\properties_test.go
+ */
+package properties
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/nijinekoyo/AutoTranslation/pkg/table/resource"
+)
+
+const testProperties = `# Login page
+login.title = Sign in
+login.welcome=Welcome back, {0}! You have {1,number,integer} messages.
+! Error shown when the user''s password is wrong
+error.password : Don''t forget your password, {0}.
+multi.line = First part \
+             second part
+caf\u00e9 = Caf\u00e9 \u2615
+empty=
+`
+
+func TestProperties_RoundTrip(t *testing.T) {
+	Directory := t.TempDir()
+	FilePath := filepath.Join(Directory, "messages.properties")
+	OutputPath := LocalePath(FilePath, "fr-FR")
+	if err := os.WriteFile(FilePath, []byte(testProperties), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	TableInstance, err := New(FilePath, OutputPath, "fr-FR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	Datas, err := TableInstance.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// MessageFormat中的两个单引号作为一个传给翻译
+	want := [][]string{
+		{"source", "target", "key", "context"},
+		{"Sign in", "", "login.title", "Login page"},
+		{"Welcome back, ⟦1⟧! You have ⟦2⟧ messages.", "", "login.welcome", resource.PlaceholderNote},
+		{"Don't forget your password, ⟦1⟧.", "", "error.password", "Error shown when the user''s password is wrong\n" + resource.PlaceholderNote},
+		{"First part second part", "", "multi.line", ""},
+		{"Café ☕", "", "café", ""},
+	}
+	if !reflect.DeepEqual(Datas, want) {
+		t.Fatalf("Read() = %q, want %q", Datas, want)
+	}
+
+	Datas[1][1] = " Se connecter"
+	Datas[2][1] = "Bon retour, ⟦1⟧ ! Vous avez ⟦2⟧ messages."
+	Datas[3][1] = "N'oubliez pas votre mot de passe, ⟦1⟧."
+	Datas[4][1] = "Première partie\nseconde partie"
+	Datas[5][1] = "Café ☕"
+	if err := TableInstance.Write(Datas); err != nil {
+		t.Fatal(err)
+	}
+
+	// 只包含ASCII的文件中非ASCII字符写为\uXXXX，占位符还原为参数
+	Output := `# Login page
+login.title = \ Se connecter
+login.welcome=Bon retour, {0} ! Vous avez {1,number,integer} messages.
+! Error shown when the user''s password is wrong
+error.password : N''oubliez pas votre mot de passe, {0}.
+multi.line = Premi\u00E8re partie\nseconde partie
+caf\u00e9 = Caf\u00E9 \u2615
+empty=
+`
+	if got, _ := os.ReadFile(filepath.Join(Directory, "messages_fr_FR.properties")); string(got) != Output {
+		t.Errorf("output = %s, want %s", got, Output)
+	}
+
+	// 再次读取时从输出文件中获取已有译文
+	TableInstance, err = New(FilePath, OutputPath, "fr-FR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if Datas, _ = TableInstance.Read(); Datas[3][1] != "N'oubliez pas votre mot de passe, ⟦1⟧." {
+		t.Errorf("Read() existing translation = %q", Datas[3][1])
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	if _, err := Parse([]byte(`key=\u12`)); err == nil {
+		t.Error("Parse() with malformed \\uxxxx should fail")
+	}
+}
+
+func TestLocalePath(t *testing.T) {
+	Directory := t.TempDir()
+	for _, Name := range []string{"messages.properties", "messages_en.properties", "i18n/app.properties", "user_id.properties", "field_to.properties"} {
+		Path := filepath.Join(Directory, filepath.FromSlash(Name))
+		if err := os.MkdirAll(filepath.Dir(Path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(Path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for Path, want := range map[string]string{
+		"messages.properties":            "messages_zh_CN.properties",
+		"messages_en.properties":         "messages_zh_CN.properties",
+		"messages_en_US.properties":      "messages_zh_CN.properties",
+		"error_codes.properties":         "error_codes_zh_CN.properties",
+		"i18n/app_messages.properties":   "i18n/app_messages_zh_CN.properties",
+		"i18n/app_zh_Hant_TW.properties": "i18n/app_zh_CN.properties",
+		// 没有基础资源包时不是语言后缀
+		"user_id.properties":   "user_id_zh_CN.properties",
+		"field_to.properties":  "field_to_zh_CN.properties",
+		"orders_en.properties": "orders_en_zh_CN.properties",
+	} {
+		got := LocalePath(filepath.Join(Directory, filepath.FromSlash(Path)), "zh-CN")
+		if want := filepath.Join(Directory, filepath.FromSlash(want)); got != want {
+			t.Errorf("LocalePath(%q) = %q, want %q", Path, got, want)
+		}
+	}
+	if got := LocaleSuffix("zh-Hant-TW"); got != "zh_Hant_TW" {
+		t.Errorf("LocaleSuffix() = %q", got)
+	}
+}
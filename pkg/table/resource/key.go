@@ -0,0 +1,30 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-20 16:52:08
+ * @LastEditTime: 2025-07-20 17:06:31
+ * @LastEditors: nijineko
+ * @Description: 按原文生成的条目键
+ * @FilePath: \AutoTranslation\pkg\table\resource\key.go
+ */
+package resource
+
+import (
+	"strconv"
+)
+
+// 按原文生成条目键，用于没有自身键的文档，插入或删除条目不影响其他条目的键
+type TextKeys map[string]int
+
+/**
+ * @description: 生成下一个条目的键，为原文的哈希，相同原文按出现顺序加上"#2"、"#3"等序号
+ * @param {string} Source 原文
+ * @return {string} 条目的键
+ */
+func (k TextKeys) Next(Source string) string {
+	k[Source]++
+	Key := hashSource(Source)
+	if Count := k[Source]; Count > 1 {
+		Key += "#" + strconv.Itoa(Count)
+	}
+	return Key
+}
@@ -0,0 +1,119 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-17 10:12:36
+ * @LastEditTime: 2025-07-21 10:05:48
+ * @LastEditors: nijineko
+ * @Description: 不可翻译内容的占位符和按语言后缀命名的输出文件
+ * @FilePath: \AutoTranslation\pkg\table\resource\placeholder.go
+ */
+package resource
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// 不可翻译内容的占位符，如"⟦1⟧"，成对内容的结束占位符如"⟦/1⟧"
+var placeholderPattern = regexp.MustCompile(`⟦(/?)(\d+)⟧`)
+
+// 文件名中的语言后缀，如"movie.en.srt"中的"en"
+var languageSuffixPattern = regexp.MustCompile(`^[a-z]{2,3}([-_][A-Za-z0-9]{2,8})*$`)
+
+/**
+ * @description: 获取第Number个占位符
+ * @param {int} Number 编号，从1开始
+ * @return {string} 占位符，如"⟦1⟧"
+ */
+func Placeholder(Number int) string {
+	return fmt.Sprintf("⟦%d⟧", Number)
+}
+
+/**
+ * @description: 获取第Number个成对内容的结束占位符
+ * @param {int} Number 编号，与开始占位符相同
+ * @return {string} 结束占位符，如"⟦/1⟧"
+ */
+func ClosingPlaceholder(Number int) string {
+	return fmt.Sprintf("⟦/%d⟧", Number)
+}
+
+// 文本中的一个占位符
+type PlaceholderMatch struct {
+	Start, End int  // 在文本中的位置
+	Number     int  // 编号
+	Closing    bool // 是否为结束占位符
+}
+
+/**
+ * @description: 查找文本中的全部占位符
+ * @param {string} Text 文本
+ * @return {[]PlaceholderMatch} 占位符，按出现顺序排列
+ */
+func FindPlaceholders(Text string) []PlaceholderMatch {
+	var Matches []PlaceholderMatch
+	for _, Index := range placeholderPattern.FindAllStringSubmatchIndex(Text, -1) {
+		Number, _ := strconv.Atoi(Text[Index[4]:Index[5]])
+		Matches = append(Matches, PlaceholderMatch{Start: Index[0], End: Index[1], Number: Number, Closing: Index[3] > Index[2]})
+	}
+	return Matches
+}
+
+/**
+ * @description: 将译文中的占位符还原为原内容，重复和不存在的占位符去除
+ * @param {string} Text 译文
+ * @param {[]string} Values 原内容，按占位符编号排列
+ * @return {string} 还原后的文本
+ * @return {bool} 是否每个占位符都已还原，译文遗漏占位符时为false，此时原内容会丢失，调用方应保留原文
+ */
+func RestorePlaceholders(Text string, Values []string) (string, bool) {
+	Used := map[int]bool{}
+	var Builder strings.Builder
+	Last := 0
+	for _, Match := range FindPlaceholders(Text) {
+		Builder.WriteString(Text[Last:Match.Start])
+		Last = Match.End
+		if Match.Closing || Match.Number < 1 || Match.Number > len(Values) || Used[Match.Number] {
+			continue
+		}
+		Used[Match.Number] = true
+		Builder.WriteString(Values[Match.Number-1])
+	}
+	Builder.WriteString(Text[Last:])
+	return Builder.String(), len(Used) == len(Values)
+}
+
+/**
+ * @description: 获取与原文件放在一起、带有语言后缀的目标语言文件路径，如"movie.srt"替换为"movie.zh-CN.srt"，已有的语言后缀被替换
+ * @param {string} Path 文件路径
+ * @param {string} Language 目标语言
+ * @return {string} 目标语言的文件路径
+ */
+func SuffixPath(Path, Language string) string {
+	if Language == "" {
+		return Path
+	}
+	Extension := filepath.Ext(Path)
+	Base := strings.TrimSuffix(Path, Extension)
+	if Suffix := filepath.Ext(Base); Suffix != "" && isLanguage(Suffix[1:]) {
+		Base = strings.TrimSuffix(Base, Suffix)
+	}
+	return Base + "." + Language + Extension
+}
+
+/**
+ * @description: 判断文件名后缀是否为语言代码
+ * @param {string} Suffix 后缀
+ * @return {bool} 是否为语言代码
+ */
+func isLanguage(Suffix string) bool {
+	if !languageSuffixPattern.MatchString(Suffix) {
+		return false
+	}
+	_, err := language.Parse(strings.ReplaceAll(Suffix, "_", "-"))
+	return err == nil
+}
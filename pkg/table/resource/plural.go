@@ -0,0 +1,93 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-14 13:02:41
+ * @LastEditTime: 2025-07-14 13:40:15
+ * @LastEditors: nijineko
+ * @Description: CLDR复数类别
+ * @FilePath: \AutoTranslation\pkg\table\resource\plural.go
+ */
+package resource
+
+import (
+	"slices"
+	"strings"
+)
+
+// CLDR复数类别，按标准顺序排列
+var PluralCategories = []string{"zero", "one", "two", "few", "many", "other"}
+
+// 各语言使用的基数复数类别，键为语言代码
+var languagePlurals = map[string][]string{}
+
+func init() {
+	for Categories, Languages := range map[string][]string{
+		"other":                       {"ja", "zh", "ko", "vi", "th", "id", "ms", "lo", "my", "km"},
+		"one other":                   {"en", "de", "nl", "sv", "da", "no", "nb", "nn", "fi", "et", "el", "hu", "tr", "bg", "hi", "bn", "fa", "ur", "af", "sq", "az", "eu", "gl", "ka", "kk", "ky", "mn", "sw", "ta", "te", "uz"},
+		"one many other":              {"fr", "es", "it", "pt", "ca"},
+		"one few other":               {"ro", "hr", "sr", "bs"},
+		"one few many other":          {"ru", "uk", "be", "pl", "cs", "sk", "lt"},
+		"one two other":               {"he", "iw"},
+		"one two few other":           {"sl"},
+		"one two few many other":      {"ga"},
+		"zero one other":              {"lv"},
+		"zero one two few many other": {"ar", "cy"},
+	} {
+		for _, Language := range Languages {
+			languagePlurals[Language] = strings.Fields(Categories)
+		}
+	}
+}
+
+/**
+ * @description: 获取语言使用的基数复数类别
+ * @param {string} Language 语言代码，如"ru"、"zh-CN"、"pt_BR"
+ * @return {[]string} 复数类别，未知语言返回nil
+ */
+func LanguagePlurals(Language string) []string {
+	Base, _, _ := strings.Cut(strings.ToLower(strings.ReplaceAll(Language, "_", "-")), "-")
+	return languagePlurals[Base]
+}
+
+/**
+ * @description: 拆分带复数后缀的键，如"item_one"
+ * @param {string} Key 键
+ * @param {string} Separator 键与复数类别之间的分隔符
+ * @return {string} 去除后缀的键
+ * @return {string} 复数类别，不是复数键时为空
+ */
+func SplitPlural(Key, Separator string) (string, string) {
+	for _, Category := range PluralCategories {
+		if Base, ok := strings.CutSuffix(Key, Separator+Category); ok && Base != "" {
+			return Base, Category
+		}
+	}
+	return Key, ""
+}
+
+/**
+ * @description: 按目标语言调整复数类别，保留已有的类别和zero，去除目标语言不使用的类别，补充缺少的类别
+ * @param {[]string} Categories 源文件中已有的复数类别
+ * @param {string} Language 目标语言
+ * @return {[]string} 保留的类别，按原有顺序排列
+ * @return {[]string} 需要补充的类别，按标准顺序排列
+ */
+func AdjustPlurals(Categories []string, Language string) ([]string, []string) {
+	Target := LanguagePlurals(Language)
+	if Target == nil {
+		// 未知语言保持原样
+		return Categories, nil
+	}
+
+	var Keep, Missing []string
+	for _, Category := range Categories {
+		if Category == "zero" || slices.Contains(Target, Category) {
+			Keep = append(Keep, Category)
+		}
+	}
+	for _, Category := range Target {
+		if !slices.Contains(Categories, Category) {
+			Missing = append(Missing, Category)
+		}
+	}
+	return Keep, Missing
+}
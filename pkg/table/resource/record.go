@@ -0,0 +1,100 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-21 14:26:51
+ * @LastEditTime: 2025-07-21 14:26:51
+ * @LastEditors: nijineko
+ * @Description: 目标语言文件中各条目译文对应的原文记录
+ * @FilePath: \AutoTranslation\pkg\table\resource\record.go
+ */
+package resource
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/nijinekoyo/AutoTranslation/tools/file"
+)
+
+// 原文记录，键为条目的键，值为翻译时原文的哈希
+type sourceRecord map[string]string
+
+/**
+ * @description: 获取目标语言文件的原文记录路径，与目标语言文件放在一起并隐藏，如"values-ja/.strings.xml.sources"
+ * @param {string} OutputPath 目标语言文件路径
+ * @return {string} 原文记录路径
+ */
+func RecordPath(OutputPath string) string {
+	return filepath.Join(filepath.Dir(OutputPath), "."+filepath.Base(OutputPath)+".sources")
+}
+
+/**
+ * @description: 读取目标语言文件的原文记录
+ * @param {string} OutputPath 目标语言文件路径
+ * @return {sourceRecord} 原文记录，不存在时为nil
+ * @return {error} 错误信息
+ */
+func readRecord(OutputPath string) (sourceRecord, error) {
+	Data, err := os.ReadFile(RecordPath(OutputPath))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	Record := sourceRecord{}
+	if err := json.Unmarshal(Data, &Record); err != nil {
+		return nil, err
+	}
+	return Record, nil
+}
+
+/**
+ * @description: 写入目标语言文件的原文记录
+ * @param {string} OutputPath 目标语言文件路径
+ * @param {[]Entry} Entries 可翻译条目
+ * @param {[]string} Translations 各条目的译文，只记录已翻译的条目
+ * @return {error} 错误信息
+ */
+func writeRecord(OutputPath string, Entries []Entry, Translations []string) error {
+	Record := sourceRecord{}
+	for Index, Entry := range Entries {
+		if Translations[Index] != "" {
+			Record[Entry.Key] = hashSource(Entry.Source)
+		}
+	}
+	Data, err := json.MarshalIndent(Record, "", "  ")
+	if err != nil {
+		return err
+	}
+	return file.WriteAtomic(RecordPath(OutputPath), func(Writer io.Writer) error {
+		_, err := Writer.Write(Data)
+		return err
+	})
+}
+
+/**
+ * @description: 判断已有译文是否对应当前原文，没有记录的文件无法判断，视为对应
+ * @param {string} Key 条目的键
+ * @param {string} Source 当前原文
+ * @return {bool} 是否对应
+ */
+func (r sourceRecord) matches(Key, Source string) bool {
+	if r == nil {
+		return true
+	}
+	return r[Key] == hashSource(Source)
+}
+
+/**
+ * @description: 计算原文的哈希
+ * @param {string} Source 原文
+ * @return {string} 哈希的前8字节
+ */
+func hashSource(Source string) string {
+	Sum := sha256.Sum256([]byte(Source))
+	return hex.EncodeToString(Sum[:8])
+}
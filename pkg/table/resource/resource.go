@@ -0,0 +1,392 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-14 09:35:12
+ * @LastEditTime: 2025-07-14 11:48:26
+ * @LastEditors: nijineko
+ * @Description: 本地化资源文件的表格视图，每个可翻译条目为一行
+ * @FilePath: \AutoTranslation\pkg\table\resource\resource.go
+ */
+package resource
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/nijinekoyo/AutoTranslation/internal/log"
+	"github.com/nijinekoyo/AutoTranslation/tools/file"
+)
+
+// 资源文件的条目由文件结构决定，不能插入或删除行
+var ErrFixedRows = errors.New("rows of a resource file cannot be inserted or deleted")
+
+// 表格视图的列，从1开始计数
+const (
+	ColumnSource  = 1 // 原文
+	ColumnTarget  = 2 // 译文
+	ColumnKey     = 3 // 条目的键
+	ColumnContext = 4 // 条目的说明，如译者注释
+)
+
+// 表格视图的表头
+var Header = []string{"source", "target", "key", "context"}
+
+// 传给大语言模型的占位符说明
+const PlaceholderNote = "⟦1⟧、⟦2⟧等为格式说明符或参数的占位符，须原样保留在译文中的对应位置"
+
+// 可翻译条目
+type Entry struct {
+	Key     string // 条目的键，在文件中唯一
+	Source  string // 原文
+	Target  string // 文件中已有的译文，只有双语格式才有
+	Context string // 条目的说明，如译者注释，作为上下文传给大语言模型
+}
+
+// 解析后的资源文件
+type Document interface {
+	Entries() []Entry                                               // 可翻译条目，按文件中的顺序排列
+	Marshal(Translations []string, Language string) ([]byte, error) // 按目标语言写入译文并保持原有结构，译文与条目顺序一致，为空表示未翻译
+}
+
+// 包含多种语言译文的资源文件，如.xcstrings字符串目录，已有的译文取决于目标语言
+type MultilingualDocument interface {
+	Document
+	Targets(Language string) []string // 目标语言已有的译文，与条目顺序一致，未翻译时为空
+}
+
+// 资源文件格式
+type Format struct {
+	Parse     func(Data []byte) (Document, error) // 解析文件内容
+	Bilingual bool                                // 文件同时包含原文和译文，如PO和XLIFF，否则译文写入单独的目标语言文件
+	Document  bool                                // 译文按原文的位置生成整篇文档，如字幕和Markdown，输出文件中找不到原文与译文的对应关系，不读取其中的译文
+	Protect   *regexp.Regexp                      // 须原样保留的内容，如格式说明符，翻译前替换为占位符，写入时还原，为nil时不替换
+}
+
+// 资源文件表格处理结构体
+type ResourceTable struct {
+	filePath   string // 源文件路径
+	outputPath string // 输出文件路径
+	language   string // 目标语言
+
+	document     Document       // 源文件
+	entries      []Entry        // 可翻译条目
+	translations []string       // 各条目的译文
+	protect      *regexp.Regexp // 须原样保留的内容
+	values       [][]string     // 各条目原文中须原样保留的内容，按占位符编号排列
+	record       bool           // 是否记录译文对应的原文，目标语言文件中没有原文时用于发现修改过的原文
+
+	isClosed bool // 是否已关闭
+}
+
+/**
+ * @description: 打开资源文件，输出文件已存在时读取其中的译文，以便跳过已翻译的条目
+ * @param {string} FilePath 源文件路径
+ * @param {string} OutputPath 输出文件路径，为空时覆盖源文件
+ * @param {string} Language 目标语言
+ * @param {Format} Format 文件格式
+ * @return {*ResourceTable} 返回一个新的ResourceTable实例
+ * @return {error} 错误信息
+ */
+func New(FilePath, OutputPath, Language string, Format Format) (*ResourceTable, error) {
+	if OutputPath == "" {
+		OutputPath = FilePath
+	}
+
+	Data, err := os.ReadFile(FilePath)
+	if err != nil {
+		return nil, err
+	}
+	Document, err := Format.Parse(Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", FilePath, err)
+	}
+
+	Entries := Document.Entries()
+	Translations := make([]string, len(Entries))
+	for Index, Entry := range Entries {
+		Translations[Index] = Entry.Target
+	}
+	if Multilingual, ok := Document.(MultilingualDocument); ok {
+		for Index, Target := range Multilingual.Targets(Language) {
+			if Index < len(Entries) && Target != "" {
+				Entries[Index].Target = Target
+				Translations[Index] = Target
+			}
+		}
+	}
+
+	// 单独的目标语言文件中，条目的原文即为译文，整篇文档的译文由翻译记忆库复用
+	Separate := !Format.Document && !file.SamePath(FilePath, OutputPath)
+	if Separate {
+		Existing, err := readTranslations(OutputPath, Format)
+		if err != nil {
+			return nil, err
+		}
+		Changed := 0
+		for Index, Entry := range Entries {
+			Translation, ok := Existing[Entry.Key]
+			if !ok || Translations[Index] != "" {
+				continue
+			}
+			// 原文修改后已有译文不再对应，重新翻译
+			if !Translation.matches(Entry.Key, Entry.Source) {
+				Changed++
+				continue
+			}
+			Translations[Index] = Translation.text
+		}
+		if Changed > 0 {
+			log.Print().Info("Translation", fmt.Sprintf("Source of %d entries in %s changed since the last translation, translating them again", Changed, FilePath))
+		}
+	}
+
+	Values := make([][]string, len(Entries))
+	if Format.Protect != nil {
+		for Index, Entry := range Entries {
+			Values[Index] = Format.Protect.FindAllString(Entry.Source, -1)
+		}
+	}
+
+	return &ResourceTable{
+		filePath:     FilePath,
+		outputPath:   OutputPath,
+		language:     Language,
+		document:     Document,
+		entries:      Entries,
+		translations: Translations,
+		protect:      Format.Protect,
+		values:       Values,
+		record:       Separate && !Format.Bilingual,
+	}, nil
+}
+
+// 输出文件中已有的译文
+type existingTranslation struct {
+	text   string       // 译文
+	source string       // 双语格式中译文对应的原文
+	record sourceRecord // 目标语言文件的原文记录，为nil时无法判断原文是否修改
+}
+
+/**
+ * @description: 判断已有译文是否对应当前原文
+ * @param {string} Key 条目的键
+ * @param {string} Source 当前原文
+ * @return {bool} 是否对应
+ */
+func (e existingTranslation) matches(Key, Source string) bool {
+	if e.record != nil {
+		return e.record.matches(Key, Source)
+	}
+	return e.source == "" || e.source == Source
+}
+
+/**
+ * @description: 读取已存在的输出文件中的译文，双语格式读取译文对应的原文，其他格式读取原文记录
+ * @param {string} OutputPath 输出文件路径
+ * @param {Format} Format 文件格式
+ * @return {map[string]existingTranslation} 译文，键为条目的键，文件不存在时为空
+ * @return {error} 错误信息
+ */
+func readTranslations(OutputPath string, Format Format) (map[string]existingTranslation, error) {
+	Data, err := os.ReadFile(OutputPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	Document, err := Format.Parse(Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", OutputPath, err)
+	}
+
+	var Record sourceRecord
+	if !Format.Bilingual {
+		if Record, err = readRecord(OutputPath); err != nil {
+			return nil, fmt.Errorf("failed to read source record of %s: %w", OutputPath, err)
+		}
+	}
+
+	Translations := map[string]existingTranslation{}
+	for _, Entry := range Document.Entries() {
+		if Format.Bilingual {
+			Translations[Entry.Key] = existingTranslation{text: Entry.Target, source: Entry.Source}
+		} else {
+			Translations[Entry.Key] = existingTranslation{text: Entry.Source, record: Record}
+		}
+	}
+	return Translations, nil
+}
+
+/**
+ * @description: 关闭表格实例
+ * @return {error} 错误信息
+ */
+func (r *ResourceTable) Close() error {
+	r.isClosed = true
+	return nil
+}
+
+/**
+ * @description: 读取表格视图，第一行为表头，之后每行依次为原文、译文、键和说明，须原样保留的内容替换为占位符
+ * @return {[][]string} 表格数据
+ * @return {error} 错误信息
+ */
+func (r *ResourceTable) Read() ([][]string, error) {
+	if r.isClosed {
+		return nil, os.ErrClosed
+	}
+
+	Datas := [][]string{append([]string{}, Header...)}
+	for Index, Entry := range r.entries {
+		Source, Translation, Context := Entry.Source, r.translations[Index], Entry.Context
+		if Values := r.values[Index]; len(Values) > 0 {
+			Source = r.replace(Source, Values)
+			Translation = r.replace(Translation, Values)
+			Context = strings.TrimSpace(Context + "\n" + PlaceholderNote)
+		}
+		Datas = append(Datas, []string{Source, Translation, Entry.Key, Context})
+	}
+	return Datas, nil
+}
+
+/**
+ * @description: 将文本中须原样保留的内容替换为占位符，编号为原文中相同内容的位置，译文中调整了顺序的内容也对应原文的编号
+ * @param {string} Text 原文或已有的译文
+ * @param {[]string} Values 原文中须原样保留的内容
+ * @return {string} 替换后的文本，原文中没有的内容保持不变
+ */
+func (r *ResourceTable) replace(Text string, Values []string) string {
+	Used := make([]bool, len(Values))
+	return r.protect.ReplaceAllStringFunc(Text, func(Match string) string {
+		for Index, Value := range Values {
+			if !Used[Index] && Value == Match {
+				Used[Index] = true
+				return Placeholder(Index + 1)
+			}
+		}
+		return Match
+	})
+}
+
+/**
+ * @description: 按表格视图中的译文列写入输出文件，其他列的修改会被忽略
+ * @param {[][]string} Datas 表格数据，第一行为表头
+ * @return {error} 错误信息
+ */
+func (r *ResourceTable) Write(Datas [][]string) error {
+	if r.isClosed {
+		return os.ErrClosed
+	}
+
+	Translations := make([]string, len(r.entries))
+	for Index, Entry := range r.entries {
+		Row := Index + 1
+		if Row < len(Datas) && len(Datas[Row]) >= ColumnTarget {
+			Translations[Index] = Datas[Row][ColumnTarget-1]
+		}
+
+		// 还原占位符，未修改的已有译文保持原样
+		Values := r.values[Index]
+		if len(Values) == 0 || Translations[Index] == "" {
+			continue
+		}
+		if Translations[Index] == r.replace(r.translations[Index], Values) {
+			Translations[Index] = r.translations[Index]
+			continue
+		}
+		Translation, ok := RestorePlaceholders(Translations[Index], Values)
+		if !ok {
+			log.Print().Warning("Translation", fmt.Sprintf("Translation of %q is missing format placeholders, keeping the source", Entry.Source))
+			Translation = ""
+		}
+		Translations[Index] = Translation
+	}
+
+	Data, err := r.document.Marshal(Translations, r.language)
+	if err != nil {
+		return err
+	}
+	err = file.WriteAtomic(r.outputPath, func(Writer io.Writer) error {
+		_, err := Writer.Write(Data)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if r.record {
+		if err := writeRecord(r.outputPath, r.entries, Translations); err != nil {
+			return err
+		}
+	}
+	r.translations = Translations
+
+	return nil
+}
+
+/**
+ * @description: 更新指定行的数据
+ * @param {int} Row 行号，从1开始计数
+ * @param {[]string} Data 行数据
+ * @return {error} 错误信息
+ */
+func (r *ResourceTable) UpdateLine(Row int, Data []string) error {
+	Datas, err := r.Read()
+	if err != nil {
+		return err
+	}
+	if Row < 1 || Row > len(Datas) {
+		return os.ErrInvalid
+	}
+	Datas[Row-1] = Data
+	return r.Write(Datas)
+}
+
+/**
+ * @description: 更新指定单元格的数据
+ * @param {int} Row 行号，从1开始计数
+ * @param {int} Col 列号，从1开始计数
+ * @param {string} Data 数据内容
+ * @return {error} 错误信息
+ */
+func (r *ResourceTable) UpdateCell(Row, Col int, Data string) error {
+	Datas, err := r.Read()
+	if err != nil {
+		return err
+	}
+	if Row < 1 || Row > len(Datas) || Col < 1 || Col > len(Datas[Row-1]) {
+		return os.ErrInvalid
+	}
+	Datas[Row-1][Col-1] = Data
+	return r.Write(Datas)
+}
+
+/**
+ * @description: 资源文件不能追加行
+ * @param {[]string} Data 新行数据
+ * @return {error} 总是返回ErrFixedRows
+ */
+func (r *ResourceTable) Append(Data []string) error {
+	return ErrFixedRows
+}
+
+/**
+ * @description: 资源文件不能插入行
+ * @param {int} Row 行号，从1开始计数
+ * @param {[]string} Data 新行数据
+ * @return {error} 总是返回ErrFixedRows
+ */
+func (r *ResourceTable) Insert(Row int, Data []string) error {
+	return ErrFixedRows
+}
+
+/**
+ * @description: 资源文件不能删除行
+ * @param {int} Row 行号，从1开始计数
+ * @return {error} 总是返回ErrFixedRows
+ */
+func (r *ResourceTable) Delete(Row int) error {
+	return ErrFixedRows
+}
@@ -0,0 +1,31 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-18 10:05:47
+ * @LastEditTime: 2025-07-18 11:20:36
+ * @LastEditors: nijineko
+ * @Description: printf风格的格式说明符
+ * @FilePath: \AutoTranslation\pkg\table\resource\specifier.go
+ */
+package resource
+
+import (
+	"regexp"
+)
+
+// 格式说明符，如"%d"、"%1$s"、"%@"、"%lld"，不包含空格标记以免误判"50% off"
+var SpecifierPattern = regexp.MustCompile(`%(\d+\$)?[-#+0']*\d*(\.\d+)?(hh|h|ll|l|q|z|t|j|L)?[@diuoxXfFeEgGaAcCsSp%]`)
+
+// MessageFormat和.NET复合格式的参数，如"{0}"、"{1,number,#.##}"、"{0:N2}"
+var ArgumentPattern = regexp.MustCompile(`\{\d+(?:[,:][^{}]*)?\}`)
+
+// 格式说明符和MessageFormat参数，用于两种格式混用的文件
+var FormatPattern = regexp.MustCompile(SpecifierPattern.String() + "|" + ArgumentPattern.String())
+
+/**
+ * @description: 获取文本中的MessageFormat参数
+ * @param {string} Text 文本
+ * @return {[]string} 参数，按出现顺序排列
+ */
+func Arguments(Text string) []string {
+	return ArgumentPattern.FindAllString(Text, -1)
+}
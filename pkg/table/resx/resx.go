@@ -0,0 +1,233 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-22 10:35:26
+ * @LastEditTime: 2025-07-22 15:42:17
+ * @LastEditors: nijineko
+ * @Description: .NET .resx资源文件处理
+ * @FilePath: \AutoTranslation\pkg\table\resx\resx.go
+ */
+package resx
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/nijinekoyo/AutoTranslation/pkg/table/resource"
+)
+
+// .resx资源格式，译文写入目标语言的附属资源文件
+var Format = resource.Format{Parse: Parse, Protect: resource.ArgumentPattern}
+
+// 写入<value>时需要转义的字符
+var escaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// 一个字符串资源的值在文件中的位置
+type value struct {
+	start, end int
+}
+
+// 解析后的.resx文件
+type document struct {
+	data    []byte
+	values  []value // 与条目一一对应
+	entries []resource.Entry
+}
+
+/**
+ * @description: 创建一个新的.resx资源文件处理实例
+ * @param {string} FilePath 资源文件路径
+ * @param {string} OutputPath 输出文件路径
+ * @param {string} Language 目标语言
+ * @return {*resource.ResourceTable} 返回一个新的资源文件表格实例
+ * @return {error} 错误信息
+ */
+func New(FilePath, OutputPath, Language string) (*resource.ResourceTable, error) {
+	return resource.New(FilePath, OutputPath, Language, Format)
+}
+
+/**
+ * @description: 获取目标语言的附属资源文件路径，如"Resources.resx"替换为"Resources.zh-CN.resx"
+ * @param {string} Path 资源文件路径
+ * @param {string} Language 目标语言
+ * @return {string} 目标语言的资源文件路径
+ */
+func LocalePath(Path, Language string) string {
+	return resource.SuffixPath(Path, strings.ReplaceAll(Language, "_", "-"))
+}
+
+/**
+ * @description: 解析.resx文件，只翻译字符串类型的<data>，<comment>作为说明，带有type或mimetype的资源和设计器元数据跳过
+ * @param {[]byte} Data 文件内容
+ * @return {resource.Document} 解析后的文件
+ * @return {error} 错误信息
+ */
+func Parse(Data []byte) (resource.Document, error) {
+	Document := &document{data: Data}
+	Decoder := xml.NewDecoder(bytes.NewReader(Data))
+
+	FoundRoot := false
+	for {
+		Token, err := Decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		Element, ok := Token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		if !FoundRoot {
+			if Element.Name.Local != "root" {
+				return nil, fmt.Errorf("unexpected root element %s", Element.Name.Local)
+			}
+			FoundRoot = true
+			continue
+		}
+		if Element.Name.Local != "data" || !isString(Element) {
+			if err := Decoder.Skip(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err := Document.readData(Decoder, attribute(Element, "name")); err != nil {
+			return nil, err
+		}
+	}
+	if !FoundRoot {
+		return nil, errors.New("missing root element")
+	}
+	return Document, nil
+}
+
+/**
+ * @description: 判断<data>是否为可翻译的字符串资源
+ * @param {xml.StartElement} Element data元素
+ * @return {bool} 是否为字符串资源
+ */
+func isString(Element xml.StartElement) bool {
+	if attribute(Element, "mimetype") != "" {
+		return false
+	}
+	if Type := attribute(Element, "type"); Type != "" && !strings.HasPrefix(Type, "System.String") {
+		return false
+	}
+	// WinForms设计器的元数据，如">>button1.Name"
+	return !strings.HasPrefix(attribute(Element, "name"), ">>")
+}
+
+/**
+ * @description: 读取<data>中的<value>和<comment>
+ * @param {*xml.Decoder} Decoder 解码器，位于data的开始标记之后
+ * @param {string} Name 资源名称
+ * @return {error} 错误信息
+ */
+func (d *document) readData(Decoder *xml.Decoder, Name string) error {
+	var Value *value
+	Source, Comment := "", ""
+	for {
+		Token, err := Decoder.Token()
+		if err != nil {
+			return err
+		}
+		switch Element := Token.(type) {
+		case xml.EndElement:
+			if Value == nil || Source == "" {
+				return nil
+			}
+			d.values = append(d.values, *Value)
+			d.entries = append(d.entries, resource.Entry{Key: Name, Source: Source, Context: Comment})
+			return nil
+		case xml.StartElement:
+			Start := int(Decoder.InputOffset())
+			Text, End, err := readText(Decoder)
+			if err != nil {
+				return err
+			}
+			switch Element.Name.Local {
+			case "value":
+				Value, Source = &value{Start, End}, Text
+			case "comment":
+				Comment = strings.TrimSpace(Text)
+			}
+		}
+	}
+}
+
+/**
+ * @description: 读取当前元素的文本
+ * @param {*xml.Decoder} Decoder 解码器，位于元素的开始标记之后
+ * @return {string} 文本
+ * @return {int} 元素结束标记在文件中的位置
+ * @return {error} 错误信息
+ */
+func readText(Decoder *xml.Decoder) (string, int, error) {
+	var Text strings.Builder
+	for Depth := 0; ; {
+		Before := int(Decoder.InputOffset())
+		Token, err := Decoder.Token()
+		if err != nil {
+			return "", 0, err
+		}
+		switch Element := Token.(type) {
+		case xml.CharData:
+			Text.Write(Element)
+		case xml.StartElement:
+			Depth++
+		case xml.EndElement:
+			if Depth == 0 {
+				return Text.String(), Before, nil
+			}
+			Depth--
+		}
+	}
+}
+
+/**
+ * @description: 获取元素的属性值
+ * @param {xml.StartElement} Element 元素
+ * @param {string} Name 属性名称
+ * @return {string} 属性值，不存在时为空
+ */
+func attribute(Element xml.StartElement, Name string) string {
+	for _, Attribute := range Element.Attr {
+		if Attribute.Name.Local == Name {
+			return Attribute.Value
+		}
+	}
+	return ""
+}
+
+/**
+ * @description: 获取可翻译条目
+ * @return {[]resource.Entry} 可翻译条目
+ */
+func (d *document) Entries() []resource.Entry {
+	return d.entries
+}
+
+/**
+ * @description: 生成目标语言资源文件，只替换<value>的内容，<comment>、资源头和其他资源保持不变，未翻译的条目保留原文
+ * @param {[]string} Translations 各条目的译文
+ * @param {string} Language 目标语言
+ * @return {[]byte} 文件内容
+ * @return {error} 错误信息
+ */
+func (d *document) Marshal(Translations []string, Language string) ([]byte, error) {
+	var Buffer bytes.Buffer
+	Last := 0
+	for Index, Value := range d.values {
+		if Index >= len(Translations) || Translations[Index] == "" {
+			continue
+		}
+		Buffer.Write(d.data[Last:Value.start])
+		Buffer.WriteString(escaper.Replace(Translations[Index]))
+		Last = Value.end
+	}
+	Buffer.Write(d.data[Last:])
+	return Buffer.Bytes(), nil
+}
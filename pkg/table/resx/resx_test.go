@@ -0,0 +1,110 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-22 15:05:48
+ * @LastEditTime: 2025-07-22 15:44:30
+ * @LastEditors: nijineko
+ * @Description: .NET .resx资源文件处理测试
+ * @FilePath: \AutoTranslation\pkg\table\resx\resx_test.go
+ */
+package resx
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/nijinekoyo/AutoTranslation/pkg/table/resource"
+)
+
+const testResx = `<?xml version="1.0" encoding="utf-8"?>
+<root>
+  <resheader name="resmimetype">
+    <value>text/microsoft-resx</value>
+  </resheader>
+  <data name="Greeting" xml:space="preserve">
+    <value>Hello, {0}!</value>
+    <comment>Shown after login</comment>
+  </data>
+  <data name="Terms" xml:space="preserve">
+    <value>Terms &amp; Conditions</value>
+  </data>
+  <data name="Empty" xml:space="preserve">
+    <value />
+  </data>
+  <data name="Logo" type="System.Drawing.Bitmap, System.Drawing" mimetype="application/x-microsoft.net.object.bytearray.base64">
+    <value>iVBORw0KGgo=</value>
+  </data>
+  <data name="&gt;&gt;button1.Name" xml:space="preserve">
+    <value>button1</value>
+  </data>
+</root>
+`
+
+func TestResx_RoundTrip(t *testing.T) {
+	Directory := t.TempDir()
+	FilePath := filepath.Join(Directory, "Strings.resx")
+	OutputPath := LocalePath(FilePath, "de")
+	if err := os.WriteFile(FilePath, []byte(testResx), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	TableInstance, err := New(FilePath, OutputPath, "de")
+	if err != nil {
+		t.Fatal(err)
+	}
+	Datas, err := TableInstance.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 资源头、二进制资源和设计器元数据跳过
+	want := [][]string{
+		{"source", "target", "key", "context"},
+		{"Hello, ⟦1⟧!", "", "Greeting", "Shown after login\n" + resource.PlaceholderNote},
+		{"Terms & Conditions", "", "Terms", ""},
+	}
+	if !reflect.DeepEqual(Datas, want) {
+		t.Fatalf("Read() = %q, want %q", Datas, want)
+	}
+
+	Datas[1][1] = "Hallo, ⟦1⟧!"
+	Datas[2][1] = "AGB & <Bedingungen>"
+	if err := TableInstance.Write(Datas); err != nil {
+		t.Fatal(err)
+	}
+
+	Output := `<?xml version="1.0" encoding="utf-8"?>
+<root>
+  <resheader name="resmimetype">
+    <value>text/microsoft-resx</value>
+  </resheader>
+  <data name="Greeting" xml:space="preserve">
+    <value>Hallo, {0}!</value>
+    <comment>Shown after login</comment>
+  </data>
+  <data name="Terms" xml:space="preserve">
+    <value>AGB &amp; &lt;Bedingungen&gt;</value>
+  </data>
+  <data name="Empty" xml:space="preserve">
+    <value />
+  </data>
+  <data name="Logo" type="System.Drawing.Bitmap, System.Drawing" mimetype="application/x-microsoft.net.object.bytearray.base64">
+    <value>iVBORw0KGgo=</value>
+  </data>
+  <data name="&gt;&gt;button1.Name" xml:space="preserve">
+    <value>button1</value>
+  </data>
+</root>
+`
+	if got, _ := os.ReadFile(filepath.Join(Directory, "Strings.de.resx")); string(got) != Output {
+		t.Errorf("output = %s, want %s", got, Output)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, Content := range []string{`<resources/>`, `<root><data name="a"><value>x</data></root>`, ``} {
+		if _, err := Parse([]byte(Content)); err == nil {
+			t.Errorf("Parse(%q) should fail", Content)
+		}
+	}
+}
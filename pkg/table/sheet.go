@@ -0,0 +1,95 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-08 10:14:06
+ * @LastEditTime: 2025-07-08 11:02:37
+ * @LastEditors: nijineko
+ * @Description: 工作表选择规则
+ * @FilePath: \AutoTranslation\pkg\table\sheet.go
+ */
+package table
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+)
+
+// 工作表选择规则
+type SheetSelector struct {
+	all      bool
+	names    []string
+	patterns []*regexp.Regexp
+}
+
+/**
+ * @description: 创建工作表选择规则，名称和正则都为空且不选择全部时只选择第一个工作表
+ * @param {bool} All 是否选择全部工作表
+ * @param {[]string} Names 工作表名称列表
+ * @param {[]string} Patterns 工作表名称正则列表
+ * @return {*SheetSelector} SheetSelector实例
+ * @return {error} 错误信息
+ */
+func NewSheetSelector(All bool, Names []string, Patterns []string) (*SheetSelector, error) {
+	Selector := &SheetSelector{
+		all:   All,
+		names: Names,
+	}
+	for _, Pattern := range Patterns {
+		Regexp, err := regexp.Compile(Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sheet pattern %q: %w", Pattern, err)
+		}
+		Selector.patterns = append(Selector.patterns, Regexp)
+	}
+	return Selector, nil
+}
+
+/**
+ * @description: 按规则选择工作表
+ * @param {[]string} Sheets 表格中的全部工作表名称
+ * @return {[]string} 选中的工作表名称，保持表格中的顺序
+ * @return {[]string} 表格中不存在的指定名称
+ */
+func (s *SheetSelector) Select(Sheets []string) ([]string, []string) {
+	if len(Sheets) == 0 {
+		return nil, nil
+	}
+	if !s.all && len(s.names) == 0 && len(s.patterns) == 0 {
+		return Sheets[:1], nil
+	}
+
+	var Selected []string
+	for _, Sheet := range Sheets {
+		if s.Match(Sheet) {
+			Selected = append(Selected, Sheet)
+		}
+	}
+
+	var Missing []string
+	if !s.all {
+		for _, Name := range s.names {
+			if !slices.Contains(Sheets, Name) {
+				Missing = append(Missing, Name)
+			}
+		}
+	}
+
+	return Selected, Missing
+}
+
+/**
+ * @description: 判断工作表是否符合规则
+ * @param {string} Sheet 工作表名称
+ * @return {bool} 是否符合
+ */
+func (s *SheetSelector) Match(Sheet string) bool {
+	if s.all || slices.Contains(s.names, Sheet) {
+		return true
+	}
+	for _, Pattern := range s.patterns {
+		if Pattern.MatchString(Sheet) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-08 10:50:44
+ * @LastEditTime: 2025-07-08 10:58:12
+ * @LastEditors: nijineko
+ * @Description: 工作表选择规则测试
+ * @FilePath: \AutoTranslation\pkg\table\sheet_test.go
+ */
+package table
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSheetSelector_Select(t *testing.T) {
+	Sheets := []string{"Menu", "Dialog_01", "Dialog_02", "Notes"}
+
+	tests := []struct {
+		name        string
+		all         bool
+		names       []string
+		patterns    []string
+		want        []string
+		wantMissing []string
+	}{
+		{name: "First sheet by default", want: []string{"Menu"}},
+		{name: "All sheets", all: true, want: Sheets},
+		{name: "Names keep workbook order", names: []string{"Notes", "Menu"}, want: []string{"Menu", "Notes"}},
+		{name: "Patterns", patterns: []string{`^Dialog_\d+$`}, want: []string{"Dialog_01", "Dialog_02"}},
+		{name: "Names and patterns", names: []string{"Menu"}, patterns: []string{`_02$`}, want: []string{"Menu", "Dialog_02"}},
+		{name: "Missing name", names: []string{"Menu", "Credits"}, want: []string{"Menu"}, wantMissing: []string{"Credits"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewSheetSelector(tt.all, tt.names, tt.patterns)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, gotMissing := s.Select(Sheets)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SheetSelector.Select() = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(gotMissing, tt.wantMissing) {
+				t.Errorf("SheetSelector.Select() missing = %v, want %v", gotMissing, tt.wantMissing)
+			}
+		})
+	}
+
+	if _, err := NewSheetSelector(false, nil, []string{"("}); err == nil {
+		t.Errorf("NewSheetSelector() should reject invalid patterns")
+	}
+}
@@ -0,0 +1,86 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-20 13:02:47
+ * @LastEditTime: 2025-07-20 16:35:10
+ * @LastEditors: nijineko
+ * @Description: Advanced SubStation Alpha (.ass/.ssa)字幕处理
+ * @FilePath: \AutoTranslation\pkg\table\subtitle\ass.go
+ */
+package subtitle
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/nijinekoyo/AutoTranslation/pkg/table/resource"
+)
+
+// ASS/SSA资源格式，每条Dialogue为一行，译文写入另一个文件
+var ASSFormat = resource.Format{Parse: ParseASS, Document: true}
+
+var (
+	// 特效标签块，如"{\i1}"、"{\pos(10,20)\c&H00FF00&}"
+	overrideTagPattern = regexp.MustCompile(`\{[^}]*\}`)
+	// 绘图模式，其后的文本为矢量图形
+	drawingPattern = regexp.MustCompile(`\\p[1-9]`)
+)
+
+// 未声明Format时Events中的字段数
+const defaultEventFields = 10
+
+/**
+ * @description: 创建一个新的ASS/SSA字幕处理实例
+ * @param {string} FilePath 字幕文件路径
+ * @param {string} OutputPath 输出文件路径
+ * @param {string} Language 目标语言
+ * @return {*resource.ResourceTable} 返回一个新的资源文件表格实例
+ * @return {error} 错误信息
+ */
+func NewASS(FilePath, OutputPath, Language string) (*resource.ResourceTable, error) {
+	return resource.New(FilePath, OutputPath, Language, ASSFormat)
+}
+
+/**
+ * @description: 解析ASS/SSA字幕，只翻译[Events]中Dialogue的Text字段，时间轴、样式和特效标签保持不变，\N转换为换行
+ * @param {[]byte} Data 文件内容
+ * @return {resource.Document} 解析后的文件
+ * @return {error} 错误信息
+ */
+func ParseASS(Data []byte) (resource.Document, error) {
+	Document := newDocument(Data, true)
+	Section := ""
+	Fields := defaultEventFields
+	for Index, Line := range Document.lines {
+		Trimmed := strings.TrimSpace(Line)
+		if strings.HasPrefix(Trimmed, "[") && strings.HasSuffix(Trimmed, "]") {
+			Section = strings.ToLower(Trimmed)
+			continue
+		}
+		if Section != "[events]" {
+			continue
+		}
+
+		Name, Value, Found := strings.Cut(Line, ":")
+		if !Found {
+			continue
+		}
+		switch strings.TrimSpace(Name) {
+		case "Format":
+			// Text始终是最后一个字段
+			Fields = len(strings.Split(Value, ","))
+		case "Dialogue":
+			Values := strings.SplitN(Value, ",", Fields)
+			if len(Values) < Fields {
+				return nil, fmt.Errorf("line %d: dialogue has %d fields, want %d", Index+1, len(Values), Fields)
+			}
+			Text := Values[Fields-1]
+			if drawingPattern.MatchString(strings.Join(overrideTagPattern.FindAllString(Text, -1), "")) {
+				continue
+			}
+			Document.addCue(cue{first: Index, last: Index + 1, prefix: Line[:len(Line)-len(Text)]}, strings.ReplaceAll(Text, `\N`, "\n"), overrideTagPattern)
+		}
+	}
+	Document.addContext()
+	return Document, nil
+}
@@ -0,0 +1,94 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-20 10:40:18
+ * @LastEditTime: 2025-07-20 16:20:55
+ * @LastEditors: nijineko
+ * @Description: SubRip (.srt)字幕处理
+ * @FilePath: \AutoTranslation\pkg\table\subtitle\srt.go
+ */
+package subtitle
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/nijinekoyo/AutoTranslation/pkg/table/resource"
+)
+
+// SRT资源格式，每条字幕为一行，译文写入另一个文件
+var SRTFormat = resource.Format{Parse: ParseSRT, Document: true}
+
+// SRT和WebVTT中的样式标签，如"<i>"、"<font color=red>"、"<v Roger>"，以及部分播放器支持的"{\an8}"
+var markupTagPattern = regexp.MustCompile(`<[^>\n]*>|\{\\[^}\n]*\}`)
+
+/**
+ * @description: 创建一个新的SRT字幕处理实例
+ * @param {string} FilePath 字幕文件路径
+ * @param {string} OutputPath 输出文件路径
+ * @param {string} Language 目标语言
+ * @return {*resource.ResourceTable} 返回一个新的资源文件表格实例
+ * @return {error} 错误信息
+ */
+func NewSRT(FilePath, OutputPath, Language string) (*resource.ResourceTable, error) {
+	return resource.New(FilePath, OutputPath, Language, SRTFormat)
+}
+
+/**
+ * @description: 解析SRT字幕，序号和时间轴保持不变，时间轴之后的行为字幕文本
+ * @param {[]byte} Data 文件内容
+ * @return {resource.Document} 解析后的文件
+ * @return {error} 错误信息
+ */
+func ParseSRT(Data []byte) (resource.Document, error) {
+	Document := newDocument(Data, false)
+	for _, Block := range splitBlocks(Document.lines) {
+		Timing := timingLine(Document.lines, Block)
+		if Timing < 0 {
+			return nil, fmt.Errorf("line %d: missing timing line", Block[0]+1)
+		}
+		Document.addCue(cue{first: Timing + 1, last: Block[1]}, strings.Join(Document.lines[Timing+1:Block[1]], "\n"), markupTagPattern)
+	}
+	Document.addContext()
+	return Document, nil
+}
+
+/**
+ * @description: 按空行拆分字幕块
+ * @param {[]string} Lines 文件的行
+ * @return {[][2]int} 各块的起始行和结束行，不包括结束行
+ */
+func splitBlocks(Lines []string) [][2]int {
+	var Blocks [][2]int
+	Start := -1
+	for Index, Line := range Lines {
+		switch {
+		case strings.TrimSpace(Line) == "":
+			if Start >= 0 {
+				Blocks = append(Blocks, [2]int{Start, Index})
+				Start = -1
+			}
+		case Start < 0:
+			Start = Index
+		}
+	}
+	if Start >= 0 {
+		Blocks = append(Blocks, [2]int{Start, len(Lines)})
+	}
+	return Blocks
+}
+
+/**
+ * @description: 查找字幕块中的时间轴行
+ * @param {[]string} Lines 文件的行
+ * @param {[2]int} Block 字幕块
+ * @return {int} 时间轴所在的行，不存在时返回-1
+ */
+func timingLine(Lines []string, Block [2]int) int {
+	for Index := Block[0]; Index < Block[1]; Index++ {
+		if strings.Contains(Lines[Index], "-->") {
+			return Index
+		}
+	}
+	return -1
+}
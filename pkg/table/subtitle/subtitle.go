@@ -0,0 +1,202 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-20 10:12:36
+ * @LastEditTime: 2025-07-20 16:47:21
+ * @LastEditors: nijineko
+ * @Description: 字幕文件处理，每条字幕为一行
+ * @FilePath: \AutoTranslation\pkg\table\subtitle\subtitle.go
+ */
+package subtitle
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/nijinekoyo/AutoTranslation/internal/log"
+	"github.com/nijinekoyo/AutoTranslation/pkg/table/resource"
+)
+
+// 传给大语言模型的占位符说明
+const placeholderNote = "⟦1⟧等为字幕样式标签的占位符，须原样保留在译文中的对应位置"
+
+// WebVTT文本中需要转换为字符引用的字符
+var entityEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// 一条字幕
+type cue struct {
+	first, last int    // 文本所在的行，不包括last
+	prefix      string // ASS中Text字段之前的内容，SRT和WebVTT为空
+	leading     string // 文本开头的标签，如"{\an8}"、"<i>"
+	trailing    string // 文本结尾的标签，如"</i>"
+	tags        []string
+}
+
+// 解析后的字幕文件
+type document struct {
+	lines        []string // 原始行，不包含换行符
+	lineEnding   string   // 换行符
+	finalNewline bool     // 文件是否以换行结尾
+	bom          bool     // 文件是否带有UTF-8 BOM
+	ass          bool     // 是否为ASS/SSA，换行写为\N
+	entities     bool     // 是否为WebVTT，文本中的&amp;等字符引用需要转换
+	cues         []cue
+	entries      []resource.Entry
+	keys         resource.TextKeys // 按原文生成的条目键
+}
+
+/**
+ * @description: 获取与原字幕文件放在一起的目标语言字幕文件路径，如"movie.srt"替换为"movie.zh-CN.srt"，已有的语言后缀被替换
+ * @param {string} Path 字幕文件路径
+ * @param {string} Language 目标语言
+ * @return {string} 目标语言的字幕文件路径
+ */
+func LocalePath(Path, Language string) string {
+	return resource.SuffixPath(Path, Language)
+}
+
+/**
+ * @description: 拆分文件内容为行，记录换行符、结尾换行和BOM
+ * @param {[]byte} Data 文件内容
+ * @param {bool} ASS 是否为ASS/SSA
+ * @return {*document} 未包含字幕的文件
+ */
+func newDocument(Data []byte, ASS bool) *document {
+	Text, HasBOM := strings.CutPrefix(string(Data), "\uFEFF")
+	Document := &document{lineEnding: "\n", finalNewline: strings.HasSuffix(Text, "\n"), bom: HasBOM, ass: ASS, keys: resource.TextKeys{}}
+	if strings.Contains(Text, "\r\n") {
+		Document.lineEnding = "\r\n"
+		Text = strings.ReplaceAll(Text, "\r\n", "\n")
+	}
+	Document.lines = strings.Split(strings.TrimSuffix(Text, "\n"), "\n")
+	return Document
+}
+
+/**
+ * @description: 添加一条字幕，首尾的标签去除，中间的标签替换为占位符
+ * @param {cue} Cue 字幕的位置
+ * @param {string} Text 字幕文本
+ * @param {*regexp.Regexp} TagPattern 标签的格式
+ */
+func (d *document) addCue(Cue cue, Text string, TagPattern *regexp.Regexp) {
+	Matches := TagPattern.FindAllStringIndex(Text, -1)
+
+	// 首尾连续的标签
+	Start, End := 0, len(Text)
+	First, Last := 0, len(Matches)
+	for First < len(Matches) && Matches[First][0] == Start {
+		Start = Matches[First][1]
+		First++
+	}
+	for Last > First && Matches[Last-1][1] == End {
+		End = Matches[Last-1][0]
+		Last--
+	}
+	if strings.TrimSpace(Text[Start:End]) == "" {
+		return
+	}
+	Cue.leading, Cue.trailing = Text[:Start], Text[End:]
+
+	var Builder strings.Builder
+	Offset := Start
+	for _, Match := range Matches[First:Last] {
+		Builder.WriteString(d.unescape(Text[Offset:Match[0]]))
+		Cue.tags = append(Cue.tags, Text[Match[0]:Match[1]])
+		Builder.WriteString(resource.Placeholder(len(Cue.tags)))
+		Offset = Match[1]
+	}
+	Builder.WriteString(d.unescape(Text[Offset:End]))
+
+	d.cues = append(d.cues, Cue)
+	d.entries = append(d.entries, resource.Entry{Key: d.keys.Next(Builder.String()), Source: Builder.String()})
+}
+
+/**
+ * @description: 转换WebVTT文本中的字符引用
+ * @param {string} Text 文本
+ * @return {string} 转换后的文本
+ */
+func (d *document) unescape(Text string) string {
+	if !d.entities {
+		return Text
+	}
+	return html.UnescapeString(Text)
+}
+
+/**
+ * @description: 以相邻的字幕作为条目的说明
+ */
+func (d *document) addContext() {
+	for Index := range d.entries {
+		var Context []string
+		if Index > 0 {
+			Context = append(Context, "上一条字幕："+d.entries[Index-1].Source)
+		}
+		if Index+1 < len(d.entries) {
+			Context = append(Context, "下一条字幕："+d.entries[Index+1].Source)
+		}
+		if len(d.cues[Index].tags) > 0 {
+			Context = append(Context, placeholderNote)
+		}
+		d.entries[Index].Context = strings.Join(Context, "\n")
+	}
+}
+
+/**
+ * @description: 获取可翻译条目
+ * @return {[]resource.Entry} 可翻译条目
+ */
+func (d *document) Entries() []resource.Entry {
+	return d.entries
+}
+
+/**
+ * @description: 生成目标语言字幕，时间轴、样式和标签保持不变，未翻译或译文遗漏标签占位符的字幕保留原文
+ * @param {[]string} Translations 各条目的译文
+ * @param {string} Language 目标语言
+ * @return {[]byte} 文件内容
+ * @return {error} 错误信息
+ */
+func (d *document) Marshal(Translations []string, Language string) ([]byte, error) {
+	var Lines []string
+	Last := 0
+	for Index, Cue := range d.cues {
+		if Index >= len(Translations) || Translations[Index] == "" {
+			continue
+		}
+		Text := Translations[Index]
+		if d.entities {
+			Text = entityEscaper.Replace(Text)
+		}
+		// 译文遗漏了标签的占位符时保留原字幕，避免样式丢失
+		Text, ok := resource.RestorePlaceholders(Text, Cue.tags)
+		if !ok {
+			log.Print().Warning("Translation", fmt.Sprintf("Translation of cue %q is missing style tag placeholders, keeping the source", d.entries[Index].Source))
+			continue
+		}
+		Text = Cue.leading + Text + Cue.trailing
+		Lines = append(Lines, d.lines[Last:Cue.first]...)
+		if d.ass {
+			Lines = append(Lines, Cue.prefix+strings.ReplaceAll(Text, "\n", `\N`))
+		} else {
+			// 空行会结束字幕，译文中的空行去除
+			for _, Line := range strings.Split(Text, "\n") {
+				if strings.TrimSpace(Line) != "" {
+					Lines = append(Lines, Line)
+				}
+			}
+		}
+		Last = Cue.last
+	}
+	Lines = append(Lines, d.lines[Last:]...)
+
+	Text := strings.Join(Lines, d.lineEnding)
+	if d.finalNewline {
+		Text += d.lineEnding
+	}
+	if d.bom {
+		Text = "\uFEFF" + Text
+	}
+	return []byte(Text), nil
+}
@@ -0,0 +1,239 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-20 14:10:52
+ * @LastEditTime: 2025-07-20 16:52:38
+ * @LastEditors: nijineko
+ * @Description: 字幕文件处理测试
+ * @FilePath: \AutoTranslation\pkg\table\subtitle\subtitle_test.go
+ */
+package subtitle
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSRT_RoundTrip(t *testing.T) {
+	Directory := t.TempDir()
+	FilePath := filepath.Join(Directory, "movie.en.srt")
+	OutputPath := LocalePath(FilePath, "zh-CN")
+	Content := "1\r\n00:00:01,000 --> 00:00:02,500\r\n{\\an8}<i>Hello!</i>\r\n\r\n2\r\n00:00:03,000 --> 00:00:05,000\r\nI said <b>no</b>,\r\nreally.\r\n\r\n3\r\n00:00:06,000 --> 00:00:07,000\r\n<i></i>\r\n"
+	if err := os.WriteFile(FilePath, []byte(Content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	TableInstance, err := NewSRT(FilePath, OutputPath, "zh-CN")
+	if err != nil {
+		t.Fatal(err)
+	}
+	Datas, err := TableInstance.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 首尾的标签不传给翻译，中间的标签替换为占位符，相邻的字幕作为说明
+	want := [][]string{
+		{"source", "target", "key", "context"},
+		{"Hello!", "", "334d016f755cd6dc", "下一条字幕：I said ⟦1⟧no⟦2⟧,\nreally."},
+		{"I said ⟦1⟧no⟦2⟧,\nreally.", "", "20649c41044d8fbd", "上一条字幕：Hello!\n" + placeholderNote},
+	}
+	if !reflect.DeepEqual(Datas, want) {
+		t.Fatalf("Read() = %q, want %q", Datas, want)
+	}
+
+	Datas[1][1] = "你好！"
+	Datas[2][1] = "我说了⟦1⟧不⟦2⟧，\n\n真的。"
+	if err := TableInstance.Write(Datas); err != nil {
+		t.Fatal(err)
+	}
+
+	Output := "1\r\n00:00:01,000 --> 00:00:02,500\r\n{\\an8}<i>你好！</i>\r\n\r\n2\r\n00:00:03,000 --> 00:00:05,000\r\n我说了<b>不</b>，\r\n真的。\r\n\r\n3\r\n00:00:06,000 --> 00:00:07,000\r\n<i></i>\r\n"
+	if got, _ := os.ReadFile(filepath.Join(Directory, "movie.zh-CN.srt")); string(got) != Output {
+		t.Errorf("output = %q, want %q", got, Output)
+	}
+
+	// 原文插入字幕后，其他字幕的键不变，也不会读取输出文件中按位置错开的译文
+	Content = "1\r\n00:00:00,000 --> 00:00:00,500\r\nWelcome.\r\n\r\n" + Content
+	if err := os.WriteFile(FilePath, []byte(Content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	TableInstance, err = NewSRT(FilePath, OutputPath, "zh-CN")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if Datas, _ = TableInstance.Read(); len(Datas) != 4 || Datas[2][2] != "334d016f755cd6dc" || Datas[1][1] != "" || Datas[2][1] != "" || Datas[3][1] != "" {
+		t.Errorf("Read() after inserting a cue = %q", Datas)
+	}
+}
+
+func TestDuplicateKeys(t *testing.T) {
+	Document, err := ParseSRT([]byte("1\n00:00:01,000 --> 00:00:02,000\nYes.\n\n2\n00:00:03,000 --> 00:00:04,000\nNo.\n\n3\n00:00:05,000 --> 00:00:06,000\nYes.\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, Entry := range Document.Entries() {
+		got = append(got, Entry.Key)
+	}
+	// 相同原文的字幕按出现顺序区分
+	if want := []string{got[0], got[1], got[0] + "#2"}; !reflect.DeepEqual(got, want) || got[0] == got[1] {
+		t.Errorf("Entries() keys = %q", got)
+	}
+}
+
+func TestVTT_RoundTrip(t *testing.T) {
+	FilePath := filepath.Join(t.TempDir(), "talk.vtt")
+	Content := `WEBVTT - Talk
+
+NOTE This is a comment
+
+STYLE
+::cue(.loud) { color: red }
+
+intro
+00:00:01.000 --> 00:00:04.000 align:start line:0%
+<v Roger>Tom &amp; Jerry</v>
+
+00:00:05.000 --> 00:00:06.000
+Bye <c.loud>now</c>!
+`
+	if err := os.WriteFile(FilePath, []byte(Content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	TableInstance, err := NewVTT(FilePath, LocalePath(FilePath, "ja"), "ja")
+	if err != nil {
+		t.Fatal(err)
+	}
+	Datas, err := TableInstance.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(Datas) != 3 || Datas[1][0] != "Tom & Jerry" || Datas[2][0] != "Bye ⟦1⟧now⟦2⟧!" {
+		t.Fatalf("Read() = %q", Datas)
+	}
+	Datas[1][1] = "トム＆ジェリー <笑>"
+	Datas[2][1] = "⟦1⟧じゃあ⟦2⟧ね！"
+	if err := TableInstance.Write(Datas); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `WEBVTT - Talk
+
+NOTE This is a comment
+
+STYLE
+::cue(.loud) { color: red }
+
+intro
+00:00:01.000 --> 00:00:04.000 align:start line:0%
+<v Roger>トム＆ジェリー &lt;笑&gt;</v>
+
+00:00:05.000 --> 00:00:06.000
+<c.loud>じゃあ</c>ね！
+`
+	if got, _ := os.ReadFile(filepath.Join(filepath.Dir(FilePath), "talk.ja.vtt")); string(got) != want {
+		t.Errorf("output = %s, want %s", got, want)
+	}
+
+	if _, err := ParseVTT([]byte("1\n00:00:01.000 --> 00:00:02.000\nHi\n")); err != ErrNotWebVTT {
+		t.Errorf("ParseVTT() without header error = %v", err)
+	}
+}
+
+func TestASS_RoundTrip(t *testing.T) {
+	FilePath := filepath.Join(t.TempDir(), "episode.ass")
+	Content := `[Script Info]
+Title: Episode 1
+ScriptType: v4.00+
+
+[V4+ Styles]
+Format: Name, Fontname, Fontsize, PrimaryColour, SecondaryColour, OutlineColour, BackColour, Bold, Italic, Underline, StrikeOut, ScaleX, ScaleY, Spacing, Angle, BorderStyle, Outline, Shadow, Alignment, MarginL, MarginR, MarginV, Encoding
+Style: Default,Arial,20,&H00FFFFFF,&H000000FF,&H00000000,&H00000000,0,0,0,0,100,100,0,0,1,2,2,2,10,10,10,1
+
+[Events]
+Format: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text
+Dialogue: 0,0:00:01.00,0:00:03.00,Default,Alice,0,0,0,,{\i1}Wait, what?{\i0}
+Comment: 0,0:00:02.00,0:00:03.00,Default,,0,0,0,,Timing note
+Dialogue: 0,0:00:03.00,0:00:05.00,Default,Bob,0,0,0,,{\pos(320,50)}It's {\b1}fine{\b0}.\NReally.
+Dialogue: 0,0:00:05.00,0:00:06.00,Sign,,0,0,0,,{\p1}m 0 0 l 100 0 100 100{\p0}
+`
+	if err := os.WriteFile(FilePath, []byte(Content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	TableInstance, err := NewASS(FilePath, LocalePath(FilePath, "de"), "de")
+	if err != nil {
+		t.Fatal(err)
+	}
+	Datas, err := TableInstance.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 特效标签不传给翻译，绘图和Comment跳过
+	want := [][]string{
+		{"source", "target", "key", "context"},
+		{"Wait, what?", "", "3fe2a07691e51426", "下一条字幕：It's ⟦1⟧fine⟦2⟧.\nReally."},
+		{"It's ⟦1⟧fine⟦2⟧.\nReally.", "", "dd176223ff11edf0", "上一条字幕：Wait, what?\n" + placeholderNote},
+	}
+	if !reflect.DeepEqual(Datas, want) {
+		t.Fatalf("Read() = %q, want %q", Datas, want)
+	}
+
+	Datas[1][1] = "Moment, was?"
+	Datas[2][1] = "Es ist ⟦1⟧gut⟦2⟧.\nWirklich."
+	if err := TableInstance.Write(Datas); err != nil {
+		t.Fatal(err)
+	}
+
+	Output := `[Script Info]
+Title: Episode 1
+ScriptType: v4.00+
+
+[V4+ Styles]
+Format: Name, Fontname, Fontsize, PrimaryColour, SecondaryColour, OutlineColour, BackColour, Bold, Italic, Underline, StrikeOut, ScaleX, ScaleY, Spacing, Angle, BorderStyle, Outline, Shadow, Alignment, MarginL, MarginR, MarginV, Encoding
+Style: Default,Arial,20,&H00FFFFFF,&H000000FF,&H00000000,&H00000000,0,0,0,0,100,100,0,0,1,2,2,2,10,10,10,1
+
+[Events]
+Format: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text
+Dialogue: 0,0:00:01.00,0:00:03.00,Default,Alice,0,0,0,,{\i1}Moment, was?{\i0}
+Comment: 0,0:00:02.00,0:00:03.00,Default,,0,0,0,,Timing note
+Dialogue: 0,0:00:03.00,0:00:05.00,Default,Bob,0,0,0,,{\pos(320,50)}Es ist {\b1}gut{\b0}.\NWirklich.
+Dialogue: 0,0:00:05.00,0:00:06.00,Sign,,0,0,0,,{\p1}m 0 0 l 100 0 100 100{\p0}
+`
+	if got, _ := os.ReadFile(filepath.Join(filepath.Dir(FilePath), "episode.de.ass")); string(got) != Output {
+		t.Errorf("output = %s, want %s", got, Output)
+	}
+}
+
+func TestLocalePath(t *testing.T) {
+	for Path, want := range map[string]string{
+		"movie.srt":       "movie.zh-CN.srt",
+		"movie.en.srt":    "movie.zh-CN.srt",
+		"movie.en_US.vtt": "movie.zh-CN.vtt",
+		"movie.2024.ass":  "movie.2024.zh-CN.ass",
+		"Mr.Robot.srt":    "Mr.Robot.zh-CN.srt",
+	} {
+		if got := LocalePath(Path, "zh-CN"); got != want {
+			t.Errorf("LocalePath(%q) = %q, want %q", Path, got, want)
+		}
+	}
+}
+
+func TestMissingPlaceholder(t *testing.T) {
+	Content := "1\n00:00:01,000 --> 00:00:02,000\nI said <b>no</b>.\n\n2\n00:00:03,000 --> 00:00:04,000\nBye.\n"
+	Document, err := ParseSRT([]byte(Content))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 遗漏标签占位符的译文不写入，保留原字幕
+	got, err := Document.Marshal([]string{"我说了不。", "再见。"}, "zh-CN")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "1\n00:00:01,000 --> 00:00:02,000\nI said <b>no</b>.\n\n2\n00:00:03,000 --> 00:00:04,000\n再见。\n"; string(got) != want {
+		t.Errorf("Marshal() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,58 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-20 11:25:03
+ * @LastEditTime: 2025-07-20 16:20:55
+ * @LastEditors: nijineko
+ * @Description: WebVTT (.vtt)字幕处理
+ * @FilePath: \AutoTranslation\pkg\table\subtitle\vtt.go
+ */
+package subtitle
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/nijinekoyo/AutoTranslation/pkg/table/resource"
+)
+
+// WebVTT资源格式，每条字幕为一行，译文写入另一个文件
+var VTTFormat = resource.Format{Parse: ParseVTT, Document: true}
+
+// 文件不是以WEBVTT开头的WebVTT字幕
+var ErrNotWebVTT = errors.New("missing WEBVTT header")
+
+/**
+ * @description: 创建一个新的WebVTT字幕处理实例
+ * @param {string} FilePath 字幕文件路径
+ * @param {string} OutputPath 输出文件路径
+ * @param {string} Language 目标语言
+ * @return {*resource.ResourceTable} 返回一个新的资源文件表格实例
+ * @return {error} 错误信息
+ */
+func NewVTT(FilePath, OutputPath, Language string) (*resource.ResourceTable, error) {
+	return resource.New(FilePath, OutputPath, Language, VTTFormat)
+}
+
+/**
+ * @description: 解析WebVTT字幕，文件头、NOTE、STYLE和REGION块以及时间轴和设置保持不变，时间轴之后的行为字幕文本
+ * @param {[]byte} Data 文件内容
+ * @return {resource.Document} 解析后的文件
+ * @return {error} 错误信息
+ */
+func ParseVTT(Data []byte) (resource.Document, error) {
+	Document := newDocument(Data, false)
+	Document.entities = true
+	if !strings.HasPrefix(Document.lines[0], "WEBVTT") {
+		return nil, ErrNotWebVTT
+	}
+	for _, Block := range splitBlocks(Document.lines)[1:] {
+		// 没有时间轴的块不是字幕
+		Timing := timingLine(Document.lines, Block)
+		if Timing < 0 {
+			continue
+		}
+		Document.addCue(cue{first: Timing + 1, last: Block[1]}, strings.Join(Document.lines[Timing+1:Block[1]], "\n"), markupTagPattern)
+	}
+	Document.addContext()
+	return Document, nil
+}
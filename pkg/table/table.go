@@ -20,6 +20,57 @@ type Table interface {
 	Delete(Row int) error
 }
 
+// 多工作表表格接口，读写操作作用于当前选中的工作表
+type SheetTable interface {
+	Table
+	Sheets() []string              // 按顺序获取全部工作表名称
+	SelectSheet(Name string) error // 选中工作表
+}
+
+// 单元格更新
+type CellUpdate struct {
+	Row         int    // 行号，从1开始计数
+	Column      int    // 列号，从1开始计数
+	Value       string // 单元格内容
+	StyleColumn int    // 继承同一行中该列单元格的样式，从1开始计数，0表示不继承
+}
+
+// 单元格级读写接口，只修改指定的单元格，保留其他单元格的类型、样式、公式和富文本
+type CellTable interface {
+	Table
+	IsFormula(Row, Col int) (bool, error)  // 判断单元格是否为公式，行号和列号从1开始计数
+	WriteCells(Updates []CellUpdate) error // 写入指定的单元格
+}
+
+// 流式读取的行迭代器
+type RowReader interface {
+	Next() bool    // 读取下一行，没有更多行或出错时返回false
+	Row() []string // 当前行数据
+	Err() error    // 读取过程中的错误
+	Close() error
+}
+
+// 流式逐行写入器
+type RowWriter interface {
+	WriteRow(Row []string) error // 按顺序写入下一行
+	Flush() error                // 将已写入的行写入文件
+	Close() error                // 完成写入，已写入的行替换当前工作表的全部内容
+	Abort() error                // 放弃写入，当前工作表保持不变
+}
+
+// 流式读写接口，用于无法一次读入内存的大文件
+type StreamTable interface {
+	Table
+	ReadRows() (RowReader, error)  // 从当前工作表的第一行开始流式读取
+	WriteRows() (RowWriter, error) // 创建当前工作表的逐行写入器
+}
+
+// 关闭时保存文件的表格实现该接口，只读取数据时使用Discard关闭，不写入输出文件
+type DiscardTable interface {
+	Table
+	Discard() error // 放弃修改并关闭表格实例
+}
+
 /**
  * @description: 读取表格数据
  * @param {Table} TableInstance 表格实例
@@ -0,0 +1,91 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-17 17:05:33
+ * @LastEditTime: 2025-07-17 18:12:09
+ * @LastEditors: nijineko
+ * @Description: 将表格导出为XLIFF 1.2文件
+ * @FilePath: \AutoTranslation\pkg\table\xliff\export.go
+ */
+package xliff
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// 导出的文件，对应一个file元素
+type ExportFile struct {
+	Original       string     // 表格或工作表名称，写入file的original属性
+	SourceLanguage string     // 源语言，为空时写入"und"
+	TargetLanguage string     // 目标语言
+	Rows           [][]string // 表格数据
+	SourceColumn   int        // 原文列索引，从0开始计数
+	TargetColumn   int        // 译文列索引，从0开始计数，为-1或超出行长度时只导出原文
+	ContextColumn  int        // 说明列索引，从0开始计数，为-1时不导出note
+	SkipHeader     bool       // 第一行是否为表头
+}
+
+/**
+ * @description: 将表格中的原文列和译文列导出为XLIFF 1.2文件，每个非空原文单元格为一个trans-unit，id为从1开始的行号，已有译文标记为待审校
+ * @param {io.Writer} Writer 输出
+ * @param {[]ExportFile} Files 导出的文件，通常每个工作表的每组列映射为一个
+ * @return {int} 导出的条目数量
+ * @return {error} 错误信息
+ */
+func Export(Writer io.Writer, Files []ExportFile) (int, error) {
+	Buffer := bufio.NewWriter(Writer)
+	fmt.Fprint(Buffer, `<?xml version="1.0" encoding="UTF-8"?>`+"\n")
+	fmt.Fprint(Buffer, `<xliff version="1.2" xmlns="urn:oasis:names:tc:xliff:document:1.2">`+"\n")
+
+	Count := 0
+	for _, File := range Files {
+		Count += exportFile(Buffer, File)
+	}
+
+	fmt.Fprint(Buffer, "</xliff>\n")
+	return Count, Buffer.Flush()
+}
+
+/**
+ * @description: 将一组列导出为file元素
+ * @param {*bufio.Writer} Buffer 输出
+ * @param {ExportFile} File 导出的文件
+ * @return {int} 导出的条目数量
+ */
+func exportFile(Buffer *bufio.Writer, File ExportFile) int {
+	SourceLanguage := File.SourceLanguage
+	if SourceLanguage == "" {
+		SourceLanguage = "und"
+	}
+	Cell := func(Row []string, Index int) string {
+		if Index < 0 || Index >= len(Row) {
+			return ""
+		}
+		return Row[Index]
+	}
+
+	fmt.Fprintf(Buffer, `  <file original="%s" source-language="%s" target-language="%s" datatype="plaintext">`+"\n", escapeAttribute(File.Original), escapeAttribute(SourceLanguage), escapeAttribute(File.TargetLanguage))
+	fmt.Fprint(Buffer, "    <body>\n")
+	Count := 0
+	for Index, Row := range File.Rows {
+		Source := Cell(Row, File.SourceColumn)
+		if (File.SkipHeader && Index == 0) || Source == "" {
+			continue
+		}
+
+		fmt.Fprintf(Buffer, `      <trans-unit id="%d">`+"\n", Index+1)
+		fmt.Fprintf(Buffer, "        <source>%s</source>\n", escapeText(Source))
+		if Target := Cell(Row, File.TargetColumn); Target != "" {
+			fmt.Fprintf(Buffer, `        <target state="%s">%s</target>`+"\n", StateV1, escapeText(Target))
+		}
+		if Context := Cell(Row, File.ContextColumn); Context != "" {
+			fmt.Fprintf(Buffer, "        <note>%s</note>\n", escapeText(Context))
+		}
+		fmt.Fprint(Buffer, "      </trans-unit>\n")
+		Count++
+	}
+	fmt.Fprint(Buffer, "    </body>\n")
+	fmt.Fprint(Buffer, "  </file>\n")
+	return Count
+}
@@ -0,0 +1,566 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-17 09:35:12
+ * @LastEditTime: 2025-07-17 16:48:20
+ * @LastEditors: nijineko
+ * @Description: XLIFF 1.2/2.0翻译交换文件处理
+ * @FilePath: \AutoTranslation\pkg\table\xliff\xliff.go
+ */
+package xliff
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/nijinekoyo/AutoTranslation/internal/log"
+	"github.com/nijinekoyo/AutoTranslation/pkg/table/resource"
+)
+
+// 资源文件格式，XLIFF文件同时包含原文和译文
+var Format = resource.Format{Parse: Parse, Bilingual: true}
+
+// 写入译文后的状态
+const (
+	StateV1 = "needs-review-translation" // XLIFF 1.2写入target的state属性
+	StateV2 = "translated"               // XLIFF 2.0写入segment的state属性，2.0没有待审校状态
+)
+
+// 开始标记中的元素名称，可能带有命名空间前缀
+var tagNamePattern = regexp.MustCompile(`^<([^\s/>]+)`)
+
+// 传给大语言模型的占位符说明
+const placeholderNote = "⟦1⟧、⟦/1⟧等为内联标记的占位符，须原样保留在译文中的对应位置"
+
+// 成对的内联标记，内容可翻译，其他内联标记连同内容整体作为一个占位符
+var pairedTags = []string{"g", "pc", "mrk"}
+
+// 文件中的一段原始内容
+type span struct {
+	start, end int
+}
+
+// 可翻译的片段，XLIFF 1.2的trans-unit或XLIFF 2.0的segment
+type segment struct {
+	key    string
+	file   int // 所在file元素的索引
+	source string
+	tags   map[string]string // 原文中的占位符，值为原始内联标记
+
+	sourceEnd int    // </source>之后的位置，没有译文时在此插入target
+	indent    string // source所在行的缩进
+
+	target       *span  // 已有的target元素，没有时为nil
+	targetStart  string // 已有target的开始标记
+	targetText   string // 已有的译文
+	stateTag     *span  // XLIFF 2.0中segment的开始标记
+	untranslated bool   // XLIFF 1.2中target的state表示未翻译
+}
+
+// 解析后的XLIFF文件
+type document struct {
+	data         []byte
+	version      int    // 主版本号，1或2
+	languageTags []span // 需要补全目标语言的开始标记，1.2为file，2.0为xliff
+	segments     []*segment
+	entries      []resource.Entry
+}
+
+/**
+ * @description: 创建一个新的XLIFF文件处理实例
+ * @param {string} FilePath XLIFF文件路径
+ * @param {string} OutputPath 输出文件路径，为空时覆盖源文件
+ * @param {string} Language 目标语言，文件中未指定目标语言时写入
+ * @return {*resource.ResourceTable} 返回一个新的资源文件表格实例
+ * @return {error} 错误信息
+ */
+func New(FilePath, OutputPath, Language string) (*resource.ResourceTable, error) {
+	return resource.New(FilePath, OutputPath, Language, Format)
+}
+
+// 解析状态
+type parser struct {
+	decoder  *xml.Decoder
+	document *document
+
+	files    []string // 各file元素的original或id属性
+	unitID   string   // 当前单元的id
+	notes    []string // 当前单元的说明
+	unit     []*segment
+	current  *segment // 当前片段
+	inUnit   bool
+	skipUnit bool // 当前单元不翻译
+}
+
+/**
+ * @description: 解析XLIFF文件，已有译文的片段保持不变，内联标记替换为占位符
+ * @param {[]byte} Data 文件内容
+ * @return {resource.Document} 解析后的文件
+ * @return {error} 错误信息
+ */
+func Parse(Data []byte) (resource.Document, error) {
+	Parser := &parser{
+		decoder:  xml.NewDecoder(bytes.NewReader(Data)),
+		document: &document{data: Data, version: 1},
+	}
+
+	FoundRoot := false
+	for {
+		Start := Parser.offset()
+		Token, err := Parser.decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		Tag := span{Start, Parser.offset()}
+
+		switch Element := Token.(type) {
+		case xml.StartElement:
+			if !FoundRoot {
+				if Element.Name.Local != "xliff" {
+					return nil, fmt.Errorf("unexpected root element %s", Element.Name.Local)
+				}
+				FoundRoot = true
+			}
+			if err := Parser.start(Element, Tag); err != nil {
+				return nil, err
+			}
+		case xml.EndElement:
+			Parser.end(Element)
+		}
+	}
+	if !FoundRoot {
+		return nil, errors.New("missing xliff element")
+	}
+
+	Parser.document.collect(Parser.files)
+	return Parser.document, nil
+}
+
+/**
+ * @description: 获取解码器当前的位置
+ * @return {int} 位置
+ */
+func (p *parser) offset() int {
+	return int(p.decoder.InputOffset())
+}
+
+/**
+ * @description: 处理开始标记
+ * @param {xml.StartElement} Element 开始标记
+ * @param {span} Tag 开始标记在文件中的位置
+ * @return {error} 错误信息
+ */
+func (p *parser) start(Element xml.StartElement, Tag span) error {
+	Version := p.document.version
+	switch Element.Name.Local {
+	case "xliff":
+		if strings.HasPrefix(attribute(Element, "version"), "2") {
+			p.document.version = 2
+			p.document.languageTags = append(p.document.languageTags, Tag)
+		}
+	case "file":
+		Name := attribute(Element, "original")
+		if Name == "" {
+			Name = attribute(Element, "id")
+		}
+		p.files = append(p.files, Name)
+		if Version == 1 {
+			p.document.languageTags = append(p.document.languageTags, Tag)
+		}
+	case "trans-unit", "unit":
+		p.inUnit = true
+		p.unitID = attribute(Element, "id")
+		p.skipUnit = attribute(Element, "translate") == "no"
+		p.notes, p.unit = nil, nil
+		if Version == 1 {
+			p.current = &segment{}
+		}
+	case "segment":
+		if p.inUnit {
+			p.current = &segment{key: attribute(Element, "id"), stateTag: &Tag}
+		}
+	case "alt-trans", "seg-source", "originalData", "ignorable":
+		// 参考译文、分段原文、原始数据和不可翻译的片段
+		return p.decoder.Skip()
+	case "note":
+		if !p.inUnit {
+			return p.decoder.Skip()
+		}
+		Text, _, _, err := p.readContent()
+		if err != nil {
+			return err
+		}
+		if Text = strings.TrimSpace(Text); Text != "" {
+			p.notes = append(p.notes, Text)
+		}
+	case "source":
+		if p.current == nil {
+			return p.decoder.Skip()
+		}
+		Text, Tags, End, err := p.readContent()
+		if err != nil {
+			return err
+		}
+		p.current.source, p.current.tags, p.current.sourceEnd = Text, Tags, End
+		p.current.indent = lineIndent(p.document.data, Tag.start)
+	case "target":
+		if p.current == nil {
+			return p.decoder.Skip()
+		}
+		Text, _, End, err := p.readContent()
+		if err != nil {
+			return err
+		}
+		p.current.target = &span{Tag.start, End}
+		p.current.targetStart = string(p.document.data[Tag.start:Tag.end])
+		p.current.targetText = Text
+		State := attribute(Element, "state")
+		p.current.untranslated = Version == 1 && (State == "new" || State == "needs-translation")
+	}
+	return nil
+}
+
+/**
+ * @description: 处理结束标记
+ * @param {xml.EndElement} Element 结束标记
+ */
+func (p *parser) end(Element xml.EndElement) {
+	switch Element.Name.Local {
+	case "segment":
+		if p.current != nil && p.document.version == 2 {
+			p.unit = append(p.unit, p.current)
+			p.current = nil
+		}
+	case "trans-unit", "unit":
+		if p.current != nil && p.document.version == 1 {
+			p.unit = append(p.unit, p.current)
+		}
+		p.current = nil
+		p.inUnit = false
+		if p.skipUnit {
+			return
+		}
+
+		for Index, Segment := range p.unit {
+			if Segment.source == "" {
+				continue
+			}
+			Key := p.unitID
+			if len(p.unit) > 1 {
+				SegmentID := Segment.key
+				if SegmentID == "" {
+					SegmentID = strconv.Itoa(Index + 1)
+				}
+				Key += "#" + SegmentID
+			}
+			Segment.key = Key
+			Segment.file = len(p.files) - 1
+
+			p.document.segments = append(p.document.segments, Segment)
+			Context := slices.Clone(p.notes)
+			if len(Segment.tags) > 0 {
+				Context = append(Context, placeholderNote)
+			}
+			Target := Segment.targetText
+			if Segment.untranslated {
+				Target = ""
+			}
+			p.document.entries = append(p.document.entries, resource.Entry{Source: Segment.source, Target: Target, Context: strings.Join(Context, "\n")})
+		}
+	}
+}
+
+/**
+ * @description: 读取当前元素的内容直到对应的结束标记，内联标记替换为占位符
+ * @return {string} 文本
+ * @return {map[string]string} 占位符，值为原始内联标记
+ * @return {int} 结束标记之后的位置
+ * @return {error} 错误信息
+ */
+func (p *parser) readContent() (string, map[string]string, int, error) {
+	var Text strings.Builder
+	Tags := map[string]string{}
+	var Open []int // 尚未结束的成对标记，0表示自闭合的成对标记
+	Count := 0
+
+	for {
+		Start := p.offset()
+		Token, err := p.decoder.Token()
+		if err != nil {
+			return "", nil, 0, err
+		}
+		Raw := string(p.document.data[Start:p.offset()])
+
+		switch Value := Token.(type) {
+		case xml.CharData:
+			Text.Write(Value)
+		case xml.StartElement:
+			Count++
+			Placeholder := resource.Placeholder(Count)
+			if slices.Contains(pairedTags, Value.Name.Local) && !strings.HasSuffix(Raw, "/>") {
+				Open = append(Open, Count)
+			} else if slices.Contains(pairedTags, Value.Name.Local) {
+				// 自闭合的成对标记没有内容，跳过解码器补充的结束标记
+				Open = append(Open, 0)
+			} else {
+				// 其他内联标记连同内容作为一个占位符
+				if err := p.decoder.Skip(); err != nil {
+					return "", nil, 0, err
+				}
+				Raw = string(p.document.data[Start:p.offset()])
+			}
+			Tags[Placeholder] = Raw
+			Text.WriteString(Placeholder)
+		case xml.EndElement:
+			if len(Open) == 0 {
+				return Text.String(), Tags, p.offset(), nil
+			}
+			Last := Open[len(Open)-1]
+			Open = Open[:len(Open)-1]
+			if Last > 0 {
+				Placeholder := resource.ClosingPlaceholder(Last)
+				Tags[Placeholder] = Raw
+				Text.WriteString(Placeholder)
+			}
+		}
+	}
+}
+
+/**
+ * @description: 生成条目的键，存在多个file元素时以file的名称为前缀
+ * @param {[]string} Files 各file元素的名称
+ */
+func (d *document) collect(Files []string) {
+	for Index, Segment := range d.segments {
+		Key := Segment.key
+		if len(Files) > 1 && Segment.file >= 0 {
+			Prefix := Files[Segment.file]
+			if Prefix == "" {
+				Prefix = strconv.Itoa(Segment.file)
+			}
+			Key = Prefix + "/" + Key
+		}
+		d.entries[Index].Key = Key
+	}
+}
+
+/**
+ * @description: 获取可翻译条目
+ * @return {[]resource.Entry} 可翻译条目
+ */
+func (d *document) Entries() []resource.Entry {
+	return d.entries
+}
+
+/**
+ * @description: 写入译文并标记为待审校，已有译文的片段和文件其余部分保持不变，文件中未指定目标语言时写入
+ * @param {[]string} Translations 各条目的译文
+ * @param {string} Language 目标语言
+ * @return {[]byte} 文件内容
+ * @return {error} 错误信息
+ */
+func (d *document) Marshal(Translations []string, Language string) ([]byte, error) {
+	type edit struct {
+		span
+		text string
+	}
+	var Edits []edit
+
+	if Language != "" {
+		Name := "target-language"
+		if d.version == 2 {
+			Name = "trgLang"
+		}
+		for _, Tag := range d.languageTags {
+			Raw := string(d.data[Tag.start:Tag.end])
+			if Updated := setAttribute(Raw, Name, Language, false); Updated != Raw {
+				Edits = append(Edits, edit{Tag, Updated})
+			}
+		}
+	}
+
+	for Index, Segment := range d.segments {
+		if Index >= len(Translations) || Translations[Index] == "" || d.entries[Index].Target != "" {
+			continue
+		}
+		Content, ok := restore(Translations[Index], Segment.tags)
+		if !ok {
+			log.Print().Warning("Translation", fmt.Sprintf("Translation of segment %q is missing inline tag placeholders, keeping the source", d.entries[Index].Source))
+			continue
+		}
+
+		StartTag := "<target>"
+		if Segment.target != nil {
+			StartTag = Segment.targetStart
+		}
+		if d.version == 1 {
+			StartTag = setAttribute(StartTag, "state", StateV1, true)
+		}
+		StartTag = strings.TrimSuffix(strings.TrimSuffix(StartTag, ">"), "/") + ">"
+		Element := StartTag + Content + "</" + tagNamePattern.FindStringSubmatch(StartTag)[1] + ">"
+
+		if Segment.target != nil {
+			Edits = append(Edits, edit{*Segment.target, Element})
+		} else {
+			Edits = append(Edits, edit{span{Segment.sourceEnd, Segment.sourceEnd}, lineBreak(d.data) + Segment.indent + Element})
+		}
+		if Segment.stateTag != nil {
+			Raw := string(d.data[Segment.stateTag.start:Segment.stateTag.end])
+			Edits = append(Edits, edit{*Segment.stateTag, setAttribute(Raw, "state", StateV2, true)})
+		}
+	}
+
+	slices.SortFunc(Edits, func(A, B edit) int { return A.start - B.start })
+	var Buffer bytes.Buffer
+	Last := 0
+	for _, Edit := range Edits {
+		Buffer.Write(d.data[Last:Edit.start])
+		Buffer.WriteString(Edit.text)
+		Last = Edit.end
+	}
+	Buffer.Write(d.data[Last:])
+	return Buffer.Bytes(), nil
+}
+
+/**
+ * @description: 将译文中的占位符还原为内联标记，重复或嵌套错误的占位符按普通文本处理
+ * @param {string} Text 译文
+ * @param {map[string]string} Tags 占位符，值为原始内联标记
+ * @return {string} 转义后的XML内容
+ * @return {bool} 是否每个内联标记都已还原，译文遗漏占位符时为false，此时调用方应保留原文
+ */
+func restore(Text string, Tags map[string]string) (string, bool) {
+	Matches := resource.FindPlaceholders(Text)
+
+	// 成对的占位符必须按顺序正确嵌套，否则还原后的XML无效
+	Valid := make([]bool, len(Matches))
+	Used := map[string]bool{}
+	var Open []int // 尚未结束的开始占位符在Matches中的索引
+	for Index, Match := range Matches {
+		Placeholder := Text[Match.Start:Match.End]
+		if _, ok := Tags[Placeholder]; !ok || Used[Placeholder] {
+			continue
+		}
+		Used[Placeholder] = true
+		switch {
+		case Match.Closing:
+			// 结束占位符与最近的开始占位符对应
+			if len(Open) > 0 && Matches[Open[len(Open)-1]].Number == Match.Number {
+				Valid[Open[len(Open)-1]], Valid[Index] = true, true
+				Open = Open[:len(Open)-1]
+			}
+		case Tags[resource.ClosingPlaceholder(Match.Number)] != "":
+			Open = append(Open, Index)
+		default:
+			Valid[Index] = true
+		}
+	}
+
+	var Builder strings.Builder
+	Last := 0
+	Restored := 0
+	for Index, Match := range Matches {
+		if !Valid[Index] {
+			continue
+		}
+		Builder.WriteString(escapeText(Text[Last:Match.Start]))
+		Builder.WriteString(Tags[Text[Match.Start:Match.End]])
+		Last = Match.End
+		Restored++
+	}
+	Builder.WriteString(escapeText(Text[Last:]))
+	return Builder.String(), Restored == len(Tags)
+}
+
+/**
+ * @description: 获取元素的属性
+ * @param {xml.StartElement} Element 开始标记
+ * @param {string} Name 属性名称
+ * @return {string} 属性值，不存在时为空
+ */
+func attribute(Element xml.StartElement, Name string) string {
+	for _, Attr := range Element.Attr {
+		if Attr.Name.Local == Name && Attr.Name.Space == "" {
+			return Attr.Value
+		}
+	}
+	return ""
+}
+
+/**
+ * @description: 设置开始标记的属性，保持其他属性的原始写法
+ * @param {string} Tag 原始开始标记
+ * @param {string} Name 属性名称
+ * @param {string} Value 属性值
+ * @param {bool} Overwrite 属性已存在且不为空时是否覆盖
+ * @return {string} 修改后的开始标记
+ */
+func setAttribute(Tag, Name, Value string, Overwrite bool) string {
+	Pattern := regexp.MustCompile(`(\s` + regexp.QuoteMeta(Name) + `\s*=\s*)("[^"]*"|'[^']*')`)
+	Attr := `"` + escapeAttribute(Value) + `"`
+	if Match := Pattern.FindStringSubmatchIndex(Tag); Match != nil {
+		if !Overwrite && Match[5]-Match[4] > 2 {
+			return Tag
+		}
+		return Tag[:Match[4]] + Attr + Tag[Match[5]:]
+	}
+
+	End := len(Tag) - 1
+	if strings.HasSuffix(Tag, "/>") {
+		End--
+	}
+	return Tag[:End] + " " + Name + "=" + Attr + Tag[End:]
+}
+
+/**
+ * @description: 获取指定位置所在行的缩进
+ * @param {[]byte} Data 文件内容
+ * @param {int} Offset 位置
+ * @return {string} 缩进，该位置之前有其他内容时为空
+ */
+func lineIndent(Data []byte, Offset int) string {
+	LineStart := bytes.LastIndexByte(Data[:Offset], '\n') + 1
+	Indent := Data[LineStart:Offset]
+	if len(bytes.TrimLeft(Indent, " \t")) > 0 {
+		return ""
+	}
+	return string(Indent)
+}
+
+/**
+ * @description: 获取文件使用的换行符
+ * @param {[]byte} Data 文件内容
+ * @return {string} 换行符
+ */
+func lineBreak(Data []byte) string {
+	if bytes.Contains(Data, []byte("\r\n")) {
+		return "\r\n"
+	}
+	return "\n"
+}
+
+/**
+ * @description: 转义XML文本中的特殊字符，保留换行和引号
+ * @param {string} Text 文本
+ * @return {string} 转义后的文本
+ */
+func escapeText(Text string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(Text)
+}
+
+/**
+ * @description: 转义XML属性值中的特殊字符
+ * @param {string} Text 属性值
+ * @return {string} 转义后的属性值
+ */
+func escapeAttribute(Text string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;").Replace(Text)
+}
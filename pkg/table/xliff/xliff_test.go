@@ -0,0 +1,235 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-17 18:50:27
+ * @LastEditTime: 2025-07-17 19:34:51
+ * @LastEditors: nijineko
+ * @Description: XLIFF 1.2/2.0翻译交换文件处理测试
+ * @FilePath: \AutoTranslation\pkg\table\xliff\xliff_test.go
+ */
+package xliff
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const testXLIFF12 = `<?xml version="1.0" encoding="UTF-8"?>
+<xliff version="1.2" xmlns="urn:oasis:names:tc:xliff:document:1.2">
+  <file original="app" source-language="en" datatype="plaintext">
+    <body>
+      <trans-unit id="greeting">
+        <source>Hello <g id="1">dear</g> user<x id="2"/> &amp; friends</source>
+        <note>Shown on login</note>
+      </trans-unit>
+      <trans-unit id="done">
+        <source>Done</source>
+        <target state="translated">Готово</target>
+      </trans-unit>
+      <trans-unit id="retry">
+        <source>Retry</source>
+        <target state="new"/>
+        <alt-trans><source>Retry</source><target>Повтор</target></alt-trans>
+      </trans-unit>
+      <trans-unit id="brand" translate="no">
+        <source>Acme</source>
+      </trans-unit>
+    </body>
+  </file>
+</xliff>
+`
+
+func TestXLIFF12_RoundTrip(t *testing.T) {
+	FilePath := filepath.Join(t.TempDir(), "app.xlf")
+	if err := os.WriteFile(FilePath, []byte(testXLIFF12), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	TableInstance, err := New(FilePath, "", "ru")
+	if err != nil {
+		t.Fatal(err)
+	}
+	Datas, err := TableInstance.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"source", "target", "key", "context"},
+		{"Hello ⟦1⟧dear⟦/1⟧ user⟦2⟧ & friends", "", "greeting", "Shown on login\n" + placeholderNote},
+		{"Done", "Готово", "done", ""},
+		{"Retry", "", "retry", ""},
+	}
+	if !reflect.DeepEqual(Datas, want) {
+		t.Fatalf("Read() = %q, want %q", Datas, want)
+	}
+
+	Datas[1][1] = "Привет, ⟦1⟧дорогой⟦/1⟧ пользователь⟦2⟧ & друзья"
+	Datas[3][1] = "Повторить"
+	if err := TableInstance.Write(Datas); err != nil {
+		t.Fatal(err)
+	}
+
+	Output := `<?xml version="1.0" encoding="UTF-8"?>
+<xliff version="1.2" xmlns="urn:oasis:names:tc:xliff:document:1.2">
+  <file original="app" source-language="en" datatype="plaintext" target-language="ru">
+    <body>
+      <trans-unit id="greeting">
+        <source>Hello <g id="1">dear</g> user<x id="2"/> &amp; friends</source>
+        <target state="needs-review-translation">Привет, <g id="1">дорогой</g> пользователь<x id="2"/> &amp; друзья</target>
+        <note>Shown on login</note>
+      </trans-unit>
+      <trans-unit id="done">
+        <source>Done</source>
+        <target state="translated">Готово</target>
+      </trans-unit>
+      <trans-unit id="retry">
+        <source>Retry</source>
+        <target state="needs-review-translation">Повторить</target>
+        <alt-trans><source>Retry</source><target>Повтор</target></alt-trans>
+      </trans-unit>
+      <trans-unit id="brand" translate="no">
+        <source>Acme</source>
+      </trans-unit>
+    </body>
+  </file>
+</xliff>
+`
+	if got, _ := os.ReadFile(FilePath); string(got) != Output {
+		t.Errorf("output = %s, want %s", got, Output)
+	}
+}
+
+func TestXLIFF20_Segments(t *testing.T) {
+	FilePath := filepath.Join(t.TempDir(), "app.xlf")
+	Content := `<xliff xmlns="urn:oasis:names:tc:xliff:document:2.0" version="2.0" srcLang="en">
+ <file id="f1">
+  <unit id="u1">
+   <notes><note>Menu</note></notes>
+   <segment id="s1">
+    <source>Open <ph id="1"/>file</source>
+   </segment>
+   <ignorable><source> </source></ignorable>
+   <segment>
+    <source>Close</source>
+    <target>Закрыть</target>
+   </segment>
+  </unit>
+ </file>
+</xliff>`
+	if err := os.WriteFile(FilePath, []byte(Content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	TableInstance, err := New(FilePath, "", "ru")
+	if err != nil {
+		t.Fatal(err)
+	}
+	Datas, _ := TableInstance.Read()
+	if len(Datas) != 3 || Datas[1][2] != "u1#s1" || Datas[2][2] != "u1#2" || Datas[2][1] != "Закрыть" {
+		t.Fatalf("Read() = %q", Datas)
+	}
+	Datas[1][1] = "Открыть ⟦1⟧файл"
+	if err := TableInstance.Write(Datas); err != nil {
+		t.Fatal(err)
+	}
+
+	// 2.0的状态写在segment上
+	want := `<xliff xmlns="urn:oasis:names:tc:xliff:document:2.0" version="2.0" srcLang="en" trgLang="ru">
+ <file id="f1">
+  <unit id="u1">
+   <notes><note>Menu</note></notes>
+   <segment id="s1" state="translated">
+    <source>Open <ph id="1"/>file</source>
+    <target>Открыть <ph id="1"/>файл</target>
+   </segment>
+   <ignorable><source> </source></ignorable>
+   <segment>
+    <source>Close</source>
+    <target>Закрыть</target>
+   </segment>
+  </unit>
+ </file>
+</xliff>`
+	if got, _ := os.ReadFile(FilePath); string(got) != want {
+		t.Errorf("output = %s, want %s", got, want)
+	}
+}
+
+func TestRestore(t *testing.T) {
+	Tags := map[string]string{"⟦1⟧": `<g id="1">`, "⟦/1⟧": "</g>", "⟦2⟧": `<x id="2"/>`}
+	tests := []struct {
+		text   string
+		want   string
+		wantOK bool
+	}{
+		{"⟦1⟧a⟦/1⟧ ⟦2⟧", `<g id="1">a</g> <x id="2"/>`, true},
+		{"⟦2⟧ ⟦1⟧a < b⟦/1⟧", `<x id="2"/> <g id="1">a &lt; b</g>`, true},
+		{"a⟦/1⟧ ⟦2⟧⟦2⟧", `a⟦/1⟧ <x id="2"/>⟦2⟧`, false},
+		{"⟦1⟧a < b ⟦3⟧", `⟦1⟧a &lt; b ⟦3⟧`, false},
+		{"⟦1⟧a⟦/1⟧", `<g id="1">a</g>`, false},
+	}
+	for _, tt := range tests {
+		got, ok := restore(tt.text, Tags)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("restore(%q) = %q, %v, want %q, %v", tt.text, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestMissingPlaceholder(t *testing.T) {
+	Content := `<xliff version="1.2"><file source-language="en"><body>
+<trans-unit id="open"><source>Open <x id="1"/>file</source></trans-unit>
+<trans-unit id="close"><source>Close</source></trans-unit>
+</body></file></xliff>`
+	Document, err := Parse([]byte(Content))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 遗漏内联标记占位符的译文不写入，保留原文
+	got, err := Document.Marshal([]string{"Открыть файл", "Закрыть"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<xliff version="1.2"><file source-language="en"><body>
+<trans-unit id="open"><source>Open <x id="1"/>file</source></trans-unit>
+<trans-unit id="close"><source>Close</source>
+<target state="needs-review-translation">Закрыть</target></trans-unit>
+</body></file></xliff>`
+	if string(got) != want {
+		t.Errorf("Marshal() = %s, want %s", got, want)
+	}
+}
+
+func TestExport(t *testing.T) {
+	Rows := [][]string{
+		{"key", "en", "ja", "note"},
+		{"title", "Hello & bye", "こんにちは", "Title"},
+		{"empty", "", "", ""},
+		{"body", "Text"},
+	}
+
+	var Buffer bytes.Buffer
+	Count, err := Export(&Buffer, []ExportFile{
+		{Original: "strings.csv", SourceLanguage: "en", TargetLanguage: "ja", Rows: Rows, SourceColumn: 1, TargetColumn: 2, ContextColumn: 3, SkipHeader: true},
+		{Original: "strings.csv", TargetLanguage: "fr", Rows: Rows, SourceColumn: 1, TargetColumn: 4, ContextColumn: -1, SkipHeader: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if Count != 4 {
+		t.Errorf("Count = %d, want 4", Count)
+	}
+
+	// 导出的文件可以直接作为XLIFF读取
+	Document, err := Parse(Buffer.Bytes())
+	if err != nil {
+		t.Fatalf("Parse() error = %v\n%s", err, Buffer.String())
+	}
+	Entries := Document.Entries()
+	if len(Entries) != 4 || Entries[0].Source != "Hello & bye" || Entries[0].Target != "こんにちは" || Entries[0].Context != "Title" || Entries[1].Key != "strings.csv/4" || Entries[2].Target != "" {
+		t.Errorf("Entries() = %+v", Entries)
+	}
+}
@@ -0,0 +1,652 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-09 10:12:38
+ * @LastEditTime: 2025-07-09 14:36:05
+ * @LastEditors: nijineko
+ * @Description: Excel 97-2003 (BIFF8) 工作簿读取
+ * @FilePath: \AutoTranslation\pkg\table\xls\xls.go
+ */
+package xls
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"unicode/utf16"
+
+	"github.com/richardlehane/mscfb"
+)
+
+var (
+	// 文件不是有效的xls工作簿
+	ErrInvalidWorkbook = errors.New("invalid xls workbook")
+	// 不支持的BIFF版本，只支持Excel 97及之后的BIFF8格式
+	ErrUnsupportedVersion = errors.New("unsupported xls version, only BIFF8 (Excel 97-2003) is supported")
+	// 工作簿已加密
+	ErrEncrypted = errors.New("encrypted xls workbook is not supported")
+)
+
+// BIFF记录类型
+const (
+	recordFormula    = 0x0006
+	recordEOF        = 0x000A
+	recordFilePass   = 0x002F
+	recordContinue   = 0x003C
+	recordBoundSheet = 0x0085
+	recordMulRK      = 0x00BD
+	recordXF         = 0x00E0
+	recordMergeCells = 0x00E5
+	recordSST        = 0x00FC
+	recordLabelSST   = 0x00FD
+	recordNumber     = 0x0203
+	recordLabel      = 0x0204
+	recordBoolErr    = 0x0205
+	recordString     = 0x0207
+	recordRK         = 0x027E
+	recordFormat     = 0x041E
+	recordBOF        = 0x0809
+
+	biff8Version = 0x0600
+)
+
+// 单元格
+type Cell struct {
+	Value      any    // 单元格值，可以是string、float64、bool，为nil表示空单元格
+	NumFmt     int    // 数字格式编号，0表示常规
+	NumFmtCode string // 自定义数字格式代码，内置格式为空
+	IsFormula  bool   // 是否为公式，Value为公式的计算结果
+}
+
+// 合并单元格区域，行号和列号从0开始计数
+type MergeRange struct {
+	FirstRow, LastRow int
+	FirstCol, LastCol int
+}
+
+// 工作表
+type Sheet struct {
+	Name   string       // 工作表名称
+	Hidden bool         // 是否隐藏
+	Rows   [][]Cell     // 单元格数据，行号和列号从0开始计数
+	Merges []MergeRange // 合并单元格区域
+}
+
+// 工作簿
+type Workbook struct {
+	Sheets []Sheet // 按顺序排列的工作表，不包含图表和宏表
+}
+
+// BIFF记录，CONTINUE记录的数据按顺序附加在前一条记录之后
+type record struct {
+	id     uint16
+	chunks [][]byte
+}
+
+// 工作表位置
+type boundSheet struct {
+	name     string
+	position uint32
+	hidden   bool
+}
+
+// 全局信息
+type globals struct {
+	sst     []string
+	xfs     []uint16          // 各XF对应的数字格式编号
+	formats map[uint16]string // 自定义数字格式代码
+}
+
+/**
+ * @description: 打开xls工作簿
+ * @param {string} FilePath 文件路径
+ * @return {*Workbook} 工作簿数据
+ * @return {error} 错误信息
+ */
+func Open(FilePath string) (*Workbook, error) {
+	FileHandle, err := os.Open(FilePath)
+	if err != nil {
+		return nil, err
+	}
+	defer FileHandle.Close()
+
+	return Read(FileHandle)
+}
+
+/**
+ * @description: 读取xls工作簿
+ * @param {io.ReaderAt} Reader 复合文档数据
+ * @return {*Workbook} 工作簿数据
+ * @return {error} 错误信息
+ */
+func Read(Reader io.ReaderAt) (*Workbook, error) {
+	Document, err := mscfb.New(Reader)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidWorkbook, err)
+	}
+
+	for Entry, err := Document.Next(); err == nil; Entry, err = Document.Next() {
+		switch Entry.Name {
+		case "Workbook":
+			Stream, err := io.ReadAll(Entry)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidWorkbook, err)
+			}
+			return Parse(Stream)
+		case "Book":
+			// Excel 5.0/95 使用的BIFF5格式
+			return nil, ErrUnsupportedVersion
+		}
+	}
+
+	return nil, fmt.Errorf("%w: workbook stream not found", ErrInvalidWorkbook)
+}
+
+/**
+ * @description: 解析BIFF8工作簿数据流
+ * @param {[]byte} Stream Workbook数据流
+ * @return {*Workbook} 工作簿数据
+ * @return {error} 错误信息
+ */
+func Parse(Stream []byte) (*Workbook, error) {
+	Records, Offsets, err := readRecords(Stream)
+	if err != nil {
+		return nil, err
+	}
+	if len(Records) == 0 || Records[0].id != recordBOF {
+		return nil, fmt.Errorf("%w: missing BOF record", ErrInvalidWorkbook)
+	}
+	if Version := binary.LittleEndian.Uint16(pad(Records[0].chunks[0], 2)); Version != biff8Version {
+		return nil, ErrUnsupportedVersion
+	}
+
+	// 解析全局信息，直到全局部分的EOF
+	Globals := globals{formats: map[uint16]string{}}
+	var Sheets []boundSheet
+	for _, Record := range Records[1:] {
+		if Record.id == recordEOF {
+			break
+		}
+
+		Data := Record.chunks[0]
+		switch Record.id {
+		case recordFilePass:
+			return nil, ErrEncrypted
+		case recordBoundSheet:
+			if len(Data) < 8 {
+				return nil, fmt.Errorf("%w: truncated BOUNDSHEET record", ErrInvalidWorkbook)
+			}
+			if Data[5] != 0 {
+				// 图表、宏表等非工作表
+				continue
+			}
+			Reader := newChunkReader([][]byte{Data[6:]})
+			Name, err := Reader.shortString()
+			if err != nil {
+				return nil, err
+			}
+			Sheets = append(Sheets, boundSheet{
+				name:     Name,
+				position: binary.LittleEndian.Uint32(Data[0:4]),
+				hidden:   Data[4]&0x03 != 0,
+			})
+		case recordSST:
+			if Globals.sst, err = readSST(Record.chunks); err != nil {
+				return nil, err
+			}
+		case recordXF:
+			if len(Data) < 4 {
+				return nil, fmt.Errorf("%w: truncated XF record", ErrInvalidWorkbook)
+			}
+			Globals.xfs = append(Globals.xfs, binary.LittleEndian.Uint16(Data[2:4]))
+		case recordFormat:
+			if len(Data) < 2 {
+				return nil, fmt.Errorf("%w: truncated FORMAT record", ErrInvalidWorkbook)
+			}
+			Reader := newChunkReader(append([][]byte{Data[2:]}, Record.chunks[1:]...))
+			Code, err := Reader.longString()
+			if err != nil {
+				return nil, err
+			}
+			Globals.formats[binary.LittleEndian.Uint16(Data[0:2])] = Code
+		}
+	}
+
+	// 按记录位置解析各工作表
+	WorkbookData := &Workbook{}
+	for _, SheetInfo := range Sheets {
+		Index := sort.Search(len(Offsets), func(i int) bool { return Offsets[i] >= int(SheetInfo.position) })
+		if Index == len(Offsets) || Offsets[Index] != int(SheetInfo.position) {
+			return nil, fmt.Errorf("%w: sheet %q has an invalid position", ErrInvalidWorkbook, SheetInfo.name)
+		}
+
+		SheetData, err := parseSheet(Records[Index:], &Globals)
+		if err != nil {
+			return nil, fmt.Errorf("sheet %q: %w", SheetInfo.name, err)
+		}
+		SheetData.Name = SheetInfo.name
+		SheetData.Hidden = SheetInfo.hidden
+		WorkbookData.Sheets = append(WorkbookData.Sheets, *SheetData)
+	}
+
+	return WorkbookData, nil
+}
+
+/**
+ * @description: 解析工作表记录
+ * @param {[]record} Records 从工作表BOF开始的记录
+ * @param {*globals} Globals 全局信息
+ * @return {*Sheet} 工作表数据
+ * @return {error} 错误信息
+ */
+func parseSheet(Records []record, Globals *globals) (*Sheet, error) {
+	if Records[0].id != recordBOF {
+		return nil, fmt.Errorf("%w: missing sheet BOF record", ErrInvalidWorkbook)
+	}
+
+	SheetData := &Sheet{}
+	Set := func(Row, Col int, XF uint16, Value any, IsFormula bool) {
+		for len(SheetData.Rows) <= Row {
+			SheetData.Rows = append(SheetData.Rows, nil)
+		}
+		for len(SheetData.Rows[Row]) <= Col {
+			SheetData.Rows[Row] = append(SheetData.Rows[Row], Cell{})
+		}
+
+		CellData := Cell{Value: Value, IsFormula: IsFormula}
+		if _, IsNumber := Value.(float64); IsNumber && int(XF) < len(Globals.xfs) {
+			CellData.NumFmt = int(Globals.xfs[XF])
+			CellData.NumFmtCode = Globals.formats[Globals.xfs[XF]]
+		}
+		SheetData.Rows[Row][Col] = CellData
+	}
+
+	// 字符串结果的公式，值在随后的STRING记录中
+	HasPendingString := false
+	var PendingRow, PendingCol int
+	var PendingXF uint16
+
+	for _, Record := range Records[1:] {
+		if Record.id == recordEOF {
+			return SheetData, nil
+		}
+
+		Data := Record.chunks[0]
+		if len(Data) < 6 && Record.id != recordString && Record.id != recordMergeCells {
+			continue
+		}
+		Row := int(binary.LittleEndian.Uint16(pad(Data, 2)))
+		Col := int(binary.LittleEndian.Uint16(pad(Data, 4)[2:]))
+		XF := binary.LittleEndian.Uint16(pad(Data, 6)[4:])
+
+		switch Record.id {
+		case recordLabelSST:
+			if len(Data) < 10 {
+				return nil, fmt.Errorf("%w: truncated LABELSST record", ErrInvalidWorkbook)
+			}
+			SSTIndex := int(binary.LittleEndian.Uint32(Data[6:10]))
+			if SSTIndex >= len(Globals.sst) {
+				return nil, fmt.Errorf("%w: shared string index %d out of range", ErrInvalidWorkbook, SSTIndex)
+			}
+			Set(Row, Col, XF, Globals.sst[SSTIndex], false)
+		case recordLabel:
+			Reader := newChunkReader(append([][]byte{Data[6:]}, Record.chunks[1:]...))
+			Text, err := Reader.longString()
+			if err != nil {
+				return nil, err
+			}
+			Set(Row, Col, XF, Text, false)
+		case recordNumber:
+			if len(Data) < 14 {
+				return nil, fmt.Errorf("%w: truncated NUMBER record", ErrInvalidWorkbook)
+			}
+			Set(Row, Col, XF, math.Float64frombits(binary.LittleEndian.Uint64(Data[6:14])), false)
+		case recordRK:
+			if len(Data) < 10 {
+				return nil, fmt.Errorf("%w: truncated RK record", ErrInvalidWorkbook)
+			}
+			Set(Row, Col, XF, decodeRK(binary.LittleEndian.Uint32(Data[6:10])), false)
+		case recordMulRK:
+			// 行号、起始列号、若干(XF, RK)、结束列号
+			for Offset := 4; Offset+6 <= len(Data)-2; Offset += 6 {
+				Set(Row, Col+(Offset-4)/6, binary.LittleEndian.Uint16(Data[Offset:]), decodeRK(binary.LittleEndian.Uint32(Data[Offset+2:])), false)
+			}
+		case recordBoolErr:
+			if len(Data) < 8 {
+				return nil, fmt.Errorf("%w: truncated BOOLERR record", ErrInvalidWorkbook)
+			}
+			if Data[7] == 0 {
+				Set(Row, Col, XF, Data[6] != 0, false)
+			} else {
+				Set(Row, Col, XF, errorText(Data[6]), false)
+			}
+		case recordFormula:
+			if len(Data) < 14 {
+				return nil, fmt.Errorf("%w: truncated FORMULA record", ErrInvalidWorkbook)
+			}
+			Result := Data[6:14]
+			if Result[6] != 0xFF || Result[7] != 0xFF {
+				Set(Row, Col, XF, math.Float64frombits(binary.LittleEndian.Uint64(Result)), true)
+				continue
+			}
+			switch Result[0] {
+			case 0:
+				HasPendingString, PendingRow, PendingCol, PendingXF = true, Row, Col, XF
+				Set(Row, Col, XF, "", true)
+			case 1:
+				Set(Row, Col, XF, Result[2] != 0, true)
+			case 2:
+				Set(Row, Col, XF, errorText(Result[2]), true)
+			default:
+				Set(Row, Col, XF, nil, true)
+			}
+		case recordString:
+			if !HasPendingString {
+				continue
+			}
+			Reader := newChunkReader(Record.chunks)
+			Text, err := Reader.longString()
+			if err != nil {
+				return nil, err
+			}
+			Set(PendingRow, PendingCol, PendingXF, Text, true)
+			HasPendingString = false
+		case recordMergeCells:
+			if len(Data) < 2 {
+				continue
+			}
+			Count := int(binary.LittleEndian.Uint16(Data[0:2]))
+			for Offset := 2; Offset+8 <= len(Data) && Count > 0; Offset, Count = Offset+8, Count-1 {
+				SheetData.Merges = append(SheetData.Merges, MergeRange{
+					FirstRow: int(binary.LittleEndian.Uint16(Data[Offset:])),
+					LastRow:  int(binary.LittleEndian.Uint16(Data[Offset+2:])),
+					FirstCol: int(binary.LittleEndian.Uint16(Data[Offset+4:])),
+					LastCol:  int(binary.LittleEndian.Uint16(Data[Offset+6:])),
+				})
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("%w: missing sheet EOF record", ErrInvalidWorkbook)
+}
+
+/**
+ * @description: 将数据流拆分为记录，CONTINUE记录合并到前一条记录
+ * @param {[]byte} Stream 数据流
+ * @return {[]record} 记录列表
+ * @return {[]int} 每条记录在数据流中的偏移
+ * @return {error} 错误信息
+ */
+func readRecords(Stream []byte) ([]record, []int, error) {
+	var Records []record
+	var Offsets []int
+	for Offset := 0; Offset+4 <= len(Stream); {
+		ID := binary.LittleEndian.Uint16(Stream[Offset:])
+		Size := int(binary.LittleEndian.Uint16(Stream[Offset+2:]))
+		if Offset+4+Size > len(Stream) {
+			return nil, nil, fmt.Errorf("%w: truncated record 0x%04X", ErrInvalidWorkbook, ID)
+		}
+		Data := Stream[Offset+4 : Offset+4+Size]
+
+		if ID == recordContinue && len(Records) > 0 {
+			Last := &Records[len(Records)-1]
+			Last.chunks = append(Last.chunks, Data)
+		} else {
+			Records = append(Records, record{id: ID, chunks: [][]byte{Data}})
+			Offsets = append(Offsets, Offset)
+		}
+		Offset += 4 + Size
+	}
+	return Records, Offsets, nil
+}
+
+/**
+ * @description: 读取共享字符串表
+ * @param {[][]byte} Chunks SST记录及其CONTINUE记录的数据
+ * @return {[]string} 共享字符串
+ * @return {error} 错误信息
+ */
+func readSST(Chunks [][]byte) ([]string, error) {
+	Reader := newChunkReader(Chunks)
+	if _, err := Reader.uint32(); err != nil {
+		return nil, err
+	}
+	Count, err := Reader.uint32()
+	if err != nil {
+		return nil, err
+	}
+
+	Strings := make([]string, 0, min(int(Count), 1<<16))
+	for range Count {
+		Text, err := Reader.longString()
+		if err != nil {
+			return nil, err
+		}
+		Strings = append(Strings, Text)
+	}
+	return Strings, nil
+}
+
+/**
+ * @description: 解码RK格式的数字
+ * @param {uint32} RK RK值
+ * @return {float64} 数字
+ */
+func decodeRK(RK uint32) float64 {
+	var Value float64
+	if RK&0x02 != 0 {
+		Value = float64(int32(RK) >> 2)
+	} else {
+		Value = math.Float64frombits(uint64(RK&0xFFFFFFFC) << 32)
+	}
+	if RK&0x01 != 0 {
+		Value /= 100
+	}
+	return Value
+}
+
+/**
+ * @description: 获取错误值的显示文本
+ * @param {byte} Code 错误代码
+ * @return {string} 错误文本
+ */
+func errorText(Code byte) string {
+	switch Code {
+	case 0x00:
+		return "#NULL!"
+	case 0x07:
+		return "#DIV/0!"
+	case 0x0F:
+		return "#VALUE!"
+	case 0x17:
+		return "#REF!"
+	case 0x1D:
+		return "#NAME?"
+	case 0x24:
+		return "#NUM!"
+	default:
+		return "#N/A"
+	}
+}
+
+/**
+ * @description: 补齐数据长度，避免读取过短的记录时越界
+ * @param {[]byte} Data 数据
+ * @param {int} Size 最小长度
+ * @return {[]byte} 补齐后的数据
+ */
+func pad(Data []byte, Size int) []byte {
+	if len(Data) >= Size {
+		return Data
+	}
+	return append(append([]byte{}, Data...), make([]byte, Size-len(Data))...)
+}
+
+// 跨越CONTINUE记录读取数据
+type chunkReader struct {
+	chunks [][]byte
+	index  int // 当前数据块
+	offset int // 当前数据块中的偏移
+}
+
+/**
+ * @description: 创建数据块读取器
+ * @param {[][]byte} Chunks 数据块
+ * @return {*chunkReader} chunkReader实例
+ */
+func newChunkReader(Chunks [][]byte) *chunkReader {
+	return &chunkReader{chunks: Chunks}
+}
+
+/**
+ * @description: 跳过已读完的数据块
+ * @return {bool} 是否还有数据
+ */
+func (r *chunkReader) available() bool {
+	for r.index < len(r.chunks) && r.offset >= len(r.chunks[r.index]) {
+		r.index++
+		r.offset = 0
+	}
+	return r.index < len(r.chunks)
+}
+
+/**
+ * @description: 读取指定长度的数据，可以跨越数据块
+ * @param {int} Size 长度
+ * @return {[]byte} 数据
+ * @return {error} 错误信息
+ */
+func (r *chunkReader) read(Size int) ([]byte, error) {
+	Data := make([]byte, 0, Size)
+	for len(Data) < Size {
+		if !r.available() {
+			return nil, fmt.Errorf("%w: unexpected end of record", ErrInvalidWorkbook)
+		}
+		Chunk := r.chunks[r.index]
+		Count := min(Size-len(Data), len(Chunk)-r.offset)
+		Data = append(Data, Chunk[r.offset:r.offset+Count]...)
+		r.offset += Count
+	}
+	return Data, nil
+}
+
+func (r *chunkReader) uint8() (uint8, error) {
+	Data, err := r.read(1)
+	if err != nil {
+		return 0, err
+	}
+	return Data[0], nil
+}
+
+func (r *chunkReader) uint16() (uint16, error) {
+	Data, err := r.read(2)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint16(Data), nil
+}
+
+func (r *chunkReader) uint32() (uint32, error) {
+	Data, err := r.read(4)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint32(Data), nil
+}
+
+/**
+ * @description: 读取8位长度前缀的字符串
+ * @return {string} 字符串
+ * @return {error} 错误信息
+ */
+func (r *chunkReader) shortString() (string, error) {
+	Count, err := r.uint8()
+	if err != nil {
+		return "", err
+	}
+	return r.unicodeString(int(Count))
+}
+
+/**
+ * @description: 读取16位长度前缀的字符串
+ * @return {string} 字符串
+ * @return {error} 错误信息
+ */
+func (r *chunkReader) longString() (string, error) {
+	Count, err := r.uint16()
+	if err != nil {
+		return "", err
+	}
+	return r.unicodeString(int(Count))
+}
+
+/**
+ * @description: 读取选项字节和字符，跳过富文本格式和扩展数据
+ * @param {int} Count 字符数量
+ * @return {string} 字符串
+ * @return {error} 错误信息
+ */
+func (r *chunkReader) unicodeString(Count int) (string, error) {
+	Flags, err := r.uint8()
+	if err != nil {
+		return "", err
+	}
+
+	var RunCount, ExtSize int
+	if Flags&0x08 != 0 {
+		Value, err := r.uint16()
+		if err != nil {
+			return "", err
+		}
+		RunCount = int(Value)
+	}
+	if Flags&0x04 != 0 {
+		Value, err := r.uint32()
+		if err != nil {
+			return "", err
+		}
+		ExtSize = int(Value)
+	}
+
+	// 字符跨越CONTINUE记录时，新记录以选项字节开头，可能改变字符宽度
+	Units := make([]uint16, 0, Count)
+	HighByte := Flags&0x01 != 0
+	Index := r.index
+	for len(Units) < Count {
+		if !r.available() {
+			return "", fmt.Errorf("%w: unexpected end of string", ErrInvalidWorkbook)
+		}
+		if r.index != Index {
+			Index = r.index
+			Flags, err := r.uint8()
+			if err != nil {
+				return "", err
+			}
+			HighByte = Flags&0x01 != 0
+		}
+
+		Chunk := r.chunks[r.index]
+		for len(Units) < Count && r.offset < len(Chunk) {
+			if HighByte {
+				if r.offset+2 > len(Chunk) {
+					return "", fmt.Errorf("%w: split UTF-16 character", ErrInvalidWorkbook)
+				}
+				Units = append(Units, binary.LittleEndian.Uint16(Chunk[r.offset:]))
+				r.offset += 2
+			} else {
+				Units = append(Units, uint16(Chunk[r.offset]))
+				r.offset++
+			}
+		}
+	}
+
+	// 跳过富文本格式和东亚语言扩展数据
+	if _, err := r.read(RunCount*4 + ExtSize); err != nil {
+		return "", err
+	}
+
+	return string(utf16.Decode(Units)), nil
+}
@@ -0,0 +1,179 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-09 14:40:26
+ * @LastEditTime: 2025-07-09 15:01:37
+ * @LastEditors: nijineko
+ * @Description: xls工作簿读取测试
+ * @FilePath: \AutoTranslation\pkg\table\xls\xls_test.go
+ */
+package xls
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"path/filepath"
+	"slices"
+	"testing"
+	"unicode/utf16"
+)
+
+// 构造一条BIFF记录
+func biffRecord(ID uint16, Data ...[]byte) []byte {
+	Body := bytes.Join(Data, nil)
+	Header := binary.LittleEndian.AppendUint16(nil, ID)
+	Header = binary.LittleEndian.AppendUint16(Header, uint16(len(Body)))
+	return append(Header, Body...)
+}
+
+func u16(Values ...uint16) []byte {
+	var Data []byte
+	for _, Value := range Values {
+		Data = binary.LittleEndian.AppendUint16(Data, Value)
+	}
+	return Data
+}
+
+func u32(Value uint32) []byte {
+	return binary.LittleEndian.AppendUint32(nil, Value)
+}
+
+func utf16le(Text string) []byte {
+	return u16(utf16.Encode([]rune(Text))...)
+}
+
+// 构造一个包含一个工作表的BIFF8数据流
+func testStream(t *testing.T) []byte {
+	t.Helper()
+
+	SheetBOF := biffRecord(recordBOF, u16(biff8Version, 0x0010), make([]byte, 12))
+	Sheet := bytes.Join([][]byte{
+		SheetBOF,
+		biffRecord(recordLabelSST, u16(0, 0, 0), u32(0)),
+		biffRecord(recordLabelSST, u16(0, 1, 0), u32(1)),
+		biffRecord(recordRK, u16(1, 0, 0), u32(42<<2|0x02)),
+		biffRecord(recordNumber, u16(1, 1, 1), binary.LittleEndian.AppendUint64(nil, math.Float64bits(45000))),
+		biffRecord(recordFormula, u16(2, 0, 0), []byte{0, 0, 0, 0, 0, 0, 0xFF, 0xFF}, make([]byte, 8)),
+		biffRecord(recordString, u16(2), []byte{0}, []byte("ok")),
+		biffRecord(recordMergeCells, u16(1, 3, 3, 0, 1)),
+		biffRecord(recordEOF),
+	}, nil)
+
+	// 第二个字符串跨越CONTINUE记录，后半部分以选项字节开头
+	SST := bytes.Join([][]byte{
+		biffRecord(recordSST, u32(2), u32(2), u16(5), []byte{0}, []byte("Hello"), u16(3), []byte{1}, utf16le("日")),
+		biffRecord(recordContinue, []byte{1}, utf16le("本語")),
+	}, nil)
+
+	BuildGlobals := func(Position uint32) []byte {
+		return bytes.Join([][]byte{
+			biffRecord(recordBOF, u16(biff8Version, 0x0005), make([]byte, 12)),
+			biffRecord(recordXF, u16(0, 0), make([]byte, 16)),
+			biffRecord(recordXF, u16(0, 14), make([]byte, 16)),
+			biffRecord(recordBoundSheet, u32(Position), []byte{0, 0, 5, 0}, []byte("Sheet")),
+			SST,
+			biffRecord(recordEOF),
+		}, nil)
+	}
+	// 工作表位置取决于全局部分的长度
+	Globals := BuildGlobals(0)
+	return append(BuildGlobals(uint32(len(Globals))), Sheet...)
+}
+
+func TestParse(t *testing.T) {
+	Workbook, err := Parse(testStream(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(Workbook.Sheets) != 1 || Workbook.Sheets[0].Name != "Sheet" {
+		t.Fatalf("Parse() sheets = %+v, want one sheet named %q", Workbook.Sheets, "Sheet")
+	}
+
+	Rows := Workbook.Sheets[0].Rows
+	tests := []struct {
+		name      string
+		row, col  int
+		want      any
+		isFormula bool
+		numFmt    int
+	}{
+		{name: "Shared string", row: 0, col: 0, want: "Hello"},
+		{name: "Shared string across CONTINUE", row: 0, col: 1, want: "日本語"},
+		{name: "RK number", row: 1, col: 0, want: float64(42)},
+		{name: "Date number", row: 1, col: 1, want: float64(45000), numFmt: 14},
+		{name: "String formula", row: 2, col: 0, want: "ok", isFormula: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.row >= len(Rows) || tt.col >= len(Rows[tt.row]) {
+				t.Fatalf("cell (%d, %d) is missing", tt.row, tt.col)
+			}
+			got := Rows[tt.row][tt.col]
+			if got.Value != tt.want || got.IsFormula != tt.isFormula || got.NumFmt != tt.numFmt {
+				t.Errorf("cell (%d, %d) = %+v, want %v (formula %v, format %d)", tt.row, tt.col, got, tt.want, tt.isFormula, tt.numFmt)
+			}
+		})
+	}
+
+	if Merges := Workbook.Sheets[0].Merges; len(Merges) != 1 || Merges[0] != (MergeRange{FirstRow: 3, LastRow: 3, FirstCol: 0, LastCol: 1}) {
+		t.Errorf("Parse() merges = %+v", Merges)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	Stream := testStream(t)
+	if _, err := Parse(Stream[:len(Stream)-10]); !errors.Is(err, ErrInvalidWorkbook) {
+		t.Errorf("Parse(truncated) error = %v, want %v", err, ErrInvalidWorkbook)
+	}
+
+	BIFF5 := biffRecord(recordBOF, u16(0x0500, 0x0005), make([]byte, 4))
+	if _, err := Parse(BIFF5); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("Parse(BIFF5) error = %v, want %v", err, ErrUnsupportedVersion)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	// LibreOffice Calc保存的工作簿，包含多个工作表和公式
+	Workbook, err := Open(filepath.Join("testdata", "calc.xls"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var Names []string
+	for _, Sheet := range Workbook.Sheets {
+		Names = append(Names, Sheet.Name)
+	}
+	if want := []string{"Test sheet 1", "Test sheet 2", "Sheet3"}; !slices.Equal(Names, want) {
+		t.Fatalf("Open() sheets = %q, want %q", Names, want)
+	}
+
+	tests := []struct {
+		name      string
+		sheet     int
+		row, col  int
+		want      any
+		isFormula bool
+	}{
+		{name: "Shared string", sheet: 0, row: 0, col: 0, want: "Test1"},
+		{name: "Shared string in row", sheet: 0, row: 1, col: 0, want: "Avocado"},
+		{name: "Number", sheet: 0, row: 2, col: 2, want: float64(5)},
+		{name: "Formula", sheet: 0, row: 3, col: 2, want: float64(7), isFormula: true},
+		{name: "Second sheet", sheet: 1, row: 0, col: 0, want: "Test2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			Rows := Workbook.Sheets[tt.sheet].Rows
+			if tt.row >= len(Rows) || tt.col >= len(Rows[tt.row]) {
+				t.Fatalf("cell (%d, %d) is missing", tt.row, tt.col)
+			}
+			got := Rows[tt.row][tt.col]
+			if got.Value != tt.want || got.IsFormula != tt.isFormula {
+				t.Errorf("cell (%d, %d) = %+v, want %v (formula %v)", tt.row, tt.col, got, tt.want, tt.isFormula)
+			}
+		})
+	}
+	if Rows := Workbook.Sheets[2].Rows; len(Rows) != 0 {
+		t.Errorf("Open() empty sheet rows = %v", Rows)
+	}
+}
@@ -0,0 +1,297 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-15 10:12:27
+ * @LastEditTime: 2025-07-15 15:36:49
+ * @LastEditors: nijineko
+ * @Description: YAML本地化资源文件处理，支持Rails和Hugo风格的语言文件
+ * @FilePath: \AutoTranslation\pkg\table\yaml\yaml.go
+ */
+package yaml
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/nijinekoyo/AutoTranslation/pkg/table/resource"
+	"golang.org/x/text/language"
+	"gopkg.in/yaml.v3"
+)
+
+// 资源文件格式
+var Format = resource.Format{Parse: Parse}
+
+// 根节点语言键的格式，如"ja"、"zh-CN"、"pt_BR"
+var languageKeyPattern = regexp.MustCompile(`^[a-z]{2,3}([-_][A-Za-z0-9]{2,8})*$`)
+
+// 解析后的YAML文件
+type document struct {
+	documents   []*yaml.Node // 文件中的各个文档
+	indent      int          // 缩进空格数
+	languageKey *yaml.Node   // Rails风格的根节点语言键，如"ja:"，没有时为nil
+	entries     []resource.Entry
+	nodes       map[*yaml.Node]int // 可翻译的字符串节点，值为条目索引
+}
+
+/**
+ * @description: 创建一个新的YAML资源文件处理实例
+ * @param {string} FilePath YAML文件路径
+ * @param {string} OutputPath 输出文件路径，为空时覆盖源文件
+ * @param {string} Language 目标语言，用于重命名根节点语言键和调整复数键
+ * @return {*resource.ResourceTable} 返回一个新的资源文件表格实例
+ * @return {error} 错误信息
+ */
+func New(FilePath, OutputPath, Language string) (*resource.ResourceTable, error) {
+	return resource.New(FilePath, OutputPath, Language, Format)
+}
+
+/**
+ * @description: 解析YAML文件，字符串叶子节点展开为以点连接的键路径，Rails风格的根节点语言键不计入键路径
+ * @param {[]byte} Data 文件内容
+ * @return {resource.Document} 解析后的文件
+ * @return {error} 错误信息
+ */
+func Parse(Data []byte) (resource.Document, error) {
+	Document := &document{indent: detectIndent(Data), nodes: map[*yaml.Node]int{}}
+
+	Decoder := yaml.NewDecoder(bytes.NewReader(Data))
+	for {
+		var Node yaml.Node
+		if err := Decoder.Decode(&Node); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		Document.documents = append(Document.documents, &Node)
+	}
+	if len(Document.documents) == 0 {
+		return nil, errors.New("empty yaml file")
+	}
+
+	for Index, Node := range Document.documents {
+		Prefix := ""
+		if len(Document.documents) > 1 {
+			Prefix = strconv.Itoa(Index)
+		}
+		Root := Node
+		if Root.Kind == yaml.DocumentNode && len(Root.Content) > 0 {
+			Root = Root.Content[0]
+		}
+
+		// 只有一个文档且根节点只有一个语言键时视为Rails风格
+		if len(Document.documents) == 1 && Root.Kind == yaml.MappingNode && len(Root.Content) == 2 && Root.Content[1].Kind == yaml.MappingNode && isLanguage(Root.Content[0].Value) {
+			Document.languageKey = Root.Content[0]
+			Root = Root.Content[1]
+		}
+		Document.collect(Root, Prefix)
+	}
+
+	return Document, nil
+}
+
+/**
+ * @description: 判断键是否为语言代码
+ * @param {string} Key 键
+ * @return {bool} 是否为语言代码
+ */
+func isLanguage(Key string) bool {
+	if !languageKeyPattern.MatchString(Key) {
+		return false
+	}
+	_, err := language.Parse(strings.ReplaceAll(Key, "_", "-"))
+	return err == nil
+}
+
+/**
+ * @description: 检测缩进空格数，以第一个缩进的映射键为准
+ * @param {[]byte} Data 文件内容
+ * @return {int} 缩进空格数，默认为2
+ */
+func detectIndent(Data []byte) int {
+	for _, Line := range strings.Split(string(Data), "\n") {
+		Content := strings.TrimLeft(Line, " ")
+		if Content == "" || Content == Line || strings.HasPrefix(Content, "#") || strings.HasPrefix(Content, "-") {
+			continue
+		}
+		return len(Line) - len(Content)
+	}
+	return 2
+}
+
+/**
+ * @description: 按文件顺序收集可翻译的字符串，别名引用的节点只在锚点处翻译一次
+ * @param {*yaml.Node} Node 节点
+ * @param {string} Path 节点的键路径
+ */
+func (d *document) collect(Node *yaml.Node, Path string) {
+	Join := func(Key string) string {
+		if Path == "" {
+			return Key
+		}
+		return Path + "." + Key
+	}
+
+	switch Node.Kind {
+	case yaml.MappingNode:
+		// Hugo旧格式的条目由id和translation组成，id不翻译
+		IsHugoItem := false
+		for Index := 0; Index+1 < len(Node.Content); Index += 2 {
+			IsHugoItem = IsHugoItem || Node.Content[Index].Value == "translation"
+		}
+		for Index := 0; Index+1 < len(Node.Content); Index += 2 {
+			if IsHugoItem && Node.Content[Index].Value == "id" {
+				continue
+			}
+			d.collect(Node.Content[Index+1], Join(Node.Content[Index].Value))
+		}
+	case yaml.SequenceNode:
+		for Index, Item := range Node.Content {
+			d.collect(Item, Join(strconv.Itoa(Index)))
+		}
+	case yaml.ScalarNode:
+		if Node.ShortTag() != "!!str" || Node.Value == "" {
+			return
+		}
+		d.nodes[Node] = len(d.entries)
+		d.entries = append(d.entries, resource.Entry{Key: Path, Source: Node.Value})
+	}
+}
+
+/**
+ * @description: 获取可翻译条目
+ * @return {[]resource.Entry} 可翻译条目
+ */
+func (d *document) Entries() []resource.Entry {
+	return d.entries
+}
+
+/**
+ * @description: 生成目标语言文件，保持注释、锚点、键的顺序和引号风格，重命名根节点语言键并按目标语言调整复数键
+ * @param {[]string} Translations 各条目的译文
+ * @param {string} Language 目标语言
+ * @return {[]byte} 文件内容
+ * @return {error} 错误信息
+ */
+func (d *document) Marshal(Translations []string, Language string) ([]byte, error) {
+	// 复制节点树后修改，源文件的节点保持不变
+	Clones := map[*yaml.Node]*yaml.Node{}
+	var Documents []*yaml.Node
+	for _, Node := range d.documents {
+		Documents = append(Documents, clone(Node, Clones))
+	}
+
+	for Node, Index := range d.nodes {
+		if Index >= len(Translations) || Translations[Index] == "" {
+			continue
+		}
+		Translation := Translations[Index]
+		// 块标量保留结尾的换行，避免改变块标量的截断方式
+		if Node.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0 && strings.HasSuffix(Node.Value, "\n") && !strings.HasSuffix(Translation, "\n") {
+			Translation += "\n"
+		}
+		Clones[Node].Value = Translation
+	}
+	if d.languageKey != nil && Language != "" {
+		Clones[d.languageKey].Value = Language
+	}
+	for _, Node := range Documents {
+		pluralize(Node, Language, map[*yaml.Node]bool{})
+	}
+
+	var Buffer bytes.Buffer
+	Encoder := yaml.NewEncoder(&Buffer)
+	Encoder.SetIndent(d.indent)
+	for _, Node := range Documents {
+		if err := Encoder.Encode(Node); err != nil {
+			return nil, err
+		}
+	}
+	if err := Encoder.Close(); err != nil {
+		return nil, err
+	}
+	return Buffer.Bytes(), nil
+}
+
+/**
+ * @description: 深复制节点树，别名指向复制后的锚点
+ * @param {*yaml.Node} Node 节点
+ * @param {map[*yaml.Node]*yaml.Node} Clones 已复制的节点，键为原节点
+ * @return {*yaml.Node} 复制后的节点
+ */
+func clone(Node *yaml.Node, Clones map[*yaml.Node]*yaml.Node) *yaml.Node {
+	if Node == nil {
+		return nil
+	}
+	if Clone, ok := Clones[Node]; ok {
+		return Clone
+	}
+
+	Clone := *Node
+	Clones[Node] = &Clone
+	Clone.Content = make([]*yaml.Node, len(Node.Content))
+	for Index, Item := range Node.Content {
+		Clone.Content[Index] = clone(Item, Clones)
+	}
+	Clone.Alias = clone(Node.Alias, Clones)
+	return &Clone
+}
+
+/**
+ * @description: 按目标语言调整复数映射，如Rails和Hugo的"one:"和"other:"，去除目标语言不使用的类别，缺少的类别使用other的译文
+ * @param {*yaml.Node} Node 节点
+ * @param {string} Language 目标语言
+ * @param {map[*yaml.Node]bool} Visited 已处理的节点，避免重复处理锚点
+ */
+func pluralize(Node *yaml.Node, Language string, Visited map[*yaml.Node]bool) {
+	if Visited[Node] {
+		return
+	}
+	Visited[Node] = true
+	for _, Item := range Node.Content {
+		pluralize(Item, Language, Visited)
+	}
+	if Node.Kind != yaml.MappingNode {
+		return
+	}
+
+	// 只有全部键都是复数类别且值都是字符串，并且包含other时才视为复数映射
+	Members := map[string]int{}
+	for Index := 0; Index+1 < len(Node.Content); Index += 2 {
+		Key, Value := Node.Content[Index], Node.Content[Index+1]
+		if !slices.Contains(resource.PluralCategories, Key.Value) || Value.Kind != yaml.ScalarNode || Value.ShortTag() != "!!str" {
+			return
+		}
+		Members[Key.Value] = Index
+	}
+	Other, ok := Members["other"]
+	if !ok {
+		return
+	}
+
+	var Categories []string
+	for _, Category := range resource.PluralCategories {
+		if _, ok := Members[Category]; ok {
+			Categories = append(Categories, Category)
+		}
+	}
+	Keep, Missing := resource.AdjustPlurals(Categories, Language)
+
+	var Content []*yaml.Node
+	for Index := 0; Index+1 < len(Node.Content); Index += 2 {
+		if slices.Contains(Keep, Node.Content[Index].Value) {
+			Content = append(Content, Node.Content[Index], Node.Content[Index+1])
+		}
+	}
+	for _, Category := range Missing {
+		Key, Value := *Node.Content[Other], *Node.Content[Other+1]
+		Key.Value = Category
+		Key.HeadComment, Key.LineComment, Key.FootComment = "", "", ""
+		Value.Anchor, Value.HeadComment, Value.LineComment, Value.FootComment = "", "", "", ""
+		Content = append(Content, &Key, &Value)
+	}
+	Node.Content = Content
+}
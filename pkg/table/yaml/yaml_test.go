@@ -0,0 +1,112 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-15 15:40:18
+ * @LastEditTime: 2025-07-15 16:12:05
+ * @LastEditors: nijineko
+ * @Description: YAML本地化资源文件处理测试
+ * @FilePath: \AutoTranslation\pkg\table\yaml\yaml_test.go
+ */
+package yaml
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const testLocale = `# Japanese locale
+ja:
+  greeting: こんにちは # inline comment
+  quoted: "引用"
+  single: 'シングル'
+  defaults: &defaults
+    ok: OK
+  copy: *defaults
+  count: 3
+  multiline: |
+    一行目
+    二行目
+  apples:
+    one: "%{count} 個"
+    other: "%{count} 個"
+`
+
+func TestYAML_RoundTrip(t *testing.T) {
+	Directory := t.TempDir()
+	FilePath := filepath.Join(Directory, "ja.yml")
+	OutputPath := filepath.Join(Directory, "zh-CN.yml")
+	if err := os.WriteFile(FilePath, []byte(testLocale), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	TableInstance, err := New(FilePath, OutputPath, "zh-CN")
+	if err != nil {
+		t.Fatal(err)
+	}
+	Datas, err := TableInstance.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 根节点语言键不计入键路径，别名和非字符串值不可翻译
+	var Keys []string
+	for _, Row := range Datas[1:] {
+		Keys = append(Keys, Row[2])
+	}
+	want := []string{"greeting", "quoted", "single", "defaults.ok", "multiline", "apples.one", "apples.other"}
+	if !reflect.DeepEqual(Keys, want) {
+		t.Fatalf("keys = %q, want %q", Keys, want)
+	}
+
+	for Index, Translation := range []string{"你好", "引用", "单引号", "好的", "第一行\n第二行", "%{count} 个", "%{count} 个"} {
+		Datas[Index+1][1] = Translation
+	}
+	if err := TableInstance.Write(Datas); err != nil {
+		t.Fatal(err)
+	}
+
+	Output := `# Japanese locale
+zh-CN:
+  greeting: 你好 # inline comment
+  quoted: "引用"
+  single: '单引号'
+  defaults: &defaults
+    ok: 好的
+  copy: *defaults
+  count: 3
+  multiline: |
+    第一行
+    第二行
+  apples:
+    other: "%{count} 个"
+`
+	if got, _ := os.ReadFile(OutputPath); string(got) != Output {
+		t.Errorf("output = %s, want %s", got, Output)
+	}
+
+	// 再次打开时按相同的键路径读取已有的译文
+	Reopened, err := New(FilePath, OutputPath, "zh-CN")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if Datas, _ := Reopened.Read(); Datas[1][1] != "你好" || Datas[7][1] != "%{count} 个" {
+		t.Errorf("existing translations = %q", Datas)
+	}
+}
+
+func TestParse_HugoStyle(t *testing.T) {
+	Document, err := Parse([]byte("- id: hello\n  translation: Hello\n- id: count\n  translation:\n    one: One item\n    other: \"{{ .Count }} items\"\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var Keys []string
+	for _, Entry := range Document.Entries() {
+		Keys = append(Keys, Entry.Key)
+	}
+	// id用于引用条目，不翻译
+	want := []string{"0.translation", "1.translation.one", "1.translation.other"}
+	if !reflect.DeepEqual(Keys, want) {
+		t.Errorf("keys = %q, want %q", Keys, want)
+	}
+}
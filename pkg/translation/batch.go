@@ -0,0 +1,93 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-04 11:02:15
+ * @LastEditTime: 2025-07-04 11:40:52
+ * @LastEditors: nijineko
+ * @Description: 批量翻译
+ * @FilePath: \AutoTranslation\pkg\translation\batch.go
+ */
+package translation
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	// 批量翻译结果数量与输入不一致
+	ErrBatchSizeMismatch = errors.New("batch translation failed: result count does not match input count")
+)
+
+// 批量翻译接口，返回结果与输入按索引一一对应
+type BatchTranslation interface {
+	Translation
+	TranslateBatch(Ctx context.Context, Texts []string, SourceLanguage *string, TargetLang string) ([]string, error)
+}
+
+/**
+ * @description: 批量翻译文本
+ * @param {context.Context} Ctx 上下文，用于取消请求
+ * @param {Translation} TranslatorInstance 翻译器实例，不支持批量翻译时逐条翻译
+ * @param {[]string} Texts 要翻译的文本列表
+ * @param {*string} SourceLanguage 源语言，为nil表示自动检测
+ * @param {string} TargetLanguage 目标语言
+ * @return {[]string} 返回翻译后的文本列表，顺序与输入一致
+ * @return {error} 错误信息
+ */
+func TranslateBatch(Ctx context.Context, TranslatorInstance Translation, Texts []string, SourceLanguage *string, TargetLanguage string) ([]string, error) {
+	return AsBatch(TranslatorInstance).TranslateBatch(Ctx, Texts, SourceLanguage, TargetLanguage)
+}
+
+/**
+ * @description: 获取翻译器的批量翻译实现
+ * @param {Translation} TranslatorInstance 翻译器实例
+ * @return {BatchTranslation} 翻译器本身支持批量翻译时直接返回，否则返回逐条翻译的适配器
+ */
+func AsBatch(TranslatorInstance Translation) BatchTranslation {
+	if BatchInstance, ok := TranslatorInstance.(BatchTranslation); ok {
+		return BatchInstance
+	}
+	return NewBatchAdapter(TranslatorInstance)
+}
+
+// 批量翻译适配器，将单条翻译器包装为批量翻译器
+type BatchAdapter struct {
+	Translation
+}
+
+/**
+ * @description: 创建一个新的批量翻译适配器
+ * @param {Translation} TranslatorInstance 单条翻译器实例
+ * @return {*BatchAdapter} BatchAdapter实例
+ */
+func NewBatchAdapter(TranslatorInstance Translation) *BatchAdapter {
+	return &BatchAdapter{
+		Translation: TranslatorInstance,
+	}
+}
+
+/**
+ * @description: 逐条翻译文本列表
+ * @param {context.Context} Ctx 上下文，用于取消请求
+ * @param {[]string} Texts 要翻译的文本列表
+ * @param {*string} SourceLanguage 源语言，为nil表示自动检测
+ * @param {string} TargetLanguage 目标语言
+ * @return {[]string} 返回翻译后的文本列表，顺序与输入一致
+ * @return {error} 错误信息
+ */
+func (b *BatchAdapter) TranslateBatch(Ctx context.Context, Texts []string, SourceLanguage *string, TargetLanguage string) ([]string, error) {
+	Results := make([]string, len(Texts))
+	for Index, Text := range Texts {
+		if err := Ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		TranslatedText, err := b.TranslateText(SelectNotes(Ctx, Index), Text, SourceLanguage, TargetLanguage)
+		if err != nil {
+			return nil, err
+		}
+		Results[Index] = TranslatedText
+	}
+
+	return Results, nil
+}
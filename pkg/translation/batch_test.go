@@ -0,0 +1,104 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-04 11:02:15
+ * @LastEditTime: 2025-07-04 11:40:52
+ * @LastEditors: nijineko
+ * @Description: 批量翻译测试
+ * @FilePath: \AutoTranslation\pkg\translation\batch_test.go
+ */
+package translation
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// 按固定映射返回译文的测试翻译器
+type mapTranslator map[string]string
+
+func (m mapTranslator) TranslateText(Ctx context.Context, Text string, SourceLanguage *string, TargetLang string) (string, error) {
+	return m[Text], nil
+}
+
+// 总是返回错误的测试翻译器
+type errorTranslator struct {
+	err error
+}
+
+func (e errorTranslator) TranslateText(Ctx context.Context, Text string, SourceLanguage *string, TargetLang string) (string, error) {
+	return "", e.err
+}
+
+// 自带批量翻译实现的测试翻译器
+type batchTranslator struct {
+	mapTranslator
+}
+
+func (b batchTranslator) TranslateBatch(Ctx context.Context, Texts []string, SourceLanguage *string, TargetLang string) ([]string, error) {
+	return Texts, nil
+}
+
+func TestBatchAdapter_TranslateBatch(t *testing.T) {
+	Canceled, Cancel := context.WithCancel(context.Background())
+	Cancel()
+
+	tests := []struct {
+		name       string
+		translator Translation
+		ctx        context.Context
+		texts      []string
+		want       []string
+		wantErr    bool
+	}{
+		{
+			name:       "keeps input order",
+			translator: mapTranslator{"はい": "是", "いいえ": "不", "東京": "东京"},
+			ctx:        context.Background(),
+			texts:      []string{"東京", "はい", "いいえ", "はい"},
+			want:       []string{"东京", "是", "不", "是"},
+		},
+		{
+			name:       "empty input",
+			translator: mapTranslator{},
+			ctx:        context.Background(),
+			texts:      []string{},
+			want:       []string{},
+		},
+		{
+			name:       "translator error",
+			translator: errorTranslator{err: errors.New("boom")},
+			ctx:        context.Background(),
+			texts:      []string{"はい"},
+			wantErr:    true,
+		},
+		{
+			name:       "canceled context",
+			translator: mapTranslator{"はい": "是"},
+			ctx:        Canceled,
+			texts:      []string{"はい"},
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewBatchAdapter(tt.translator).TranslateBatch(tt.ctx, tt.texts, nil, "zh-CN")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("BatchAdapter.TranslateBatch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("BatchAdapter.TranslateBatch() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAsBatch(t *testing.T) {
+	if _, ok := AsBatch(mapTranslator{}).(*BatchAdapter); !ok {
+		t.Errorf("AsBatch() did not wrap a single-text translator in BatchAdapter")
+	}
+	if _, ok := AsBatch(batchTranslator{}).(batchTranslator); !ok {
+		t.Errorf("AsBatch() wrapped a translator that already supports batches")
+	}
+}
@@ -0,0 +1,155 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-05 10:41:03
+ * @LastEditTime: 2025-07-07 10:36:47
+ * @LastEditors: nijineko
+ * @Description: 翻译记忆库缓存
+ * @FilePath: \AutoTranslation\pkg\translation\cache\cache.go
+ */
+package cache
+
+import (
+	"context"
+	"strings"
+
+	"github.com/nijinekoyo/AutoTranslation/internal/log"
+	"github.com/nijinekoyo/AutoTranslation/pkg/translation"
+)
+
+// 缓存作用域，区分不同翻译服务和模型配置的结果
+type Scope struct {
+	Service    string // 翻译服务名称
+	Model      string // 模型名称
+	PromptHash string // 提示词哈希
+	UsePrompt  bool   // 附加提示词和文本说明是否影响翻译结果，为true时二者参与缓存键计算
+}
+
+// 带翻译记忆库的翻译器
+type CacheTranslator struct {
+	translator translation.Translation
+	store      *Store
+	scope      Scope
+}
+
+/**
+ * @description: 为翻译器添加翻译记忆库，命中时不再请求翻译服务
+ * @param {translation.Translation} TranslatorInstance 翻译器实例
+ * @param {*Store} StoreInstance 翻译记忆库
+ * @param {Scope} CacheScope 缓存作用域
+ * @return {*CacheTranslator} CacheTranslator实例
+ */
+func New(TranslatorInstance translation.Translation, StoreInstance *Store, CacheScope Scope) *CacheTranslator {
+	return &CacheTranslator{
+		translator: TranslatorInstance,
+		store:      StoreInstance,
+		scope:      CacheScope,
+	}
+}
+
+/**
+ * @description: 翻译文本，优先使用翻译记忆库中的结果
+ * @param {context.Context} Ctx 上下文，用于取消请求
+ * @param {string} Text 要翻译的文本
+ * @param {*string} SourceLanguage 源语言，为nil表示自动检测
+ * @param {string} TargetLanguage 目标语言
+ * @return {string} 返回翻译后的文本
+ * @return {error} 错误信息
+ */
+func (c *CacheTranslator) TranslateText(Ctx context.Context, Text string, SourceLanguage *string, TargetLanguage string) (string, error) {
+	CacheKey := c.key(Ctx, Text, translation.NoteFrom(Ctx, 0), SourceLanguage, TargetLanguage)
+	if TranslatedText, ok := c.store.Get(CacheKey); ok {
+		return TranslatedText, nil
+	}
+
+	TranslatedText, err := c.translator.TranslateText(Ctx, Text, SourceLanguage, TargetLanguage)
+	if err != nil {
+		return "", err
+	}
+	c.save(CacheKey, TranslatedText)
+
+	return TranslatedText, nil
+}
+
+/**
+ * @description: 批量翻译文本，只请求未命中的文本
+ * @param {context.Context} Ctx 上下文，用于取消请求
+ * @param {[]string} Texts 要翻译的文本列表
+ * @param {*string} SourceLanguage 源语言，为nil表示自动检测
+ * @param {string} TargetLanguage 目标语言
+ * @return {[]string} 返回翻译后的文本列表，顺序与输入一致
+ * @return {error} 错误信息
+ */
+func (c *CacheTranslator) TranslateBatch(Ctx context.Context, Texts []string, SourceLanguage *string, TargetLanguage string) ([]string, error) {
+	Results := make([]string, len(Texts))
+	CacheKeys := make([]Key, len(Texts))
+
+	// 收集未命中的文本
+	var MissIndexes []int
+	var MissTexts []string
+	for Index, Text := range Texts {
+		CacheKeys[Index] = c.key(Ctx, Text, translation.NoteFrom(Ctx, Index), SourceLanguage, TargetLanguage)
+		if TranslatedText, ok := c.store.Get(CacheKeys[Index]); ok {
+			Results[Index] = TranslatedText
+			continue
+		}
+		MissIndexes = append(MissIndexes, Index)
+		MissTexts = append(MissTexts, Text)
+	}
+	if len(MissTexts) == 0 {
+		return Results, nil
+	}
+
+	TranslatedTexts, err := translation.TranslateBatch(translation.SelectNotes(Ctx, MissIndexes...), c.translator, MissTexts, SourceLanguage, TargetLanguage)
+	if err != nil {
+		return nil, err
+	}
+	if len(TranslatedTexts) != len(MissTexts) {
+		return nil, translation.ErrBatchSizeMismatch
+	}
+	for MissIndex, Index := range MissIndexes {
+		Results[Index] = TranslatedTexts[MissIndex]
+		c.save(CacheKeys[Index], TranslatedTexts[MissIndex])
+	}
+
+	return Results, nil
+}
+
+/**
+ * @description: 生成缓存键
+ * @param {context.Context} Ctx 上下文，用于读取附加提示词
+ * @param {string} Text 源文本
+ * @param {string} Note 源文本的说明，与附加提示词一样会影响翻译结果
+ * @param {*string} SourceLanguage 源语言，为nil表示自动检测
+ * @param {string} TargetLanguage 目标语言
+ * @return {Key} 缓存键
+ */
+func (c *CacheTranslator) key(Ctx context.Context, Text, Note string, SourceLanguage *string, TargetLanguage string) Key {
+	CacheKey := Key{
+		SourceText:     Text,
+		TargetLanguage: TargetLanguage,
+		Service:        c.scope.Service,
+		Model:          c.scope.Model,
+		PromptHash:     c.scope.PromptHash,
+	}
+	if SourceLanguage != nil {
+		CacheKey.SourceLanguage = *SourceLanguage
+	}
+	if Prompt := translation.PromptFrom(Ctx); c.scope.UsePrompt && (Prompt != "" || Note != "") {
+		CacheKey.PromptHash = translation.HashText(c.scope.PromptHash, Prompt, Note)
+	}
+	return CacheKey
+}
+
+/**
+ * @description: 保存翻译结果，为空或与原文相同的结果可能是翻译失败，不保存以免之后一直复用，写入失败不影响翻译流程
+ * @param {Key} CacheKey 缓存键
+ * @param {string} TranslatedText 翻译结果
+ */
+func (c *CacheTranslator) save(CacheKey Key, TranslatedText string) {
+	if Text := strings.TrimSpace(TranslatedText); Text == "" || Text == strings.TrimSpace(CacheKey.SourceText) {
+		return
+	}
+	if err := c.store.Put(CacheKey, TranslatedText); err != nil {
+		log.Print().Warning("Cache", "Failed to save translation to cache:", err)
+	}
+}
@@ -0,0 +1,159 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-05 11:48:37
+ * @LastEditTime: 2025-07-05 11:52:16
+ * @LastEditors: nijineko
+ * @Description: 翻译记忆库缓存测试
+ * @FilePath: \AutoTranslation\pkg\translation\cache\cache_test.go
+ */
+package cache
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/nijinekoyo/AutoTranslation/pkg/translation"
+)
+
+// 将说明附加到译文中的测试翻译器，用于检查说明是否与文本对应
+type noteTranslator struct {
+	calls *[][]string
+}
+
+func (n noteTranslator) TranslateText(Ctx context.Context, Text string, SourceLanguage *string, TargetLanguage string) (string, error) {
+	Results, err := n.TranslateBatch(Ctx, []string{Text}, SourceLanguage, TargetLanguage)
+	if err != nil {
+		return "", err
+	}
+	return Results[0], nil
+}
+
+func (n noteTranslator) TranslateBatch(Ctx context.Context, Texts []string, SourceLanguage *string, TargetLanguage string) ([]string, error) {
+	*n.calls = append(*n.calls, Texts)
+	Results := make([]string, len(Texts))
+	for Index, Text := range Texts {
+		Results[Index] = Text + "/" + translation.NoteFrom(Ctx, Index)
+	}
+	return Results, nil
+}
+
+func TestCacheTranslator_TranslateBatch(t *testing.T) {
+	StoreInstance, err := Open(filepath.Join(t.TempDir(), "cache.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer StoreInstance.Close()
+
+	tests := []struct {
+		name      string
+		texts     []string
+		notes     []string
+		want      []string
+		wantCalls [][]string
+	}{
+		{
+			name:      "same text with different notes",
+			texts:     []string{"Open", "Open", "Close"},
+			notes:     []string{"verb", "adjective", ""},
+			want:      []string{"Open/verb", "Open/adjective", "Close/"},
+			wantCalls: [][]string{{"Open", "Open", "Close"}},
+		},
+		{
+			name:      "cached entries keep their own notes",
+			texts:     []string{"Open", "Save", "Open"},
+			notes:     []string{"adjective", "menu", "verb"},
+			want:      []string{"Open/adjective", "Save/menu", "Open/verb"},
+			wantCalls: [][]string{{"Save"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var Calls [][]string
+			c := New(noteTranslator{calls: &Calls}, StoreInstance, Scope{Service: "test", UsePrompt: true})
+
+			got, err := c.TranslateBatch(translation.WithNotes(context.Background(), tt.notes), tt.texts, nil, "zh-CN")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CacheTranslator.TranslateBatch() = %q, want %q", got, tt.want)
+			}
+			if !reflect.DeepEqual(Calls, tt.wantCalls) {
+				t.Errorf("CacheTranslator.TranslateBatch() calls = %q, want %q", Calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+// 按映射返回译文的测试翻译器，记录请求次数
+type mapTranslator struct {
+	results map[string]string
+	calls   *int
+}
+
+func (m mapTranslator) TranslateText(Ctx context.Context, Text string, SourceLanguage *string, TargetLanguage string) (string, error) {
+	*m.calls++
+	return m.results[Text], nil
+}
+
+func TestCacheTranslator_Unacceptable(t *testing.T) {
+	StoreInstance, err := Open(filepath.Join(t.TempDir(), "cache.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer StoreInstance.Close()
+
+	tests := []struct {
+		name      string
+		text      string
+		want      string
+		wantCalls int // 翻译两次的请求次数
+	}{
+		{name: "translated", text: "Open", want: "打开", wantCalls: 1},
+		{name: "empty result", text: "Save", want: "", wantCalls: 2},
+		{name: "same as source", text: "Close", want: "Close", wantCalls: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			Calls := 0
+			c := New(mapTranslator{results: map[string]string{"Open": "打开", "Close": "Close"}, calls: &Calls}, StoreInstance, Scope{Service: "test"})
+
+			// 为空或与原文相同的结果不保存，再次翻译时重新请求
+			for range 2 {
+				got, err := c.TranslateText(context.Background(), tt.text, nil, "zh-CN")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got != tt.want {
+					t.Errorf("CacheTranslator.TranslateText() = %q, want %q", got, tt.want)
+				}
+			}
+			if Calls != tt.wantCalls {
+				t.Errorf("CacheTranslator.TranslateText() calls = %d, want %d", Calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestCacheTranslator_TargetLanguage(t *testing.T) {
+	StoreInstance, err := Open(filepath.Join(t.TempDir(), "cache.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer StoreInstance.Close()
+
+	Calls := 0
+	c := New(mapTranslator{results: map[string]string{"Open": "打开"}, calls: &Calls}, StoreInstance, Scope{Service: "test"})
+
+	// 不同列映射的目标语言分别缓存
+	for _, TargetLanguage := range []string{"ko", "zh-TW", "ko"} {
+		if _, err := c.TranslateText(context.Background(), "Open", nil, TargetLanguage); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if Calls != 2 {
+		t.Errorf("CacheTranslator.TranslateText() calls = %d, want one per target language", Calls)
+	}
+}
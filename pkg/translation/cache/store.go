@@ -0,0 +1,345 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-05 10:05:22
+ * @LastEditTime: 2025-07-05 11:48:37
+ * @LastEditors: nijineko
+ * @Description: 翻译记忆库存储
+ * @FilePath: \AutoTranslation\pkg\translation\cache\store.go
+ */
+package cache
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"sync/atomic"
+	"time"
+
+	"github.com/nijinekoyo/AutoTranslation/pkg/translation"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	entriesBucket = []byte("entries") // 翻译条目
+	statsBucket   = []byte("stats")   // 累计命中统计
+
+	hitsKey   = []byte("hits")
+	missesKey = []byte("misses")
+)
+
+// 缓存键，相同键的翻译结果可以直接复用
+type Key struct {
+	SourceText     string `json:"source_text"`     // 源文本
+	SourceLanguage string `json:"source_language"` // 源语言，自动检测时为空
+	TargetLanguage string `json:"target_language"` // 目标语言
+	Service        string `json:"service"`         // 翻译服务名称
+	Model          string `json:"model"`           // 模型名称，非大语言模型为空
+	PromptHash     string `json:"prompt_hash"`     // 提示词哈希，非大语言模型为空
+}
+
+// 缓存条目
+type Entry struct {
+	Key
+	Translation string    `json:"translation"` // 翻译结果
+	UpdatedAt   time.Time `json:"updated_at"`  // 更新时间
+}
+
+// 缓存统计
+type Stats struct {
+	Entries        int            // 条目总数
+	ServiceEntries map[string]int // 各翻译服务的条目数量
+	Hits, Misses   int64          // 本次运行的命中与未命中次数
+	TotalHits      int64          // 累计命中次数
+	TotalMisses    int64          // 累计未命中次数
+}
+
+// 翻译记忆库
+type Store struct {
+	db *bolt.DB
+
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+/**
+ * @description: 打开翻译记忆库，文件不存在时自动创建
+ * @param {string} FilePath 数据库文件路径
+ * @return {*Store} Store实例
+ * @return {error} 错误信息
+ */
+func Open(FilePath string) (*Store, error) {
+	DB, err := bolt.Open(FilePath, 0644, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	err = DB.Update(func(Tx *bolt.Tx) error {
+		if _, err := Tx.CreateBucketIfNotExists(entriesBucket); err != nil {
+			return err
+		}
+		_, err := Tx.CreateBucketIfNotExists(statsBucket)
+		return err
+	})
+	if err != nil {
+		DB.Close()
+		return nil, err
+	}
+
+	return &Store{db: DB}, nil
+}
+
+/**
+ * @description: 保存累计统计并关闭翻译记忆库
+ * @return {error} 错误信息
+ */
+func (s *Store) Close() error {
+	err := s.db.Update(func(Tx *bolt.Tx) error {
+		Bucket := Tx.Bucket(statsBucket)
+		if err := addCounter(Bucket, hitsKey, s.hits.Swap(0)); err != nil {
+			return err
+		}
+		return addCounter(Bucket, missesKey, s.misses.Swap(0))
+	})
+	if CloseErr := s.db.Close(); err == nil {
+		err = CloseErr
+	}
+	return err
+}
+
+/**
+ * @description: 查询翻译结果并记录命中统计
+ * @param {Key} CacheKey 缓存键
+ * @return {string} 翻译结果
+ * @return {bool} 是否命中
+ */
+func (s *Store) Get(CacheKey Key) (string, bool) {
+	var CacheEntry Entry
+	Found := false
+	s.db.View(func(Tx *bolt.Tx) error {
+		Value := Tx.Bucket(entriesBucket).Get(CacheKey.hash())
+		if Value == nil {
+			return nil
+		}
+		if err := json.Unmarshal(Value, &CacheEntry); err != nil {
+			return err
+		}
+		Found = true
+		return nil
+	})
+
+	if !Found {
+		s.misses.Add(1)
+		return "", false
+	}
+	s.hits.Add(1)
+	return CacheEntry.Translation, true
+}
+
+/**
+ * @description: 保存翻译结果
+ * @param {Key} CacheKey 缓存键
+ * @param {string} Translation 翻译结果
+ * @return {error} 错误信息
+ */
+func (s *Store) Put(CacheKey Key, Translation string) error {
+	return s.put(Entry{Key: CacheKey, Translation: Translation, UpdatedAt: time.Now()})
+}
+
+/**
+ * @description: 获取缓存统计
+ * @return {Stats} 统计数据
+ * @return {error} 错误信息
+ */
+func (s *Store) Stats() (Stats, error) {
+	StatsData := Stats{
+		ServiceEntries: map[string]int{},
+		Hits:           s.hits.Load(),
+		Misses:         s.misses.Load(),
+	}
+
+	err := s.db.View(func(Tx *bolt.Tx) error {
+		StatsData.TotalHits = getCounter(Tx.Bucket(statsBucket), hitsKey) + StatsData.Hits
+		StatsData.TotalMisses = getCounter(Tx.Bucket(statsBucket), missesKey) + StatsData.Misses
+
+		return Tx.Bucket(entriesBucket).ForEach(func(_, Value []byte) error {
+			var CacheEntry Entry
+			if err := json.Unmarshal(Value, &CacheEntry); err != nil {
+				return err
+			}
+			StatsData.Entries++
+			StatsData.ServiceEntries[CacheEntry.Service]++
+			return nil
+		})
+	})
+
+	return StatsData, err
+}
+
+/**
+ * @description: 以JSON Lines格式导出全部条目
+ * @param {io.Writer} Writer 输出
+ * @return {int} 导出的条目数量
+ * @return {error} 错误信息
+ */
+func (s *Store) Export(Writer io.Writer) (int, error) {
+	Count := 0
+	Encoder := json.NewEncoder(Writer)
+	Encoder.SetEscapeHTML(false)
+
+	err := s.db.View(func(Tx *bolt.Tx) error {
+		return Tx.Bucket(entriesBucket).ForEach(func(_, Value []byte) error {
+			var CacheEntry Entry
+			if err := json.Unmarshal(Value, &CacheEntry); err != nil {
+				return err
+			}
+			Count++
+			return Encoder.Encode(CacheEntry)
+		})
+	})
+
+	return Count, err
+}
+
+/**
+ * @description: 从JSON Lines格式导入条目，已存在的条目会被覆盖
+ * @param {io.Reader} Reader 输入
+ * @return {int} 导入的条目数量
+ * @return {error} 错误信息
+ */
+func (s *Store) Import(Reader io.Reader) (int, error) {
+	Count := 0
+	Decoder := json.NewDecoder(bufio.NewReader(Reader))
+
+	err := s.db.Update(func(Tx *bolt.Tx) error {
+		Bucket := Tx.Bucket(entriesBucket)
+		for {
+			var CacheEntry Entry
+			if err := Decoder.Decode(&CacheEntry); err == io.EOF {
+				return nil
+			} else if err != nil {
+				return err
+			}
+			if CacheEntry.UpdatedAt.IsZero() {
+				CacheEntry.UpdatedAt = time.Now()
+			}
+
+			Value, err := json.Marshal(CacheEntry)
+			if err != nil {
+				return err
+			}
+			if err := Bucket.Put(CacheEntry.hash(), Value); err != nil {
+				return err
+			}
+			Count++
+		}
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return Count, nil
+}
+
+/**
+ * @description: 清除缓存条目
+ * @param {string} Service 只清除指定翻译服务的条目，为空时清除全部条目和统计
+ * @return {int} 清除的条目数量
+ * @return {error} 错误信息
+ */
+func (s *Store) Purge(Service string) (int, error) {
+	Count := 0
+	err := s.db.Update(func(Tx *bolt.Tx) error {
+		if Service == "" {
+			Count = Tx.Bucket(entriesBucket).Stats().KeyN
+			s.hits.Store(0)
+			s.misses.Store(0)
+			for _, Name := range [][]byte{entriesBucket, statsBucket} {
+				if err := Tx.DeleteBucket(Name); err != nil {
+					return err
+				}
+				if _, err := Tx.CreateBucket(Name); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		// 先收集再删除，遍历时不能修改桶
+		var Keys [][]byte
+		Bucket := Tx.Bucket(entriesBucket)
+		err := Bucket.ForEach(func(Key, Value []byte) error {
+			var CacheEntry Entry
+			if err := json.Unmarshal(Value, &CacheEntry); err != nil {
+				return err
+			}
+			if CacheEntry.Service == Service {
+				Keys = append(Keys, Key)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, Key := range Keys {
+			if err := Bucket.Delete(Key); err != nil {
+				return err
+			}
+		}
+		Count = len(Keys)
+		return nil
+	})
+
+	return Count, err
+}
+
+/**
+ * @description: 写入条目
+ * @param {Entry} CacheEntry 条目
+ * @return {error} 错误信息
+ */
+func (s *Store) put(CacheEntry Entry) error {
+	Value, err := json.Marshal(CacheEntry)
+	if err != nil {
+		return err
+	}
+	// 并发写入时合并为同一个事务，减少磁盘同步次数
+	return s.db.Batch(func(Tx *bolt.Tx) error {
+		return Tx.Bucket(entriesBucket).Put(CacheEntry.hash(), Value)
+	})
+}
+
+/**
+ * @description: 计算缓存键的存储哈希
+ * @return {[]byte} 哈希值
+ */
+func (k Key) hash() []byte {
+	return []byte(translation.HashText(k.SourceText, k.SourceLanguage, k.TargetLanguage, k.Service, k.Model, k.PromptHash))
+}
+
+/**
+ * @description: 读取计数器
+ * @param {*bolt.Bucket} Bucket 统计桶
+ * @param {[]byte} Name 计数器名称
+ * @return {int64} 计数值
+ */
+func getCounter(Bucket *bolt.Bucket, Name []byte) int64 {
+	Value := Bucket.Get(Name)
+	if len(Value) != 8 {
+		return 0
+	}
+	return int64(binary.BigEndian.Uint64(Value))
+}
+
+/**
+ * @description: 累加计数器
+ * @param {*bolt.Bucket} Bucket 统计桶
+ * @param {[]byte} Name 计数器名称
+ * @param {int64} Delta 增量
+ * @return {error} 错误信息
+ */
+func addCounter(Bucket *bolt.Bucket, Name []byte, Delta int64) error {
+	Value := make([]byte, 8)
+	binary.BigEndian.PutUint64(Value, uint64(getCounter(Bucket, Name)+Delta))
+	return Bucket.Put(Name, Value)
+}
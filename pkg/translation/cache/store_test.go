@@ -0,0 +1,67 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-05 11:30:18
+ * @LastEditTime: 2025-07-05 11:46:02
+ * @LastEditors: nijineko
+ * @Description: 翻译记忆库存储测试
+ * @FilePath: \AutoTranslation\pkg\translation\cache\store_test.go
+ */
+package cache
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/nijinekoyo/AutoTranslation/pkg/translation"
+)
+
+func TestStore(t *testing.T) {
+	StoreInstance, err := Open(filepath.Join(t.TempDir(), "cache.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer StoreInstance.Close()
+
+	GoogleKey := Key{SourceText: "こんにちは", SourceLanguage: "ja-JP", TargetLanguage: "zh-CN", Service: "google"}
+	OpenAIKey := Key{SourceText: "こんにちは", SourceLanguage: "ja-JP", TargetLanguage: "zh-CN", Service: "openai", Model: "gpt-4o", PromptHash: translation.HashText("prompt")}
+
+	if err := StoreInstance.Put(GoogleKey, "你好"); err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := StoreInstance.Get(GoogleKey); !ok || got != "你好" {
+		t.Errorf("Store.Get() = %v, %v, want 你好, true", got, ok)
+	}
+	if _, ok := StoreInstance.Get(OpenAIKey); ok {
+		t.Errorf("Store.Get() hit for a different service")
+	}
+	if err := StoreInstance.Put(OpenAIKey, "您好"); err != nil {
+		t.Fatal(err)
+	}
+
+	Stats, err := StoreInstance.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if Stats.Entries != 2 || Stats.Hits != 1 || Stats.Misses != 1 {
+		t.Errorf("Store.Stats() = %+v", Stats)
+	}
+
+	// 导出后清空再导入
+	var Buffer bytes.Buffer
+	if Count, err := StoreInstance.Export(&Buffer); err != nil || Count != 2 {
+		t.Fatalf("Store.Export() = %v, %v", Count, err)
+	}
+	if Count, err := StoreInstance.Purge("openai"); err != nil || Count != 1 {
+		t.Fatalf("Store.Purge(openai) = %v, %v", Count, err)
+	}
+	if Count, err := StoreInstance.Purge(""); err != nil || Count != 1 {
+		t.Fatalf("Store.Purge() = %v, %v", Count, err)
+	}
+	if Count, err := StoreInstance.Import(&Buffer); err != nil || Count != 2 {
+		t.Fatalf("Store.Import() = %v, %v", Count, err)
+	}
+	if got, ok := StoreInstance.Get(OpenAIKey); !ok || got != "您好" {
+		t.Errorf("Store.Get() after import = %v, %v, want 您好, true", got, ok)
+	}
+}
@@ -0,0 +1,198 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-05 14:12:40
+ * @LastEditTime: 2025-07-05 15:36:11
+ * @LastEditors: nijineko
+ * @Description: 翻译服务降级链
+ * @FilePath: \AutoTranslation\pkg\translation\fallback.go
+ */
+package translation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/nijinekoyo/AutoTranslation/internal/log"
+)
+
+var (
+	// 降级链中没有翻译服务
+	ErrNoService = errors.New("translation failed: no translation service configured")
+)
+
+// 降级链中的翻译服务
+type Service struct {
+	Name       string      // 翻译服务名称
+	Translator Translation // 翻译器实例
+}
+
+// 翻译服务降级链，按顺序尝试，前一个服务失败时使用下一个服务
+type FallbackTranslator struct {
+	services []Service
+}
+
+/**
+ * @description: 创建一个新的翻译服务降级链
+ * @param {...Service} Services 按优先级排列的翻译服务
+ * @return {*FallbackTranslator} FallbackTranslator实例
+ */
+func NewFallbackTranslator(Services ...Service) *FallbackTranslator {
+	return &FallbackTranslator{
+		services: Services,
+	}
+}
+
+/**
+ * @description: 翻译文本
+ * @param {context.Context} Ctx 上下文，用于取消请求
+ * @param {string} Text 要翻译的文本
+ * @param {*string} SourceLanguage 源语言，为nil表示自动检测
+ * @param {string} TargetLanguage 目标语言
+ * @return {string} 返回翻译后的文本
+ * @return {error} 错误信息
+ */
+func (f *FallbackTranslator) TranslateText(Ctx context.Context, Text string, SourceLanguage *string, TargetLanguage string) (string, error) {
+	Results, _, err := f.TranslateBatchWithProvenance(Ctx, []string{Text}, SourceLanguage, TargetLanguage)
+	if err != nil {
+		return "", err
+	}
+	return Results[0], nil
+}
+
+/**
+ * @description: 批量翻译文本
+ * @param {context.Context} Ctx 上下文，用于取消请求
+ * @param {[]string} Texts 要翻译的文本列表
+ * @param {*string} SourceLanguage 源语言，为nil表示自动检测
+ * @param {string} TargetLanguage 目标语言
+ * @return {[]string} 返回翻译后的文本列表，顺序与输入一致
+ * @return {error} 错误信息
+ */
+func (f *FallbackTranslator) TranslateBatch(Ctx context.Context, Texts []string, SourceLanguage *string, TargetLanguage string) ([]string, error) {
+	Results, _, err := f.TranslateBatchWithProvenance(Ctx, Texts, SourceLanguage, TargetLanguage)
+	return Results, err
+}
+
+/**
+ * @description: 批量翻译文本，并返回每条译文来自哪个翻译服务
+ * @param {context.Context} Ctx 上下文，用于取消请求
+ * @param {[]string} Texts 要翻译的文本列表
+ * @param {*string} SourceLanguage 源语言，为nil表示自动检测
+ * @param {string} TargetLanguage 目标语言
+ * @return {[]string} 返回翻译后的文本列表，顺序与输入一致
+ * @return {[]string} 返回每条译文对应的翻译服务名称
+ * @return {error} 错误信息
+ */
+func (f *FallbackTranslator) TranslateBatchWithProvenance(Ctx context.Context, Texts []string, SourceLanguage *string, TargetLanguage string) ([]string, []string, error) {
+	if len(f.services) == 0 {
+		return nil, nil, ErrNoService
+	}
+
+	Results := make([]string, len(Texts))
+	Providers := make([]string, len(Texts))
+
+	// 待翻译的条目索引，每经过一个服务只保留失败的条目
+	Pending := make([]int, len(Texts))
+	for Index := range Texts {
+		Pending[Index] = Index
+	}
+
+	var LastErr error
+	for ServiceIndex, ServiceInstance := range f.services {
+		if len(Pending) == 0 {
+			break
+		}
+		if err := Ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+		IsLast := ServiceIndex == len(f.services)-1
+
+		PendingTexts := make([]string, len(Pending))
+		for Index, TextIndex := range Pending {
+			PendingTexts[Index] = Texts[TextIndex]
+		}
+
+		TranslatedTexts, err := TranslateBatch(SelectNotes(Ctx, Pending...), ServiceInstance.Translator, PendingTexts, SourceLanguage, TargetLanguage)
+		if err == nil && len(TranslatedTexts) != len(PendingTexts) {
+			err = ErrBatchSizeMismatch
+		}
+		if err != nil {
+			LastErr = err
+			if !IsLast {
+				log.Print().Warning("Translation", fmt.Sprintf("Service %s failed, falling back to %s:", ServiceInstance.Name, f.services[ServiceIndex+1].Name), err)
+			}
+			continue
+		}
+
+		var Failed []int
+		for Index, TextIndex := range Pending {
+			// 最后一个服务的结果即使不理想也保留，优先保留之前服务给出的非空结果
+			if IsLast {
+				if Providers[TextIndex] == "" || TranslatedTexts[Index] != "" {
+					Results[TextIndex] = TranslatedTexts[Index]
+					Providers[TextIndex] = ServiceInstance.Name
+				}
+				continue
+			}
+
+			Results[TextIndex] = TranslatedTexts[Index]
+			Providers[TextIndex] = ServiceInstance.Name
+			if !IsAcceptable(Texts[TextIndex], TranslatedTexts[Index], SourceLanguage, TargetLanguage) {
+				Failed = append(Failed, TextIndex)
+			}
+		}
+		if len(Failed) > 0 && !IsLast {
+			log.Print().Warning("Translation", fmt.Sprintf("Service %s returned %d unusable translations, falling back to %s", ServiceInstance.Name, len(Failed), f.services[ServiceIndex+1].Name))
+		}
+		Pending = Failed
+	}
+
+	// 所有服务都失败的条目
+	for _, TextIndex := range Pending {
+		if Providers[TextIndex] == "" {
+			return nil, nil, fmt.Errorf("all translation services failed: %w", LastErr)
+		}
+	}
+
+	return Results, Providers, nil
+}
+
+/**
+ * @description: 判断译文是否可用，译文为空或源语言与目标语言不同但译文与原文相同时视为不可用
+ * @param {string} Text 原文
+ * @param {string} TranslatedText 译文
+ * @param {*string} SourceLanguage 源语言，为nil表示自动检测
+ * @param {string} TargetLanguage 目标语言
+ * @return {bool} 是否可用
+ */
+func IsAcceptable(Text, TranslatedText string, SourceLanguage *string, TargetLanguage string) bool {
+	if strings.TrimSpace(TranslatedText) == "" {
+		return strings.TrimSpace(Text) == ""
+	}
+
+	// 自动检测源语言时无法判断是否需要翻译
+	if SourceLanguage == nil || sameLanguage(*SourceLanguage, TargetLanguage) {
+		return true
+	}
+	// 不包含文字的文本（数字、符号等）翻译前后相同是正常的
+	if strings.IndexFunc(Text, unicode.IsLetter) < 0 {
+		return true
+	}
+	return strings.TrimSpace(Text) != strings.TrimSpace(TranslatedText)
+}
+
+/**
+ * @description: 判断两个语言代码是否相同，忽略大小写和分隔符差异
+ * @param {string} A 语言代码
+ * @param {string} B 语言代码
+ * @return {bool} 是否相同
+ */
+func sameLanguage(A, B string) bool {
+	Normalize := func(Language string) string {
+		return strings.ToLower(strings.ReplaceAll(Language, "_", "-"))
+	}
+	return Normalize(A) == Normalize(B)
+}
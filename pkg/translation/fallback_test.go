@@ -0,0 +1,46 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-05 15:20:33
+ * @LastEditTime: 2025-07-05 15:34:58
+ * @LastEditors: nijineko
+ * @Description: 翻译服务降级链测试
+ * @FilePath: \AutoTranslation\pkg\translation\fallback_test.go
+ */
+package translation
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestFallbackTranslator_TranslateBatchWithProvenance(t *testing.T) {
+	SourceLanguage := "ja-JP"
+
+	f := NewFallbackTranslator(
+		Service{Name: "broken", Translator: &flakyTranslator{failures: 100, err: errors.New("boom")}},
+		Service{Name: "partial", Translator: mapTranslator{"はい": "是", "いいえ": "いいえ"}},
+		Service{Name: "full", Translator: mapTranslator{"はい": "对", "いいえ": "不", "東京": "东京", "123": "123"}},
+	)
+
+	got, gotProviders, err := f.TranslateBatchWithProvenance(context.Background(), []string{"はい", "いいえ", "東京", "123"}, &SourceLanguage, "zh-CN")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"是", "不", "东京", "123"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("FallbackTranslator.TranslateBatchWithProvenance() = %q, want %q", got, want)
+	}
+	if want := []string{"partial", "full", "full", "full"}; !reflect.DeepEqual(gotProviders, want) {
+		t.Errorf("FallbackTranslator.TranslateBatchWithProvenance() providers = %q, want %q", gotProviders, want)
+	}
+}
+
+func TestFallbackTranslator_AllFailed(t *testing.T) {
+	f := NewFallbackTranslator(
+		Service{Name: "broken", Translator: &flakyTranslator{failures: 100, err: errors.New("boom")}},
+	)
+	if _, err := f.TranslateText(context.Background(), "はい", nil, "zh-CN"); err == nil {
+		t.Errorf("FallbackTranslator.TranslateText() error = nil, want error")
+	}
+}
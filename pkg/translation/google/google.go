@@ -1,7 +1,7 @@
 /*
  * @Author: nijineko
  * @Date: 2025-07-03 16:41:28
- * @LastEditTime: 2025-07-03 17:00:49
+ * @LastEditTime: 2025-07-04 17:15:40
  * @LastEditors: nijineko
  * @Description: Google翻译实现
  * @FilePath: \AutoTranslation\pkg\translation\google\google.go
@@ -9,13 +9,23 @@
 package google
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
 
-	"github.com/HyacinthusAcademy/yuzuhttp"
+	"github.com/nijinekoyo/AutoTranslation/pkg/translation"
 )
 
 const (
-	APIURL = "https://translate.google.com/translate_a/single"
+	APIURL      = "https://translate.google.com/translate_a/single"
+	BatchAPIURL = "https://translate.googleapis.com/translate_a/t" // 批量翻译接口，支持多个q参数
+
+	MaxBatchSize       = 128  // 单次批量请求最多包含的文本数量
+	MaxBatchCharacters = 5000 // 单次批量请求最多包含的文本字节数
 )
 
 var (
@@ -26,38 +36,48 @@ var (
 )
 
 // Goole翻译结构体
-type GoogleTranslator struct{}
+type GoogleTranslator struct {
+	client *http.Client
+}
 
 /**
  * @description: 创建一个新的Google翻译实例
  * @return {*GoogleTranslator} GoogleTranslator实例
  */
 func New() *GoogleTranslator {
-	return &GoogleTranslator{}
+	return &GoogleTranslator{
+		client: http.DefaultClient,
+	}
 }
 
 /**
  * @description: 翻译文本
+ * @param {context.Context} Ctx 上下文，取消时中断进行中的请求
  * @param {string} Text 要翻译的文本
  * @param {*string} SourceLanguage 源语言，为nil表示自动检测
  * @param {string} TargetLanguage 目标语言
  * @return {string} 返回翻译后的文本
  * @return {error} 错误信息
  */
-func (g *GoogleTranslator) TranslateText(Text string, SourceLanguage *string, TargetLanguage string) (string, error) {
-	Request := yuzuhttp.Get(APIURL).
-		AddQuery("client", "gtx").
-		AddQuery("tl", TargetLanguage).
-		AddQuery("dt", "t").
-		AddQuery("q", Text)
+func (g *GoogleTranslator) TranslateText(Ctx context.Context, Text string, SourceLanguage *string, TargetLanguage string) (string, error) {
+	Query := url.Values{}
+	Query.Set("client", "gtx")
+	Query.Set("tl", TargetLanguage)
+	Query.Set("dt", "t")
+	Query.Set("q", Text)
 	if SourceLanguage != nil {
-		Request.AddQuery("sl", *SourceLanguage)
+		Query.Set("sl", *SourceLanguage)
 	} else {
-		Request.AddQuery("sl", "auto")
+		Query.Set("sl", "auto")
+	}
+
+	Request, err := http.NewRequestWithContext(Ctx, http.MethodGet, APIURL+"?"+Query.Encode(), nil)
+	if err != nil {
+		return "", err
 	}
 
 	var ResponseData []any
-	if err := Request.Do().BodyJSON(&ResponseData); err != nil {
+	if err := g.do(Request, &ResponseData); err != nil {
 		return "", err
 	}
 
@@ -83,3 +103,130 @@ func (g *GoogleTranslator) TranslateText(Text string, SourceLanguage *string, Ta
 
 	return "", ErrResponseFormat
 }
+
+/**
+ * @description: 批量翻译文本，超出单次请求限制时自动分批
+ * @param {context.Context} Ctx 上下文，取消时中断进行中的请求
+ * @param {[]string} Texts 要翻译的文本列表
+ * @param {*string} SourceLanguage 源语言，为nil表示自动检测
+ * @param {string} TargetLanguage 目标语言
+ * @return {[]string} 返回翻译后的文本列表，顺序与输入一致
+ * @return {error} 错误信息
+ */
+func (g *GoogleTranslator) TranslateBatch(Ctx context.Context, Texts []string, SourceLanguage *string, TargetLanguage string) ([]string, error) {
+	Results := make([]string, 0, len(Texts))
+
+	Start, Characters := 0, 0
+	for Index := 0; Index <= len(Texts); Index++ {
+		// 达到单次请求限制或遍历结束时发送当前批次
+		if Index == len(Texts) || (Index > Start && (Index-Start >= MaxBatchSize || Characters+len(Texts[Index]) > MaxBatchCharacters)) {
+			if Index > Start {
+				Chunk, err := g.translateChunk(Ctx, Texts[Start:Index], SourceLanguage, TargetLanguage)
+				if err != nil {
+					return nil, err
+				}
+				Results = append(Results, Chunk...)
+			}
+			Start, Characters = Index, 0
+		}
+		if Index < len(Texts) {
+			Characters += len(Texts[Index])
+		}
+	}
+
+	return Results, nil
+}
+
+/**
+ * @description: 发送一次批量翻译请求
+ * @param {context.Context} Ctx 上下文
+ * @param {[]string} Texts 要翻译的文本列表
+ * @param {*string} SourceLanguage 源语言，为nil表示自动检测
+ * @param {string} TargetLanguage 目标语言
+ * @return {[]string} 返回翻译后的文本列表
+ * @return {error} 错误信息
+ */
+func (g *GoogleTranslator) translateChunk(Ctx context.Context, Texts []string, SourceLanguage *string, TargetLanguage string) ([]string, error) {
+	// 单条文本的响应格式与批量不同，直接使用单条翻译
+	if len(Texts) == 1 {
+		TranslatedText, err := g.TranslateText(Ctx, Texts[0], SourceLanguage, TargetLanguage)
+		if err != nil {
+			return nil, err
+		}
+		return []string{TranslatedText}, nil
+	}
+
+	Query := url.Values{}
+	Query.Set("client", "gtx")
+	Query.Set("tl", TargetLanguage)
+	if SourceLanguage != nil {
+		Query.Set("sl", *SourceLanguage)
+	} else {
+		Query.Set("sl", "auto")
+	}
+
+	// 文本放在请求体中，避免URL过长
+	Form := url.Values{}
+	for _, Text := range Texts {
+		Form.Add("q", Text)
+	}
+
+	Request, err := http.NewRequestWithContext(Ctx, http.MethodPost, BatchAPIURL+"?"+Query.Encode(), strings.NewReader(Form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	Request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var ResponseData []any
+	if err := g.do(Request, &ResponseData); err != nil {
+		return nil, err
+	}
+	if len(ResponseData) == 0 {
+		return nil, ErrResponseEmpty
+	}
+	if len(ResponseData) != len(Texts) {
+		return nil, translation.ErrBatchSizeMismatch
+	}
+
+	// 自动检测源语言时每项为[译文, 检测到的语言]，否则为译文字符串
+	Results := make([]string, len(ResponseData))
+	for Index, Item := range ResponseData {
+		switch Value := Item.(type) {
+		case string:
+			Results[Index] = Value
+		case []any:
+			if len(Value) == 0 {
+				return nil, ErrResponseFormat
+			}
+			TranslationText, ok := Value[0].(string)
+			if !ok {
+				return nil, ErrResponseFormat
+			}
+			Results[Index] = TranslationText
+		default:
+			return nil, ErrResponseFormat
+		}
+	}
+
+	return Results, nil
+}
+
+/**
+ * @description: 发起翻译请求并解析JSON响应
+ * @param {*http.Request} Request 请求
+ * @param {any} Value 响应数据接收对象
+ * @return {error} 错误信息
+ */
+func (g *GoogleTranslator) do(Request *http.Request, Value any) error {
+	Response, err := g.client.Do(Request)
+	if err != nil {
+		return err
+	}
+	defer Response.Body.Close()
+
+	if Response.StatusCode != http.StatusOK {
+		return translation.NewStatusError(Response.StatusCode, Response.Header, fmt.Errorf("translation failed: unexpected status %s", Response.Status))
+	}
+
+	return json.NewDecoder(Response.Body).Decode(Value)
+}
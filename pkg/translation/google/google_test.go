@@ -9,7 +9,20 @@
 
 package google
 
-import "testing"
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/nijinekoyo/AutoTranslation/pkg/translation"
+)
 
 func TestGoogleTranslator_TranslateText(t *testing.T) {
 	type args struct {
@@ -38,7 +51,7 @@ func TestGoogleTranslator_TranslateText(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := tt.g.TranslateText(tt.args.Text, tt.args.SourceLanguage, tt.args.TargetLang)
+			got, err := tt.g.TranslateText(context.Background(), tt.args.Text, tt.args.SourceLanguage, tt.args.TargetLang)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("GoogleTranslator.TranslateText() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -49,3 +62,138 @@ func TestGoogleTranslator_TranslateText(t *testing.T) {
 		})
 	}
 }
+
+// 将请求转发到测试服务器的传输层
+type rewriteTransport struct {
+	target *url.URL
+}
+
+func (r rewriteTransport) RoundTrip(Request *http.Request) (*http.Response, error) {
+	Request = Request.Clone(Request.Context())
+	Request.URL.Scheme = r.target.Scheme
+	Request.URL.Host = r.target.Host
+	return http.DefaultTransport.RoundTrip(Request)
+}
+
+/**
+ * @description: 创建连接到模拟Google翻译接口的翻译器
+ * @param {*testing.T} t 测试实例
+ * @param {int} Drop 批量响应中丢弃的条目数量，用于模拟数量不一致
+ * @param {*[]int} BatchSizes 记录每次批量请求包含的文本数量
+ * @return {*GoogleTranslator} GoogleTranslator实例
+ */
+func newTestTranslator(t *testing.T, Drop int, BatchSizes *[]int) *GoogleTranslator {
+	Server := httptest.NewServer(http.HandlerFunc(func(Writer http.ResponseWriter, Request *http.Request) {
+		if err := Request.ParseForm(); err != nil {
+			t.Error(err)
+		}
+		AutoDetect := Request.URL.Query().Get("sl") == "auto"
+
+		var ResponseData any
+		switch Request.URL.Path {
+		case "/translate_a/single":
+			ResponseData = []any{[]any{[]any{"T:" + Request.URL.Query().Get("q"), Request.URL.Query().Get("q")}}}
+		case "/translate_a/t":
+			Texts := Request.PostForm["q"]
+			*BatchSizes = append(*BatchSizes, len(Texts))
+			Items := []any{}
+			for _, Text := range Texts[:len(Texts)-Drop] {
+				// 自动检测源语言时每项附带检测到的语言
+				if AutoDetect {
+					Items = append(Items, []any{"T:" + Text, "ja"})
+				} else {
+					Items = append(Items, "T:"+Text)
+				}
+			}
+			ResponseData = Items
+		default:
+			http.NotFound(Writer, Request)
+			return
+		}
+		json.NewEncoder(Writer).Encode(ResponseData)
+	}))
+	t.Cleanup(Server.Close)
+
+	Target, _ := url.Parse(Server.URL)
+	return &GoogleTranslator{
+		client: &http.Client{Transport: rewriteTransport{target: Target}},
+	}
+}
+
+func TestGoogleTranslator_TranslateBatch(t *testing.T) {
+	SourceLanguage := "ja-JP"
+
+	Many := make([]string, MaxBatchSize+2)
+	for Index := range Many {
+		Many[Index] = fmt.Sprintf("text%d", Index)
+	}
+	Long := []string{strings.Repeat("a", MaxBatchCharacters-10), strings.Repeat("b", 20), "c"}
+
+	tests := []struct {
+		name           string
+		texts          []string
+		sourceLanguage *string
+		drop           int
+		wantBatchSizes []int
+		wantErr        error
+	}{
+		{
+			name:           "auto detect returns pairs",
+			texts:          []string{"はい", "いいえ", "東京"},
+			wantBatchSizes: []int{3},
+		},
+		{
+			name:           "fixed source returns strings",
+			texts:          []string{"はい", "いいえ"},
+			sourceLanguage: &SourceLanguage,
+			wantBatchSizes: []int{2},
+		},
+		{
+			name:           "single text uses single endpoint",
+			texts:          []string{"はい"},
+			wantBatchSizes: nil,
+		},
+		{
+			name:           "split by count",
+			texts:          Many,
+			wantBatchSizes: []int{MaxBatchSize, 2},
+		},
+		{
+			name:           "split by characters",
+			texts:          Long,
+			wantBatchSizes: []int{2},
+		},
+		{
+			name:           "result count mismatch",
+			texts:          []string{"はい", "いいえ"},
+			drop:           1,
+			wantBatchSizes: []int{2},
+			wantErr:        translation.ErrBatchSizeMismatch,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var BatchSizes []int
+			g := newTestTranslator(t, tt.drop, &BatchSizes)
+
+			got, err := g.TranslateBatch(context.Background(), tt.texts, tt.sourceLanguage, "zh-CN")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GoogleTranslator.TranslateBatch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(BatchSizes, tt.wantBatchSizes) {
+				t.Errorf("GoogleTranslator.TranslateBatch() batch sizes = %v, want %v", BatchSizes, tt.wantBatchSizes)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			want := make([]string, len(tt.texts))
+			for Index, Text := range tt.texts {
+				want[Index] = "T:" + Text
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("GoogleTranslator.TranslateBatch() = %q, want %q", got, want)
+			}
+		})
+	}
+}
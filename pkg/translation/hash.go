@@ -0,0 +1,30 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-07 10:36:47
+ * @LastEditTime: 2025-07-07 10:36:47
+ * @LastEditors: nijineko
+ * @Description: 文本哈希
+ * @FilePath: \AutoTranslation\pkg\translation\hash.go
+ */
+package translation
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+)
+
+/**
+ * @description: 计算文本哈希，用于生成提示词哈希和缓存存储键
+ * @param {...string} Texts 文本列表
+ * @return {string} 十六进制哈希值
+ */
+func HashText(Texts ...string) string {
+	Hash := sha256.New()
+	for _, Text := range Texts {
+		// 写入长度前缀，避免拼接产生歧义
+		binary.Write(Hash, binary.BigEndian, uint64(len(Text)))
+		Hash.Write([]byte(Text))
+	}
+	return hex.EncodeToString(Hash.Sum(nil))
+}
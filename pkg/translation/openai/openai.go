@@ -1,7 +1,7 @@
 /*
  * @Author: nijineko
  * @Date: 2025-07-03 17:09:58
- * @LastEditTime: 2025-07-03 17:45:16
+ * @LastEditTime: 2025-07-07 10:31:09
  * @LastEditors: nijineko
  * @Description: OpenAI翻译实现
  * @FilePath: \AutoTranslation\pkg\translation\openai\openai.go
@@ -10,13 +10,33 @@ package openai
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"net/http"
+	"strings"
 
 	"github.com/nijinekoyo/AutoTranslation/internal/config"
+	"github.com/nijinekoyo/AutoTranslation/pkg/translation"
 	"github.com/openai/openai-go"
 	"github.com/openai/openai-go/option"
 )
 
+const (
+	// 批量翻译提示，约束模型按JSON结构返回译文
+	BatchPrompt = `接下来用户会发送一个JSON数组，数组中每一项包含编号id和待翻译文本text。` +
+		`请分别翻译每一项的text，并且只返回如下格式的JSON，不要输出其他任何内容：` +
+		`{"translations":[{"id":编号,"text":"翻译后的文本"}]}`
+	// 批量翻译中条目说明的提示
+	BatchNotePrompt = `部分条目包含context字段，是该条目的说明或使用场景，仅供翻译时参考，不要翻译或输出context的内容。`
+	// 单条翻译中文本说明的提示
+	NotePrompt = `以下是待翻译文本的说明或使用场景，仅供翻译时参考，不要翻译或输出说明的内容：`
+	// 源语言和目标语言的提示，列映射可以使用不同的目标语言，以此为准
+	LanguagePrompt = `请将待翻译文本从%s翻译为%s（BCP 47语言代码），如果其他提示中的目标语言与此不同，以此为准。`
+	// 未指定源语言时的描述
+	AutoLanguagePrompt = `自动识别的源语言`
+)
+
 type OpenAITranslator struct {
 	APIKey string
 
@@ -25,8 +45,22 @@ type OpenAITranslator struct {
 
 var (
 	ErrInvalidRole = errors.New("invalid role in OpenAI messages")
+	// 响应中没有可用的回复
+	ErrResponseEmpty = errors.New("translation failed: no choices in response")
 )
 
+// 批量翻译条目
+type batchItem struct {
+	ID      int    `json:"id"`                // 条目编号，对应输入索引
+	Text    string `json:"text"`              // 文本
+	Context string `json:"context,omitempty"` // 条目说明，只在请求中使用
+}
+
+// 批量翻译响应
+type batchResponse struct {
+	Translations []batchItem `json:"translations"`
+}
+
 /**
  * @description: 创建一个新的OpenAI翻译实例
  * @param {string} APIKey OpenAI API密钥
@@ -38,19 +72,154 @@ func New(APIKey string) *OpenAITranslator {
 		client: openai.NewClient(
 			option.WithAPIKey(APIKey),
 			option.WithBaseURL(config.Get().Translation.OpenAI.BaseURL),
+			option.WithMaxRetries(0), // 重试由translation.RetryTranslator统一处理
 		),
 	}
 }
 
 /**
  * @description: 翻译文本
+ * @param {context.Context} Ctx 上下文，取消时中断进行中的请求
  * @param {string} Text 要翻译的文本
  * @param {*string} SourceLanguage 源语言，为nil表示自动检测
  * @param {string} TargetLanguage 目标语言
  * @return {string} 返回翻译后的文本
  * @return {error} 错误信息
  */
-func (o *OpenAITranslator) TranslateText(Text string, SourceLanguage *string, TargetLanguage string) (string, error) {
+func (o *OpenAITranslator) TranslateText(Ctx context.Context, Text string, SourceLanguage *string, TargetLanguage string) (string, error) {
+	Messages, err := buildMessages(Ctx, SourceLanguage, TargetLanguage)
+	if err != nil {
+		return "", err
+	}
+
+	// 添加文本说明和文本
+	if Note := translation.NoteFrom(Ctx, 0); Note != "" {
+		Messages = append(Messages, openai.SystemMessage(NotePrompt+"\n"+Note))
+	}
+	Messages = append(Messages, openai.UserMessage(Text))
+
+	return o.complete(Ctx, Messages)
+}
+
+/**
+ * @description: 批量翻译文本，以带编号的JSON结构发送并按编号取回译文
+ * @param {context.Context} Ctx 上下文，取消时中断进行中的请求
+ * @param {[]string} Texts 要翻译的文本列表
+ * @param {*string} SourceLanguage 源语言，为nil表示自动检测
+ * @param {string} TargetLanguage 目标语言
+ * @return {[]string} 返回翻译后的文本列表，顺序与输入一致
+ * @return {error} 错误信息
+ */
+func (o *OpenAITranslator) TranslateBatch(Ctx context.Context, Texts []string, SourceLanguage *string, TargetLanguage string) ([]string, error) {
+	if len(Texts) == 0 {
+		return []string{}, nil
+	}
+	if len(Texts) == 1 {
+		TranslatedText, err := o.TranslateText(Ctx, Texts[0], SourceLanguage, TargetLanguage)
+		if err != nil {
+			return nil, err
+		}
+		return []string{TranslatedText}, nil
+	}
+
+	Messages, err := buildMessages(Ctx, SourceLanguage, TargetLanguage)
+	if err != nil {
+		return nil, err
+	}
+
+	Items := make([]batchItem, len(Texts))
+	HasNote := false
+	for Index, Text := range Texts {
+		Items[Index] = batchItem{ID: Index, Text: Text, Context: translation.NoteFrom(Ctx, Index)}
+		HasNote = HasNote || Items[Index].Context != ""
+	}
+	ItemsJSON, err := json.Marshal(Items)
+	if err != nil {
+		return nil, err
+	}
+
+	Messages = append(Messages, openai.SystemMessage(BatchPrompt))
+	if HasNote {
+		Messages = append(Messages, openai.SystemMessage(BatchNotePrompt))
+	}
+	Messages = append(Messages, openai.UserMessage(string(ItemsJSON)))
+
+	Content, err := o.complete(Ctx, Messages)
+	if err != nil {
+		return nil, err
+	}
+
+	// 按编号回填译文
+	Results := make([]string, len(Texts))
+	Filled := make([]bool, len(Texts))
+	var ResponseData batchResponse
+	if err := json.Unmarshal([]byte(trimCodeFence(Content)), &ResponseData); err == nil {
+		for _, Item := range ResponseData.Translations {
+			if Item.ID < 0 || Item.ID >= len(Texts) {
+				continue
+			}
+			Results[Item.ID] = Item.Text
+			Filled[Item.ID] = true
+		}
+	}
+
+	// 模型遗漏或返回格式错误的条目逐条重新翻译
+	for Index, Text := range Texts {
+		if Filled[Index] {
+			continue
+		}
+		TranslatedText, err := o.TranslateText(translation.SelectNotes(Ctx, Index), Text, SourceLanguage, TargetLanguage)
+		if err != nil {
+			return nil, err
+		}
+		Results[Index] = TranslatedText
+	}
+
+	return Results, nil
+}
+
+/**
+ * @description: 发送对话请求
+ * @param {context.Context} Ctx 上下文
+ * @param {[]openai.ChatCompletionMessageParamUnion} Messages 消息列表
+ * @return {string} 返回模型回复内容
+ * @return {error} 错误信息
+ */
+func (o *OpenAITranslator) complete(Ctx context.Context, Messages []openai.ChatCompletionMessageParamUnion) (string, error) {
+	ChatCompletion, err := o.client.Chat.Completions.New(Ctx,
+		openai.ChatCompletionNewParams{
+			Messages: Messages,
+			Model:    config.Get().Translation.OpenAI.Model,
+		},
+	)
+	if err != nil {
+		// 转换为统一的状态错误，以便重试时读取状态码和Retry-After
+		var APIErr *openai.Error
+		if errors.As(err, &APIErr) {
+			var Header http.Header
+			if APIErr.Response != nil {
+				Header = APIErr.Response.Header
+			}
+			return "", translation.NewStatusError(APIErr.StatusCode, Header, err)
+		}
+		return "", err
+	}
+	if len(ChatCompletion.Choices) == 0 {
+		return "", ErrResponseEmpty
+	}
+
+	return ChatCompletion.Choices[0].Message.Content, nil
+}
+
+/**
+ * @description: 根据配置构建前置消息、术语表消息、语言提示和附加提示词
+ * @param {context.Context} Ctx 上下文，用于读取附加提示词
+ * @param {*string} SourceLanguage 源语言，为nil表示自动检测
+ * @param {string} TargetLanguage 目标语言
+ * @return {[]openai.ChatCompletionMessageParamUnion} 消息列表
+ * @return {error} 错误信息
+ */
+func buildMessages(Ctx context.Context, SourceLanguage *string, TargetLanguage string) ([]openai.ChatCompletionMessageParamUnion, error) {
 	var Messages []openai.ChatCompletionMessageParamUnion
 
 	// 添加前置消息
@@ -65,7 +234,7 @@ func (o *OpenAITranslator) TranslateText(Text string, SourceLanguage *string, Ta
 		case "assistant":
 			Messages = append(Messages, openai.AssistantMessage(MessageStr.Content))
 		default:
-			return "", ErrInvalidRole
+			return nil, ErrInvalidRole
 		}
 	}
 
@@ -81,18 +250,58 @@ func (o *OpenAITranslator) TranslateText(Text string, SourceLanguage *string, Ta
 		Messages = append(Messages, openai.AssistantMessage(GlossaryMessage))
 	}
 
-	// 添加文本
-	Messages = append(Messages, openai.UserMessage(Text))
+	// 添加源语言和目标语言，前置消息中的语言只适用于默认的目标语言
+	Source := AutoLanguagePrompt
+	if SourceLanguage != nil {
+		Source = *SourceLanguage
+	}
+	Messages = append(Messages, openai.SystemMessage(fmt.Sprintf(LanguagePrompt, Source, TargetLanguage)))
 
-	ChatCompletion, err := o.client.Chat.Completions.New(context.Background(),
-		openai.ChatCompletionNewParams{
-			Messages: Messages,
-			Model:    config.Get().Translation.OpenAI.Model,
-		},
-	)
-	if err != nil {
-		return "", err
+	// 添加附加提示词
+	if Prompt := translation.PromptFrom(Ctx); Prompt != "" {
+		Messages = append(Messages, openai.SystemMessage(Prompt))
 	}
 
-	return ChatCompletion.Choices[0].Message.Content, nil
+	return Messages, nil
+}
+
+/**
+ * @description: 计算当前配置的提示词哈希，提示词或术语表变化时哈希随之变化
+ * @return {string} 提示词哈希
+ */
+func PromptHash() string {
+	var Texts []string
+	for _, MessageStr := range config.Get().Translation.OpenAI.Messages {
+		Texts = append(Texts, MessageStr.Role, MessageStr.Content)
+	}
+	Texts = append(Texts, config.Get().Translation.LargeLanguageModel.GlossaryPrompt)
+	for _, Glossary := range config.Get().Translation.LargeLanguageModel.Glossaries {
+		Texts = append(Texts, Glossary.Name, Glossary.Description)
+		for _, Entry := range Glossary.Entries {
+			Texts = append(Texts, Entry.Source, Entry.Target)
+		}
+	}
+	Texts = append(Texts, BatchPrompt, LanguagePrompt)
+
+	return translation.HashText(Texts...)
+}
+
+/**
+ * @description: 去除模型回复中包裹JSON的Markdown代码块标记
+ * @param {string} Content 模型回复内容
+ * @return {string} 去除标记后的内容
+ */
+func trimCodeFence(Content string) string {
+	Content = strings.TrimSpace(Content)
+	if !strings.HasPrefix(Content, "```") {
+		return Content
+	}
+
+	// 去除首行的```或```json
+	if Index := strings.Index(Content, "\n"); Index >= 0 {
+		Content = Content[Index+1:]
+	}
+	Content = strings.TrimSuffix(strings.TrimSpace(Content), "```")
+
+	return strings.TrimSpace(Content)
 }
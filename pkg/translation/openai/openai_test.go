@@ -0,0 +1,259 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-04 14:12:36
+ * @LastEditTime: 2025-07-04 14:12:36
+ * @LastEditors: nijineko
+ * @Description: OpenAI翻译实现测试
+ * @FilePath: \AutoTranslation\pkg\translation\openai\openai_test.go
+ */
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/nijinekoyo/AutoTranslation/pkg/translation"
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+)
+
+/**
+ * @description: 创建连接到模拟对话接口的翻译器，批量请求由Reply生成回复，单条请求返回"T:"加原文
+ * @param {*testing.T} t 测试实例
+ * @param {func([]batchItem) string} Reply 根据批量请求条目生成模型回复
+ * @param {*[]string} Singles 记录逐条翻译请求的文本
+ * @return {*OpenAITranslator} OpenAITranslator实例
+ */
+func newTestTranslator(t *testing.T, Reply func([]batchItem) string, Singles *[]string) *OpenAITranslator {
+	Server := httptest.NewServer(http.HandlerFunc(func(Writer http.ResponseWriter, Request *http.Request) {
+		var RequestData struct {
+			Messages []struct {
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		if err := json.NewDecoder(Request.Body).Decode(&RequestData); err != nil {
+			t.Error(err)
+			return
+		}
+		Text := RequestData.Messages[len(RequestData.Messages)-1].Content
+
+		var Items []batchItem
+		Content := ""
+		if err := json.Unmarshal([]byte(Text), &Items); err == nil {
+			Content = Reply(Items)
+		} else {
+			*Singles = append(*Singles, Text)
+			Content = "T:" + Text
+		}
+
+		Writer.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(Writer).Encode(map[string]any{
+			"id":      "chatcmpl-test",
+			"object":  "chat.completion",
+			"created": 0,
+			"model":   "test",
+			"choices": []map[string]any{
+				{"index": 0, "finish_reason": "stop", "message": map[string]any{"role": "assistant", "content": Content}},
+			},
+		})
+	}))
+	t.Cleanup(Server.Close)
+
+	return &OpenAITranslator{
+		client: openai.NewClient(
+			option.WithAPIKey("test"),
+			option.WithBaseURL(Server.URL+"/"),
+			option.WithMaxRetries(0),
+		),
+	}
+}
+
+/**
+ * @description: 生成批量翻译响应JSON
+ * @param {[]batchItem} Items 条目列表
+ * @return {string} 响应JSON
+ */
+func marshalReply(Items []batchItem) string {
+	for Index := range Items {
+		Items[Index].Text = "T:" + Items[Index].Text
+		Items[Index].Context = ""
+	}
+	Data, _ := json.Marshal(batchResponse{Translations: Items})
+	return string(Data)
+}
+
+func TestOpenAITranslator_TranslateBatch(t *testing.T) {
+	tests := []struct {
+		name        string
+		reply       func([]batchItem) string
+		wantSingles []string
+	}{
+		{
+			name:  "plain JSON",
+			reply: marshalReply,
+		},
+		{
+			name: "code fenced JSON",
+			reply: func(Items []batchItem) string {
+				return "```json\n" + marshalReply(Items) + "\n```"
+			},
+		},
+		{
+			name: "out of order ids",
+			reply: func(Items []batchItem) string {
+				for Left, Right := 0, len(Items)-1; Left < Right; Left, Right = Left+1, Right-1 {
+					Items[Left], Items[Right] = Items[Right], Items[Left]
+				}
+				return marshalReply(Items)
+			},
+		},
+		{
+			name: "missing id falls back to single translation",
+			reply: func(Items []batchItem) string {
+				return marshalReply(Items[:2])
+			},
+			wantSingles: []string{"東京"},
+		},
+		{
+			name: "out of range id is ignored",
+			reply: func(Items []batchItem) string {
+				Items[2].ID = 99
+				return marshalReply(Items)
+			},
+			wantSingles: []string{"東京"},
+		},
+		{
+			name: "malformed reply falls back for every text",
+			reply: func(Items []batchItem) string {
+				return "抱歉，我无法完成这个请求。"
+			},
+			wantSingles: []string{"はい", "いいえ", "東京"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var Singles []string
+			o := newTestTranslator(t, tt.reply, &Singles)
+
+			Texts := []string{"はい", "いいえ", "東京"}
+			got, err := o.TranslateBatch(context.Background(), Texts, nil, "zh-CN")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := []string{"T:はい", "T:いいえ", "T:東京"}; !reflect.DeepEqual(got, want) {
+				t.Errorf("OpenAITranslator.TranslateBatch() = %q, want %q", got, want)
+			}
+			if !reflect.DeepEqual(Singles, tt.wantSingles) {
+				t.Errorf("OpenAITranslator.TranslateBatch() single requests = %q, want %q", Singles, tt.wantSingles)
+			}
+		})
+	}
+}
+
+func TestTrimCodeFence(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{name: "no fence", content: ` {"translations":[]} `, want: `{"translations":[]}`},
+		{name: "json fence", content: "```json\n{\"translations\":[]}\n```", want: `{"translations":[]}`},
+		{name: "bare fence", content: "```\n{\"translations\":[]}\n```\n", want: `{"translations":[]}`},
+		{name: "unterminated fence", content: "```json\n{\"translations\":[]}", want: `{"translations":[]}`},
+		{name: "fence only", content: "```", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := trimCodeFence(tt.content); got != tt.want {
+				t.Errorf("trimCodeFence() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpenAITranslator_TranslateBatchNotes(t *testing.T) {
+	var gotContexts []string
+	o := newTestTranslator(t, func(Items []batchItem) string {
+		for _, Item := range Items {
+			gotContexts = append(gotContexts, Item.Context)
+		}
+		return marshalReply(Items)
+	}, new([]string))
+
+	// 相同原文的条目使用各自的说明
+	Ctx := translation.WithNotes(context.Background(), []string{"动词", "", "形容词"})
+	if _, err := o.TranslateBatch(Ctx, []string{"Open", "Save", "Open"}, nil, "zh-CN"); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"动词", "", "形容词"}; !reflect.DeepEqual(gotContexts, want) {
+		t.Errorf("OpenAITranslator.TranslateBatch() contexts = %q, want %q", gotContexts, want)
+	}
+}
+
+func TestOpenAITranslator_Language(t *testing.T) {
+	SourceLanguage := "ja"
+	tests := []struct {
+		name           string
+		texts          []string
+		sourceLanguage *string
+		targetLanguage string
+		want           string
+	}{
+		{
+			name:           "single text",
+			texts:          []string{"はい"},
+			sourceLanguage: &SourceLanguage,
+			targetLanguage: "ko",
+			want:           fmt.Sprintf(LanguagePrompt, "ja", "ko"),
+		},
+		{
+			name:           "batch with auto detect",
+			texts:          []string{"はい", "いいえ"},
+			targetLanguage: "zh-TW",
+			want:           fmt.Sprintf(LanguagePrompt, AutoLanguagePrompt, "zh-TW"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 记录请求中的全部消息
+			var Contents []string
+			Server := httptest.NewServer(http.HandlerFunc(func(Writer http.ResponseWriter, Request *http.Request) {
+				var RequestData struct {
+					Messages []struct {
+						Content string `json:"content"`
+					} `json:"messages"`
+				}
+				json.NewDecoder(Request.Body).Decode(&RequestData)
+				for _, Message := range RequestData.Messages {
+					Contents = append(Contents, Message.Content)
+				}
+
+				Writer.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(Writer).Encode(map[string]any{
+					"id":      "chatcmpl-test",
+					"object":  "chat.completion",
+					"created": 0,
+					"model":   "test",
+					"choices": []map[string]any{
+						{"index": 0, "finish_reason": "stop", "message": map[string]any{"role": "assistant", "content": `{"translations":[]}`}},
+					},
+				})
+			}))
+			defer Server.Close()
+			o := &OpenAITranslator{client: openai.NewClient(option.WithAPIKey("test"), option.WithBaseURL(Server.URL+"/"), option.WithMaxRetries(0))}
+
+			if _, err := o.TranslateBatch(context.Background(), tt.texts, tt.sourceLanguage, tt.targetLanguage); err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Contains(Contents, tt.want) {
+				t.Errorf("OpenAITranslator.TranslateBatch() messages = %q, want %q", Contents, tt.want)
+			}
+		})
+	}
+}
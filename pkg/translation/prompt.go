@@ -0,0 +1,81 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-07 10:04:51
+ * @LastEditTime: 2025-07-07 10:20:33
+ * @LastEditors: nijineko
+ * @Description: 附加提示词
+ * @FilePath: \AutoTranslation\pkg\translation\prompt.go
+ */
+package translation
+
+import "context"
+
+// 附加提示词的上下文键
+type promptKey struct{}
+
+/**
+ * @description: 为本次翻译附加提示词，大语言模型翻译服务会将其追加到前置消息之后，其他服务忽略
+ * @param {context.Context} Ctx 上下文
+ * @param {string} Prompt 附加提示词，为空时不附加
+ * @return {context.Context} 带附加提示词的上下文
+ */
+func WithPrompt(Ctx context.Context, Prompt string) context.Context {
+	if Prompt == "" {
+		return Ctx
+	}
+	return context.WithValue(Ctx, promptKey{}, Prompt)
+}
+
+/**
+ * @description: 获取本次翻译的附加提示词
+ * @param {context.Context} Ctx 上下文
+ * @return {string} 附加提示词，未设置时为空
+ */
+func PromptFrom(Ctx context.Context) string {
+	Prompt, _ := Ctx.Value(promptKey{}).(string)
+	return Prompt
+}
+
+// 待翻译文本说明的上下文键
+type notesKey struct{}
+
+/**
+ * @description: 为本次翻译的文本附加说明，如资源文件中的译者注释，大语言模型翻译服务会将其作为参考，其他服务忽略
+ * @param {context.Context} Ctx 上下文
+ * @param {[]string} Notes 说明，按索引与待翻译文本一一对应，相同原文的不同条目可以有不同说明
+ * @return {context.Context} 带说明的上下文
+ */
+func WithNotes(Ctx context.Context, Notes []string) context.Context {
+	return context.WithValue(Ctx, notesKey{}, Notes)
+}
+
+/**
+ * @description: 获取待翻译文本的说明，单条翻译时索引为0
+ * @param {context.Context} Ctx 上下文
+ * @param {int} Index 待翻译文本的索引
+ * @return {string} 说明，未设置时为空
+ */
+func NoteFrom(Ctx context.Context, Index int) string {
+	Notes, _ := Ctx.Value(notesKey{}).([]string)
+	if Index < 0 || Index >= len(Notes) {
+		return ""
+	}
+	return Notes[Index]
+}
+
+/**
+ * @description: 按索引选取部分文本的说明，用于只翻译部分文本时保持说明与文本对应
+ * @param {context.Context} Ctx 上下文
+ * @param {[]int} Indexes 选取的文本索引，顺序与新的文本列表一致
+ * @return {context.Context} 带选取后说明的上下文
+ */
+func SelectNotes(Ctx context.Context, Indexes ...int) context.Context {
+	if _, ok := Ctx.Value(notesKey{}).([]string); !ok {
+		return Ctx
+	}
+	Notes := make([]string, len(Indexes))
+	for Index, TextIndex := range Indexes {
+		Notes[Index] = NoteFrom(Ctx, TextIndex)
+	}
+	return WithNotes(Ctx, Notes)
+}
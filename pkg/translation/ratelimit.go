@@ -0,0 +1,115 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-04 16:20:12
+ * @LastEditTime: 2025-07-04 17:02:48
+ * @LastEditors: nijineko
+ * @Description: 请求限流
+ * @FilePath: \AutoTranslation\pkg\translation\ratelimit.go
+ */
+package translation
+
+import (
+	"context"
+	"unicode/utf8"
+
+	"golang.org/x/time/rate"
+)
+
+// 请求限流翻译器，基于令牌桶限制请求频率和文本令牌用量
+type RateLimitTranslator struct {
+	translator Translation
+
+	requestLimiter *rate.Limiter // 请求频率限制，为nil表示不限制
+	tokenLimiter   *rate.Limiter // 令牌用量限制，为nil表示不限制
+}
+
+/**
+ * @description: 为翻译器添加请求限流
+ * @param {Translation} TranslatorInstance 翻译器实例
+ * @param {float64} RequestsPerSecond 每秒最多请求次数，小于等于0表示不限制
+ * @param {int} TokensPerMinute 每分钟最多使用的令牌数量，小于等于0表示不限制
+ * @return {*RateLimitTranslator} RateLimitTranslator实例
+ */
+func NewRateLimitTranslator(TranslatorInstance Translation, RequestsPerSecond float64, TokensPerMinute int) *RateLimitTranslator {
+	RateLimitInstance := &RateLimitTranslator{
+		translator: TranslatorInstance,
+	}
+	if RequestsPerSecond > 0 {
+		RateLimitInstance.requestLimiter = rate.NewLimiter(rate.Limit(RequestsPerSecond), max(int(RequestsPerSecond), 1))
+	}
+	if TokensPerMinute > 0 {
+		RateLimitInstance.tokenLimiter = rate.NewLimiter(rate.Limit(float64(TokensPerMinute)/60), TokensPerMinute)
+	}
+	return RateLimitInstance
+}
+
+/**
+ * @description: 翻译文本，超出限制时等待
+ * @param {context.Context} Ctx 上下文，取消时停止等待
+ * @param {string} Text 要翻译的文本
+ * @param {*string} SourceLanguage 源语言，为nil表示自动检测
+ * @param {string} TargetLanguage 目标语言
+ * @return {string} 返回翻译后的文本
+ * @return {error} 错误信息
+ */
+func (r *RateLimitTranslator) TranslateText(Ctx context.Context, Text string, SourceLanguage *string, TargetLanguage string) (string, error) {
+	if err := r.wait(Ctx, EstimateTokens(Text)); err != nil {
+		return "", err
+	}
+	return r.translator.TranslateText(Ctx, Text, SourceLanguage, TargetLanguage)
+}
+
+/**
+ * @description: 批量翻译文本，整批按一次请求计算
+ * @param {context.Context} Ctx 上下文，取消时停止等待
+ * @param {[]string} Texts 要翻译的文本列表
+ * @param {*string} SourceLanguage 源语言，为nil表示自动检测
+ * @param {string} TargetLanguage 目标语言
+ * @return {[]string} 返回翻译后的文本列表，顺序与输入一致
+ * @return {error} 错误信息
+ */
+func (r *RateLimitTranslator) TranslateBatch(Ctx context.Context, Texts []string, SourceLanguage *string, TargetLanguage string) ([]string, error) {
+	BatchInstance, ok := r.translator.(BatchTranslation)
+	if !ok {
+		return NewBatchAdapter(r).TranslateBatch(Ctx, Texts, SourceLanguage, TargetLanguage)
+	}
+
+	Tokens := 0
+	for _, Text := range Texts {
+		Tokens += EstimateTokens(Text)
+	}
+	if err := r.wait(Ctx, Tokens); err != nil {
+		return nil, err
+	}
+	return BatchInstance.TranslateBatch(Ctx, Texts, SourceLanguage, TargetLanguage)
+}
+
+/**
+ * @description: 等待直到请求和令牌配额可用
+ * @param {context.Context} Ctx 上下文
+ * @param {int} Tokens 本次请求预计使用的令牌数量
+ * @return {error} 错误信息
+ */
+func (r *RateLimitTranslator) wait(Ctx context.Context, Tokens int) error {
+	if r.requestLimiter != nil {
+		if err := r.requestLimiter.Wait(Ctx); err != nil {
+			return err
+		}
+	}
+	if r.tokenLimiter != nil {
+		// 单次请求超过桶容量时按桶容量计算，避免永远无法满足
+		if err := r.tokenLimiter.WaitN(Ctx, min(max(Tokens, 1), r.tokenLimiter.Burst())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/**
+ * @description: 估算文本的令牌数量，按字符数计算，对中日韩文本偏保守
+ * @param {string} Text 文本
+ * @return {int} 令牌数量
+ */
+func EstimateTokens(Text string) int {
+	return utf8.RuneCountInString(Text)
+}
@@ -0,0 +1,224 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-04 16:03:44
+ * @LastEditTime: 2025-07-04 17:10:25
+ * @LastEditors: nijineko
+ * @Description: 失败重试
+ * @FilePath: \AutoTranslation\pkg\translation\retry.go
+ */
+package translation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/nijinekoyo/AutoTranslation/internal/log"
+)
+
+// 翻译服务返回的HTTP状态错误
+type StatusError struct {
+	StatusCode int           // HTTP状态码
+	RetryAfter time.Duration // 服务端要求的重试等待时间，为0表示未指定
+	Err        error         // 原始错误
+}
+
+/**
+ * @description: 创建一个新的HTTP状态错误
+ * @param {int} StatusCode HTTP状态码
+ * @param {http.Header} Header 响应头，用于读取Retry-After
+ * @param {error} Err 原始错误
+ * @return {*StatusError} StatusError实例
+ */
+func NewStatusError(StatusCode int, Header http.Header, Err error) *StatusError {
+	return &StatusError{
+		StatusCode: StatusCode,
+		RetryAfter: ParseRetryAfter(Header.Get("Retry-After")),
+		Err:        Err,
+	}
+}
+
+func (e *StatusError) Error() string {
+	if e.Err != nil {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("translation failed: unexpected status %d", e.StatusCode)
+}
+
+func (e *StatusError) Unwrap() error {
+	return e.Err
+}
+
+/**
+ * @description: 解析Retry-After响应头
+ * @param {string} Value 响应头的值，可以是秒数或HTTP日期
+ * @return {time.Duration} 等待时间，无法解析时返回0
+ */
+func ParseRetryAfter(Value string) time.Duration {
+	if Value == "" {
+		return 0
+	}
+	if Seconds, err := strconv.Atoi(Value); err == nil {
+		return max(time.Duration(Seconds)*time.Second, 0)
+	}
+	if Date, err := http.ParseTime(Value); err == nil {
+		return max(time.Until(Date), 0)
+	}
+	return 0
+}
+
+/**
+ * @description: 判断错误是否可以重试
+ * @param {context.Context} Ctx 调用方上下文，已取消时不再重试
+ * @param {error} err 错误
+ * @return {bool} 是否可以重试
+ */
+func IsRetryable(Ctx context.Context, err error) bool {
+	if err == nil || Ctx.Err() != nil {
+		return false
+	}
+
+	// 单次请求超时
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var StatusErr *StatusError
+	if errors.As(err, &StatusErr) {
+		return StatusErr.StatusCode == http.StatusTooManyRequests ||
+			StatusErr.StatusCode == http.StatusRequestTimeout ||
+			StatusErr.StatusCode >= http.StatusInternalServerError
+	}
+
+	// 网络错误
+	var NetErr net.Error
+	if errors.As(err, &NetErr) {
+		return true
+	}
+	return errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// 失败重试翻译器
+type RetryTranslator struct {
+	translator Translation
+	maxRetries int
+	baseDelay  time.Duration
+	maxDelay   time.Duration
+}
+
+/**
+ * @description: 为翻译器添加带抖动的指数退避重试
+ * @param {Translation} TranslatorInstance 翻译器实例
+ * @param {int} MaxRetries 最大重试次数，小于等于0表示不重试
+ * @param {time.Duration} BaseDelay 首次重试的基础等待时间
+ * @param {time.Duration} MaxDelay 单次等待时间上限，服务端指定Retry-After时以服务端为准
+ * @return {*RetryTranslator} RetryTranslator实例
+ */
+func NewRetryTranslator(TranslatorInstance Translation, MaxRetries int, BaseDelay, MaxDelay time.Duration) *RetryTranslator {
+	return &RetryTranslator{
+		translator: TranslatorInstance,
+		maxRetries: MaxRetries,
+		baseDelay:  BaseDelay,
+		maxDelay:   MaxDelay,
+	}
+}
+
+/**
+ * @description: 翻译文本，失败时按退避策略重试
+ * @param {context.Context} Ctx 上下文，用于取消请求
+ * @param {string} Text 要翻译的文本
+ * @param {*string} SourceLanguage 源语言，为nil表示自动检测
+ * @param {string} TargetLanguage 目标语言
+ * @return {string} 返回翻译后的文本
+ * @return {error} 错误信息
+ */
+func (r *RetryTranslator) TranslateText(Ctx context.Context, Text string, SourceLanguage *string, TargetLanguage string) (string, error) {
+	var TranslatedText string
+	err := r.retry(Ctx, func() error {
+		var err error
+		TranslatedText, err = r.translator.TranslateText(Ctx, Text, SourceLanguage, TargetLanguage)
+		return err
+	})
+	return TranslatedText, err
+}
+
+/**
+ * @description: 批量翻译文本，失败时整批重试
+ * @param {context.Context} Ctx 上下文，用于取消请求
+ * @param {[]string} Texts 要翻译的文本列表
+ * @param {*string} SourceLanguage 源语言，为nil表示自动检测
+ * @param {string} TargetLanguage 目标语言
+ * @return {[]string} 返回翻译后的文本列表，顺序与输入一致
+ * @return {error} 错误信息
+ */
+func (r *RetryTranslator) TranslateBatch(Ctx context.Context, Texts []string, SourceLanguage *string, TargetLanguage string) ([]string, error) {
+	BatchInstance, ok := r.translator.(BatchTranslation)
+	if !ok {
+		return NewBatchAdapter(r).TranslateBatch(Ctx, Texts, SourceLanguage, TargetLanguage)
+	}
+
+	var TranslatedTexts []string
+	err := r.retry(Ctx, func() error {
+		var err error
+		TranslatedTexts, err = BatchInstance.TranslateBatch(Ctx, Texts, SourceLanguage, TargetLanguage)
+		return err
+	})
+	return TranslatedTexts, err
+}
+
+/**
+ * @description: 执行操作，可重试的错误按退避策略重试
+ * @param {context.Context} Ctx 上下文，取消时停止等待
+ * @param {func() error} Do 要执行的操作
+ * @return {error} 最后一次执行的错误
+ */
+func (r *RetryTranslator) retry(Ctx context.Context, Do func() error) error {
+	for Attempt := 0; ; Attempt++ {
+		err := Do()
+		if Attempt >= r.maxRetries || !IsRetryable(Ctx, err) {
+			return err
+		}
+
+		Delay := r.delay(Attempt, err)
+		log.Print().Warning("Translation", fmt.Sprintf("Retry %d/%d in %s:", Attempt+1, r.maxRetries, Delay.Round(time.Millisecond)), err)
+
+		Timer := time.NewTimer(Delay)
+		select {
+		case <-Ctx.Done():
+			Timer.Stop()
+			return err
+		case <-Timer.C:
+		}
+	}
+}
+
+/**
+ * @description: 计算下一次重试前的等待时间
+ * @param {int} Attempt 已重试次数，从0开始计数
+ * @param {error} err 上一次执行的错误
+ * @return {time.Duration} 等待时间
+ */
+func (r *RetryTranslator) delay(Attempt int, err error) time.Duration {
+	// 服务端指定了等待时间时以服务端为准
+	var StatusErr *StatusError
+	if errors.As(err, &StatusErr) && StatusErr.RetryAfter > 0 {
+		return StatusErr.RetryAfter
+	}
+
+	// 指数退避，在[上限/2, 上限]之间随机抖动
+	Backoff := r.baseDelay << min(Attempt, 30)
+	if r.maxDelay > 0 && (Backoff > r.maxDelay || Backoff <= 0) {
+		Backoff = r.maxDelay
+	}
+	if Backoff <= 0 {
+		return 0
+	}
+	Half := Backoff / 2
+	return Half + rand.N(Backoff-Half+1)
+}
@@ -0,0 +1,111 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-04 17:20:51
+ * @LastEditTime: 2025-07-04 17:28:14
+ * @LastEditors: nijineko
+ * @Description: 失败重试测试
+ * @FilePath: \AutoTranslation\pkg\translation\retry_test.go
+ */
+package translation
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// 前若干次请求失败的测试翻译器
+type flakyTranslator struct {
+	failures int
+	err      error
+	calls    int
+}
+
+func (f *flakyTranslator) TranslateText(Ctx context.Context, Text string, SourceLanguage *string, TargetLang string) (string, error) {
+	f.calls++
+	if f.calls <= f.failures {
+		return "", f.err
+	}
+	return Text + "!", nil
+}
+
+func TestRetryTranslator_TranslateText(t *testing.T) {
+	TooManyRequests := NewStatusError(http.StatusTooManyRequests, http.Header{"Retry-After": []string{"0"}}, nil)
+	BadRequest := NewStatusError(http.StatusBadRequest, nil, nil)
+
+	tests := []struct {
+		name      string
+		flaky     *flakyTranslator
+		retries   int
+		want      string
+		wantErr   bool
+		wantCalls int
+	}{
+		{
+			name:      "Retry until success",
+			flaky:     &flakyTranslator{failures: 2, err: TooManyRequests},
+			retries:   3,
+			want:      "text!",
+			wantCalls: 3,
+		},
+		{
+			name:      "Give up after max retries",
+			flaky:     &flakyTranslator{failures: 5, err: TooManyRequests},
+			retries:   2,
+			wantErr:   true,
+			wantCalls: 3,
+		},
+		{
+			name:      "Do not retry client errors",
+			flaky:     &flakyTranslator{failures: 5, err: BadRequest},
+			retries:   3,
+			wantErr:   true,
+			wantCalls: 1,
+		},
+		{
+			name:      "Do not retry unknown errors",
+			flaky:     &flakyTranslator{failures: 5, err: errors.New("boom")},
+			retries:   3,
+			wantErr:   true,
+			wantCalls: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRetryTranslator(tt.flaky, tt.retries, time.Millisecond, 2*time.Millisecond)
+			got, err := r.TranslateText(context.Background(), "text", nil, "zh-CN")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("RetryTranslator.TranslateText() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("RetryTranslator.TranslateText() = %v, want %v", got, tt.want)
+			}
+			if tt.flaky.calls != tt.wantCalls {
+				t.Errorf("RetryTranslator.TranslateText() calls = %v, want %v", tt.flaky.calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  time.Duration
+	}{
+		{name: "Empty", value: "", want: 0},
+		{name: "Seconds", value: "120", want: 2 * time.Minute},
+		{name: "Past date", value: "Wed, 21 Oct 2015 07:28:00 GMT", want: 0},
+		{name: "Invalid", value: "soon", want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseRetryAfter(tt.value); got != tt.want {
+				t.Errorf("ParseRetryAfter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
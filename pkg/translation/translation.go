@@ -1,19 +1,25 @@
 /*
  * @Author: nijineko
  * @Date: 2025-07-03 16:34:04
- * @LastEditTime: 2025-07-03 16:34:12
+ * @LastEditTime: 2025-07-04 10:12:30
  * @LastEditors: nijineko
  * @Description: 翻译包
  * @FilePath: \AutoTranslation\pkg\translation\translation.go
  */
 package translation
 
+import (
+	"context"
+	"time"
+)
+
 type Translation interface {
-	TranslateText(Text string, SourceLanguage *string, TargetLang string) (string, error)
+	TranslateText(Ctx context.Context, Text string, SourceLanguage *string, TargetLang string) (string, error)
 }
 
 /**
  * @description: 翻译文本
+ * @param {context.Context} Ctx 上下文，用于取消请求
  * @param {Translation} TranslatorInstance 翻译器实例
  * @param {string} Text 要翻译的文本
  * @param {*string} SourceLanguage 源语言，为nil表示自动检测
@@ -21,7 +27,71 @@ type Translation interface {
  * @return {string} 返回翻译后的文本
  * @return {error} 错误信息
  */
-func TranslateText(TranslatorInstance Translation, Text string, SourceLanguage *string, TargetLanguage string) (string, error) {
+func TranslateText(Ctx context.Context, TranslatorInstance Translation, Text string, SourceLanguage *string, TargetLanguage string) (string, error) {
 	// 调用翻译器进行翻译
-	return TranslatorInstance.TranslateText(Text, SourceLanguage, TargetLanguage)
-}
\ No newline at end of file
+	return TranslatorInstance.TranslateText(Ctx, Text, SourceLanguage, TargetLanguage)
+}
+
+// 单次请求超时翻译器
+type TimeoutTranslator struct {
+	translator Translation
+	timeout    time.Duration
+}
+
+/**
+ * @description: 为翻译器的每次请求添加超时限制
+ * @param {Translation} TranslatorInstance 翻译器实例
+ * @param {time.Duration} Timeout 单次请求超时时间，小于等于0表示不限制
+ * @return {*TimeoutTranslator} TimeoutTranslator实例
+ */
+func NewTimeoutTranslator(TranslatorInstance Translation, Timeout time.Duration) *TimeoutTranslator {
+	return &TimeoutTranslator{
+		translator: TranslatorInstance,
+		timeout:    Timeout,
+	}
+}
+
+/**
+ * @description: 翻译文本，超过超时时间后取消请求
+ * @param {context.Context} Ctx 上下文，用于取消请求
+ * @param {string} Text 要翻译的文本
+ * @param {*string} SourceLanguage 源语言，为nil表示自动检测
+ * @param {string} TargetLanguage 目标语言
+ * @return {string} 返回翻译后的文本
+ * @return {error} 错误信息
+ */
+func (t *TimeoutTranslator) TranslateText(Ctx context.Context, Text string, SourceLanguage *string, TargetLanguage string) (string, error) {
+	if t.timeout <= 0 {
+		return t.translator.TranslateText(Ctx, Text, SourceLanguage, TargetLanguage)
+	}
+
+	TimeoutCtx, Cancel := context.WithTimeout(Ctx, t.timeout)
+	defer Cancel()
+
+	return t.translator.TranslateText(TimeoutCtx, Text, SourceLanguage, TargetLanguage)
+}
+
+/**
+ * @description: 批量翻译文本，原生批量翻译按整批计算超时，逐条翻译时按单条计算超时
+ * @param {context.Context} Ctx 上下文，用于取消请求
+ * @param {[]string} Texts 要翻译的文本列表
+ * @param {*string} SourceLanguage 源语言，为nil表示自动检测
+ * @param {string} TargetLanguage 目标语言
+ * @return {[]string} 返回翻译后的文本列表，顺序与输入一致
+ * @return {error} 错误信息
+ */
+func (t *TimeoutTranslator) TranslateBatch(Ctx context.Context, Texts []string, SourceLanguage *string, TargetLanguage string) ([]string, error) {
+	BatchInstance, ok := t.translator.(BatchTranslation)
+	if !ok {
+		return NewBatchAdapter(t).TranslateBatch(Ctx, Texts, SourceLanguage, TargetLanguage)
+	}
+
+	if t.timeout <= 0 {
+		return BatchInstance.TranslateBatch(Ctx, Texts, SourceLanguage, TargetLanguage)
+	}
+
+	TimeoutCtx, Cancel := context.WithTimeout(Ctx, t.timeout)
+	defer Cancel()
+
+	return BatchInstance.TranslateBatch(TimeoutCtx, Texts, SourceLanguage, TargetLanguage)
+}
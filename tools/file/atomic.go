@@ -0,0 +1,146 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-06 10:02:47
+ * @LastEditTime: 2025-07-06 10:31:05
+ * @LastEditors: nijineko
+ * @Description: 原子写入工具
+ * @FilePath: \AutoTranslation\tools\file\atomic.go
+ */
+package file
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// 原子写入的文件，提交前写入同目录下的临时文件
+type AtomicFile struct {
+	path     string
+	tempFile *os.File
+	writer   *bufio.Writer
+	isDone   bool // 是否已提交或放弃
+}
+
+/**
+ * @description: 原子写入文件，先写入同目录下的临时文件，成功后再重命名为目标文件
+ * @param {string} Path 目标文件路径
+ * @param {func(io.Writer) error} Write 写入函数
+ * @return {error} 错误信息
+ */
+func WriteAtomic(Path string, Write func(Writer io.Writer) error) error {
+	File, err := CreateAtomic(Path)
+	if err != nil {
+		return err
+	}
+	if err := Write(File); err != nil {
+		File.Abort()
+		return err
+	}
+	return File.Commit()
+}
+
+/**
+ * @description: 创建原子写入的文件，可以分多次写入，调用Commit后才会替换目标文件
+ * @param {string} Path 目标文件路径
+ * @return {*AtomicFile} 原子写入的文件
+ * @return {error} 错误信息
+ */
+func CreateAtomic(Path string) (*AtomicFile, error) {
+	if err := os.MkdirAll(filepath.Dir(Path), 0755); err != nil {
+		return nil, err
+	}
+
+	// 临时文件与目标文件在同一目录，保证重命名是原子操作
+	TempFile, err := os.CreateTemp(filepath.Dir(Path), "."+filepath.Base(Path)+".*.tmp")
+	if err != nil {
+		return nil, err
+	}
+
+	return &AtomicFile{
+		path:     Path,
+		tempFile: TempFile,
+		writer:   bufio.NewWriter(TempFile),
+	}, nil
+}
+
+/**
+ * @description: 写入数据到临时文件
+ * @param {[]byte} Data 数据
+ * @return {int} 写入的字节数
+ * @return {error} 错误信息
+ */
+func (a *AtomicFile) Write(Data []byte) (int, error) {
+	if a.isDone {
+		return 0, os.ErrClosed
+	}
+	return a.writer.Write(Data)
+}
+
+/**
+ * @description: 将缓冲区中的数据写入临时文件
+ * @return {error} 错误信息
+ */
+func (a *AtomicFile) Flush() error {
+	if a.isDone {
+		return os.ErrClosed
+	}
+	return a.writer.Flush()
+}
+
+/**
+ * @description: 提交写入，将临时文件重命名为目标文件，失败时删除临时文件
+ * @return {error} 错误信息
+ */
+func (a *AtomicFile) Commit() error {
+	if a.isDone {
+		return os.ErrClosed
+	}
+	Success := false
+	defer func() {
+		if !Success {
+			a.Abort()
+		}
+	}()
+
+	if err := a.writer.Flush(); err != nil {
+		return err
+	}
+	if err := a.tempFile.Sync(); err != nil {
+		return err
+	}
+	if err := a.tempFile.Close(); err != nil {
+		return err
+	}
+
+	// 保留原文件的权限
+	Mode := os.FileMode(0644)
+	if FileInfo, err := os.Stat(a.path); err == nil {
+		Mode = FileInfo.Mode().Perm()
+	}
+	if err := os.Chmod(a.tempFile.Name(), Mode); err != nil {
+		return err
+	}
+
+	if err := os.Rename(a.tempFile.Name(), a.path); err != nil {
+		return err
+	}
+	Success = true
+	a.isDone = true
+
+	return nil
+}
+
+/**
+ * @description: 放弃写入并删除临时文件，目标文件保持不变
+ * @return {error} 错误信息
+ */
+func (a *AtomicFile) Abort() error {
+	if a.isDone {
+		return nil
+	}
+	a.isDone = true
+	a.tempFile.Close()
+	return os.Remove(a.tempFile.Name())
+}
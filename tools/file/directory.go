@@ -11,15 +11,17 @@ package file
 import (
 	"os"
 	"path/filepath"
+	"slices"
 )
 
 /**
  * @description: 获取指定目录下的所有文件路径
  * @param {string} Path 目录路径
+ * @param {...string} SkipDirectories 跳过的目录，如位于输入目录中的输出目录
  * @return {[]string} 返回文件路径列表
  * @return {error} 错误信息
  */
-func GetDirectoryFilePaths(Path string) ([]string, error) {
+func GetDirectoryFilePaths(Path string, SkipDirectories ...string) ([]string, error) {
 	var FileList []string
 	Files, err := os.ReadDir(Path)
 	if err != nil {
@@ -29,8 +31,14 @@ func GetDirectoryFilePaths(Path string) ([]string, error) {
 		if !File.IsDir() {
 			FileList = append(FileList, filepath.Join(Path, File.Name()))
 		} else {
+			if slices.ContainsFunc(SkipDirectories, func(Directory string) bool {
+				return SamePath(Directory, filepath.Join(Path, File.Name()))
+			}) {
+				continue
+			}
+
 			// 如果是文件夹，则递归获取文件夹内的文件
-			SubFiles, subErr := GetDirectoryFilePaths(filepath.Join(Path, File.Name()))
+			SubFiles, subErr := GetDirectoryFilePaths(filepath.Join(Path, File.Name()), SkipDirectories...)
 			if subErr != nil {
 				return FileList, subErr
 			}
@@ -39,3 +47,18 @@ func GetDirectoryFilePaths(Path string) ([]string, error) {
 	}
 	return FileList, err
 }
+
+/**
+ * @description: 判断两个路径是否指向同一位置
+ * @param {string} A 路径
+ * @param {string} B 路径
+ * @return {bool} 是否相同
+ */
+func SamePath(A, B string) bool {
+	AbsA, errA := filepath.Abs(A)
+	AbsB, errB := filepath.Abs(B)
+	if errA != nil || errB != nil {
+		return filepath.Clean(A) == filepath.Clean(B)
+	}
+	return AbsA == AbsB
+}
@@ -0,0 +1,66 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-06 10:15:30
+ * @LastEditTime: 2025-07-06 10:48:12
+ * @LastEditors: nijineko
+ * @Description: 输出路径工具
+ * @FilePath: \AutoTranslation\tools\file\output.go
+ */
+package file
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+/**
+ * @description: 根据输出目录和文件名模板计算输出文件路径
+ * @param {string} InputPath 输入文件路径
+ * @param {string} InputRoot 输入根目录，输入为目录时用于保持相对目录结构，输入为文件时为空
+ * @param {string} Directory 输出目录，为空时输出到输入文件所在目录
+ * @param {string} Pattern 文件名模板，支持{name}、{ext}以及Variables中的变量，为空时保持原文件名
+ * @param {map[string]string} Variables 模板变量，键不包含花括号
+ * @return {string} 输出文件路径
+ * @return {error} 错误信息
+ */
+func OutputPath(InputPath, InputRoot, Directory, Pattern string, Variables map[string]string) (string, error) {
+	Ext := filepath.Ext(InputPath)
+	if Value, ok := Variables["ext"]; ok {
+		// 输出格式与输入不同时由调用方指定扩展名
+		Ext = Value
+	}
+
+	// 计算输出文件名
+	FileName := filepath.Base(InputPath)
+	if Pattern != "" {
+		Replacements := []string{
+			"{name}", strings.TrimSuffix(filepath.Base(InputPath), filepath.Ext(InputPath)),
+			"{ext}", Ext,
+		}
+		for Key, Value := range Variables {
+			if Key == "ext" {
+				continue
+			}
+			Replacements = append(Replacements, "{"+Key+"}", Value)
+		}
+		FileName = strings.NewReplacer(Replacements...).Replace(Pattern)
+	} else if Ext != filepath.Ext(InputPath) {
+		FileName = strings.TrimSuffix(FileName, filepath.Ext(InputPath)) + Ext
+	}
+
+	if Directory == "" {
+		return filepath.Join(filepath.Dir(InputPath), FileName), nil
+	}
+
+	// 保持输入目录下的相对目录结构
+	RelativeDir := ""
+	if InputRoot != "" {
+		Relative, err := filepath.Rel(InputRoot, filepath.Dir(InputPath))
+		if err != nil {
+			return "", err
+		}
+		RelativeDir = Relative
+	}
+
+	return filepath.Join(Directory, RelativeDir, FileName), nil
+}
@@ -0,0 +1,69 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-06 10:50:21
+ * @LastEditTime: 2025-07-06 10:58:40
+ * @LastEditors: nijineko
+ * @Description: 输出路径工具测试
+ * @FilePath: \AutoTranslation\tools\file\output_test.go
+ */
+package file
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestOutputPath(t *testing.T) {
+	Variables := map[string]string{"target_lang": "zh-CN"}
+
+	tests := []struct {
+		name      string
+		inputPath string
+		inputRoot string
+		directory string
+		pattern   string
+		variables map[string]string
+		want      string
+	}{
+		{
+			name:      "Pattern next to input",
+			inputPath: filepath.Join("data", "script.xlsx"),
+			pattern:   "{name}.{target_lang}{ext}",
+			variables: Variables,
+			want:      filepath.Join("data", "script.zh-CN.xlsx"),
+		},
+		{
+			name:      "Directory keeps relative structure",
+			inputPath: filepath.Join("data", "chapter1", "script.csv"),
+			inputRoot: "data",
+			directory: "out",
+			variables: Variables,
+			want:      filepath.Join("out", "chapter1", "script.csv"),
+		},
+		{
+			name:      "Single file into directory",
+			inputPath: filepath.Join("data", "chapter1", "script.csv"),
+			directory: "out",
+			pattern:   "{target_lang}_{name}{ext}",
+			variables: Variables,
+			want:      filepath.Join("out", "zh-CN_script.csv"),
+		},
+		{
+			name:      "Override extension",
+			inputPath: filepath.Join("data", "script.xls"),
+			variables: map[string]string{"ext": ".xlsx"},
+			want:      filepath.Join("data", "script.xlsx"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := OutputPath(tt.inputPath, tt.inputRoot, tt.directory, tt.pattern, tt.variables)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("OutputPath() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-04 14:10:37
+ * @LastEditTime: 2025-07-04 14:42:19
+ * @LastEditors: nijineko
+ * @Description: 并发任务工具
+ * @FilePath: \AutoTranslation\tools\worker\pool.go
+ */
+package worker
+
+import (
+	"context"
+	"sync"
+)
+
+/**
+ * @description: 使用固定数量的协程并发执行任务，并按任务顺序回调结果
+ * @param {context.Context} Ctx 上下文，取消后不再分发新任务，未分发的任务不会执行
+ * @param {int} Concurrency 并发数量，小于1时按1处理
+ * @param {int} Count 任务数量
+ * @param {func(context.Context, int) T} Work 任务函数，参数为任务索引，会被并发调用
+ * @param {func(int, T)} Done 结果回调，在调用方协程中按任务索引顺序调用
+ * @return {int} 已完成并回调的任务数量
+ */
+func RunOrdered[T any](Ctx context.Context, Concurrency int, Count int, Work func(Ctx context.Context, Index int) T, Done func(Index int, Result T)) int {
+	Concurrency = max(Concurrency, 1)
+
+	Results := make([]T, Count)
+	Finished := make([]chan struct{}, Count)
+	for Index := range Finished {
+		Finished[Index] = make(chan struct{})
+	}
+
+	// 分发任务，取消后停止分发
+	Jobs := make(chan int)
+	Stopped := make(chan struct{}) // 分发结束时关闭
+	DispatchedCount := 0           // 实际分发的任务数量，Stopped关闭后可读取
+	go func() {
+		defer close(Stopped)
+		defer close(Jobs)
+		for ; DispatchedCount < Count; DispatchedCount++ {
+			select {
+			case Jobs <- DispatchedCount:
+			case <-Ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var WaitGroup sync.WaitGroup
+	for range Concurrency {
+		WaitGroup.Add(1)
+		go func() {
+			defer WaitGroup.Done()
+			for Index := range Jobs {
+				Results[Index] = Work(Ctx, Index)
+				close(Finished[Index])
+			}
+		}()
+	}
+	defer WaitGroup.Wait()
+
+	// 按顺序等待任务完成并回调结果
+	for Index := range Count {
+		select {
+		case <-Finished[Index]:
+		case <-Stopped:
+			if Index >= DispatchedCount {
+				// 剩余任务未被分发
+				return Index
+			}
+			<-Finished[Index]
+		}
+		Done(Index, Results[Index])
+	}
+
+	return Count
+}
@@ -0,0 +1,77 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-04 14:45:10
+ * @LastEditTime: 2025-07-04 14:51:36
+ * @LastEditors: nijineko
+ * @Description: 并发任务工具测试
+ * @FilePath: \AutoTranslation\tools\worker\pool_test.go
+ */
+package worker
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRunOrdered(t *testing.T) {
+	tests := []struct {
+		name        string
+		concurrency int
+		count       int
+	}{
+		{name: "Sequential", concurrency: 1, count: 10},
+		{name: "Concurrent", concurrency: 4, count: 50},
+		{name: "Invalid concurrency", concurrency: 0, count: 3},
+		{name: "No tasks", concurrency: 4, count: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var Order []int
+			got := RunOrdered(context.Background(), tt.concurrency, tt.count,
+				func(Ctx context.Context, Index int) int {
+					// 让靠前的任务更晚完成，检查回调顺序
+					time.Sleep(time.Duration(tt.count-Index) * 100 * time.Microsecond)
+					return Index * 2
+				},
+				func(Index int, Result int) {
+					if Result != Index*2 {
+						t.Errorf("RunOrdered() result of task %d = %d, want %d", Index, Result, Index*2)
+					}
+					Order = append(Order, Index)
+				},
+			)
+			if got != tt.count {
+				t.Errorf("RunOrdered() = %v, want %v", got, tt.count)
+			}
+			for Index, Value := range Order {
+				if Value != Index {
+					t.Fatalf("RunOrdered() callback order = %v", Order)
+				}
+			}
+		})
+	}
+}
+
+func TestRunOrderedCancel(t *testing.T) {
+	Ctx, Cancel := context.WithCancel(context.Background())
+	defer Cancel()
+
+	got := RunOrdered(Ctx, 2, 100,
+		func(Ctx context.Context, Index int) int {
+			if Index >= 10 {
+				// 模拟进行中的请求，取消后才返回
+				<-Ctx.Done()
+			}
+			return Index
+		},
+		func(Index int, Result int) {
+			if Index == 9 {
+				Cancel()
+			}
+		},
+	)
+	if got < 10 || got == 100 {
+		t.Errorf("RunOrdered() = %v, want stop after cancel", got)
+	}
+}
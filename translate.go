@@ -0,0 +1,1003 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-07 11:02:36
+ * @LastEditTime: 2025-07-07 14:28:51
+ * @LastEditors: nijineko
+ * @Description: 表格文件翻译流程
+ * @FilePath: \AutoTranslation\translate.go
+ */
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/nijinekoyo/AutoTranslation/internal/config"
+	"github.com/nijinekoyo/AutoTranslation/internal/log"
+	"github.com/nijinekoyo/AutoTranslation/pkg/checkpoint"
+	"github.com/nijinekoyo/AutoTranslation/pkg/table"
+	"github.com/nijinekoyo/AutoTranslation/pkg/table/android"
+	"github.com/nijinekoyo/AutoTranslation/pkg/table/apple"
+	"github.com/nijinekoyo/AutoTranslation/pkg/table/csv"
+	"github.com/nijinekoyo/AutoTranslation/pkg/table/excel"
+	"github.com/nijinekoyo/AutoTranslation/pkg/table/ini"
+	"github.com/nijinekoyo/AutoTranslation/pkg/table/json"
+	"github.com/nijinekoyo/AutoTranslation/pkg/table/markdown"
+	"github.com/nijinekoyo/AutoTranslation/pkg/table/ods"
+	"github.com/nijinekoyo/AutoTranslation/pkg/table/po"
+	"github.com/nijinekoyo/AutoTranslation/pkg/table/properties"
+	"github.com/nijinekoyo/AutoTranslation/pkg/table/resource"
+	"github.com/nijinekoyo/AutoTranslation/pkg/table/resx"
+	"github.com/nijinekoyo/AutoTranslation/pkg/table/subtitle"
+	"github.com/nijinekoyo/AutoTranslation/pkg/table/xliff"
+	"github.com/nijinekoyo/AutoTranslation/pkg/table/yaml"
+	"github.com/nijinekoyo/AutoTranslation/pkg/translation"
+	"github.com/nijinekoyo/AutoTranslation/tools/worker"
+	"github.com/noa-log/colorize"
+)
+
+var (
+	// 不支持的表格格式
+	ErrUnsupportedFormat = errors.New("unsupported table format")
+	// 表格文件无法读取
+	ErrOpenTable = errors.New("failed to open table")
+	// 列映射不适用于资源文件
+	ErrResourceMapping = errors.New("unsupported column mapping for resource file")
+	// 输入目录中不像本地化文件的通用格式文件
+	ErrSkippedFile = errors.New("not a localization file, add it to files.include to translate it")
+)
+
+// 资源文件格式的扩展名，资源文件只有原文和译文两列可以翻译
+var resourceExtensions = []string{
+	".json", ".yml", ".yaml", ".po", ".pot", ".xlf", ".xliff", ".xml", ".strings", ".xcstrings",
+	".srt", ".vtt", ".ass", ".ssa", ".md", ".markdown", ".txt", ".properties", ".resx", ".ini",
+}
+
+// 解析后的列映射
+type columnTask struct {
+	config.ColumnMapping
+
+	// 按表头解析后的列索引，每个文件单独解析
+	SourceColumn     int // 源列索引，从0开始计数
+	TargetColumn     int // 目标列索引，从0开始计数
+	ProvenanceColumn int // 译文来源列索引，从0开始计数，为-1时不记录
+	ContextColumn    int // 说明列索引，从0开始计数，为-1时不使用
+
+	Translator *translation.FallbackTranslator // 翻译服务降级链
+}
+
+// 翻译批次，同一批次的行属于同一个列映射
+type translationBatch struct {
+	Task int   // 列映射索引
+	Rows []int // 行索引，从0开始计数
+}
+
+// 翻译批次结果
+type batchResult struct {
+	SourceTexts     []string
+	TranslatedTexts []string
+	Providers       []string // 每条译文来自的翻译服务
+	Err             error
+}
+
+// 翻译计划
+type translationPlan struct {
+	Sheets     *table.SheetSelector // 工作表选择规则
+	Tasks      []columnTask         // 默认列映射任务
+	SheetTasks []sheetTasks         // 指定工作表的列映射任务，按顺序匹配第一个
+}
+
+// 指定工作表的列映射任务
+type sheetTasks struct {
+	Name    string         // 工作表名称
+	Pattern *regexp.Regexp // 工作表名称正则，为nil时只按名称匹配
+	Tasks   []columnTask   // 列映射任务
+}
+
+/**
+ * @description: 按配置创建翻译计划
+ * @param {func(string) (translation.Translation, error)} NewTranslator 按服务名称创建翻译器，同一服务只创建一次，以便在各映射间共享限流
+ * @return {*translationPlan} 翻译计划
+ * @return {error} 错误信息
+ */
+func newTranslationPlan(NewTranslator func(Service string) (translation.Translation, error)) (*translationPlan, error) {
+	Sheets, err := table.NewSheetSelector(config.Get().Sheets.All, config.Get().Sheets.Names, config.Get().Sheets.Patterns)
+	if err != nil {
+		return nil, err
+	}
+
+	Translators := map[string]translation.Translation{}
+	GetTranslator := func(Service string) (translation.Translation, error) {
+		if ServiceTranslator, ok := Translators[Service]; ok {
+			return ServiceTranslator, nil
+		}
+		ServiceTranslator, err := NewTranslator(Service)
+		if err != nil {
+			return nil, err
+		}
+		Translators[Service] = ServiceTranslator
+		return ServiceTranslator, nil
+	}
+
+	Plan := &translationPlan{Sheets: Sheets}
+	if Plan.Tasks, err = newColumnTasks(config.Get().ColumnMappings(), GetTranslator); err != nil {
+		return nil, err
+	}
+
+	for _, Mapping := range config.Get().Sheets.Mappings {
+		if Mapping.Name == "" && Mapping.Pattern == "" {
+			return nil, errors.New("sheet mapping requires a name or pattern")
+		}
+		if len(Mapping.Columns) == 0 {
+			return nil, fmt.Errorf("sheet mapping %q has no columns", Mapping.Name+Mapping.Pattern)
+		}
+
+		SheetPlan := sheetTasks{Name: Mapping.Name}
+		if Mapping.Pattern != "" {
+			if SheetPlan.Pattern, err = regexp.Compile(Mapping.Pattern); err != nil {
+				return nil, fmt.Errorf("invalid sheet pattern %q: %w", Mapping.Pattern, err)
+			}
+		}
+		if SheetPlan.Tasks, err = newColumnTasks(config.Get().CompleteMappings(Mapping.Columns), GetTranslator); err != nil {
+			return nil, err
+		}
+		Plan.SheetTasks = append(Plan.SheetTasks, SheetPlan)
+	}
+
+	return Plan, nil
+}
+
+/**
+ * @description: 获取工作表使用的列映射任务
+ * @param {string} Sheet 工作表名称，单工作表格式为空
+ * @return {[]columnTask} 列映射任务
+ */
+func (p *translationPlan) tasksFor(Sheet string) []columnTask {
+	for _, SheetPlan := range p.SheetTasks {
+		// 只配置正则的映射名称为空，不能匹配单工作表格式的空名称
+		if (SheetPlan.Name != "" && Sheet == SheetPlan.Name) || (SheetPlan.Pattern != nil && SheetPlan.Pattern.MatchString(Sheet)) {
+			return SheetPlan.Tasks
+		}
+	}
+	return p.Tasks
+}
+
+/**
+ * @description: 描述翻译计划中的列映射，用于区分翻译进度日志
+ * @return {[]string} 列映射描述
+ */
+func (p *translationPlan) describe() []string {
+	Describe := func(Prefix string, Tasks []columnTask) (Parts []string) {
+		for _, Task := range Tasks {
+			Parts = append(Parts, fmt.Sprintf("%s%s>%s:%s", Prefix, Task.Source, Task.Target, Task.TargetLanguage))
+		}
+		return Parts
+	}
+
+	Parts := Describe("", p.Tasks)
+	for _, SheetPlan := range p.SheetTasks {
+		Prefix := SheetPlan.Name + "|"
+		if SheetPlan.Pattern != nil {
+			Prefix += SheetPlan.Pattern.String() + "|"
+		}
+		Parts = append(Parts, Describe(Prefix, SheetPlan.Tasks)...)
+	}
+	return Parts
+}
+
+/**
+ * @description: 创建列映射任务
+ * @param {[]config.ColumnMapping} Mappings 已补全语言和翻译服务的列映射
+ * @param {func(string) (translation.Translation, error)} GetTranslator 按服务名称获取翻译器
+ * @return {[]columnTask} 列映射任务
+ * @return {error} 错误信息
+ */
+func newColumnTasks(Mappings []config.ColumnMapping, GetTranslator func(Service string) (translation.Translation, error)) ([]columnTask, error) {
+	var Tasks []columnTask
+	for _, Mapping := range Mappings {
+		if Mapping.Source.IsZero() || Mapping.Target.IsZero() {
+			return nil, fmt.Errorf("invalid column mapping: source %q, target %q", Mapping.Source, Mapping.Target)
+		}
+
+		// 按映射指定的服务顺序组成降级链
+		var Services []translation.Service
+		for _, Service := range Mapping.Service {
+			ServiceTranslator, err := GetTranslator(Service)
+			if err != nil {
+				return nil, err
+			}
+			Services = append(Services, translation.Service{Name: Service, Translator: ServiceTranslator})
+		}
+
+		Tasks = append(Tasks, columnTask{
+			ColumnMapping: Mapping,
+			Translator:    translation.NewFallbackTranslator(Services...),
+		})
+	}
+	return Tasks, nil
+}
+
+/**
+ * @description: 按扩展名打开表格文件
+ * @param {string} FilePath 输入文件路径
+ * @param {string} OutputPath 输出文件路径
+ * @param {string} Language 目标语言，资源文件按该语言写入译文
+ * @return {table.Table} 表格实例
+ * @return {error} 错误信息
+ */
+func openTable(FilePath, OutputPath, Language string) (table.Table, error) {
+	// 通过扩展名需要使用的表格处理器
+	switch strings.ToLower(filepath.Ext(FilePath)) {
+	case ".xlsx", ".xls":
+		return excel.New(FilePath, OutputPath)
+	case ".ods":
+		return ods.New(FilePath, OutputPath)
+	case ".json":
+		return json.New(FilePath, OutputPath, Language)
+	case ".yml", ".yaml":
+		return yaml.New(FilePath, OutputPath, Language)
+	case ".po", ".pot":
+		return po.New(FilePath, OutputPath, Language)
+	case ".xlf", ".xliff":
+		return xliff.New(FilePath, OutputPath, Language)
+	case ".xml":
+		return android.New(FilePath, OutputPath, Language)
+	case ".strings":
+		return apple.NewStrings(FilePath, OutputPath, Language)
+	case ".xcstrings":
+		return apple.NewCatalog(FilePath, OutputPath, Language)
+	case ".srt":
+		return subtitle.NewSRT(FilePath, OutputPath, Language)
+	case ".vtt":
+		return subtitle.NewVTT(FilePath, OutputPath, Language)
+	case ".ass", ".ssa":
+		return subtitle.NewASS(FilePath, OutputPath, Language)
+	case ".md", ".markdown":
+		return markdown.New(FilePath, OutputPath, Language)
+	case ".txt":
+		return markdown.NewText(FilePath, OutputPath, Language)
+	case ".properties":
+		return properties.New(FilePath, OutputPath, Language)
+	case ".resx":
+		return resx.New(FilePath, OutputPath, Language)
+	case ".ini":
+		return ini.New(FilePath, OutputPath, Language)
+	case ".csv", ".tsv":
+		Dialect, err := csvDialect(FilePath)
+		if err != nil {
+			return nil, err
+		}
+		return csv.NewWithDialect(FilePath, OutputPath, Dialect)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, filepath.Ext(FilePath))
+	}
+}
+
+/**
+ * @description: 判断输入目录中的文件是否为本地化文件，JSON和XML等通用扩展名需要符合本地化文件的结构，TXT文件需要在files.include中指定
+ * @param {string} FilePath 文件路径
+ * @return {bool} 是否需要翻译
+ */
+func isLocalizationFile(FilePath string) bool {
+	if config.Get().IncludeFile(FilePath) {
+		return true
+	}
+	switch strings.ToLower(filepath.Ext(FilePath)) {
+	case ".json":
+		return json.IsLocalePath(FilePath)
+	case ".xml":
+		return android.IsResourcePath(FilePath)
+	case ".txt":
+		// 纯文本没有可识别的本地化结构，只翻译指定的文件
+		return false
+	}
+	return true
+}
+
+/**
+ * @description: 检查资源文件的列映射并获取目标语言，资源文件只能将原文列翻译到译文列
+ * @param {[]columnTask} Tasks 列映射任务
+ * @return {string} 目标语言
+ * @return {error} 错误信息
+ */
+func resourceLanguage(Tasks []columnTask) (string, error) {
+	if len(Tasks) != 1 {
+		return "", fmt.Errorf("%w: expected a single mapping, got %d", ErrResourceMapping, len(Tasks))
+	}
+
+	Task := Tasks[0]
+	Source, SourceErr := table.ResolveColumn(resource.Header, string(Task.Source))
+	Target, TargetErr := table.ResolveColumn(resource.Header, string(Task.Target))
+	if SourceErr != nil || TargetErr != nil || Source != resource.ColumnSource-1 || Target != resource.ColumnTarget-1 {
+		return "", fmt.Errorf("%w: source %q and target %q must be columns %d and %d", ErrResourceMapping, Task.Source, Task.Target, resource.ColumnSource, resource.ColumnTarget)
+	}
+	if !Task.ColumnMapping.ProvenanceColumn.IsZero() {
+		// 资源文件只写回译文列
+		return "", fmt.Errorf("%w: provenance column %q cannot be written", ErrResourceMapping, Task.ColumnMapping.ProvenanceColumn)
+	}
+
+	return Task.TargetLanguage, nil
+}
+
+/**
+ * @description: 按配置获取CSV文件的方言，TSV文件未指定分隔符时使用制表符
+ * @param {string} FilePath 输入文件路径
+ * @return {csv.Dialect} CSV方言
+ * @return {error} 错误信息
+ */
+func csvDialect(FilePath string) (csv.Dialect, error) {
+	Config := config.Get().CSVDialect(FilePath)
+
+	var Dialect csv.Dialect
+	for _, Option := range []struct {
+		name  string
+		value string
+		rune  *rune
+	}{
+		{"delimiter", Config.Delimiter, &Dialect.Delimiter},
+		{"comment", Config.Comment, &Dialect.Comment},
+	} {
+		if Option.value == "" {
+			continue
+		}
+		Runes := []rune(Option.value)
+		if len(Runes) != 1 {
+			return csv.Dialect{}, fmt.Errorf("csv %s must be a single character: %q", Option.name, Option.value)
+		}
+		*Option.rune = Runes[0]
+	}
+	if Dialect.Delimiter == 0 && strings.EqualFold(filepath.Ext(FilePath), ".tsv") {
+		Dialect.Delimiter = '\t'
+	}
+	Dialect.LazyQuotes = Config.LazyQuotes
+	Dialect.TrimLeadingSpace = Config.TrimLeadingSpace
+	Dialect.Encoding = Config.Encoding
+	Dialect.OutputEncoding = Config.OutputEncoding
+	Dialect.AddBOM = Config.AddBOM
+
+	return Dialect, nil
+}
+
+/**
+ * @description: 获取文件的目标语言，资源文件使用列映射的目标语言，其他文件使用配置的目标语言
+ * @param {string} FilePath 输入文件路径
+ * @param {*translationPlan} Plan 翻译计划
+ * @return {string} 目标语言
+ * @return {error} 错误信息
+ */
+func fileLanguage(FilePath string, Plan *translationPlan) (string, error) {
+	if !slices.Contains(resourceExtensions, strings.ToLower(filepath.Ext(FilePath))) {
+		return config.Get().Translation.TargetLanguage, nil
+	}
+	return resourceLanguage(Plan.tasksFor(""))
+}
+
+/**
+ * @description: 计算文件的输出路径，xls输出为xlsx，资源文件按目标语言放在对应的位置
+ * @param {string} FilePath 输入文件路径
+ * @param {string} InputRoot 输入根目录，输入为文件时为空
+ * @param {string} Language 目标语言
+ * @return {string} 输出文件路径
+ * @return {error} 错误信息
+ */
+func fileOutputPath(FilePath, InputRoot, Language string) (string, error) {
+	OutputPath, err := outputPath(FilePath, InputRoot, Language)
+	if err != nil {
+		return "", err
+	}
+	// xls只能读取，结果写入同名的xlsx文件
+	OutputPath = excel.XLSXPath(OutputPath)
+	return localeOutputPath(FilePath, OutputPath, Language), nil
+}
+
+/**
+ * @description: 翻译单个表格文件，逐个翻译选中的工作表并写入输出文件
+ * @param {context.Context} Ctx 上下文，取消时保存已完成的结果后返回
+ * @param {string} FilePath 输入文件路径
+ * @param {string} InputRoot 输入根目录，输入为文件时为空
+ * @param {*translationPlan} Plan 翻译计划
+ * @return {error} 错误信息
+ */
+func translateFile(Ctx context.Context, FilePath, InputRoot string, Plan *translationPlan) error {
+	// 输入为目录时跳过package.json、pom.xml、LICENSE.txt之类不是本地化文件的通用格式文件
+	if InputRoot != "" && !isLocalizationFile(FilePath) {
+		return fmt.Errorf("%s: %w", FilePath, ErrSkippedFile)
+	}
+
+	// 资源文件使用列映射的目标语言写入对应语言的文件
+	Language, err := fileLanguage(FilePath, Plan)
+	if err != nil {
+		return fmt.Errorf("%s: %w", FilePath, err)
+	}
+
+	// 计算输出文件路径
+	OutputPath, err := fileOutputPath(FilePath, InputRoot, Language)
+	if err != nil {
+		return err
+	}
+
+	TableInstance, err := openTable(FilePath, OutputPath, Language)
+	if errors.Is(err, ErrUnsupportedFormat) {
+		return err
+	} else if err != nil {
+		return fmt.Errorf("%w: %w", ErrOpenTable, err)
+	}
+
+	// 选择需要翻译的工作表，单工作表格式使用空名称
+	Sheets := []string{""}
+	SheetInstance, IsSheetTable := TableInstance.(table.SheetTable)
+	if IsSheetTable {
+		var Missing []string
+		Sheets, Missing = Plan.Sheets.Select(SheetInstance.Sheets())
+		for _, Name := range Missing {
+			log.Print().Warning("Translation", fmt.Sprintf("Sheet %q does not exist in %s", Name, FilePath))
+		}
+	}
+
+	// 流式读写大文件，不支持的格式一次读入全部数据
+	var StreamInstance table.StreamTable
+	IsStreamTable := false
+	if config.Get().Stream {
+		if StreamInstance, IsStreamTable = TableInstance.(table.StreamTable); !IsStreamTable {
+			log.Print().Warning("Translation", fmt.Sprintf("Streaming is not supported for %s, reading the whole file", FilePath))
+		}
+	}
+
+	// 打开翻译进度日志，恢复上次中断前已完成的翻译
+	var Journal *checkpoint.Journal
+	if config.Get().Checkpoint.Enable {
+		Journal, err = openJournal(FilePath, OutputPath, Plan)
+		if err != nil {
+			TableInstance.Close()
+			return err
+		}
+		defer Journal.Close()
+	}
+
+	for _, Sheet := range Sheets {
+		if IsSheetTable {
+			if err = SheetInstance.SelectSheet(Sheet); err != nil {
+				break
+			}
+		}
+		if IsStreamTable {
+			err = translateSheetStream(Ctx, StreamInstance, Sheet, Plan.tasksFor(Sheet), Journal)
+		} else {
+			err = translateSheet(Ctx, TableInstance, Sheet, Plan.tasksFor(Sheet), Journal)
+		}
+		if err != nil {
+			err = fmt.Errorf("%s: %w", FilePath, err)
+			break
+		}
+		if Ctx.Err() != nil {
+			break
+		}
+	}
+
+	// 保存翻译后的表格数据
+	if CloseErr := TableInstance.Close(); err == nil {
+		err = CloseErr
+	}
+	if err != nil {
+		return err
+	}
+	if Ctx.Err() != nil {
+		log.Print().Warning("Translation", fmt.Sprintf("Translation cancelled, partial results saved for file: %s", FilePath))
+		return Ctx.Err()
+	}
+
+	// 翻译完成，不再需要进度日志
+	if Journal != nil {
+		if err := Journal.Remove(); err != nil {
+			log.Print().Warning("Checkpoint", err)
+		}
+	}
+	log.Print().Info("Translation", fmt.Sprintf("Translation completed for file: %s -> %s", FilePath, OutputPath))
+
+	return nil
+}
+
+/**
+ * @description: 获取资源文件的目标语言输出路径，移动端字符串资源写入目标语言的资源目录，资源包、字幕和文档写入原文件旁带语言后缀的文件
+ * @param {string} FilePath 输入文件路径
+ * @param {string} OutputPath 按输出配置计算的输出文件路径
+ * @param {string} Language 目标语言
+ * @return {string} 目标语言的输出文件路径，其他格式保持不变
+ */
+func localeOutputPath(FilePath, OutputPath, Language string) string {
+	switch strings.ToLower(filepath.Ext(FilePath)) {
+	case ".xml":
+		OutputPath = localeDirectoryPath(FilePath, OutputPath, Language, android.LocalePath)
+	case ".strings":
+		OutputPath = localeDirectoryPath(FilePath, OutputPath, Language, apple.LocalePath)
+	case ".srt", ".vtt", ".ass", ".ssa":
+		OutputPath = subtitle.LocalePath(OutputPath, Language)
+	case ".md", ".markdown", ".txt":
+		OutputPath = markdown.LocalePath(OutputPath, Language)
+	case ".properties":
+		// 按源文件判断已有的语言后缀，不使用文件名模板
+		OutputPath = filepath.Join(filepath.Dir(OutputPath), filepath.Base(properties.LocalePath(FilePath, Language)))
+	case ".resx":
+		OutputPath = resx.LocalePath(OutputPath, Language)
+	case ".ini":
+		OutputPath = ini.LocalePath(OutputPath, Language)
+	}
+	return OutputPath
+}
+
+/**
+ * @description: 获取移动端字符串资源在目标语言资源目录中的路径，文件名与源文件相同，不使用文件名模板
+ * @param {string} FilePath 输入文件路径
+ * @param {string} OutputPath 按输出配置计算的输出文件路径
+ * @param {string} Language 目标语言
+ * @param {func(string, string) string} LocalePath 资源目录的替换规则
+ * @return {string} 目标语言的输出文件路径，源文件不在资源目录中时保持不变
+ */
+func localeDirectoryPath(FilePath, OutputPath, Language string, LocalePath func(Path, Language string) string) string {
+	SourceName := filepath.Join(filepath.Dir(OutputPath), filepath.Base(FilePath))
+	if Localized := LocalePath(SourceName, Language); Localized != SourceName {
+		return Localized
+	}
+	return OutputPath
+}
+
+/**
+ * @description: 翻译当前工作表，一次读取后填充全部列映射
+ * @param {context.Context} Ctx 上下文，取消时保存已完成的结果后返回
+ * @param {table.Table} TableInstance 表格实例，已选中需要翻译的工作表
+ * @param {string} Sheet 工作表名称，单工作表格式为空
+ * @param {[]columnTask} Tasks 列映射任务
+ * @param {*checkpoint.Journal} Journal 翻译进度日志，为nil表示不记录
+ * @return {error} 错误信息
+ */
+func translateSheet(Ctx context.Context, TableInstance table.Table, Sheet string, Tasks []columnTask, Journal *checkpoint.Journal) error {
+	// 读取表格数据
+	TableDatas, err := TableInstance.Read()
+	if err != nil {
+		return fmt.Errorf("failed to read table data: %w", err)
+	}
+
+	if len(TableDatas) == 0 {
+		log.Print().Warning("Translation", fmt.Sprintf("%s is empty, skipping translation", sheetLabel(Sheet)))
+		return nil
+	}
+
+	// 保留读取时的数据，写入时只更新发生变化的单元格
+	Original := make([][]string, len(TableDatas))
+	for Index, Row := range TableDatas {
+		Original[Index] = slices.Clone(Row)
+	}
+
+	// 按第一行表头解析列引用
+	Tasks, SkipHeader, err := resolveColumns(resourceContextTasks(TableInstance, Tasks), TableDatas)
+	if err != nil {
+		return fmt.Errorf("failed to resolve columns in %s: %w", sheetLabel(Sheet), err)
+	}
+
+	// 支持单元格级读写的表格可以识别公式
+	CellInstance, IsCellTable := TableInstance.(table.CellTable)
+	var IsFormula func(Row, Col int) (bool, error)
+	if IsCellTable {
+		IsFormula = CellInstance.IsFormula
+	}
+
+	if err := translateRows(Ctx, Sheet, Tasks, TableDatas, 0, SkipHeader, IsFormula, Journal); err != nil {
+		return err
+	}
+
+	// 保存翻译后的表格数据
+	if !IsCellTable {
+		return TableInstance.Write(TableDatas)
+	}
+
+	// 只写入发生变化的单元格，目标列继承源列的样式
+	StyleColumns := map[int]int{}
+	for _, Task := range Tasks {
+		StyleColumns[Task.TargetColumn] = Task.SourceColumn + 1
+	}
+	var Updates []table.CellUpdate
+	for RowIndex, Row := range TableDatas {
+		for ColumnIndex, Value := range Row {
+			if RowIndex < len(Original) && ColumnIndex < len(Original[RowIndex]) && Original[RowIndex][ColumnIndex] == Value {
+				continue
+			}
+			if Value == "" && (RowIndex >= len(Original) || ColumnIndex >= len(Original[RowIndex])) {
+				// 扩展行数据时补充的空单元格
+				continue
+			}
+			Updates = append(Updates, table.CellUpdate{
+				Row:         RowIndex + 1,
+				Column:      ColumnIndex + 1,
+				Value:       Value,
+				StyleColumn: StyleColumns[ColumnIndex],
+			})
+		}
+	}
+	return CellInstance.WriteCells(Updates)
+}
+
+/**
+ * @description: 流式翻译当前工作表，按块读取、翻译并写入，内存中只保留一个块
+ * @param {context.Context} Ctx 上下文，取消后剩余的行原样写入
+ * @param {table.StreamTable} TableInstance 表格实例，已选中需要翻译的工作表
+ * @param {string} Sheet 工作表名称，单工作表格式为空
+ * @param {[]columnTask} Tasks 列映射任务
+ * @param {*checkpoint.Journal} Journal 翻译进度日志，为nil表示不记录
+ * @return {error} 错误信息
+ */
+func translateSheetStream(Ctx context.Context, TableInstance table.StreamTable, Sheet string, Tasks []columnTask, Journal *checkpoint.Journal) error {
+	Reader, err := TableInstance.ReadRows()
+	if err != nil {
+		return fmt.Errorf("failed to read table data: %w", err)
+	}
+	defer Reader.Close()
+
+	if !Reader.Next() {
+		if err := Reader.Err(); err != nil {
+			return fmt.Errorf("failed to read table data: %w", err)
+		}
+		log.Print().Warning("Translation", fmt.Sprintf("%s is empty, skipping translation", sheetLabel(Sheet)))
+		return nil
+	}
+
+	// 只能按第一行表头解析列引用，新建的列放在表头之后
+	Header := [][]string{slices.Clone(Reader.Row())}
+	Tasks, SkipHeader, err := resolveColumns(Tasks, Header)
+	if err != nil {
+		return fmt.Errorf("failed to resolve columns in %s: %w", sheetLabel(Sheet), err)
+	}
+
+	Writer, err := TableInstance.WriteRows()
+	if err != nil {
+		return err
+	}
+	Success := false
+	defer func() {
+		if !Success {
+			Writer.Abort()
+		}
+	}()
+
+	// 每块的行数足够所有并发请求各翻译多个批次
+	ChunkSize := max(config.Get().Translation.BatchSize, 1) * max(config.Get().Concurrency, 1) * 8
+	Chunk, Offset, HasMore := Header, 0, true
+	for HasMore {
+		for len(Chunk) < ChunkSize {
+			if HasMore = Reader.Next(); !HasMore {
+				break
+			}
+			Chunk = append(Chunk, Reader.Row())
+		}
+		if err := Reader.Err(); err != nil {
+			return fmt.Errorf("failed to read table data: %w", err)
+		}
+
+		// 取消后不再翻译，剩余的行原样写入
+		if Ctx.Err() == nil {
+			if err := translateRows(Ctx, Sheet, Tasks, Chunk, Offset, SkipHeader, nil, Journal); err != nil {
+				return err
+			}
+		}
+		for _, Row := range Chunk {
+			if err := Writer.WriteRow(Row); err != nil {
+				return err
+			}
+		}
+		if err := Writer.Flush(); err != nil {
+			return err
+		}
+		Offset += len(Chunk)
+		Chunk = Chunk[:0]
+	}
+
+	// 先关闭读取器，输出文件与源文件相同时才能替换
+	if err := Reader.Close(); err != nil {
+		return err
+	}
+	Success = true
+	return Writer.Close()
+}
+
+/**
+ * @description: 翻译表格数据中的行，结果直接写入表格数据
+ * @param {context.Context} Ctx 上下文，取消时停止翻译并保留已完成的结果
+ * @param {string} Sheet 工作表名称，单工作表格式为空
+ * @param {[]columnTask} Tasks 已解析列索引的列映射任务
+ * @param {[][]string} TableDatas 表格数据，目标列超出范围时会扩展行数据
+ * @param {int} Offset 表格数据第一行在工作表中的索引，从0开始计数
+ * @param {bool} SkipHeader 工作表第一行是否为表头
+ * @param {func(int, int) (bool, error)} IsFormula 判断单元格是否为公式，行号和列号从1开始计数，为nil时不识别公式
+ * @param {*checkpoint.Journal} Journal 翻译进度日志，为nil表示不记录
+ * @return {error} 错误信息
+ */
+func translateRows(Ctx context.Context, Sheet string, Tasks []columnTask, TableDatas [][]string, Offset int, SkipHeader bool, IsFormula func(Row, Col int) (bool, error), Journal *checkpoint.Journal) error {
+	// 遍历表格数据，按列映射收集待翻译的行
+	PendingRows := make([][]int, len(Tasks))
+	for Index, Row := range TableDatas {
+		if (config.Get().SkipTableHeader || SkipHeader) && Offset+Index == 0 {
+			// 如果跳过表头，则继续下一行，流式翻译时只有第一块包含表头
+			continue
+		}
+
+		for TaskIndex, Task := range Tasks {
+			if len(Row) <= Task.SourceColumn {
+				log.Print().Error("Translation", fmt.Sprintf("%s: Source column %s is out of range", rowLabel(Sheet, Offset+Index+1), table.ColumnLetters(Task.SourceColumn)))
+				continue
+			}
+			if len(TableDatas[Index]) <= max(Task.TargetColumn, Task.ProvenanceColumn) {
+				// 如果目标列索引超出范围，则扩展行数据
+				for len(TableDatas[Index]) <= max(Task.TargetColumn, Task.ProvenanceColumn) {
+					TableDatas[Index] = append(TableDatas[Index], "")
+				}
+			}
+
+			if config.Get().SkipIfNotEmpty && TableDatas[Index][Task.TargetColumn] != "" {
+				// 如果待翻译单元格不为空且配置了跳过，则跳过翻译
+				log.Print().Warning("Translation", fmt.Sprintf("%s [%s]: cell is not empty, skipping translation", rowLabel(Sheet, Offset+Index+1), Task.TargetLanguage))
+				continue
+			}
+
+			if IsFormula != nil && !config.Get().TranslateFormulas {
+				IsFormula, err := IsFormula(Offset+Index+1, Task.SourceColumn+1)
+				if err != nil {
+					return err
+				}
+				if IsFormula {
+					// 公式的值由计算得出，翻译结果会随源数据变化而失效
+					log.Print().Warning("Translation", fmt.Sprintf("%s [%s]: source cell is a formula, skipping translation", rowLabel(Sheet, Offset+Index+1), Task.TargetLanguage))
+					continue
+				}
+			}
+
+			SourceText := TableDatas[Index][Task.SourceColumn]
+			if Journal != nil {
+				if Record, ok := Journal.Lookup(Sheet, Offset+Index+1, Task.TargetColumn+1, SourceText); ok {
+					// 上次已完成翻译，直接使用记录的结果
+					TableDatas[Index][Task.TargetColumn] = Record.Translation
+					if Task.ProvenanceColumn >= 0 {
+						TableDatas[Index][Task.ProvenanceColumn] = Record.Provider
+					}
+					log.Print().Info("Checkpoint", fmt.Sprintf("%s [%s, %s]: %s -> %s", rowLabel(Sheet, Offset+Index+1), Task.TargetLanguage, Record.Provider, colorize.YellowText(SourceText), colorize.GreenText(Record.Translation)))
+					continue
+				}
+			}
+
+			PendingRows[TaskIndex] = append(PendingRows[TaskIndex], Index)
+		}
+	}
+
+	// 按列映射分批
+	BatchSize := max(config.Get().Translation.BatchSize, 1)
+	var Batches []translationBatch
+	for TaskIndex, Rows := range PendingRows {
+		for Start := 0; Start < len(Rows); Start += BatchSize {
+			Batches = append(Batches, translationBatch{Task: TaskIndex, Rows: Rows[Start:min(Start+BatchSize, len(Rows))]})
+		}
+	}
+
+	// 按批次并发翻译待翻译的行
+	FinishedCount := worker.RunOrdered(Ctx, config.Get().Concurrency, len(Batches),
+		func(Ctx context.Context, BatchIndex int) batchResult {
+			Batch := Batches[BatchIndex]
+			Task := Tasks[Batch.Task]
+
+			// 获取待翻译文本
+			SourceTexts := make([]string, len(Batch.Rows))
+			for Index, Row := range Batch.Rows {
+				SourceTexts[Index] = TableDatas[Row][Task.SourceColumn]
+			}
+
+			// 说明列的内容作为上下文
+			Notes := make([]string, len(Batch.Rows))
+			if Task.ContextColumn >= 0 {
+				for Index, Row := range Batch.Rows {
+					if Task.ContextColumn < len(TableDatas[Row]) {
+						Notes[Index] = TableDatas[Row][Task.ContextColumn]
+					}
+				}
+			}
+
+			// 翻译文本
+			TranslatedTexts, Providers, err := Task.Translator.TranslateBatchWithProvenance(translation.WithNotes(translation.WithPrompt(Ctx, Task.Prompt), Notes), SourceTexts, Task.SourceLanguage, Task.TargetLanguage)
+			return batchResult{SourceTexts: SourceTexts, TranslatedTexts: TranslatedTexts, Providers: Providers, Err: err}
+		},
+		func(BatchIndex int, Result batchResult) {
+			Batch := Batches[BatchIndex]
+			Task := Tasks[Batch.Task]
+			if Result.Err != nil {
+				log.Print().Error("Translation", fmt.Sprintf("%s-%d [%s]:", rowLabel(Sheet, Offset+Batch.Rows[0]+1), Offset+Batch.Rows[len(Batch.Rows)-1]+1, Task.TargetLanguage), Result.Err)
+				return
+			}
+
+			// 记录翻译进度
+			if Journal != nil {
+				Records := make([]checkpoint.Record, len(Batch.Rows))
+				for Index, Row := range Batch.Rows {
+					Records[Index] = checkpoint.Record{
+						Sheet:       Sheet,
+						Row:         Offset + Row + 1,
+						Column:      Task.TargetColumn + 1,
+						Source:      Result.SourceTexts[Index],
+						Translation: Result.TranslatedTexts[Index],
+						Provider:    Result.Providers[Index],
+					}
+				}
+				if err := Journal.Append(Records...); err != nil {
+					log.Print().Warning("Checkpoint", "Failed to save translation progress:", err)
+				}
+			}
+
+			// 更新翻译结果到目标列
+			for Index, Row := range Batch.Rows {
+				TableDatas[Row][Task.TargetColumn] = Result.TranslatedTexts[Index]
+				if Task.ProvenanceColumn >= 0 {
+					TableDatas[Row][Task.ProvenanceColumn] = Result.Providers[Index]
+				}
+
+				log.Print().Info("Translation", fmt.Sprintf("%s [%s, %s]: %s -> %s", rowLabel(Sheet, Offset+Row+1), Task.TargetLanguage, Result.Providers[Index], colorize.YellowText(Result.SourceTexts[Index]), colorize.GreenText(Result.TranslatedTexts[Index])))
+			}
+		},
+	)
+	if FinishedCount < len(Batches) {
+		// 任务已被取消，停止翻译并保存已完成的结果
+		Batch := Batches[FinishedCount]
+		log.Print().Warning("Translation", fmt.Sprintf("Translation interrupted at %s [%s]", rowLabel(Sheet, Offset+Batch.Rows[0]+1), Tasks[Batch.Task].TargetLanguage))
+	}
+
+	return nil
+}
+
+/**
+ * @description: 工作表在日志中的名称
+ * @param {string} Sheet 工作表名称，单工作表格式为空
+ * @return {string} 日志名称
+ */
+func sheetLabel(Sheet string) string {
+	if Sheet == "" {
+		return "Table"
+	}
+	return fmt.Sprintf("Sheet %q", Sheet)
+}
+
+/**
+ * @description: 行在日志中的名称
+ * @param {string} Sheet 工作表名称，单工作表格式为空
+ * @param {int} Row 行号，从1开始计数
+ * @return {string} 日志名称
+ */
+func rowLabel(Sheet string, Row int) string {
+	if Sheet == "" {
+		return fmt.Sprintf("Row %d", Row)
+	}
+	return fmt.Sprintf("%s row %d", Sheet, Row)
+}
+
+/**
+ * @description: 资源文件未指定说明列时使用其中的注释作为说明
+ * @param {table.Table} TableInstance 表格实例
+ * @param {[]columnTask} Tasks 列映射任务
+ * @return {[]columnTask} 列映射任务，非资源文件原样返回
+ */
+func resourceContextTasks(TableInstance table.Table, Tasks []columnTask) []columnTask {
+	if _, IsResource := TableInstance.(*resource.ResourceTable); !IsResource {
+		return Tasks
+	}
+
+	Tasks = slices.Clone(Tasks)
+	for Index := range Tasks {
+		if Tasks[Index].ColumnMapping.ContextColumn.IsZero() {
+			Tasks[Index].ColumnMapping.ContextColumn = config.ColumnRef(strconv.Itoa(resource.ColumnContext))
+		}
+	}
+	return Tasks
+}
+
+/**
+ * @description: 按表头解析列映射中的列引用，目标列和译文来源列的表头不存在时在表头末尾创建
+ * @param {[]columnTask} Tasks 列映射任务
+ * @param {[][]string} TableDatas 表格数据，第一行为表头，创建列时会被修改
+ * @return {[]columnTask} 已解析列索引的列映射任务
+ * @return {bool} 是否有列按表头名称引用，此时第一行必然是表头，不参与翻译
+ * @return {error} 错误信息
+ */
+func resolveColumns(Tasks []columnTask, TableDatas [][]string) ([]columnTask, bool, error) {
+	Resolved := make([]columnTask, len(Tasks))
+	ByName := false
+
+	// 新建的列放在所有已有数据之后，避免覆盖没有表头的列
+	Width := 0
+	for _, Row := range TableDatas {
+		Width = max(Width, len(Row))
+	}
+
+	// 解析列引用，Create为true时表头不存在则创建
+	Resolve := func(Reference config.ColumnRef, Create bool) (int, error) {
+		Index, err := table.ResolveColumn(TableDatas[0], string(Reference))
+		if err == nil {
+			_, IsBracketed := table.HeaderName(string(Reference))
+			if _, HeaderErr := table.FindHeader(TableDatas[0], string(Reference)); IsBracketed || HeaderErr == nil {
+				ByName = true
+			}
+			return Index, nil
+		}
+		if !Create || !errors.Is(err, table.ErrColumnNotFound) {
+			return 0, err
+		}
+
+		Name, ok := table.HeaderName(string(Reference))
+		if !ok {
+			Name = strings.TrimSpace(string(Reference))
+		}
+
+		ByName = true
+		for len(TableDatas[0]) < Width {
+			TableDatas[0] = append(TableDatas[0], "")
+		}
+		TableDatas[0] = append(TableDatas[0], Name)
+		Width = len(TableDatas[0])
+		log.Print().Info("Translation", fmt.Sprintf("Created column %s with header %q", table.ColumnLetters(Width-1), Name))
+		return Width - 1, nil
+	}
+
+	for Index, Task := range Tasks {
+		var err error
+		if Task.SourceColumn, err = Resolve(Task.Source, false); err != nil {
+			return nil, false, fmt.Errorf("source column: %w", err)
+		}
+		if Task.TargetColumn, err = Resolve(Task.Target, true); err != nil {
+			return nil, false, fmt.Errorf("target column: %w", err)
+		}
+		Task.ProvenanceColumn = -1
+		if !Task.ColumnMapping.ProvenanceColumn.IsZero() {
+			if Task.ProvenanceColumn, err = Resolve(Task.ColumnMapping.ProvenanceColumn, true); err != nil {
+				return nil, false, fmt.Errorf("provenance column: %w", err)
+			}
+		}
+		Task.ContextColumn = -1
+		if !Task.ColumnMapping.ContextColumn.IsZero() {
+			if Task.ContextColumn, err = Resolve(Task.ColumnMapping.ContextColumn, false); err != nil {
+				return nil, false, fmt.Errorf("context column: %w", err)
+			}
+		}
+		Resolved[Index] = Task
+	}
+
+	return Resolved, ByName, nil
+}
+
+/**
+ * @description: 打开文件对应的翻译进度日志
+ * @param {string} FilePath 输入文件路径
+ * @param {string} OutputPath 输出文件路径
+ * @param {*translationPlan} Plan 翻译计划，列映射变化时使用新的日志
+ * @return {*checkpoint.Journal} 翻译进度日志
+ * @return {error} 错误信息
+ */
+func openJournal(FilePath, OutputPath string, Plan *translationPlan) (*checkpoint.Journal, error) {
+	AbsFilePath, err := filepath.Abs(FilePath)
+	if err != nil {
+		return nil, err
+	}
+	AbsOutputPath, err := filepath.Abs(OutputPath)
+	if err != nil {
+		return nil, err
+	}
+
+	Parts := append([]string{AbsFilePath, AbsOutputPath}, Plan.describe()...)
+
+	Journal, err := checkpoint.Open(checkpoint.PathFor(config.Get().Checkpoint.Directory, filepath.Base(FilePath), Parts...))
+	if err != nil {
+		return nil, err
+	}
+	if Journal.Len() > 0 {
+		log.Print().Info("Checkpoint", fmt.Sprintf("Resuming %s from checkpoint with %d finished cells", FilePath, Journal.Len()))
+	}
+
+	return Journal, nil
+}
@@ -0,0 +1,575 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-07 14:40:12
+ * @LastEditTime: 2025-07-07 14:40:12
+ * @LastEditors: nijineko
+ * @Description: 表格文件翻译流程测试
+ * @FilePath: \AutoTranslation\translate_test.go
+ */
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/nijinekoyo/AutoTranslation/internal/config"
+	"github.com/nijinekoyo/AutoTranslation/pkg/table"
+	"github.com/nijinekoyo/AutoTranslation/pkg/translation"
+)
+
+// 在原文前加上目标语言的测试翻译器
+type prefixTranslator struct{}
+
+func (p prefixTranslator) TranslateText(Ctx context.Context, Text string, SourceLanguage *string, TargetLanguage string) (string, error) {
+	return TargetLanguage + ":" + Text, nil
+}
+
+// 内存中的测试表格，支持流式读写
+type memoryTable struct {
+	rows [][]string
+}
+
+func (m *memoryTable) Close() error { return nil }
+func (m *memoryTable) Read() ([][]string, error) {
+	Rows := make([][]string, len(m.rows))
+	for Index, Row := range m.rows {
+		Rows[Index] = slices.Clone(Row)
+	}
+	return Rows, nil
+}
+func (m *memoryTable) Write(Datas [][]string) error               { m.rows = Datas; return nil }
+func (m *memoryTable) UpdateLine(Row int, Data []string) error    { return nil }
+func (m *memoryTable) UpdateCell(Row, Col int, Data string) error { return nil }
+func (m *memoryTable) Append(Data []string) error                 { return nil }
+func (m *memoryTable) Insert(Row int, Data []string) error        { return nil }
+func (m *memoryTable) Delete(Row int) error                       { return nil }
+
+func (m *memoryTable) ReadRows() (table.RowReader, error) {
+	return &memoryReader{rows: m.rows, index: -1}, nil
+}
+func (m *memoryTable) WriteRows() (table.RowWriter, error) {
+	return &memoryWriter{table: m}, nil
+}
+
+// 内存表格的行迭代器
+type memoryReader struct {
+	rows  [][]string
+	index int
+}
+
+func (r *memoryReader) Next() bool    { r.index++; return r.index < len(r.rows) }
+func (r *memoryReader) Row() []string { return slices.Clone(r.rows[r.index]) }
+func (r *memoryReader) Err() error    { return nil }
+func (r *memoryReader) Close() error  { return nil }
+
+// 内存表格的逐行写入器，关闭时替换表格内容
+type memoryWriter struct {
+	table *memoryTable
+	rows  [][]string
+}
+
+func (w *memoryWriter) WriteRow(Row []string) error {
+	w.rows = append(w.rows, slices.Clone(Row))
+	return nil
+}
+func (w *memoryWriter) Flush() error { return nil }
+func (w *memoryWriter) Close() error { w.table.rows = w.rows; return nil }
+func (w *memoryWriter) Abort() error { return nil }
+
+/**
+ * @description: 在测试期间替换全局配置
+ * @param {*testing.T} t 测试实例
+ * @param {config.Config} Config 测试使用的配置
+ */
+func setConfig(t *testing.T, Config config.Config) {
+	Previous := config.Data
+	config.Data = Config
+	t.Cleanup(func() { config.Data = Previous })
+}
+
+/**
+ * @description: 按当前配置创建使用测试翻译器的列映射任务
+ * @param {*testing.T} t 测试实例
+ * @param {[]config.ColumnMapping} Mappings 列映射，为空时使用配置中的映射
+ * @return {[]columnTask} 列映射任务
+ */
+func newTestTasks(t *testing.T, Mappings []config.ColumnMapping) []columnTask {
+	if Mappings == nil {
+		Mappings = config.Get().ColumnMappings()
+	} else {
+		Mappings = config.Get().CompleteMappings(Mappings)
+	}
+	Tasks, err := newColumnTasks(Mappings, func(Service string) (translation.Translation, error) {
+		return prefixTranslator{}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Tasks
+}
+
+/**
+ * @description: 创建测试配置
+ * @param {[]config.ColumnMapping} Columns 列映射
+ * @return {config.Config} 测试配置
+ */
+func testConfig(Columns ...config.ColumnMapping) config.Config {
+	var Config config.Config
+	Config.Columns = Columns
+	Config.Translation.Service = config.ServiceList{"fake"}
+	Config.Translation.TargetLanguage = "zh-CN"
+	return Config
+}
+
+func TestResolveColumns(t *testing.T) {
+	tests := []struct {
+		name           string
+		mappings       []config.ColumnMapping
+		datas          [][]string
+		wantColumns    [][4]int // 源列、目标列、译文来源列、说明列
+		wantSkipHeader bool
+		wantHeader     []string
+		wantErr        error
+	}{
+		{
+			name:        "column numbers",
+			mappings:    []config.ColumnMapping{{Source: "1", Target: "3"}},
+			datas:       [][]string{{"はい", "x"}},
+			wantColumns: [][4]int{{0, 2, -1, -1}},
+			wantHeader:  []string{"はい", "x"},
+		},
+		{
+			name:        "column letters",
+			mappings:    []config.ColumnMapping{{Source: "B", Target: "C", ContextColumn: "A"}},
+			datas:       [][]string{{"note", "はい"}},
+			wantColumns: [][4]int{{1, 2, -1, 0}},
+			wantHeader:  []string{"note", "はい"},
+		},
+		{
+			name:           "header names",
+			mappings:       []config.ColumnMapping{{Source: "Japanese", Target: "English", ContextColumn: "[ID]"}},
+			datas:          [][]string{{"ID", "Japanese", "English"}, {"1", "はい", ""}},
+			wantColumns:    [][4]int{{1, 2, -1, 0}},
+			wantSkipHeader: true,
+			wantHeader:     []string{"ID", "Japanese", "English"},
+		},
+		{
+			name:           "created columns go after the widest row",
+			mappings:       []config.ColumnMapping{{Source: "2", Target: "Korean", ProvenanceColumn: "Service"}},
+			datas:          [][]string{{"ID", "Japanese"}, {"1", "はい", "note"}},
+			wantColumns:    [][4]int{{1, 3, 4, -1}},
+			wantSkipHeader: true,
+			wantHeader:     []string{"ID", "Japanese", "", "Korean", "Service"},
+		},
+		{
+			name: "multiple mappings share a created column",
+			mappings: []config.ColumnMapping{
+				{Source: "Japanese", Target: "Korean", TargetLanguage: "ko"},
+				{Source: "English", Target: "Korean", TargetLanguage: "ko"},
+				{Source: "Japanese", Target: "English", TargetLanguage: "en"},
+			},
+			datas:          [][]string{{"Japanese", "English"}},
+			wantColumns:    [][4]int{{0, 2, -1, -1}, {1, 2, -1, -1}, {0, 1, -1, -1}},
+			wantSkipHeader: true,
+			wantHeader:     []string{"Japanese", "English", "Korean"},
+		},
+		{
+			name:     "missing source column",
+			mappings: []config.ColumnMapping{{Source: "Japanese", Target: "English"}},
+			datas:    [][]string{{"ID", "English"}},
+			wantErr:  table.ErrColumnNotFound,
+		},
+		{
+			name:     "missing context column",
+			mappings: []config.ColumnMapping{{Source: "Japanese", Target: "English", ContextColumn: "Note"}},
+			datas:    [][]string{{"Japanese", "English"}},
+			wantErr:  table.ErrColumnNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setConfig(t, testConfig())
+
+			Tasks, SkipHeader, err := resolveColumns(newTestTasks(t, tt.mappings), tt.datas)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("resolveColumns() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			var Columns [][4]int
+			for _, Task := range Tasks {
+				Columns = append(Columns, [4]int{Task.SourceColumn, Task.TargetColumn, Task.ProvenanceColumn, Task.ContextColumn})
+			}
+			if !reflect.DeepEqual(Columns, tt.wantColumns) {
+				t.Errorf("resolveColumns() columns = %v, want %v", Columns, tt.wantColumns)
+			}
+			if SkipHeader != tt.wantSkipHeader {
+				t.Errorf("resolveColumns() skip header = %v, want %v", SkipHeader, tt.wantSkipHeader)
+			}
+			if !reflect.DeepEqual(tt.datas[0], tt.wantHeader) {
+				t.Errorf("resolveColumns() header = %q, want %q", tt.datas[0], tt.wantHeader)
+			}
+		})
+	}
+}
+
+func TestNewColumnTasks(t *testing.T) {
+	setConfig(t, testConfig())
+
+	var Requested []string
+	GetTranslator := func(Service string) (translation.Translation, error) {
+		Requested = append(Requested, Service)
+		if Service == "broken" {
+			return nil, errors.New("unknown service")
+		}
+		return prefixTranslator{}, nil
+	}
+
+	Tasks, err := newColumnTasks(config.Get().CompleteMappings([]config.ColumnMapping{
+		{Source: "1", Target: "2", Service: config.ServiceList{"google", "openai"}},
+		{Source: "1", Target: "3", TargetLanguage: "ko"},
+	}), GetTranslator)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(Tasks) != 2 || Tasks[1].TargetLanguage != "ko" || Tasks[0].TargetLanguage != "zh-CN" {
+		t.Errorf("newColumnTasks() languages not completed from config: %+v", Tasks)
+	}
+	if want := []string{"google", "openai", "fake"}; !reflect.DeepEqual(Requested, want) {
+		t.Errorf("newColumnTasks() requested services = %q, want %q", Requested, want)
+	}
+
+	for _, Mappings := range [][]config.ColumnMapping{
+		{{Source: "1"}},
+		{{Target: "2"}},
+		{{Source: "1", Target: "2", Service: config.ServiceList{"broken"}}},
+	} {
+		if _, err := newColumnTasks(config.Get().CompleteMappings(Mappings), GetTranslator); err == nil {
+			t.Errorf("newColumnTasks(%+v) error = nil, want error", Mappings)
+		}
+	}
+}
+
+func TestNewTranslationPlan(t *testing.T) {
+	Config := testConfig(config.ColumnMapping{Source: "1", Target: "2"})
+	Config.Sheets.Mappings = []config.SheetMapping{
+		{Name: "Menu", Columns: []config.ColumnMapping{{Source: "2", Target: "3", Service: config.ServiceList{"openai", "fake"}}}},
+		{Pattern: "^Dialog", Columns: []config.ColumnMapping{{Source: "3", Target: "4"}}},
+	}
+	setConfig(t, Config)
+
+	Created := map[string]int{}
+	Plan, err := newTranslationPlan(func(Service string) (translation.Translation, error) {
+		Created[Service]++
+		return prefixTranslator{}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 同一服务只创建一次，各映射共享限流
+	if want := map[string]int{"fake": 1, "openai": 1}; !reflect.DeepEqual(Created, want) {
+		t.Errorf("newTranslationPlan() created services = %v, want %v", Created, want)
+	}
+
+	for Sheet, want := range map[string]string{"": "2", "Menu": "3", "Dialog 1": "4", "Other": "2"} {
+		if got := Plan.tasksFor(Sheet)[0].Target; string(got) != want {
+			t.Errorf("translationPlan.tasksFor(%q) target = %s, want %s", Sheet, got, want)
+		}
+	}
+
+	Config.Sheets.Mappings = []config.SheetMapping{{Columns: []config.ColumnMapping{{Source: "1", Target: "2"}}}}
+	setConfig(t, Config)
+	if _, err := newTranslationPlan(func(Service string) (translation.Translation, error) { return prefixTranslator{}, nil }); err == nil {
+		t.Errorf("newTranslationPlan() error = nil, want error for sheet mapping without name or pattern")
+	}
+}
+
+// 整表翻译与流式翻译共用的测试用例
+var sheetTests = []struct {
+	name   string
+	config func(*config.Config)
+	datas  [][]string
+	want   [][]string
+}{
+	{
+		name: "column numbers translate the first row",
+		config: func(Config *config.Config) {
+			Config.SourceColumn, Config.TargetColumn = "1", "2"
+		},
+		datas: [][]string{{"はい"}, {"いいえ"}},
+		want:  [][]string{{"はい", "zh-CN:はい"}, {"いいえ", "zh-CN:いいえ"}},
+	},
+	{
+		name: "skip table header",
+		config: func(Config *config.Config) {
+			Config.SourceColumn, Config.TargetColumn = "1", "2"
+			Config.SkipTableHeader = true
+		},
+		datas: [][]string{{"Japanese"}, {"はい"}},
+		want:  [][]string{{"Japanese"}, {"はい", "zh-CN:はい"}},
+	},
+	{
+		name: "header names with multiple mappings",
+		config: func(Config *config.Config) {
+			Config.Columns = []config.ColumnMapping{
+				{Source: "Japanese", Target: "English", TargetLanguage: "en"},
+				{Source: "Japanese", Target: "Korean", TargetLanguage: "ko", ProvenanceColumn: "Service"},
+			}
+		},
+		datas: [][]string{{"ID", "Japanese", "English"}, {"1", "はい", ""}, {"2", "いいえ"}},
+		want: [][]string{
+			{"ID", "Japanese", "English", "Korean", "Service"},
+			{"1", "はい", "en:はい", "ko:はい", "fake"},
+			{"2", "いいえ", "en:いいえ", "ko:いいえ", "fake"},
+		},
+	},
+	{
+		name: "skip if not empty",
+		config: func(Config *config.Config) {
+			Config.SourceColumn, Config.TargetColumn = "Japanese", "Chinese"
+			Config.SkipIfNotEmpty = true
+		},
+		datas: [][]string{{"Japanese", "Chinese"}, {"はい", "是"}, {"いいえ", ""}},
+		want:  [][]string{{"Japanese", "Chinese"}, {"はい", "是"}, {"いいえ", "zh-CN:いいえ"}},
+	},
+	{
+		name: "batches across concurrent requests",
+		config: func(Config *config.Config) {
+			Config.SourceColumn, Config.TargetColumn = "1", "2"
+			Config.Translation.BatchSize = 2
+			Config.Concurrency = 3
+		},
+		datas: [][]string{{"a"}, {"b"}, {"c"}, {"d"}, {"e"}},
+		want:  [][]string{{"a", "zh-CN:a"}, {"b", "zh-CN:b"}, {"c", "zh-CN:c"}, {"d", "zh-CN:d"}, {"e", "zh-CN:e"}},
+	},
+	{
+		name: "stream chunks after the first keep their first row",
+		config: func(Config *config.Config) {
+			Config.SourceColumn, Config.TargetColumn = "Japanese", "Chinese"
+			Config.Translation.BatchSize = 1
+			Config.Concurrency = 1
+		},
+		datas: numberedRows(20),
+		want:  translatedRows(20, "Chinese"),
+	},
+}
+
+/**
+ * @description: 生成带表头的测试行，第一列为原文
+ * @param {int} Count 数据行数量
+ * @return {[][]string} 表格数据
+ */
+func numberedRows(Count int) [][]string {
+	Rows := [][]string{{"Japanese"}}
+	for Index := 1; Index <= Count; Index++ {
+		Rows = append(Rows, []string{"文" + strconv.Itoa(Index)})
+	}
+	return Rows
+}
+
+/**
+ * @description: 生成numberedRows翻译后的期望结果
+ * @param {int} Count 数据行数量
+ * @param {string} Target 目标列表头
+ * @return {[][]string} 表格数据
+ */
+func translatedRows(Count int, Target string) [][]string {
+	Rows := numberedRows(Count)
+	Rows[0] = append(Rows[0], Target)
+	for Index := 1; Index <= Count; Index++ {
+		Rows[Index] = append(Rows[Index], "zh-CN:"+Rows[Index][0])
+	}
+	return Rows
+}
+
+func TestTranslateSheet(t *testing.T) {
+	for _, tt := range sheetTests {
+		t.Run(tt.name, func(t *testing.T) {
+			Config := testConfig()
+			tt.config(&Config)
+			setConfig(t, Config)
+
+			TableInstance := &memoryTable{rows: tt.datas}
+			if err := translateSheet(context.Background(), TableInstance, "", newTestTasks(t, nil), nil); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(TableInstance.rows, tt.want) {
+				t.Errorf("translateSheet() = %q, want %q", TableInstance.rows, tt.want)
+			}
+		})
+	}
+}
+
+func TestTranslateSheetStream(t *testing.T) {
+	for _, tt := range sheetTests {
+		t.Run(tt.name, func(t *testing.T) {
+			Config := testConfig()
+			tt.config(&Config)
+			setConfig(t, Config)
+
+			TableInstance := &memoryTable{rows: tt.datas}
+			if err := translateSheetStream(context.Background(), TableInstance, "", newTestTasks(t, nil), nil); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(TableInstance.rows, tt.want) {
+				t.Errorf("translateSheetStream() = %q, want %q", TableInstance.rows, tt.want)
+			}
+		})
+	}
+}
+
+func TestResourceLanguage(t *testing.T) {
+	tests := []struct {
+		name     string
+		mappings []config.ColumnMapping
+		want     string
+		wantErr  error
+	}{
+		{
+			name:     "default columns",
+			mappings: []config.ColumnMapping{{Source: "1", Target: "2"}},
+			want:     "zh-CN",
+		},
+		{
+			name:     "mapping language",
+			mappings: []config.ColumnMapping{{Source: "source", Target: "B", TargetLanguage: "ko", ContextColumn: "context"}},
+			want:     "ko",
+		},
+		{
+			name:     "target outside the resource columns",
+			mappings: []config.ColumnMapping{{Source: "1", Target: "Korean", TargetLanguage: "ko"}},
+			wantErr:  ErrResourceMapping,
+		},
+		{
+			name:     "target is not the translation column",
+			mappings: []config.ColumnMapping{{Source: "1", Target: "3"}},
+			wantErr:  ErrResourceMapping,
+		},
+		{
+			name:     "multiple mappings",
+			mappings: []config.ColumnMapping{{Source: "1", Target: "2", TargetLanguage: "en"}, {Source: "1", Target: "2", TargetLanguage: "ko"}},
+			wantErr:  ErrResourceMapping,
+		},
+		{
+			name:     "provenance column",
+			mappings: []config.ColumnMapping{{Source: "1", Target: "2", ProvenanceColumn: "5"}},
+			wantErr:  ErrResourceMapping,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setConfig(t, testConfig())
+
+			got, err := resourceLanguage(newTestTasks(t, tt.mappings))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("resourceLanguage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("resourceLanguage() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocaleOutputPath(t *testing.T) {
+	Directory := t.TempDir()
+	for _, Name := range []string{"messages.properties", "messages_en.properties", "user_id.properties"} {
+		if err := os.WriteFile(filepath.Join(Directory, Name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	Output := filepath.Join(Directory, "out")
+
+	tests := []struct {
+		name       string
+		filePath   string
+		outputPath string
+		want       string
+	}{
+		{
+			name:       "properties ignores the filename template",
+			filePath:   filepath.Join(Directory, "messages.properties"),
+			outputPath: filepath.Join(Output, "messages.zh-CN.properties"),
+			want:       filepath.Join(Output, "messages_zh_CN.properties"),
+		},
+		{
+			name:       "properties replaces a confirmed locale suffix",
+			filePath:   filepath.Join(Directory, "messages_en.properties"),
+			outputPath: filepath.Join(Output, "messages_en.zh-CN.properties"),
+			want:       filepath.Join(Output, "messages_zh_CN.properties"),
+		},
+		{
+			name:       "properties keeps a name that only looks like a locale",
+			filePath:   filepath.Join(Directory, "user_id.properties"),
+			outputPath: filepath.Join(Directory, "user_id.properties"),
+			want:       filepath.Join(Directory, "user_id_zh_CN.properties"),
+		},
+		{
+			name:       "android keeps the source name",
+			filePath:   filepath.Join(Directory, "res", "values", "strings.xml"),
+			outputPath: filepath.Join(Output, "res", "values", "strings.zh-CN.xml"),
+			want:       filepath.Join(Output, "res", "values-zh-rCN", "strings.xml"),
+		},
+		{
+			name:       "apple keeps the source name",
+			filePath:   filepath.Join(Directory, "en.lproj", "Localizable.strings"),
+			outputPath: filepath.Join(Directory, "en.lproj", "Localizable.zh-CN.strings"),
+			want:       filepath.Join(Directory, "zh-Hans.lproj", "Localizable.strings"),
+		},
+		{
+			name:       "apple outside a .lproj directory uses the filename template",
+			filePath:   filepath.Join(Directory, "Localizable.strings"),
+			outputPath: filepath.Join(Directory, "Localizable.zh-CN.strings"),
+			want:       filepath.Join(Directory, "Localizable.zh-CN.strings"),
+		},
+		{
+			name:       "subtitle replaces the templated language suffix",
+			filePath:   filepath.Join(Directory, "movie.srt"),
+			outputPath: filepath.Join(Output, "movie.zh-CN.srt"),
+			want:       filepath.Join(Output, "movie.zh-CN.srt"),
+		},
+		{
+			name:       "tables are unchanged",
+			filePath:   filepath.Join(Directory, "strings.xlsx"),
+			outputPath: filepath.Join(Output, "strings.zh-CN.xlsx"),
+			want:       filepath.Join(Output, "strings.zh-CN.xlsx"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := localeOutputPath(tt.filePath, tt.outputPath, "zh-CN"); got != tt.want {
+				t.Errorf("localeOutputPath() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsLocalizationFile(t *testing.T) {
+	Config := testConfig()
+	Config.Files.Include = []string{"strings.json", "notes_*.txt"}
+	setConfig(t, Config)
+
+	for Path, want := range map[string]bool{
+		"locales/en.json":        true,
+		"app/strings.json":       true,
+		"package.json":           false,
+		"res/values/strings.xml": true,
+		"pom.xml":                false,
+		"LICENSE.txt":            false,
+		"docs/notes_v2.txt":      true,
+		"docs/table.xlsx":        true,
+		"subtitles/movie.srt":    true,
+	} {
+		if got := isLocalizationFile(filepath.FromSlash(Path)); got != want {
+			t.Errorf("isLocalizationFile(%q) = %v, want %v", Path, got, want)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-17 18:20:41
+ * @LastEditTime: 2025-07-17 18:46:15
+ * @LastEditors: nijineko
+ * @Description: XLIFF导出命令
+ * @FilePath: \AutoTranslation\xliff.go
+ */
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/nijinekoyo/AutoTranslation/internal/config"
+	"github.com/nijinekoyo/AutoTranslation/internal/log"
+	"github.com/nijinekoyo/AutoTranslation/pkg/table"
+	"github.com/nijinekoyo/AutoTranslation/pkg/table/xliff"
+	"github.com/nijinekoyo/AutoTranslation/pkg/translation"
+	"github.com/nijinekoyo/AutoTranslation/tools/file"
+)
+
+var (
+	// XLIFF导出命令用法错误
+	ErrXLIFFUsage = errors.New("usage: AutoTranslation xliff <table file> <output.xlf>")
+)
+
+/**
+ * @description: 执行XLIFF导出命令，按翻译时的工作表选择和列映射将表格的原文列和译文列导出为XLIFF 1.2文件，供外部翻译供应商处理
+ * @param {[]string} Args 命令参数，不包含xliff本身
+ * @return {error} 错误信息
+ */
+func runXLIFFCommand(Args []string) (err error) {
+	if len(Args) < 2 {
+		return ErrXLIFFUsage
+	}
+	FilePath := Args[0]
+
+	// 部分格式打开不存在的文件时会创建空表格，导出前先确认输入文件存在
+	if _, err := os.Stat(FilePath); err != nil {
+		return err
+	}
+
+	// 导出只使用工作表选择规则和列映射，不需要创建翻译器
+	Plan, err := newTranslationPlan(func(Service string) (translation.Translation, error) {
+		return nil, nil
+	})
+	if err != nil {
+		return err
+	}
+	Language, err := fileLanguage(FilePath, Plan)
+	if err != nil {
+		return err
+	}
+
+	TableInstance, err := openTable(FilePath, FilePath, Language)
+	if err != nil {
+		return err
+	}
+	// 导出只读取数据，关闭时不保存，避免重写用户的表格或为xls生成xlsx
+	defer func() {
+		Close := TableInstance.Close
+		if DiscardInstance, ok := TableInstance.(table.DiscardTable); ok {
+			Close = DiscardInstance.Discard
+		}
+		if CloseErr := Close(); err == nil {
+			err = CloseErr
+		}
+	}()
+
+	// 与翻译时一样选择工作表，单工作表格式使用空名称
+	Sheets := []string{""}
+	SheetInstance, IsSheetTable := TableInstance.(table.SheetTable)
+	if IsSheetTable {
+		var Missing []string
+		Sheets, Missing = Plan.Sheets.Select(SheetInstance.Sheets())
+		for _, Name := range Missing {
+			log.Print().Warning("XLIFF", fmt.Sprintf("Sheet %q does not exist in %s", Name, FilePath))
+		}
+	}
+
+	var Files []xliff.ExportFile
+	for _, Sheet := range Sheets {
+		if IsSheetTable {
+			if err := SheetInstance.SelectSheet(Sheet); err != nil {
+				return err
+			}
+		}
+		TableDatas, err := TableInstance.Read()
+		if err != nil {
+			return fmt.Errorf("failed to read table data: %w", err)
+		}
+		if len(TableDatas) == 0 {
+			log.Print().Warning("XLIFF", fmt.Sprintf("%s is empty, skipping export", sheetLabel(Sheet)))
+			continue
+		}
+
+		// 按第一行表头解析列引用，按表头名称引用列时第一行不导出
+		Tasks, SkipHeader, err := resolveColumns(resourceContextTasks(TableInstance, Plan.tasksFor(Sheet)), TableDatas)
+		if err != nil {
+			return fmt.Errorf("failed to resolve columns in %s: %w", sheetLabel(Sheet), err)
+		}
+
+		Original := Sheet
+		if Original == "" {
+			Original = filepath.Base(FilePath)
+		}
+		for _, Task := range Tasks {
+			File := xliff.ExportFile{
+				Original:       Original,
+				TargetLanguage: Task.TargetLanguage,
+				Rows:           TableDatas,
+				SourceColumn:   Task.SourceColumn,
+				TargetColumn:   Task.TargetColumn,
+				ContextColumn:  Task.ContextColumn,
+				SkipHeader:     config.Get().SkipTableHeader || SkipHeader,
+			}
+			if Task.SourceLanguage != nil {
+				File.SourceLanguage = *Task.SourceLanguage
+			}
+			Files = append(Files, File)
+		}
+	}
+
+	Count := 0
+	if err := file.WriteAtomic(Args[1], func(Writer io.Writer) (err error) {
+		Count, err = xliff.Export(Writer, Files)
+		return err
+	}); err != nil {
+		return err
+	}
+
+	log.Print().Info("XLIFF", fmt.Sprintf("Exported %d units to %s", Count, Args[1]))
+	return nil
+}
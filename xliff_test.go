@@ -0,0 +1,147 @@
+/*
+ * @Author: nijineko
+ * @Date: 2025-07-17 18:50:26
+ * @LastEditTime: 2025-07-17 18:50:26
+ * @LastEditors: nijineko
+ * @Description: XLIFF导出命令测试
+ * @FilePath: \AutoTranslation\xliff_test.go
+ */
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/nijinekoyo/AutoTranslation/internal/config"
+	"github.com/nijinekoyo/AutoTranslation/pkg/table/xliff"
+	"github.com/xuri/excelize/v2"
+)
+
+func TestRunXLIFFCommand(t *testing.T) {
+	tests := []struct {
+		name      string
+		mappings  []config.ColumnMapping
+		skip      bool
+		wantUnits []string // 导出条目的原文
+	}{
+		{
+			name:      "header names skip the header",
+			mappings:  []config.ColumnMapping{{Source: "en", Target: "ja", ContextColumn: "note"}},
+			wantUnits: []string{"Hello", "Bye"},
+		},
+		{
+			name:      "column numbers keep the first row",
+			mappings:  []config.ColumnMapping{{Source: "2", Target: "3"}},
+			wantUnits: []string{"en", "Hello", "Bye"},
+		},
+		{
+			name:      "configured header skip",
+			mappings:  []config.ColumnMapping{{Source: "2", Target: "3"}},
+			skip:      true,
+			wantUnits: []string{"Hello", "Bye"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			Config := testConfig(tt.mappings...)
+			Config.SkipTableHeader = tt.skip
+			setConfig(t, Config)
+
+			Directory := t.TempDir()
+			FilePath := filepath.Join(Directory, "strings.csv")
+			OutputPath := filepath.Join(Directory, "strings.xlf")
+			if err := os.WriteFile(FilePath, []byte("key,en,ja,note\ntitle,Hello,こんにちは,Title\nbye,Bye,,\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := runXLIFFCommand([]string{FilePath, OutputPath}); err != nil {
+				t.Fatal(err)
+			}
+			Data, err := os.ReadFile(OutputPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			Document, err := xliff.Parse(Data)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, Entry := range Document.Entries() {
+				got = append(got, Entry.Source)
+			}
+			if !slices.Equal(got, tt.wantUnits) {
+				t.Errorf("runXLIFFCommand() units = %q, want %q", got, tt.wantUnits)
+			}
+		})
+	}
+}
+
+func TestRunXLIFFCommand_MissingInput(t *testing.T) {
+	setConfig(t, testConfig(config.ColumnMapping{Source: "1", Target: "2"}))
+
+	Directory := t.TempDir()
+	FilePath := filepath.Join(Directory, "missing.xlsx")
+	OutputPath := filepath.Join(Directory, "missing.xlf")
+	if err := runXLIFFCommand([]string{FilePath, OutputPath}); !os.IsNotExist(err) {
+		t.Fatalf("runXLIFFCommand() error = %v, want not exist", err)
+	}
+
+	// 不存在的输入不会被创建为空表格，也不会写出空的XLIFF
+	for _, Path := range []string{FilePath, OutputPath} {
+		if _, err := os.Stat(Path); !os.IsNotExist(err) {
+			t.Errorf("%s exists after failed export", filepath.Base(Path))
+		}
+	}
+}
+
+func TestRunXLIFFCommand_ReadOnly(t *testing.T) {
+	setConfig(t, testConfig(config.ColumnMapping{Source: "en", Target: "ja"}))
+
+	Directory := t.TempDir()
+	FilePath := filepath.Join(Directory, "strings.xlsx")
+	OutputPath := filepath.Join(Directory, "strings.xlf")
+	File := excelize.NewFile()
+	File.SetSheetRow("Sheet1", "A1", &[]any{"en", "ja"})
+	File.SetSheetRow("Sheet1", "A2", &[]any{"Hello", ""})
+	if err := File.SaveAs(FilePath); err != nil {
+		t.Fatal(err)
+	}
+	File.Close()
+
+	ModTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	if err := os.Chtimes(FilePath, ModTime, ModTime); err != nil {
+		t.Fatal(err)
+	}
+	Before, err := os.ReadFile(FilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := runXLIFFCommand([]string{FilePath, OutputPath}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 导出不会重写输入文件，也不会生成其他文件
+	After, err := os.ReadFile(FilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(Before, After) {
+		t.Error("runXLIFFCommand() rewrote the input file")
+	}
+	if Info, err := os.Stat(FilePath); err != nil || !Info.ModTime().Equal(ModTime) {
+		t.Errorf("runXLIFFCommand() changed the input mtime: %v, %v", Info.ModTime(), err)
+	}
+	Entries, err := os.ReadDir(Directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(Entries) != 2 {
+		t.Errorf("runXLIFFCommand() left %d files, want the input and the XLIFF", len(Entries))
+	}
+}